@@ -0,0 +1,142 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"tss-demo/tss_util/audit"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+)
+
+var auditCMD = &cobra.Command{
+	Use:   "audit",
+	Short: "Verify and export the node audit log",
+	Long: `Every audit entry carries the hash of the previous entry and is signed by the node
+libp2p key so that changed, removed or reordered entries are detected by verification.
+Removal of the latest entries is detected by comparing the head hash with a previously
+exported one.`,
+}
+
+var verifyAuditCMD = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify hash chain and signatures of the audit log",
+	RunE:  verifyAudit,
+}
+
+var exportAuditCMD = &cobra.Command{
+	Use:   "export",
+	Short: "Export range of audit entries as JSON",
+	RunE:  exportAudit,
+}
+
+func init() {
+	verifyAuditCMD.Flags().String("file", "audit.jsonl", "Path to the audit log")
+	verifyAuditCMD.Flags().String("public-key", "", "Base64 encoded libp2p public key of the node")
+	_ = verifyAuditCMD.MarkFlagRequired("public-key")
+
+	exportAuditCMD.Flags().String("file", "audit.jsonl", "Path to the audit log")
+	exportAuditCMD.Flags().String("public-key", "", "Base64 encoded libp2p public key of the node")
+	_ = exportAuditCMD.MarkFlagRequired("public-key")
+	exportAuditCMD.Flags().Uint64("from", 0, "First exported sequence number")
+	exportAuditCMD.Flags().Uint64("to", 0, "Last exported sequence number")
+	exportAuditCMD.Flags().String("since", "", "Export entries recorded at or after the RFC3339 time")
+	exportAuditCMD.Flags().String("until", "", "Export entries recorded at or before the RFC3339 time")
+	exportAuditCMD.Flags().String("output", "", "Path of the export (default: stdout)")
+
+	auditCMD.AddCommand(verifyAuditCMD, exportAuditCMD)
+}
+
+func verifyAudit(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	publicKey, err := auditPublicKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	entries, err := audit.ReadEntries(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && entries[0].Sequence != 1 {
+		return fmt.Errorf("audit log starts at entry %d, earlier entries are missing", entries[0].Sequence)
+	}
+	err = audit.Verify(entries, publicKey)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Audit log is empty")
+		return nil
+	}
+	head := entries[len(entries)-1]
+	fmt.Printf("Verified %d entries, head %d hash %s\n", len(entries), head.Sequence, head.Hash)
+	return nil
+}
+
+func exportAudit(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	from, _ := cmd.Flags().GetUint64("from")
+	to, _ := cmd.Flags().GetUint64("to")
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	output, _ := cmd.Flags().GetString("output")
+	publicKey, err := auditPublicKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	r := audit.Range{From: from, To: to}
+	if since != "" {
+		r.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return fmt.Errorf("invalid since time: %w", err)
+		}
+	}
+	if until != "" {
+		r.Until, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			return fmt.Errorf("invalid until time: %w", err)
+		}
+	}
+
+	entries, err := audit.ReadEntries(path)
+	if err != nil {
+		return err
+	}
+	// exported range is only as trustworthy as the chain leading to it
+	err = audit.Verify(entries, publicKey)
+	if err != nil {
+		return err
+	}
+
+	eb, err := json.MarshalIndent(audit.Filter(entries, r), "", "  ")
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Println(string(eb))
+		return nil
+	}
+	return os.WriteFile(output, eb, 0644)
+}
+
+func auditPublicKey(cmd *cobra.Command) (crypto.PubKey, error) {
+	encoded, _ := cmd.Flags().GetString("public-key")
+	keyBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	publicKey, err := crypto.UnmarshalPublicKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return publicKey, nil
+}
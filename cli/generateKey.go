@@ -31,13 +31,20 @@ func generateKey() error {
 		return err
 	}
 	encPriv := base64.StdEncoding.EncodeToString(marshPriv)
+	marshPub, err := crypto.MarshalPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	encPub := base64.StdEncoding.EncodeToString(marshPub)
 
 	fmt.Printf(`
 LibP2P peer identity: %s \n
-LibP2P private key: %s
+LibP2P private key: %s \n
+LibP2P public key: %s
 `,
 		peerID.Pretty(),
 		encPriv,
+		encPub,
 	)
 	return nil
 }
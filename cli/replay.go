@@ -0,0 +1,215 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/comm/elector"
+	"tss-demo/tss_util/comm/p2p"
+	"tss-demo/tss_util/comm/recorder"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss"
+	"tss-demo/tss_util/tss/ecdsa/signing"
+	"tss-demo/tss_util/tss_config"
+
+	tsslib "github.com/binance-chain/tss-lib/tss"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+)
+
+const signSessionPrefix = "sid-sign-"
+
+var replayCMD = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay recorded signing session into a local tss process",
+	Long: `Replay feeds inbound messages from a session recording into a local signing process
+with the original timing and reports where the session stalled or which culprits were blamed.
+
+Tss parties draw fresh randomness on every run so messages that depend on local
+outputs from earlier rounds can fail verification during replay. Culprits blamed in
+the first round and the timing of remote peers are reproduced faithfully.`,
+	RunE: replay,
+}
+
+func init() {
+	replayCMD.Flags().String("recording", "", "Path to the session recording")
+	replayCMD.Flags().String("config", "config.json", "Path to the node configuration of the recording peer")
+	replayCMD.Flags().String("key", "", "Recording encryption key (default: recorder key from configuration)")
+	replayCMD.Flags().String("hash", "", "Signed hash (default: hash from session ID)")
+	replayCMD.Flags().Float64("speed", 1, "Replay speed multiplier, 0 replays without delays")
+	replayCMD.Flags().Duration("timeout", 5*time.Minute, "Maximum replay duration")
+	_ = replayCMD.MarkFlagRequired("recording")
+}
+
+// replayProcess disables retries so that the first failure is reported as recorded
+type replayProcess struct {
+	tss.TssProcess
+}
+
+func (p replayProcess) Retryable() bool {
+	return false
+}
+
+func replay(cmd *cobra.Command, args []string) error {
+	recordingPath, _ := cmd.Flags().GetString("recording")
+	configPath, _ := cmd.Flags().GetString("config")
+	key, _ := cmd.Flags().GetString("key")
+	hash, _ := cmd.Flags().GetString("hash")
+	speed, _ := cmd.Flags().GetFloat64("speed")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	configuration, err := tss_config.GetConfigFromFile(configPath, nil)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		key = configuration.RelayerConfig.RecorderConfig.EncryptionKey
+	}
+
+	entries, err := recorder.LoadSession(recordingPath, key)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("recording is empty")
+	}
+	sessionID := entries[0].SessionID
+	printTimeline(entries)
+
+	if hash == "" {
+		if !strings.HasPrefix(sessionID, signSessionPrefix) {
+			return fmt.Errorf("session %s is not a signing session, provide --hash", sessionID)
+		}
+		hash = strings.TrimPrefix(sessionID, signSessionPrefix)
+	}
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil {
+		return fmt.Errorf("invalid hash %s: %w", hash, err)
+	}
+
+	privBytes, err := crypto.ConfigDecodeKey(configuration.RelayerConfig.MpcConfig.Key)
+	if err != nil {
+		return err
+	}
+	priv, err := crypto.UnmarshalPrivateKey(privBytes)
+	if err != nil {
+		return err
+	}
+	h, err := libp2p.New(libp2p.Identity(priv), libp2p.NoListenAddrs, libp2p.DisableRelay())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	if h.ID() != entries[0].Host {
+		return fmt.Errorf("recording belongs to peer %s, configuration is for peer %s", entries[0].Host.Pretty(), h.ID().Pretty())
+	}
+
+	networkTopology, err := topology.NewTopologyStore(configuration.RelayerConfig.MpcConfig.TopologyConfiguration.Path).Topology()
+	if err != nil {
+		return err
+	}
+	p2p.LoadPeers(h, networkTopology.Peers)
+
+	replayComm := recorder.NewReplayCommunication(h.ID(), entries, speed)
+	signingProcess, err := signing.NewSigning(
+		new(big.Int).SetBytes(hashBytes),
+		fmt.Sprintf("replay-%s", hash),
+		sessionID,
+		h,
+		replayComm,
+		keyshare.NewECDSAKeyshareStore(configuration.RelayerConfig.MpcConfig.KeysharePath),
+		networkTopology,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	coordinator := tss.NewCoordinator(h, replayComm, elector.NewCoordinatorElectorFactory(h, configuration.RelayerConfig.BullyConfig, networkTopology))
+	coordinator.TssTimeout = timeout
+	coordinator.CoordinatorTimeout = timeout
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		if err := replayComm.Start(ctx); err != nil {
+			fmt.Printf("Replay stopped: %s\n", err)
+		}
+	}()
+
+	start := time.Now()
+	err = coordinator.Execute(ctx, []tss.TssProcess{replayProcess{signingProcess}}, make(chan interface{}, 1))
+	fmt.Printf("\nReplay finished after %s\n", time.Since(start).Round(time.Millisecond))
+	printOutcome(err)
+	printSent(entries, replayComm.Sent())
+	return nil
+}
+
+func printTimeline(entries []recorder.Entry) {
+	fmt.Printf("Session %s recorded by %s (%d messages, %s)\n",
+		entries[0].SessionID,
+		entries[0].Host.Pretty(),
+		len(entries),
+		entries[len(entries)-1].Timestamp.Sub(entries[0].Timestamp).Round(time.Millisecond),
+	)
+	fmt.Printf("%-52s %8s %8s %12s %12s %12s\n", "PEER", "RECEIVED", "SENT", "FIRST SEEN", "LAST SEEN", "MAX GAP")
+	for _, a := range recorder.Timeline(entries) {
+		fmt.Printf("%-52s %8d %8d %12s %12s %12s\n",
+			a.Peer.Pretty(), a.Received, a.Sent,
+			a.FirstSeen.Round(time.Millisecond), a.LastSeen.Round(time.Millisecond), a.MaxGap.Round(time.Millisecond),
+		)
+	}
+}
+
+func printOutcome(err error) {
+	var tssErr *tsslib.Error
+	var commErr *comm.CommunicationError
+	var coordinatorErr *tss.CoordinatorError
+	switch {
+	case err == nil:
+		fmt.Println("Outcome: session completed")
+	case errors.As(err, &tssErr):
+		fmt.Printf("Outcome: tss error in round %d: %s\n", tssErr.Round(), tssErr.Cause())
+		for _, culprit := range tssErr.Culprits() {
+			fmt.Printf("  culprit: %s\n", culprit.Id)
+		}
+	case errors.As(err, &commErr):
+		fmt.Printf("Outcome: communication error: %s\n", commErr)
+	case errors.As(err, &coordinatorErr):
+		fmt.Printf("Outcome: %s\n", coordinatorErr)
+	default:
+		fmt.Printf("Outcome: %s\n", err)
+	}
+}
+
+func printSent(recorded []recorder.Entry, replayed []recorder.Entry) {
+	count := func(entries []recorder.Entry) map[comm.MessageType]int {
+		c := make(map[comm.MessageType]int)
+		for _, e := range entries {
+			if e.Direction == comm.Outbound {
+				c[e.MessageType]++
+			}
+		}
+		return c
+	}
+
+	recordedCount := count(recorded)
+	replayedCount := count(replayed)
+	fmt.Printf("%-28s %8s %8s\n", "OUTBOUND", "RECORDED", "REPLAYED")
+	for msgType := comm.TssKeyGenMsg; msgType < comm.Unknown; msgType++ {
+		if recordedCount[msgType] == 0 && replayedCount[msgType] == 0 {
+			continue
+		}
+		fmt.Printf("%-28s %8d %8d\n", msgType, recordedCount[msgType], replayedCount[msgType])
+	}
+}
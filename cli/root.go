@@ -0,0 +1,22 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var rootCMD = &cobra.Command{
+	Use:   "tss-cli",
+	Short: "Operator tools for tss nodes",
+}
+
+func init() {
+	rootCMD.AddCommand(replayCMD, topologyCMD, auditCMD)
+}
+
+// Execute runs the root command
+func Execute() error {
+	return rootCMD.Execute()
+}
@@ -0,0 +1,253 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss_config"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/spf13/cobra"
+)
+
+var topologyCMD = &cobra.Command{
+	Use:   "topology",
+	Short: "Propose, sign and assemble signed topology updates",
+	Long: `Topology updates are approved by a quorum of the current operators.
+
+An operator proposes the new topology, every operator signs the proposal with the
+libp2p key of its node and the signatures are assembled into the topology document
+that is distributed to the nodes.`,
+}
+
+var proposeTopologyCMD = &cobra.Command{
+	Use:   "propose",
+	Short: "Create unsigned topology proposal",
+	RunE:  proposeTopology,
+}
+
+var signTopologyCMD = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign topology proposal with the node key",
+	RunE:  signTopology,
+}
+
+var assembleTopologyCMD = &cobra.Command{
+	Use:   "assemble",
+	Short: "Assemble operator signatures into signed topology document",
+	RunE:  assembleTopology,
+}
+
+func init() {
+	proposeTopologyCMD.Flags().StringArray("peer", []string{}, "Peer address including the p2p component, repeat for every peer")
+	proposeTopologyCMD.Flags().Int("threshold", 0, "Mpc threshold")
+	proposeTopologyCMD.Flags().Uint64("version", 0, "Topology version (default: current version + 1)")
+	proposeTopologyCMD.Flags().String("current", "", "Path to the currently stored topology")
+	proposeTopologyCMD.Flags().String("output", "proposal.json", "Path of the proposal")
+	_ = proposeTopologyCMD.MarkFlagRequired("peer")
+	_ = proposeTopologyCMD.MarkFlagRequired("threshold")
+
+	signTopologyCMD.Flags().String("proposal", "proposal.json", "Path to the topology proposal")
+	signTopologyCMD.Flags().String("config", "config.json", "Path to the node configuration holding the libp2p key")
+	signTopologyCMD.Flags().String("output", "", "Path of the signature (default: <peer ID>.sig.json)")
+
+	assembleTopologyCMD.Flags().String("proposal", "proposal.json", "Path to the topology proposal")
+	assembleTopologyCMD.Flags().StringArray("signature", []string{}, "Path to operator signature, repeat for every signature")
+	assembleTopologyCMD.Flags().String("current", "", "Path to the currently stored topology, approvals are checked against its operators")
+	assembleTopologyCMD.Flags().String("encryption-key", "", "Encrypt the document for remote hosting")
+	assembleTopologyCMD.Flags().String("output", "topology.signed.json", "Path of the signed topology document")
+	_ = assembleTopologyCMD.MarkFlagRequired("signature")
+
+	topologyCMD.AddCommand(proposeTopologyCMD, signTopologyCMD, assembleTopologyCMD)
+}
+
+func proposeTopology(cmd *cobra.Command, args []string) error {
+	peers, _ := cmd.Flags().GetStringArray("peer")
+	threshold, _ := cmd.Flags().GetInt("threshold")
+	version, _ := cmd.Flags().GetUint64("version")
+	currentPath, _ := cmd.Flags().GetString("current")
+	output, _ := cmd.Flags().GetString("output")
+
+	current, err := currentTopology(currentPath)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		version = 1
+		if current != nil {
+			version = current.Version + 1
+		}
+	}
+
+	rawTopology := &topology.RawTopology{
+		Threshold: fmt.Sprint(threshold),
+		Version:   fmt.Sprint(version),
+	}
+	for _, p := range peers {
+		rawTopology.Peers = append(rawTopology.Peers, topology.RawPeer{PeerAddress: p})
+	}
+	// validates the proposal before it is passed to operators
+	networkTopology, err := topology.ProcessRawTopology(rawTopology)
+	if err != nil {
+		return err
+	}
+	err = topology.ValidateTopology(current, networkTopology, false)
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(output, rawTopology)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Topology version %d proposal written to %s\n", version, output)
+	return nil
+}
+
+func signTopology(cmd *cobra.Command, args []string) error {
+	proposalPath, _ := cmd.Flags().GetString("proposal")
+	configPath, _ := cmd.Flags().GetString("config")
+	output, _ := cmd.Flags().GetString("output")
+
+	networkTopology, err := readProposal(proposalPath)
+	if err != nil {
+		return err
+	}
+
+	configuration, err := tss_config.GetConfigFromFile(configPath, nil)
+	if err != nil {
+		return err
+	}
+	privBytes, err := crypto.ConfigDecodeKey(configuration.RelayerConfig.MpcConfig.Key)
+	if err != nil {
+		return err
+	}
+	priv, err := crypto.UnmarshalPrivateKey(privBytes)
+	if err != nil {
+		return err
+	}
+
+	signature, err := networkTopology.Sign(priv)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = fmt.Sprintf("%s.sig.json", signature.Signer.Pretty())
+	}
+
+	err = writeJSON(output, signature)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Topology version %d signed by %s, signature written to %s\n", networkTopology.Version, signature.Signer.Pretty(), output)
+	return nil
+}
+
+func assembleTopology(cmd *cobra.Command, args []string) error {
+	proposalPath, _ := cmd.Flags().GetString("proposal")
+	signaturePaths, _ := cmd.Flags().GetStringArray("signature")
+	currentPath, _ := cmd.Flags().GetString("current")
+	encryptionKey, _ := cmd.Flags().GetString("encryption-key")
+	output, _ := cmd.Flags().GetString("output")
+
+	networkTopology, err := readProposal(proposalPath)
+	if err != nil {
+		return err
+	}
+	for _, path := range signaturePaths {
+		signature := topology.TopologySignature{}
+		err = readJSON(path, &signature)
+		if err != nil {
+			return err
+		}
+		networkTopology.Signatures = append(networkTopology.Signatures, signature)
+	}
+
+	current, err := currentTopology(currentPath)
+	if err != nil {
+		return err
+	}
+	err = topology.ValidateTopology(current, networkTopology, true)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(networkTopology.ToRawTopology(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if encryptionKey != "" {
+		aesEncryption, err := topology.NewAESEncryption([]byte(encryptionKey))
+		if err != nil {
+			return err
+		}
+		ct, err := aesEncryption.Encrypt(data)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(ct)
+		err = os.WriteFile(output, []byte(hex.EncodeToString(ct)), 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Encrypted topology version %d written to %s, hash: %x\n", networkTopology.Version, output, hash)
+		return nil
+	}
+
+	hash := sha256.Sum256(data)
+	err = os.WriteFile(output, data, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Topology version %d written to %s, hash: %x\n", networkTopology.Version, output, hash)
+	return nil
+}
+
+func readProposal(path string) (*topology.NetworkTopology, error) {
+	rawTopology := &topology.RawTopology{}
+	err := readJSON(path, rawTopology)
+	if err != nil {
+		return nil, err
+	}
+	// signatures are collected separately and added during assembly
+	rawTopology.Signatures = nil
+	return topology.ProcessRawTopology(rawTopology)
+}
+
+func currentTopology(path string) (*topology.NetworkTopology, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	current, err := topology.NewTopologyStore(path).Topology()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read current topology: %w", err)
+	}
+	if len(current.Peers) == 0 {
+		return nil, errors.New("current topology has no peers")
+	}
+	return current, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,696 @@
+// Code generated by clientgen from openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+type Approval struct {
+	// Approver Address of the approver
+	Approver   string    `json:"approver"`
+	Name       string    `json:"name"`
+	Signature  string    `json:"signature"`
+	ApprovedAt time.Time `json:"approvedAt"`
+}
+
+type ApproveRequest struct {
+	// Signature Hex encoded personal_sign signature of the approval message
+	Signature string `json:"signature"`
+}
+
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest          ErrorCode = "INVALID_REQUEST"
+	ErrorCodeInvalidHash             ErrorCode = "INVALID_HASH"
+	ErrorCodeInvalidTransaction      ErrorCode = "INVALID_TRANSACTION"
+	ErrorCodeTransactionRequired     ErrorCode = "TRANSACTION_REQUIRED"
+	ErrorCodeInvalidSignature        ErrorCode = "INVALID_SIGNATURE"
+	ErrorCodeUnauthenticated         ErrorCode = "UNAUTHENTICATED"
+	ErrorCodePermissionDenied        ErrorCode = "PERMISSION_DENIED"
+	ErrorCodeUnknownApprover         ErrorCode = "UNKNOWN_APPROVER"
+	ErrorCodeKeyshareNotFound        ErrorCode = "KEYSHARE_NOT_FOUND"
+	ErrorCodeSignRequestNotFound     ErrorCode = "SIGN_REQUEST_NOT_FOUND"
+	ErrorCodeSessionNotFound         ErrorCode = "SESSION_NOT_FOUND"
+	ErrorCodeApprovalDisabled        ErrorCode = "APPROVAL_DISABLED"
+	ErrorCodeSessionPending          ErrorCode = "SESSION_PENDING"
+	ErrorCodeSignRequestNotPending   ErrorCode = "SIGN_REQUEST_NOT_PENDING"
+	ErrorCodeRequestInProgress       ErrorCode = "REQUEST_IN_PROGRESS"
+	ErrorCodeSignRequestExpired      ErrorCode = "SIGN_REQUEST_EXPIRED"
+	ErrorCodeIdempotencyKeyReused    ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrorCodeRateLimited             ErrorCode = "RATE_LIMITED"
+	ErrorCodeVelocityLimitExceeded   ErrorCode = "VELOCITY_LIMIT_EXCEEDED"
+	ErrorCodeCulpritsIdentified      ErrorCode = "CULPRITS_IDENTIFIED"
+	ErrorCodePeerUnreachable         ErrorCode = "PEER_UNREACHABLE"
+	ErrorCodeCoordinatorUnresponsive ErrorCode = "COORDINATOR_UNRESPONSIVE"
+	ErrorCodeThresholdNotMet         ErrorCode = "THRESHOLD_NOT_MET"
+	ErrorCodeNodeStarting            ErrorCode = "NODE_STARTING"
+	ErrorCodeNodeShuttingDown        ErrorCode = "NODE_SHUTTING_DOWN"
+	ErrorCodeSessionTimeout          ErrorCode = "SESSION_TIMEOUT"
+	ErrorCodeChainNotFound           ErrorCode = "CHAIN_NOT_FOUND"
+	ErrorCodeApprovalRequired        ErrorCode = "APPROVAL_REQUIRED"
+	ErrorCodeGasPriceTooHigh         ErrorCode = "GAS_PRICE_TOO_HIGH"
+	ErrorCodeBroadcastFailed         ErrorCode = "BROADCAST_FAILED"
+	ErrorCodeNonceNotPending         ErrorCode = "NONCE_NOT_PENDING"
+	ErrorCodeInternal                ErrorCode = "INTERNAL"
+)
+
+type ErrorResponse struct {
+	// Code HTTP status of the response
+	Code    int64     `json:"code"`
+	Error   ErrorCode `json:"error"`
+	Message string    `json:"message"`
+	// Peers Peers that caused the failure of the session
+	Peers []string `json:"peers,omitempty"`
+}
+
+// Event Event published by the node to webhooks and WebSocket subscribers
+type Event struct {
+	// ID Unique ID of the event, kept across webhook delivery retries
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	// Node Peer ID of the node that published the event
+	Node      string `json:"node"`
+	SessionID string `json:"sessionId,omitempty"`
+	// Data String values of the event, like the hash and signature of signature.ready
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+type EventType string
+
+const (
+	EventTypeSessionStarted       EventType = "session.started"
+	EventTypeSessionCompleted     EventType = "session.completed"
+	EventTypeSessionFailed        EventType = "session.failed"
+	EventTypeSessionCancelled     EventType = "session.cancelled"
+	EventTypeSignatureReady       EventType = "signature.ready"
+	EventTypeKeyGenerated         EventType = "key.generated"
+	EventTypeKeyReshared          EventType = "key.reshared"
+	EventTypeTopologyChanged      EventType = "topology.changed"
+	EventTypeTransactionSent      EventType = "transaction.sent"
+	EventTypeTransactionConfirmed EventType = "transaction.confirmed"
+	EventTypeTransactionReverted  EventType = "transaction.reverted"
+	EventTypeTransactionReplaced  EventType = "transaction.replaced"
+)
+
+type KeygenResponse struct {
+	Code    int64  `json:"code"`
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+type KeyshareStatus struct {
+	OK        bool   `json:"ok"`
+	Threshold int64  `json:"threshold,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type LivenessReport struct {
+	Status string `json:"status"`
+}
+
+type NonceLedger struct {
+	// Next Nonce after the highest nonce handed out
+	Next uint64 `json:"next"`
+	// Confirmed Nonce of the first transaction not included in a block
+	Confirmed uint64         `json:"confirmed"`
+	Pending   []PendingNonce `json:"pending"`
+	// Released Nonces handed out again before next
+	Released []uint64 `json:"released"`
+}
+
+type NonceLedgerResponse struct {
+	Code    int64       `json:"code"`
+	Result  NonceLedger `json:"result"`
+	Message string      `json:"message"`
+}
+
+type PeerHealth struct {
+	Peer   string      `json:"peer"`
+	Status ProbeStatus `json:"status"`
+	// AverageRTT Average round trip time in nanoseconds
+	AverageRTT  int64         `json:"averageRtt"`
+	SuccessRate float64       `json:"successRate"`
+	History     []ProbeResult `json:"history"`
+}
+
+type PeersHealthResponse struct {
+	Code    int64        `json:"code"`
+	Result  []PeerHealth `json:"result"`
+	Message string       `json:"message"`
+}
+
+type PeersStatus struct {
+	OK          bool     `json:"ok"`
+	Reachable   int64    `json:"reachable"`
+	Required    int64    `json:"required"`
+	Total       int64    `json:"total"`
+	Unreachable []string `json:"unreachable,omitempty"`
+}
+
+type PendingNonce struct {
+	Nonce uint64 `json:"nonce"`
+	// Tx Hex encoded last unsigned transaction sent with the nonce, not set while the nonce is only reserved
+	Tx string `json:"tx,omitempty"`
+	// Signed Hex encoded signed versions of the transaction broadcast by this node
+	Signed  []string  `json:"signed,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+type ProbeResult struct {
+	Peer   string      `json:"peer"`
+	Time   time.Time   `json:"time"`
+	Status ProbeStatus `json:"status"`
+	// RTT Round trip time in nanoseconds
+	RTT       int64  `json:"rtt,omitempty"`
+	Version   int64  `json:"version,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ProbeStatus string
+
+const (
+	ProbeStatusHealthy          ProbeStatus = "healthy"
+	ProbeStatusUnreachable      ProbeStatus = "unreachable"
+	ProbeStatusVersionMismatch  ProbeStatus = "version_mismatch"
+	ProbeStatusKeyshareMismatch ProbeStatus = "keyshare_mismatch"
+)
+
+type ReadinessReport struct {
+	Ready    bool            `json:"ready"`
+	Keyshare *KeyshareStatus `json:"keyshare,omitempty"`
+	Peers    *PeersStatus    `json:"peers,omitempty"`
+	Sessions *SessionsStatus `json:"sessions,omitempty"`
+	Topology *TopologyStatus `json:"topology,omitempty"`
+}
+
+type SentTx struct {
+	// Hash Hex encoded hash signed by the MPC key
+	Hash string `json:"hash"`
+	// TxHash Hash of the broadcast transaction, returned only by the coordinator of the signing session
+	TxHash string `json:"txHash,omitempty"`
+}
+
+type SessionResponse struct {
+	Code int64 `json:"code"`
+	// Result Session ID
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+type SessionsStatus struct {
+	OK           bool     `json:"ok"`
+	Pending      int64    `json:"pending"`
+	Stuck        []string `json:"stuck,omitempty"`
+	ShuttingDown bool     `json:"shuttingDown,omitempty"`
+}
+
+type SignRequest struct {
+	// Hash Hex encoded 32 byte hash
+	Hash string `json:"hash"`
+	// Tx Hex encoded canonical encoding of the unsigned transaction with the hash, required by velocity limits
+	Tx string `json:"tx,omitempty"`
+	// ChainID Chain ID used to hash legacy transactions
+	ChainID int64 `json:"chainId,omitempty"`
+}
+
+type SignRequestResponse struct {
+	Code    int64             `json:"code"`
+	Result  SignRequestStatus `json:"result"`
+	Message string            `json:"message"`
+}
+
+type SignRequestStatus struct {
+	Hash      string     `json:"hash"`
+	Attempt   int64      `json:"attempt"`
+	Status    SignStatus `json:"status"`
+	Requester string     `json:"requester"`
+	// Value Value of the transaction in wei
+	Value     *big.Int   `json:"value,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	Approvals []Approval `json:"approvals"`
+	Signature string     `json:"signature,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type SignResponse struct {
+	Code int64 `json:"code"`
+	// Result Hex encoded signature
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+type SignStatus string
+
+const (
+	SignStatusPendingApproval SignStatus = "pending_approval"
+	SignStatusApproved        SignStatus = "approved"
+	SignStatusSigned          SignStatus = "signed"
+	SignStatusFailed          SignStatus = "failed"
+	SignStatusExpired         SignStatus = "expired"
+)
+
+type TopologyStatus struct {
+	OK            bool  `json:"ok"`
+	Version       int64 `json:"version"`
+	LatestVersion int64 `json:"latestVersion"`
+}
+
+type TxBuildRequest struct {
+	// ChainID ID of the configured evm chain
+	ChainID uint64 `json:"chainId"`
+	// To Recipient of the transaction, a contract is created if it is not set
+	To string `json:"to,omitempty"`
+	// Value Value of the transaction in wei
+	Value *big.Int `json:"value,omitempty"`
+	// Data Hex encoded call data
+	Data string `json:"data,omitempty"`
+	// AccessList Access list of access-list and dynamic-fee transactions
+	AccessList []AccessTuple `json:"accessList,omitempty"`
+	// Gas Gas limit, estimated if it is not set
+	Gas uint64 `json:"gas,omitempty"`
+	// GasPrice Gas price of legacy and access-list transactions in wei
+	GasPrice *big.Int `json:"gasPrice,omitempty"`
+	// MaxPriorityFeePerGas Tip of dynamic-fee transactions in wei
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas,omitempty"`
+	// MaxFeePerGas Fee cap of dynamic-fee transactions in wei
+	MaxFeePerGas *big.Int `json:"maxFeePerGas,omitempty"`
+	// Nonce Nonce of the MPC address, the next free nonce if it is not set
+	Nonce *uint64 `json:"nonce,omitempty"`
+}
+
+type TxBuildResponse struct {
+	Code    int64      `json:"code"`
+	Result  UnsignedTx `json:"result"`
+	Message string     `json:"message"`
+}
+
+type TxReplaceRequest struct {
+	// ChainID ID of the configured evm chain
+	ChainID uint64 `json:"chainId"`
+	// Nonce Nonce of the pending transaction
+	Nonce uint64 `json:"nonce"`
+	// Cancel Replace the transaction with an empty transfer to the MPC address
+	Cancel bool `json:"cancel,omitempty"`
+}
+
+type TxSendRequest struct {
+	// ChainID ID of the configured evm chain
+	ChainID uint64 `json:"chainId"`
+	// Tx Hex encoded unsigned transaction returned by tx/build
+	Tx string `json:"tx"`
+}
+
+type TxSendResponse struct {
+	Code    int64  `json:"code"`
+	Result  SentTx `json:"result"`
+	Message string `json:"message"`
+}
+
+type UnsignedTx struct {
+	// Tx Hex encoded canonical encoding of the unsigned transaction
+	Tx string `json:"tx"`
+	// Hash Hex encoded hash signed by the MPC key
+	Hash string `json:"hash"`
+}
+
+// PingResult holds the documented response of Ping
+type PingResult struct {
+	StatusCode int
+	JSON200    *map[string]interface{}
+}
+
+// Ping calls GET /api/v1/
+// Reachability of the API
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	result := &PingResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/api/v1/",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GenerateKeyResult holds the documented response of GenerateKey
+type GenerateKeyResult struct {
+	StatusCode int
+	JSON200    *KeygenResponse
+}
+
+// GenerateKey calls GET /api/v1/genkey
+// Run keygen of a new MPC key
+func (c *Client) GenerateKey(ctx context.Context) (*GenerateKeyResult, error) {
+	result := &GenerateKeyResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/api/v1/genkey",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GetPeersHealthResult holds the documented response of GetPeersHealth
+type GetPeersHealthResult struct {
+	StatusCode int
+	JSON200    *PeersHealthResponse
+}
+
+// GetPeersHealth calls GET /api/v1/peers/health
+// Recent health probes of the peers
+func (c *Client) GetPeersHealth(ctx context.Context) (*GetPeersHealthResult, error) {
+	result := &GetPeersHealthResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/api/v1/peers/health",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// ReshareResult holds the documented response of Reshare
+type ReshareResult struct {
+	StatusCode int
+	JSON200    *SessionResponse
+}
+
+// Reshare calls POST /api/v1/reshare
+// Reshare the MPC key to the current peers with the current threshold
+func (c *Client) Reshare(ctx context.Context) (*ReshareResult, error) {
+	result := &ReshareResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/reshare",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// CancelSessionResult holds the documented response of CancelSession
+type CancelSessionResult struct {
+	StatusCode int
+	JSON200    *SessionResponse
+}
+
+// CancelSession calls POST /api/v1/sessions/{sessionId}/cancel
+// Stop the local execution of a running session
+func (c *Client) CancelSession(ctx context.Context, sessionID string) (*CancelSessionResult, error) {
+	result := &CancelSessionResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/sessions/" + url.PathEscape(sessionID) + "/cancel",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// SignResult holds the documented response of Sign
+type SignResult struct {
+	StatusCode int
+	JSON200    *SignResponse
+	JSON202    *SignRequestResponse
+}
+
+// Sign calls POST /api/v1/sign
+// Sign the hash with the MPC key
+func (c *Client) Sign(ctx context.Context, body SignRequest) (*SignResult, error) {
+	result := &SignResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/sign",
+		body:       body,
+		idempotent: true,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+			202: &result.JSON202,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GetSignRequestResult holds the documented response of GetSignRequest
+type GetSignRequestResult struct {
+	StatusCode int
+	JSON200    *SignRequestResponse
+}
+
+// GetSignRequest calls GET /api/v1/sign/{hash}
+// Sign request held for approval
+func (c *Client) GetSignRequest(ctx context.Context, hash string) (*GetSignRequestResult, error) {
+	result := &GetSignRequestResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/api/v1/sign/" + url.PathEscape(hash),
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// ApproveSignRequestResult holds the documented response of ApproveSignRequest
+type ApproveSignRequestResult struct {
+	StatusCode int
+	JSON200    *SignRequestResponse
+}
+
+// ApproveSignRequest calls POST /api/v1/sign/{hash}/approvals
+// Approve the sign request
+func (c *Client) ApproveSignRequest(ctx context.Context, hash string, body ApproveRequest) (*ApproveSignRequestResult, error) {
+	result := &ApproveSignRequestResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/sign/" + url.PathEscape(hash) + "/approvals",
+		body:       body,
+		idempotent: true,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// BuildTxResult holds the documented response of BuildTx
+type BuildTxResult struct {
+	StatusCode int
+	JSON200    *TxBuildResponse
+}
+
+// BuildTx calls POST /api/v1/tx/build
+// Build the unsigned transaction sent from the MPC address
+func (c *Client) BuildTx(ctx context.Context, body TxBuildRequest) (*BuildTxResult, error) {
+	result := &BuildTxResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/tx/build",
+		body:       body,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GetNoncesResult holds the documented response of GetNonces
+type GetNoncesResult struct {
+	StatusCode int
+	JSON200    *NonceLedgerResponse
+}
+
+// GetNonces calls GET /api/v1/tx/nonces/{chainId}
+// Nonce ledger of the MPC address on the chain
+func (c *Client) GetNonces(ctx context.Context, chainID string) (*GetNoncesResult, error) {
+	result := &GetNoncesResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/api/v1/tx/nonces/" + url.PathEscape(chainID),
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// ReplaceTxResult holds the documented response of ReplaceTx
+type ReplaceTxResult struct {
+	StatusCode int
+	JSON200    *TxBuildResponse
+}
+
+// ReplaceTx calls POST /api/v1/tx/replace
+// Build the transaction replacing a pending transaction
+func (c *Client) ReplaceTx(ctx context.Context, body TxReplaceRequest) (*ReplaceTxResult, error) {
+	result := &ReplaceTxResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/tx/replace",
+		body:       body,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// SendTxResult holds the documented response of SendTx
+type SendTxResult struct {
+	StatusCode int
+	JSON200    *TxSendResponse
+}
+
+// SendTx calls POST /api/v1/tx/send
+// Sign the transaction with the MPC key and broadcast it
+func (c *Client) SendTx(ctx context.Context, body TxSendRequest) (*SendTxResult, error) {
+	result := &SendTxResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v1/tx/send",
+		body:       body,
+		idempotent: true,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GetLivenessResult holds the documented response of GetLiveness
+type GetLivenessResult struct {
+	StatusCode int
+	JSON200    *LivenessReport
+}
+
+// GetLiveness calls GET /health/live
+// Liveness of the node process
+func (c *Client) GetLiveness(ctx context.Context) (*GetLivenessResult, error) {
+	result := &GetLivenessResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/health/live",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
+
+// GetReadinessResult holds the documented response of GetReadiness
+type GetReadinessResult struct {
+	StatusCode int
+	JSON200    *ReadinessReport
+	JSON503    *ReadinessReport
+}
+
+// GetReadiness calls GET /health/ready
+// Readiness of the node to take part in signing
+func (c *Client) GetReadiness(ctx context.Context) (*GetReadinessResult, error) {
+	result := &GetReadinessResult{}
+	status, err := c.do(ctx, call{
+		method:     http.MethodGet,
+		path:       "/health/ready",
+		body:       nil,
+		idempotent: false,
+		responses: map[int]interface{}{
+			200: &result.JSON200,
+			503: &result.JSON503,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StatusCode = status
+	return result, nil
+}
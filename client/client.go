@@ -0,0 +1,237 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package client is the Go client of the node HTTP API. Types and operations in
+// client.gen.go are generated from the OpenAPI spec of the routers package.
+package client
+
+//go:generate go run ../cmd/clientgen -spec ../routers/openapi.yaml -out client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	apiKeyHeader         = "X-API-Key"
+)
+
+// RetryPolicy retries calls that failed on the network or with a retryable status
+// with exponential backoff. POST calls are only retried with an idempotency key.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// Error is a failed API call
+type Error struct {
+	StatusCode int
+	Response   ErrorResponse
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Response.Error, e.Response.Message)
+}
+
+// Retryable returns true if the same call may succeed later
+func (e *Error) Retryable() bool {
+	switch e.Response.Error {
+	case ErrorCodeRequestInProgress, ErrorCodeRateLimited, ErrorCodeThresholdNotMet,
+		ErrorCodeNodeStarting, ErrorCodePeerUnreachable, ErrorCodeCoordinatorUnresponsive,
+		ErrorCodeSessionTimeout:
+		return true
+	default:
+		return e.StatusCode == http.StatusBadGateway || e.StatusCode == http.StatusGatewayTimeout
+	}
+}
+
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	apiKey      string
+	bearerToken string
+	retry       RetryPolicy
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.bearerToken = token
+	}
+}
+
+func WithRetryPolicy(retry RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// NewClient creates client of the node at the base URL, e.g. http://127.0.0.1:8001
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey sets the idempotency key of POST calls made with the context.
+// Calls without a key get a random key that is kept across their retries.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+type call struct {
+	method     string
+	path       string
+	body       interface{}
+	idempotent bool
+	// responses are decode targets of documented responses by status code
+	responses map[int]interface{}
+}
+
+// do sends the call with retries and decodes the documented response of the returned
+// status code. Undocumented error statuses are returned as *Error.
+func (c *Client) do(ctx context.Context, call call) (int, error) {
+	var body []byte
+	if call.body != nil {
+		var err error
+		body, err = json.Marshal(call.body)
+		if err != nil {
+			return 0, err
+		}
+	}
+	idempotencyKey := ""
+	if call.idempotent {
+		idempotencyKey, _ = ctx.Value(idempotencyKeyCtx{}).(string)
+		if idempotencyKey == "" {
+			idempotencyKey = newIdempotencyKey()
+		}
+	}
+	retryable := call.method == http.MethodGet || idempotencyKey != ""
+
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		status, respBody, header, err := c.send(ctx, call.method, call.path, body, idempotencyKey)
+		if err == nil {
+			err = decode(status, respBody, call.responses)
+			if err == nil {
+				return status, nil
+			}
+			apiErr, ok := err.(*Error)
+			if !ok || !apiErr.Retryable() {
+				return status, err
+			}
+		}
+		if !retryable || attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+			return status, err
+		}
+
+		wait := backoff
+		if retryAfter, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil {
+			wait = time.Duration(retryAfter) * time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+		if backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, idempotencyKey string) (int, []byte, http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, resp.Header, err
+	}
+	return resp.StatusCode, respBody, resp.Header, nil
+}
+
+func decode(status int, body []byte, responses map[int]interface{}) error {
+	if target, ok := responses[status]; ok {
+		return json.Unmarshal(body, target)
+	}
+	if status >= 200 && status < 300 {
+		return nil
+	}
+
+	apiErr := &Error{StatusCode: status}
+	err := json.Unmarshal(body, &apiErr.Response)
+	if err != nil {
+		apiErr.Response = ErrorResponse{Code: int64(status), Error: ErrorCodeInternal, Message: string(body)}
+	}
+	return apiErr
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	return hex.EncodeToString(key)
+}
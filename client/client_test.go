@@ -0,0 +1,157 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"tss-demo/client"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const hash = "b07e3536cce658dc1615e6e43ee0af85ddeef27de5b237d806a8296f83fec261"
+
+type ClientTestSuite struct {
+	suite.Suite
+	lock      sync.Mutex
+	requests  []*http.Request
+	responses []func(w http.ResponseWriter)
+	server    *httptest.Server
+	client    *client.Client
+}
+
+func TestRunClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	s.requests = nil
+	s.responses = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.requests = append(s.requests, r)
+		respond := s.responses[0]
+		if len(s.responses) > 1 {
+			s.responses = s.responses[1:]
+		}
+		respond(w)
+	}))
+	s.client = client.NewClient(s.server.URL, client.WithAPIKey("key"), client.WithRetryPolicy(client.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}))
+}
+
+func (s *ClientTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *ClientTestSuite) respond(status int, body interface{}) {
+	s.responses = append(s.responses, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+func (s *ClientTestSuite) Test_Sign_Signed() {
+	s.respond(http.StatusOK, client.SignResponse{Code: 200, Result: "0xsignature", Message: "success"})
+
+	result, err := s.client.Sign(context.Background(), client.SignRequest{Hash: hash})
+
+	s.Nil(err)
+	s.Equal(http.StatusOK, result.StatusCode)
+	s.Equal("0xsignature", result.JSON200.Result)
+	s.Nil(result.JSON202)
+	s.Equal("key", s.requests[0].Header.Get("X-API-Key"))
+	s.NotEmpty(s.requests[0].Header.Get(client.IdempotencyKeyHeader))
+}
+
+func (s *ClientTestSuite) Test_Sign_RetriesWithSameIdempotencyKey() {
+	s.respond(http.StatusServiceUnavailable, client.ErrorResponse{Code: 503, Error: client.ErrorCodeThresholdNotMet})
+	s.respond(http.StatusOK, client.SignResponse{Code: 200, Result: "0xsignature"})
+
+	ctx := client.WithIdempotencyKey(context.Background(), "sign-1")
+	result, err := s.client.Sign(ctx, client.SignRequest{Hash: hash})
+
+	s.Nil(err)
+	s.Equal("0xsignature", result.JSON200.Result)
+	s.Len(s.requests, 2)
+	s.Equal("sign-1", s.requests[0].Header.Get(client.IdempotencyKeyHeader))
+	s.Equal("sign-1", s.requests[1].Header.Get(client.IdempotencyKeyHeader))
+}
+
+func (s *ClientTestSuite) Test_Sign_DoesNotRetryClientErrors() {
+	s.respond(http.StatusBadRequest, client.ErrorResponse{Code: 400, Error: client.ErrorCodeInvalidHash, Message: "invalid hash"})
+
+	_, err := s.client.Sign(context.Background(), client.SignRequest{Hash: "invalid"})
+
+	apiErr := &client.Error{}
+	s.True(errors.As(err, &apiErr))
+	s.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	s.Equal(client.ErrorCodeInvalidHash, apiErr.Response.Error)
+	s.Len(s.requests, 1)
+}
+
+func (s *ClientTestSuite) Test_Sign_StopsAfterMaxAttempts() {
+	s.respond(http.StatusBadGateway, client.ErrorResponse{
+		Code:  502,
+		Error: client.ErrorCodeCulpritsIdentified,
+		Peers: []string{"QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54"},
+	})
+
+	_, err := s.client.Sign(context.Background(), client.SignRequest{Hash: hash})
+
+	apiErr := &client.Error{}
+	s.True(errors.As(err, &apiErr))
+	s.Equal([]string{"QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54"}, apiErr.Response.Peers)
+	s.Len(s.requests, 3)
+}
+
+func (s *ClientTestSuite) Test_GetReadiness_NotReady() {
+	s.respond(http.StatusServiceUnavailable, client.ReadinessReport{Ready: false})
+
+	result, err := s.client.GetReadiness(context.Background())
+
+	s.Nil(err)
+	s.Equal(http.StatusServiceUnavailable, result.StatusCode)
+	s.False(result.JSON503.Ready)
+}
+
+func (s *ClientTestSuite) Test_SignAndWait_PollsHeldRequest() {
+	pending := client.SignRequestStatus{Hash: hash, Attempt: 1, Status: client.SignStatusPendingApproval}
+	signed := pending
+	signed.Status = client.SignStatusSigned
+	signed.Signature = "0xsignature"
+	s.respond(http.StatusAccepted, client.SignRequestResponse{Code: 202, Result: pending})
+	s.respond(http.StatusOK, client.SignRequestResponse{Code: 200, Result: pending})
+	s.respond(http.StatusOK, client.SignRequestResponse{Code: 200, Result: signed})
+
+	signature, err := s.client.SignAndWait(context.Background(), client.SignRequest{Hash: hash}, time.Millisecond)
+
+	s.Nil(err)
+	s.Equal("0xsignature", signature)
+	s.Len(s.requests, 3)
+	s.Equal("/api/v1/sign/"+hash, s.requests[2].URL.Path)
+}
+
+func (s *ClientTestSuite) Test_SignAndWait_Failed() {
+	failed := client.SignRequestStatus{Hash: hash, Status: client.SignStatusFailed, Error: "timeout"}
+	s.respond(http.StatusAccepted, client.SignRequestResponse{Code: 202, Result: failed})
+	s.respond(http.StatusOK, client.SignRequestResponse{Code: 200, Result: failed})
+
+	_, err := s.client.SignAndWait(context.Background(), client.SignRequest{Hash: hash}, time.Millisecond)
+
+	s.ErrorIs(err, client.ErrSignRequestFailed)
+}
@@ -0,0 +1,71 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSignRequestFailed  = errors.New("sign request failed")
+	ErrSignRequestExpired = errors.New("sign request expired before approval")
+)
+
+// WaitForSignRequest polls the sign request held for approval until it is signed,
+// failed or expired
+func (c *Client) WaitForSignRequest(ctx context.Context, hash string, interval time.Duration) (*SignRequestStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := c.GetSignRequest(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if result.JSON200 == nil {
+			return nil, fmt.Errorf("unexpected response status %d", result.StatusCode)
+		}
+		request := result.JSON200.Result
+		if request.Status != SignStatusPendingApproval && request.Status != SignStatusApproved {
+			return &request, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SignAndWait signs the hash and returns the signature. Requests held for approval
+// are polled until they are signed.
+func (c *Client) SignAndWait(ctx context.Context, body SignRequest, interval time.Duration) (string, error) {
+	result, err := c.Sign(ctx, body)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case result.JSON200 != nil:
+		return result.JSON200.Result, nil
+	case result.JSON202 == nil:
+		return "", fmt.Errorf("unexpected response status %d", result.StatusCode)
+	}
+
+	request, err := c.WaitForSignRequest(ctx, result.JSON202.Result.Hash, interval)
+	if err != nil {
+		return "", err
+	}
+	switch request.Status {
+	case SignStatusSigned:
+		return request.Signature, nil
+	case SignStatusFailed:
+		return "", fmt.Errorf("%w: %s", ErrSignRequestFailed, request.Error)
+	default:
+		return "", ErrSignRequestExpired
+	}
+}
@@ -3,6 +3,13 @@
 
 package main
 
+import (
+	"os"
+	"tss-demo/cli"
+)
+
 func main() {
-	println("cli")
+	if err := cli.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,291 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// clientgen generates types and operations of the Go API client from the OpenAPI spec
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"tss-demo/tss_util/openapi"
+)
+
+const errorSchema = "ErrorResponse"
+
+var (
+	initialisms  = map[string]bool{"api": true, "http": true, "id": true, "json": true, "ok": true, "rtt": true, "url": true}
+	wordPattern  = regexp.MustCompile(`[A-Z]?[a-z0-9]+|[A-Z]+`)
+	paramPattern = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+func main() {
+	specPath := flag.String("spec", "", "Path to the OpenAPI spec")
+	out := flag.String("out", "", "Path of the generated file")
+	pkg := flag.String("package", "client", "Package of the generated file")
+	flag.Parse()
+
+	err := run(*specPath, *out, *pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, out, pkg string) error {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return err
+	}
+	spec, err := openapi.Load(data)
+	if err != nil {
+		return err
+	}
+
+	g := &generator{spec: spec, imports: make(map[string]bool)}
+	body := g.generate()
+
+	src := &bytes.Buffer{}
+	fmt.Fprintf(src, "// Code generated by clientgen from %s. DO NOT EDIT.\n\n", filepath.Base(specPath))
+	fmt.Fprintf(src, "package %s\n\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for i := range g.imports {
+		imports = append(imports, i)
+	}
+	sort.Strings(imports)
+	src.WriteString("import (\n")
+	for _, i := range imports {
+		fmt.Fprintf(src, "\t%q\n", i)
+	}
+	src.WriteString(")\n")
+	src.Write(body)
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed formatting generated client: %w", err)
+	}
+	return os.WriteFile(out, formatted, 0644)
+}
+
+type generator struct {
+	spec    *openapi.Spec
+	imports map[string]bool
+	out     bytes.Buffer
+}
+
+func (g *generator) generate() []byte {
+	names := make([]string, 0, len(g.spec.Components.Schemas))
+	for name := range g.spec.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.schema(name, g.spec.Components.Schemas[name])
+	}
+
+	for _, route := range g.spec.Routes() {
+		// connections upgraded to other protocols are not served by the client
+		if _, ok := route.Operation.Responses["101"]; ok {
+			continue
+		}
+		g.operation(route)
+	}
+	return g.out.Bytes()
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+func (g *generator) comment(name, description string) {
+	if description == "" {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	g.printf("// %s %s\n", name, lines[0])
+	for _, line := range lines[1:] {
+		g.printf("// %s\n", line)
+	}
+}
+
+func (g *generator) schema(name string, schema *openapi.Schema) {
+	g.printf("\n")
+	g.comment(name, schema.Description)
+	switch {
+	case schema.Type == "string" && len(schema.Enum) > 0:
+		g.printf("type %s string\n\nconst (\n", name)
+		for _, value := range schema.Enum {
+			g.printf("\t%s%s %s = %q\n", name, goName(value), name, value)
+		}
+		g.printf(")\n")
+	case schema.Type == "object" && len(schema.Properties) > 0:
+		g.printf("type %s struct {\n", name)
+		for _, property := range schema.PropertyOrder {
+			propertySchema := schema.Properties[property]
+			required := schema.IsRequired(property)
+			g.comment(goName(property), propertySchema.Description)
+			tag := property
+			if !required {
+				tag += ",omitempty"
+			}
+			g.printf("\t%s %s `json:\"%s\"`\n", goName(property), g.goType(propertySchema, required), tag)
+		}
+		g.printf("}\n")
+	default:
+		g.printf("type %s = %s\n", name, g.goType(schema, true))
+	}
+}
+
+// goType returns the Go type of the schema. Optional objects and times are pointers
+// so that they are omitted from JSON.
+func (g *generator) goType(schema *openapi.Schema, required bool) string {
+	pointer := ""
+	if !required {
+		pointer = "*"
+	}
+	if schema.GoType != "" {
+		if strings.Contains(schema.GoType, "big.") {
+			g.imports["math/big"] = true
+		}
+		return schema.GoType
+	}
+	if schema.Ref != "" {
+		if g.spec.Resolve(schema).Type == "object" {
+			return pointer + schema.RefName()
+		}
+		return schema.RefName()
+	}
+
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date-time" {
+			g.imports["time"] = true
+			return pointer + "time.Time"
+		}
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(schema.Items, true)
+	default:
+		return "map[string]interface{}"
+	}
+}
+
+func (g *generator) operation(route openapi.Route) {
+	operation := route.Operation
+	name := goName(operation.OperationID)
+	g.imports["context"] = true
+	g.imports["net/http"] = true
+
+	type response struct {
+		status int
+		goType string
+	}
+	responses := make([]response, 0)
+	for key := range operation.Responses {
+		status, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		resolved, _ := g.spec.Response(operation, status)
+		media, ok := resolved.Content[openapi.JSONContentType]
+		if !ok || media.Schema == nil || media.Schema.RefName() == errorSchema {
+			continue
+		}
+		responses = append(responses, response{status: status, goType: g.goType(media.Schema, true)})
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].status < responses[j].status
+	})
+
+	g.printf("\n// %sResult holds the documented response of %s\n", name, name)
+	g.printf("type %sResult struct {\n\tStatusCode int\n", name)
+	for _, r := range responses {
+		g.printf("\tJSON%d *%s\n", r.status, r.goType)
+	}
+	g.printf("}\n")
+
+	args := []string{"ctx context.Context"}
+	idempotent := false
+	for _, param := range g.spec.Parameters(operation) {
+		switch {
+		case param.In == "path":
+			args = append(args, fmt.Sprintf("%s string", lowerFirst(goName(param.Name))))
+		case param.In == "header" && param.Name == "Idempotency-Key":
+			idempotent = true
+		}
+	}
+	body := "nil"
+	if operation.RequestBody != nil {
+		args = append(args, fmt.Sprintf("body %s", g.goType(operation.RequestBody.Content[openapi.JSONContentType].Schema, true)))
+		body = "body"
+	}
+
+	path := strconv.Quote(route.Path)
+	if paramPattern.MatchString(route.Path) {
+		g.imports["net/url"] = true
+		path = paramPattern.ReplaceAllStringFunc(path, func(param string) string {
+			return `" + url.PathEscape(` + lowerFirst(goName(strings.Trim(param, "{}"))) + `) + "`
+		})
+		path = strings.TrimSuffix(path, ` + ""`)
+	}
+
+	g.printf("\n// %s calls %s %s\n", name, route.Method, route.Path)
+	if operation.Summary != "" {
+		g.printf("// %s\n", operation.Summary)
+	}
+	g.printf("func (c *Client) %s(%s) (*%sResult, error) {\n", name, strings.Join(args, ", "), name)
+	g.printf("\tresult := &%sResult{}\n", name)
+	g.printf("\tstatus, err := c.do(ctx, call{\n")
+	g.printf("\t\tmethod: http.Method%s,\n", goName(strings.ToLower(route.Method)))
+	g.printf("\t\tpath: %s,\n", path)
+	g.printf("\t\tbody: %s,\n", body)
+	g.printf("\t\tidempotent: %t,\n", idempotent)
+	g.printf("\t\tresponses: map[int]interface{}{\n")
+	for _, r := range responses {
+		g.printf("\t\t\t%d: &result.JSON%d,\n", r.status, r.status)
+	}
+	g.printf("\t\t},\n\t})\n")
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\tresult.StatusCode = status\n\treturn result, nil\n}\n")
+}
+
+// goName converts camel case and snake case names to exported Go names
+func goName(name string) string {
+	name = strings.ReplaceAll(name, "_", " ")
+	name = strings.ReplaceAll(name, "-", " ")
+	words := make([]string, 0)
+	for _, part := range strings.Fields(name) {
+		if strings.ToUpper(part) == part {
+			part = strings.ToLower(part)
+		}
+		words = append(words, wordPattern.FindAllString(part, -1)...)
+	}
+
+	b := strings.Builder{}
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if initialisms[lower] {
+			b.WriteString(strings.ToUpper(lower))
+			continue
+		}
+		b.WriteString(strings.ToUpper(lower[:1]) + lower[1:])
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
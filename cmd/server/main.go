@@ -1,23 +1,37 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	"tss-demo/grpcapi"
 	"tss-demo/logging"
 	"tss-demo/routers"
 	"tss-demo/service"
+	"tss-demo/tss_util/tlsutil"
 
 	_ "tss-demo/config"
 )
 
+// requestShutdownTimeout is how long http requests can take to respond after tss service stopped
+const requestShutdownTimeout = 5 * time.Second
+
 func main() {
-	logging.InitLogger("tss-demo")
+	// logs are written to stdout until the relayer configuration is loaded
+	err := logging.Configure(logging.Config{Level: zerolog.InfoLevel})
+	if err != nil {
+		panic(err)
+	}
 
 	var env = viper.GetString("ENV")
 	if env != "production" {
@@ -26,26 +40,95 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	go func() {
-		err := service.Run()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	configuration, err := service.LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	// the certificate is shared by the API, gRPC and health servers and swapped on
+	// SIGHUP without restarting them
+	tlsConfig := configuration.RelayerConfig.TLSConfig
+	var certReloader *tlsutil.CertReloader
+	var serverTLSConfig *tls.Config
+	if tlsConfig.Enabled() {
+		certReloader, err = tlsutil.NewCertReloader(tlsConfig)
 		if err != nil {
 			panic(err)
 		}
+		go certReloader.ReloadOnSIGHUP(ctx)
+		serverTLSConfig = certReloader.TLSConfig()
+	}
+
+	serviceErr := make(chan error, 1)
+	go func() {
+		serviceErr <- service.Run(ctx, configuration, certReloader)
 	}()
 
 	server := routers.NewServer()
 	server.InitTssDemoApiRouter()
 
 	addr := fmt.Sprintf(":%d", viper.GetInt("port"))
-	logging.Log.Info(fmt.Sprintf("web listen: %s", addr))
+	log.Info().Bool("tls", tlsConfig.Enabled()).Msgf("web listen: %s", addr)
 	go func() {
-		if err := server.Run(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if serverTLSConfig != nil {
+			err = server.RunTLS(addr, serverTLSConfig)
+		} else {
+			err = server.Run(addr)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			panic(fmt.Sprintf("listen error: %v\n", err))
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logging.Log.Info("Server exiting")
+	// gRPC API is served only when its port is configured
+	var grpcServer *grpcapi.Server
+	if grpcPort := viper.GetInt("GRPC_PORT"); grpcPort > 0 {
+		grpcServer = grpcapi.NewServer(serverTLSConfig)
+		grpcAddr := fmt.Sprintf(":%d", grpcPort)
+		log.Info().Bool("tls", tlsConfig.Enabled()).Msgf("grpc listen: %s", grpcAddr)
+		go func() {
+			err := grpcServer.Run(grpcAddr)
+			if err != nil {
+				panic(fmt.Sprintf("grpc listen error: %v\n", err))
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	log.Info().Msg("Server shutting down")
+
+	// stop accepting new requests while running sessions are drained by the service
+	shutdownCtx, cancelShutdown := context.WithCancel(context.Background())
+	httpErr := make(chan error, 1)
+	go func() {
+		httpErr <- server.Shutdown(shutdownCtx)
+	}()
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			grpcServer.Shutdown(shutdownCtx)
+		}
+		close(grpcStopped)
+	}()
+
+	exitCode := 0
+	if err := <-serviceErr; err != nil {
+		log.Error().Err(err).Msg("tss service stopped with error")
+		exitCode = 1
+	}
+	time.AfterFunc(requestShutdownTimeout, cancelShutdown)
+	if err := <-httpErr; err != nil {
+		log.Error().Err(err).Msg("http server shutdown error")
+		exitCode = 1
+	}
+	<-grpcStopped
+	cancelShutdown()
+
+	log.Info().Msg("Server exiting")
+	_ = logging.Close()
+	os.Exit(exitCode)
 }
@@ -18,11 +18,10 @@ func init() {
 
 	// Read in from environment variables
 	// common
-	_ = viper.BindEnv("LOG.LEVEL")
-
 	_ = viper.BindEnv("ENV")
 
 	_ = viper.BindEnv("PORT")
+	_ = viper.BindEnv("GRPC_PORT")
 
 	_ = viper.BindEnv("TSS_CONFIG")
 	_ = viper.BindEnv("NAME")
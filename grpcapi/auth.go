@@ -0,0 +1,246 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"tss-demo/routers"
+	"tss-demo/service"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/limits"
+
+	tssv1 "tss-demo/proto/tss/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// permissions are permissions required by the methods, methods that start signing
+// sessions are rate limited per client
+var permissions = map[string]auth.Permission{
+	tssv1.TssService_Keygen_FullMethodName:       auth.Keygen,
+	tssv1.TssService_Sign_FullMethodName:         auth.Sign,
+	tssv1.TssService_BatchSign_FullMethodName:    auth.Sign,
+	tssv1.TssService_Reshare_FullMethodName:      auth.Reshare,
+	tssv1.TssService_ListKeys_FullMethodName:     auth.ReadStatus,
+	tssv1.TssService_GetSession_FullMethodName:   auth.ReadStatus,
+	tssv1.TssService_WatchSession_FullMethodName: auth.ReadStatus,
+}
+
+type identityCtx struct{}
+
+func authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ctx, err = authorize(ctx, info.FullMethod)
+	defer func() {
+		// panics are turned into internal errors by the recovery interceptor
+		if r := recover(); r != nil {
+			auditCall(ctx, info.FullMethod, req, status.Error(codes.Internal, "internal error"))
+			panic(r)
+		}
+		auditCall(ctx, info.FullMethod, req, err)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authorizeStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, err := authorize(stream.Context(), info.FullMethod)
+	authorized := &authorizedStream{ServerStream: stream, ctx: ctx}
+	defer func() {
+		if r := recover(); r != nil {
+			auditCall(ctx, info.FullMethod, authorized.request, status.Error(codes.Internal, "internal error"))
+			panic(r)
+		}
+		auditCall(ctx, info.FullMethod, authorized.request, err)
+	}()
+	if err != nil {
+		return err
+	}
+	return handler(srv, authorized)
+}
+
+// authorize lets through callers whose role has the permission of the method like the
+// HTTP API does. Returned context carries the identity of authenticated callers, also
+// when the call is refused.
+func authorize(ctx context.Context, method string) (context.Context, error) {
+	permission, ok := permissions[method]
+	if !ok {
+		return ctx, status.Errorf(codes.Unimplemented, "method %s is not served", method)
+	}
+	if service.Authenticator == nil {
+		return ctx, toStatus(errNodeStarting)
+	}
+
+	if service.Authenticator.Enabled() {
+		md, _ := metadata.FromIncomingContext(ctx)
+		identity, err := service.Authenticator.AuthenticateCredentials(first(md, strings.ToLower(auth.APIKeyHeader)), first(md, "authorization"))
+		if err != nil {
+			return ctx, deny(ctx, method, "api_auth", permission, err)
+		}
+		ctx = context.WithValue(ctx, identityCtx{}, identity)
+		if !identity.Role.Allows(permission) {
+			return ctx, deny(ctx, method, "api_auth", permission, &routers.APIError{
+				Status:  http.StatusForbidden,
+				Code:    routers.PermissionDenied,
+				Message: fmt.Sprintf("role %s is not allowed to %s", identity.Role, permission),
+			})
+		}
+	}
+
+	if permission == auth.Sign && service.Limiter != nil {
+		err := service.Limiter.AllowClient(client(ctx))
+		if errors.Is(err, limits.ErrRateLimited) {
+			return ctx, deny(ctx, method, "rate_limit", permission, err)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed checking client rate limit")
+		}
+	}
+	return ctx, nil
+}
+
+// deny returns the status of the refused call and records the decision with the caller identity
+func deny(ctx context.Context, method string, policy string, permission auth.Permission, err error) error {
+	caller := actor(ctx)
+	reason := err.Error()
+	log.Warn().Str("caller", caller).Str("method", method).Msgf("Denied grpc call: %s", reason)
+
+	if service.AuditLog != nil {
+		auditErr := service.AuditLog.Record(audit.Entry{
+			Type:    audit.PolicyDecision,
+			Actor:   caller,
+			Outcome: "denied",
+			Details: map[string]string{
+				"policy":     policy,
+				"permission": string(permission),
+				"method":     method,
+				"reason":     reason,
+			},
+		})
+		if auditErr != nil {
+			log.Error().Err(auditErr).Msg("Failed recording denied grpc call into audit log")
+		}
+	}
+	return toStatus(err)
+}
+
+// auditCall records the call with the caller, the request and the resulting status
+// into the audit log like the HTTP API records its calls
+func auditCall(ctx context.Context, method string, req interface{}, err error) {
+	if service.AuditLog == nil {
+		return
+	}
+	details := map[string]string{
+		"method": method,
+		"code":   status.Code(err).String(),
+	}
+	if message, ok := req.(proto.Message); ok {
+		if body, marshalErr := protojson.Marshal(message); marshalErr == nil {
+			details["body"] = string(body)
+		}
+	}
+	if err != nil {
+		details["error"] = status.Convert(err).Message()
+	}
+
+	auditErr := service.AuditLog.Record(audit.Entry{
+		Type:    audit.APICall,
+		Actor:   actor(ctx),
+		Details: details,
+	})
+	if auditErr != nil {
+		log.Error().Err(auditErr).Msgf("Failed recording grpc call %s", method)
+	}
+}
+
+// actor returns authenticated caller name with the caller address
+func actor(ctx context.Context) string {
+	identity, ok := ctx.Value(identityCtx{}).(auth.Identity)
+	if !ok {
+		return fmt.Sprintf("anonymous@%s", address(ctx))
+	}
+	return fmt.Sprintf("%s@%s", identity.Name, address(ctx))
+}
+
+// client returns authenticated caller name or the caller address of anonymous callers
+func client(ctx context.Context) string {
+	if identity, ok := ctx.Value(identityCtx{}).(auth.Identity); ok {
+		return identity.Name
+	}
+	return address(ctx)
+}
+
+// address returns the caller host without the port
+func address(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host := p.Addr.String()
+	if i := strings.LastIndex(host, ":"); i > 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+func first(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("method", info.FullMethod).Msgf("Recovered grpc call panic: %v", r)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("method", info.FullMethod).Msgf("Recovered grpc stream panic: %v", r)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(srv, stream)
+}
+
+// authorizedStream is the server stream with the context of the authorized caller. It
+// keeps the first received message as the request of the call.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	request interface{}
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.request == nil {
+		s.request = m
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grpcapi
+
+import (
+	"net/http"
+	"strings"
+	"tss-demo/routers"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of ErrorInfo details of failed calls
+const ErrorDomain = "tss-demo"
+
+var errNodeStarting = &routers.APIError{Status: http.StatusServiceUnavailable, Code: routers.NodeStarting, Message: "node is starting"}
+
+var grpcCodes = map[routers.ErrorCode]codes.Code{
+	routers.InvalidRequest:          codes.InvalidArgument,
+	routers.InvalidHash:             codes.InvalidArgument,
+	routers.InvalidTransaction:      codes.InvalidArgument,
+	routers.TransactionRequired:     codes.InvalidArgument,
+	routers.InvalidSignature:        codes.InvalidArgument,
+	routers.Unauthenticated:         codes.Unauthenticated,
+	routers.PermissionDenied:        codes.PermissionDenied,
+	routers.UnknownApprover:         codes.PermissionDenied,
+	routers.KeyshareNotFound:        codes.NotFound,
+	routers.SignRequestNotFound:     codes.NotFound,
+	routers.SessionNotFound:         codes.NotFound,
+	routers.ApprovalDisabled:        codes.NotFound,
+	routers.SessionPending:          codes.Aborted,
+	routers.SignRequestNotPending:   codes.FailedPrecondition,
+	routers.SignRequestExpired:      codes.FailedPrecondition,
+	routers.RateLimited:             codes.ResourceExhausted,
+	routers.VelocityLimitExceeded:   codes.ResourceExhausted,
+	routers.ThresholdNotMet:         codes.Unavailable,
+	routers.NodeStarting:            codes.Unavailable,
+	routers.NodeShuttingDown:        codes.Unavailable,
+	routers.CulpritsIdentified:      codes.Aborted,
+	routers.PeerUnreachable:         codes.Unavailable,
+	routers.CoordinatorUnresponsive: codes.Unavailable,
+	routers.SessionTimeout:          codes.DeadlineExceeded,
+	routers.ChainNotFound:           codes.NotFound,
+	routers.ApprovalRequired:        codes.FailedPrecondition,
+	routers.GasPriceTooHigh:         codes.FailedPrecondition,
+	routers.BroadcastFailed:         codes.Unavailable,
+	routers.NonceNotPending:         codes.NotFound,
+}
+
+// toStatus returns the gRPC status of the error. The stable error code of the HTTP API
+// is the reason of the ErrorInfo details, with peers to blame in the metadata.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	apiErr := routers.ToAPIError(err)
+	code, ok := grpcCodes[apiErr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	info := &errdetails.ErrorInfo{Reason: string(apiErr.Code), Domain: ErrorDomain}
+	if len(apiErr.Peers) > 0 {
+		info.Metadata = map[string]string{"peers": strings.Join(peerIDs(apiErr.Peers), ",")}
+	}
+
+	st, detailsErr := status.New(code, apiErr.Message).WithDetails(info)
+	if detailsErr != nil {
+		return status.Error(code, apiErr.Message)
+	}
+	return st.Err()
+}
+
+func invalidArgument(code routers.ErrorCode, message string) error {
+	return toStatus(&routers.APIError{Status: http.StatusBadRequest, Code: code, Message: message})
+}
@@ -0,0 +1,320 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package grpcapi serves the gRPC API of the node next to the HTTP API of the routers package
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"tss-demo/routers"
+	"tss-demo/service"
+	"tss-demo/service/event_handlers"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/limits"
+	"tss-demo/tss_util/sessions"
+	"tss-demo/tss_util/tss"
+
+	tssv1 "tss-demo/proto/tss/v1"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxBatchSize is the maximum number of hashes signed in one session
+	maxBatchSize = 32
+	// watchBuffer is the number of events a watcher can fall behind before it is dropped
+	watchBuffer = 64
+)
+
+var hashPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+var phases = map[tss.SessionEventType]tssv1.SessionPhase{
+	tss.EventElection:  tssv1.SessionPhase_SESSION_PHASE_ELECTION,
+	tss.EventReady:     tssv1.SessionPhase_SESSION_PHASE_READY,
+	tss.EventStarted:   tssv1.SessionPhase_SESSION_PHASE_STARTED,
+	tss.EventRound:     tssv1.SessionPhase_SESSION_PHASE_ROUND,
+	tss.EventCompleted: tssv1.SessionPhase_SESSION_PHASE_COMPLETED,
+	tss.EventFailed:    tssv1.SessionPhase_SESSION_PHASE_FAILED,
+	tss.EventCancelled: tssv1.SessionPhase_SESSION_PHASE_CANCELLED,
+}
+
+type Server struct {
+	tssv1.UnimplementedTssServiceServer
+	grpcServer *grpc.Server
+}
+
+// NewServer creates the gRPC server. Calls are served over TLS if the tls config is set.
+func NewServer(tlsConfig *tls.Config) *Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoverUnary, authorizeUnary),
+		grpc.ChainStreamInterceptor(recoverStream, authorizeStream),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := &Server{grpcServer: grpc.NewServer(opts...)}
+	tssv1.RegisterTssServiceServer(s.grpcServer, s)
+	return s
+}
+
+// Serve serves calls from the listener until the server is shut down
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpcServer.Serve(lis)
+}
+
+func (s *Server) Run(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Shutdown stops accepting new calls and waits for running calls to finish. Running calls
+// and session watchers are closed when the context is done.
+func (s *Server) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+}
+
+func (s *Server) Keygen(ctx context.Context, req *tssv1.KeygenRequest) (*tssv1.KeygenResponse, error) {
+	if service.KeygenEventHandler == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	err := service.KeygenEventHandler.HandleEvents()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &tssv1.KeygenResponse{SessionId: event_handlers.KeygenSessionID}, nil
+}
+
+func (s *Server) Sign(ctx context.Context, req *tssv1.SignRequest) (*tssv1.SignResponse, error) {
+	value, err := signValue(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.Approvals != nil {
+		request, err := service.Approvals.Submit(req.Hash, value, actor(ctx))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed submitting sign request")
+			return nil, toStatus(err)
+		}
+		return &tssv1.SignResponse{
+			SessionId: event_handlers.SignSessionID(request.Hash),
+			Signature: request.Signature,
+			Approval: &tssv1.SignApproval{
+				Status:    string(request.Status),
+				Attempt:   request.Attempt,
+				ExpiresAt: timestamppb.New(request.ExpiresAt),
+			},
+		}, nil
+	}
+
+	if service.SignEventHandler == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	signature, err := service.SignEventHandler.HandleEvents(req.Hash, value)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed executing sign")
+		return nil, toStatus(err)
+	}
+	return &tssv1.SignResponse{SessionId: event_handlers.SignSessionID(req.Hash), Signature: signature}, nil
+}
+
+func (s *Server) BatchSign(ctx context.Context, req *tssv1.BatchSignRequest) (*tssv1.BatchSignResponse, error) {
+	if service.Approvals != nil {
+		return nil, status.Error(codes.FailedPrecondition, "batches can't be signed while sign requests need approval")
+	}
+	if len(req.Requests) == 0 || len(req.Requests) > maxBatchSize {
+		return nil, invalidArgument(routers.InvalidRequest, fmt.Sprintf("batch must have between 1 and %d requests", maxBatchSize))
+	}
+
+	hashes := make([]string, len(req.Requests))
+	values := make([]*big.Int, len(req.Requests))
+	for i, request := range req.Requests {
+		value, err := signValue(request)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = request.Hash
+		values[i] = value
+	}
+
+	if service.SignEventHandler == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	signatures, err := service.SignEventHandler.HandleBatch(hashes, values)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed executing batch sign")
+		return nil, toStatus(err)
+	}
+	return &tssv1.BatchSignResponse{SessionId: event_handlers.SignSessionID(hashes[0]), Signatures: signatures}, nil
+}
+
+func (s *Server) Reshare(ctx context.Context, req *tssv1.ReshareRequest) (*tssv1.ReshareResponse, error) {
+	if service.ReshareEventHandler == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	err := service.ReshareEventHandler.HandleEvents()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &tssv1.ReshareResponse{SessionId: event_handlers.ReshareSessionID}, nil
+}
+
+func (s *Server) ListKeys(ctx context.Context, req *tssv1.ListKeysRequest) (*tssv1.ListKeysResponse, error) {
+	if service.Keyshares == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	keys := make([]*tssv1.Key, 0)
+	key, err := service.Keyshares.GetKeyshare()
+	if err == nil && key.ID() != "" {
+		keys = append(keys, toKey(key))
+	}
+	return &tssv1.ListKeysResponse{Keys: keys}, nil
+}
+
+func (s *Server) GetSession(ctx context.Context, req *tssv1.GetSessionRequest) (*tssv1.Session, error) {
+	if service.Sessions == nil {
+		return nil, toStatus(errNodeStarting)
+	}
+	sessionStatus, ok := service.Sessions.Status(req.SessionId)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session %s not found", req.SessionId)
+	}
+	return toSession(sessionStatus), nil
+}
+
+func (s *Server) WatchSession(req *tssv1.WatchSessionRequest, stream tssv1.TssService_WatchSessionServer) error {
+	if service.Sessions == nil {
+		return toStatus(errNodeStarting)
+	}
+	if req.SessionId == "" {
+		return invalidArgument(routers.InvalidRequest, "session ID is required")
+	}
+
+	// unknown sessions may never run, finished sessions are replayed up to their final phase
+	if _, ok := service.Sessions.Status(req.SessionId); !ok {
+		return status.Errorf(codes.NotFound, "session %s not found", req.SessionId)
+	}
+
+	subscription := service.Sessions.Watch(req.SessionId, watchBuffer)
+	defer subscription.Close()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if subscription.Lagged() {
+					return status.Error(codes.ResourceExhausted, "watcher fell behind session events")
+				}
+				return nil
+			}
+			err := stream.Send(toSessionEvent(event))
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// signValue validates the hash and returns the value of the transaction of the request
+func signValue(req *tssv1.SignRequest) (*big.Int, error) {
+	if !hashPattern.MatchString(req.Hash) {
+		return nil, invalidArgument(routers.InvalidHash, fmt.Sprintf("hash %s must be 32 hex encoded bytes", req.Hash))
+	}
+	if req.Tx == "" {
+		return nil, nil
+	}
+	encodedTx, err := hex.DecodeString(strings.TrimPrefix(req.Tx, "0x"))
+	if err != nil {
+		return nil, invalidArgument(routers.InvalidTransaction, err.Error())
+	}
+	value, err := limits.TxValue(encodedTx, big.NewInt(req.ChainId), req.Hash)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return value, nil
+}
+
+func toKey(key keyshare.ECDSAKeyshare) *tssv1.Key {
+	return &tssv1.Key{
+		Id:        key.ID(),
+		PublicKey: hex.EncodeToString(crypto.FromECDSAPub(key.Key.ECDSAPub.ToBtcecPubKey().ToECDSA())),
+		Threshold: int32(key.Threshold),
+		Peers:     peerIDs(key.Peers),
+	}
+}
+
+func toSession(s sessions.Status) *tssv1.Session {
+	return &tssv1.Session{
+		SessionId:   s.SessionID,
+		Process:     s.Process,
+		Phase:       phases[s.Phase],
+		Round:       uint32(s.Round),
+		RoundName:   s.RoundName,
+		Coordinator: peerID(s.Coordinator),
+		Culprits:    peerIDs(s.Culprits),
+		Error:       s.Error,
+		StartedAt:   timestamppb.New(s.StartedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func toSessionEvent(event tss.SessionEvent) *tssv1.SessionEvent {
+	e := &tssv1.SessionEvent{
+		SessionId:   event.SessionID,
+		Process:     event.Process,
+		Phase:       phases[event.Type],
+		Time:        timestamppb.New(event.Time),
+		Coordinator: peerID(event.Coordinator),
+		Round:       uint32(event.Round),
+		RoundName:   event.RoundName,
+		Culprits:    peerIDs(event.Culprits),
+	}
+	if event.Err != nil {
+		e.Error = event.Err.Error()
+	}
+	return e
+}
+
+func peerID(p peer.ID) string {
+	if p == "" {
+		return ""
+	}
+	return p.Pretty()
+}
+
+func peerIDs(peers []peer.ID) []string {
+	ids := make([]string, len(peers))
+	for i, p := range peers {
+		ids[i] = p.Pretty()
+	}
+	return ids
+}
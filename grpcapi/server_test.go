@@ -0,0 +1,322 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"tss-demo/grpcapi"
+	"tss-demo/routers"
+	"tss-demo/service"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/sessions"
+	"tss-demo/tss_util/tss"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	tssv1 "tss-demo/proto/tss/v1"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	hash      = "b07e3536cce658dc1615e6e43ee0af85ddeef27de5b237d806a8296f83fec261"
+	sessionID = "sid-sign-" + hash
+	culprit   = "QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54"
+)
+
+type signer struct{}
+
+func (s *signer) HandleEvents(hash string, value *big.Int) (string, error) {
+	return "signature", nil
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	server *grpcapi.Server
+	conn   *grpc.ClientConn
+	client tssv1.TssServiceClient
+	db     *lvldb.LVLDB
+}
+
+func TestRunServerTestSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	var err error
+	service.Authenticator, err = auth.NewAuthenticator(relayer.AuthConfig{
+		APIKeys: []relayer.APIKey{
+			{Name: "observer", Role: string(auth.Observer), KeyHash: keyHash("observer-key")},
+			{Name: "signer", Role: string(auth.Signer), KeyHash: keyHash("signer-key")},
+		},
+	})
+	s.Nil(err)
+	service.Sessions = sessions.NewHub(sessions.DefaultRetained)
+
+	lis := bufconn.Listen(1024 * 1024)
+	s.server = grpcapi.NewServer(nil)
+	go func() {
+		_ = s.server.Serve(lis)
+	}()
+	s.conn, err = grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Nil(err)
+	s.client = tssv1.NewTssServiceClient(s.conn)
+}
+
+func (s *ServerTestSuite) TearDownTest() {
+	_ = s.conn.Close()
+	s.server.Shutdown(context.Background())
+	service.Authenticator = nil
+	service.Sessions = nil
+	service.Approvals = nil
+	if s.db != nil {
+		_ = s.db.Close()
+	}
+}
+
+func keyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+// reason returns the stable error code of the failed call
+func (s *ServerTestSuite) reason(err error) string {
+	st, ok := status.FromError(err)
+	s.True(ok)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			s.Equal(grpcapi.ErrorDomain, info.Domain)
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func (s *ServerTestSuite) Test_MissingCredentials() {
+	_, err := s.client.ListKeys(context.Background(), &tssv1.ListKeysRequest{})
+
+	s.Equal(codes.Unauthenticated, status.Code(err))
+	s.Equal(string(routers.Unauthenticated), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_PermissionDenied() {
+	_, err := s.client.Sign(withKey("observer-key"), &tssv1.SignRequest{Hash: hash})
+
+	s.Equal(codes.PermissionDenied, status.Code(err))
+	s.Equal(string(routers.PermissionDenied), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_Sign_InvalidHash() {
+	_, err := s.client.Sign(withKey("signer-key"), &tssv1.SignRequest{Hash: "0x1234"})
+
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	s.Equal(string(routers.InvalidHash), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_Sign_NodeStarting() {
+	_, err := s.client.Sign(withKey("signer-key"), &tssv1.SignRequest{Hash: hash})
+
+	s.Equal(codes.Unavailable, status.Code(err))
+	s.Equal(string(routers.NodeStarting), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_BatchSign_EmptyBatch() {
+	_, err := s.client.BatchSign(withKey("signer-key"), &tssv1.BatchSignRequest{})
+
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	s.Equal(string(routers.InvalidRequest), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_BatchSign_InvalidHash() {
+	_, err := s.client.BatchSign(withKey("signer-key"), &tssv1.BatchSignRequest{
+		Requests: []*tssv1.SignRequest{{Hash: hash}, {Hash: "1234"}},
+	})
+
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	s.Equal(string(routers.InvalidHash), s.reason(err))
+}
+
+func (s *ServerTestSuite) Test_GetSession_NotFound() {
+	_, err := s.client.GetSession(withKey("observer-key"), &tssv1.GetSessionRequest{SessionId: sessionID})
+
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *ServerTestSuite) Test_CallsAudited() {
+	key, _, _ := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	path := filepath.Join(s.T().TempDir(), "audit.log")
+	var err error
+	service.AuditLog, err = audit.NewLog(path, key)
+	s.Nil(err)
+	defer func() {
+		_ = service.AuditLog.Close()
+		service.AuditLog = nil
+	}()
+
+	_, err = s.client.GetSession(withKey("observer-key"), &tssv1.GetSessionRequest{SessionId: sessionID})
+	s.Equal(codes.NotFound, status.Code(err))
+	_, err = s.client.Sign(withKey("observer-key"), &tssv1.SignRequest{Hash: hash})
+	s.Equal(codes.PermissionDenied, status.Code(err))
+
+	entries, err := audit.ReadEntries(path)
+	s.Nil(err)
+	calls := make([]audit.Entry, 0)
+	for _, entry := range entries {
+		if entry.Type == audit.APICall {
+			calls = append(calls, entry)
+		}
+	}
+	s.Len(calls, 2)
+	s.True(strings.HasPrefix(calls[0].Actor, "observer@"))
+	s.Equal(tssv1.TssService_GetSession_FullMethodName, calls[0].Details["method"])
+	s.Equal(codes.NotFound.String(), calls[0].Details["code"])
+	s.Contains(calls[0].Details["body"], sessionID)
+	s.Equal(tssv1.TssService_Sign_FullMethodName, calls[1].Details["method"])
+	s.Equal(codes.PermissionDenied.String(), calls[1].Details["code"])
+	s.NotEmpty(calls[1].Details["error"])
+}
+
+func (s *ServerTestSuite) Test_GetSession_FailedWithCulprits() {
+	culpritID, err := peer.Decode(culprit)
+	s.Nil(err)
+	service.Sessions.ObserveSession(tss.SessionEvent{SessionID: sessionID, Process: "signing", Type: tss.EventStarted, Time: time.Now()})
+	service.Sessions.ObserveSession(tss.SessionEvent{
+		SessionID: sessionID,
+		Process:   "signing",
+		Type:      tss.EventFailed,
+		Time:      time.Now(),
+		Culprits:  []peer.ID{culpritID},
+		Err:       errors.New("invalid share"),
+	})
+
+	session, err := s.client.GetSession(withKey("observer-key"), &tssv1.GetSessionRequest{SessionId: sessionID})
+
+	s.Nil(err)
+	s.Equal(tssv1.SessionPhase_SESSION_PHASE_FAILED, session.Phase)
+	s.Equal([]string{culprit}, session.Culprits)
+	s.Equal("invalid share", session.Error)
+}
+
+func (s *ServerTestSuite) observe(events ...tss.SessionEvent) {
+	for _, event := range events {
+		event.SessionID = sessionID
+		event.Time = time.Now()
+		service.Sessions.ObserveSession(event)
+	}
+}
+
+func (s *ServerTestSuite) Test_WatchSession_StreamsPhases() {
+	s.observe(tss.SessionEvent{Type: tss.EventElection})
+	stream, err := s.client.WatchSession(withKey("observer-key"), &tssv1.WatchSessionRequest{SessionId: sessionID})
+	s.Nil(err)
+
+	// replayed event is received once the watcher is subscribed
+	event, err := stream.Recv()
+	s.Nil(err)
+	s.Equal(tssv1.SessionPhase_SESSION_PHASE_ELECTION, event.Phase)
+	s.observe(
+		tss.SessionEvent{Type: tss.EventReady},
+		tss.SessionEvent{Type: tss.EventStarted},
+		tss.SessionEvent{Type: tss.EventRound, Round: 1, RoundName: "SignRound1Message1"},
+		tss.SessionEvent{Type: tss.EventCompleted},
+	)
+
+	phases := make([]tssv1.SessionPhase, 0)
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		s.Nil(err)
+		phases = append(phases, event.Phase)
+		if event.Phase == tssv1.SessionPhase_SESSION_PHASE_ROUND {
+			s.Equal(uint32(1), event.Round)
+		}
+	}
+	s.Equal([]tssv1.SessionPhase{
+		tssv1.SessionPhase_SESSION_PHASE_READY,
+		tssv1.SessionPhase_SESSION_PHASE_STARTED,
+		tssv1.SessionPhase_SESSION_PHASE_ROUND,
+		tssv1.SessionPhase_SESSION_PHASE_COMPLETED,
+	}, phases)
+}
+
+func (s *ServerTestSuite) Test_WatchSession_NotFound() {
+	stream, err := s.client.WatchSession(withKey("observer-key"), &tssv1.WatchSessionRequest{SessionId: "sid-sign-unknown"})
+	s.Nil(err)
+
+	_, err = stream.Recv()
+
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *ServerTestSuite) Test_WatchSession_FinishedSessionReplayed() {
+	s.observe(tss.SessionEvent{Type: tss.EventElection}, tss.SessionEvent{Type: tss.EventCompleted})
+	stream, err := s.client.WatchSession(withKey("observer-key"), &tssv1.WatchSessionRequest{SessionId: sessionID})
+	s.Nil(err)
+
+	phases := make([]tssv1.SessionPhase, 0)
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		s.Nil(err)
+		phases = append(phases, event.Phase)
+	}
+	s.Equal([]tssv1.SessionPhase{
+		tssv1.SessionPhase_SESSION_PHASE_ELECTION,
+		tssv1.SessionPhase_SESSION_PHASE_COMPLETED,
+	}, phases)
+}
+
+func (s *ServerTestSuite) Test_Sign_HeldForApproval() {
+	var err error
+	s.db, err = lvldb.NewLvlDB(s.T().TempDir())
+	s.Nil(err)
+	service.Approvals = approval.NewWorkflow(approval.NewStore(s.db), &signer{}, relayer.ApprovalConfig{
+		Approvers: []relayer.Approver{{Name: "alice", Address: "0x0000000000000000000000000000000000000001"}},
+		Quorum:    1,
+		Deadline:  time.Hour,
+	})
+
+	response, err := s.client.Sign(withKey("signer-key"), &tssv1.SignRequest{Hash: hash})
+
+	s.Nil(err)
+	s.Equal(sessionID, response.SessionId)
+	s.Empty(response.Signature)
+	s.Equal(string(approval.Pending), response.Approval.Status)
+	s.Equal(uint32(1), response.Approval.Attempt)
+
+	_, err = s.client.BatchSign(withKey("signer-key"), &tssv1.BatchSignRequest{Requests: []*tssv1.SignRequest{{Hash: hash}}})
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
@@ -2,90 +2,117 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/spf13/viper"
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
-var (
-	logLevel            = viper.GetString("LOG.LEVEL")
-	defaultLogLevelInfo = "info"
+const (
+	JSONFormat    = "json"
+	ConsoleFormat = "console"
 
-	Log *zap.Logger
+	megabyte = 1024 * 1024
 )
 
-func InitLogger(programName string) {
-	InitLoggerWithLogFile(programName, "")
-}
+// Fields set on log lines of the node and of tss sessions
+const (
+	NodeField    = "Node"
+	PeerField    = "Peer"
+	SessionField = "SessionID"
+	ProcessField = "Process"
+	KeyIDField   = "KeyID"
+)
 
-func InitLoggerWithLogFile(programName, logFilePath string) {
-	if logLevel == "" {
-		logLevel = defaultLogLevelInfo
-	}
+// Config defines where and how logs are written
+type Config struct {
+	Level  zerolog.Level
+	Format string
+	// File is written next to stdout if set
+	File string
+	// MaxSize is size of the log file in megabytes after which the file is rotated
+	MaxSize int
+	// MaxBackups is the number of rotated log files that are kept
+	MaxBackups int
+}
 
-	config := zap.NewProductionConfig()
-
-	// Set log level based on logLevel tss_config
-	var level zapcore.Level
-	switch logLevel {
-	case "debug":
-		level = zapcore.DebugLevel
-	case defaultLogLevelInfo:
-		level = zapcore.InfoLevel
-	case "warn":
-		level = zapcore.WarnLevel
-	case "error":
-		level = zapcore.ErrorLevel
-	default:
-		level = zapcore.InfoLevel
-	}
-	config.Level = zap.NewAtomicLevelAt(level)
+// output is the destination of all loggers so that loggers created before
+// Configure write to the configured destination
+var output = &switchWriter{w: os.Stdout}
 
-	config.EncoderConfig.TimeKey = "timestamp"
-	config.EncoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-		enc.AppendString(t.Format("2006-01-02 15:04:05"))
-	}
-	config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	config.EncoderConfig.CallerKey = "caller"
-	config.EncoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
-	config.Encoding = "json"
-
-	// Create a file to write logs
-	if logFilePath != "" {
-		// Ensure the directory exists
-		dir := filepath.Dir(logFilePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Printf("%s init_db logger error: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
-			return
-		}
+func init() {
+	log.Logger = zerolog.New(output).With().Timestamp().Logger()
+}
 
-		// Create a file to write logs
-		file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			fmt.Printf("%s init_db logger error: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
-			return
-		}
-		// Create a core that writes logs to the file
-		fileCore := zapcore.NewCore(
-			zapcore.NewJSONEncoder(config.EncoderConfig),
-			zapcore.AddSync(file),
-			level,
-		)
-		// Build the logger with the file core
-		Log = zap.New(fileCore, zap.AddCaller(), zap.AddCallerSkip(1))
-	} else {
+// Configure sets log level and output of the global logger and all loggers derived from it
+func Configure(config Config) error {
+	var w io.Writer = os.Stdout
+	var file *RotatingFile
+	if config.File != "" {
 		var err error
-		Log, err = config.Build(zap.AddCallerSkip(1))
+		file, err = NewRotatingFile(config.File, int64(config.MaxSize)*megabyte, config.MaxBackups)
 		if err != nil {
-			fmt.Printf("%s init_db logger error: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
-			return
+			return fmt.Errorf("unable to open log file: %w", err)
 		}
+		w = io.MultiWriter(os.Stdout, file)
 	}
-	Log = Log.With(zap.String("program", programName))
 
-	Log.Info("init Zap logger success!")
+	switch config.Format {
+	case "", JSONFormat:
+	case ConsoleFormat:
+		w = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
+	default:
+		return fmt.Errorf("unknown log format %s", config.Format)
+	}
+
+	zerolog.SetGlobalLevel(config.Level)
+	output.set(w, file)
+	return nil
+}
+
+// SetNode adds node name and peer ID to the global logger. Loggers derived
+// before the call don't have the fields.
+func SetNode(name string, peerID peer.ID) {
+	log.Logger = log.With().Str(NodeField, name).Str(PeerField, peerID.Pretty()).Logger()
+}
+
+// Session returns logger context with fields of a tss session
+func Session(sessionID string, process string) zerolog.Context {
+	return log.With().Str(SessionField, sessionID).Str(ProcessField, process)
+}
+
+// Close closes the log file
+func Close() error {
+	return output.set(os.Stdout, nil)
+}
+
+type switchWriter struct {
+	mu   sync.RWMutex
+	w    io.Writer
+	file *RotatingFile
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.w.Write(p)
+}
+
+// set switches the output and closes the previous log file
+func (s *switchWriter) set(w io.Writer, file *RotatingFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.file
+	s.w = w
+	s.file = file
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
 }
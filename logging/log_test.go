@@ -0,0 +1,90 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tss-demo/logging"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogTestSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestRunLogTestSuite(t *testing.T) {
+	suite.Run(t, new(LogTestSuite))
+}
+
+func (s *LogTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "out.log")
+}
+
+func (s *LogTestSuite) TearDownTest() {
+	_ = logging.Close()
+}
+
+func (s *LogTestSuite) lines() []map[string]interface{} {
+	content, err := os.ReadFile(s.path)
+	s.Nil(err)
+
+	lines := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		fields := make(map[string]interface{})
+		s.Nil(json.Unmarshal([]byte(line), &fields))
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func (s *LogTestSuite) Test_Configure_InvalidFormat() {
+	err := logging.Configure(logging.Config{Format: "xml"})
+
+	s.NotNil(err)
+}
+
+func (s *LogTestSuite) Test_Configure_FiltersLevel() {
+	err := logging.Configure(logging.Config{Level: zerolog.InfoLevel, File: s.path})
+	s.Nil(err)
+
+	log.Debug().Msg("debug")
+	log.Info().Msg("info")
+
+	lines := s.lines()
+	s.Len(lines, 1)
+	s.Equal("info", lines[0]["message"])
+}
+
+func (s *LogTestSuite) Test_Configure_LoggersCreatedBeforeConfigure() {
+	logger := log.With().Str("Module", "test").Logger()
+
+	err := logging.Configure(logging.Config{Level: zerolog.InfoLevel, File: s.path})
+	s.Nil(err)
+	logger.Info().Msg("info")
+
+	s.Len(s.lines(), 1)
+}
+
+func (s *LogTestSuite) Test_Session_SetsNodeAndSessionFields() {
+	err := logging.Configure(logging.Config{Level: zerolog.InfoLevel, File: s.path})
+	s.Nil(err)
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	peerID, _ := peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+
+	logging.SetNode("relayer1", peerID)
+	logger := logging.Session("sid-sign-1", "signing").Logger()
+	logger.Info().Msg("info")
+
+	lines := s.lines()
+	s.Equal("relayer1", lines[0][logging.NodeField])
+	s.Equal(peerID.Pretty(), lines[0][logging.PeerField])
+	s.Equal("sid-sign-1", lines[0][logging.SessionField])
+	s.Equal("signing", lines[0][logging.ProcessField])
+}
@@ -0,0 +1,81 @@
+package logging_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"tss-demo/logging"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RotatingFileTestSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestRunRotatingFileTestSuite(t *testing.T) {
+	suite.Run(t, new(RotatingFileTestSuite))
+}
+
+func (s *RotatingFileTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "logs", "out.log")
+}
+
+func (s *RotatingFileTestSuite) read(path string) string {
+	content, err := os.ReadFile(path)
+	s.Nil(err)
+	return string(content)
+}
+
+func (s *RotatingFileTestSuite) Test_Write_AppendsToExistingFile() {
+	_ = os.MkdirAll(filepath.Dir(s.path), 0755)
+	_ = os.WriteFile(s.path, []byte("old\n"), 0644)
+
+	file, err := logging.NewRotatingFile(s.path, 100, 1)
+	s.Nil(err)
+	_, err = file.Write([]byte("new\n"))
+	s.Nil(err)
+	s.Nil(file.Close())
+
+	s.Equal("old\nnew\n", s.read(s.path))
+}
+
+func (s *RotatingFileTestSuite) Test_Write_RotatesAndKeepsBackups() {
+	file, err := logging.NewRotatingFile(s.path, 6, 2)
+	s.Nil(err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		_, err = file.Write([]byte(line))
+		s.Nil(err)
+	}
+	s.Nil(file.Close())
+
+	s.Equal("four\n", s.read(s.path))
+	s.Equal("three\n", s.read(s.path+".1"))
+	s.Equal("two\n", s.read(s.path+".2"))
+	s.NoFileExists(s.path + ".3")
+}
+
+func (s *RotatingFileTestSuite) Test_Write_NoBackups() {
+	file, err := logging.NewRotatingFile(s.path, 4, 0)
+	s.Nil(err)
+
+	_, _ = file.Write([]byte("one\n"))
+	_, _ = file.Write([]byte("two\n"))
+	s.Nil(file.Close())
+
+	s.Equal("two\n", s.read(s.path))
+	s.NoFileExists(s.path + ".1")
+}
+
+func (s *RotatingFileTestSuite) Test_Write_NoMaxSize() {
+	file, err := logging.NewRotatingFile(s.path, 0, 1)
+	s.Nil(err)
+
+	_, _ = file.Write([]byte("one\n"))
+	_, _ = file.Write([]byte("two\n"))
+	s.Nil(file.Close())
+
+	s.Equal("one\ntwo\n", s.read(s.path))
+}
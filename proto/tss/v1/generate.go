@@ -0,0 +1,7 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package tssv1 is the gRPC API of the node generated from tss.proto
+package tssv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative tss/v1/tss.proto
@@ -1,14 +1,24 @@
 package routers
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
 	"github.com/gin-gonic/gin"
-	"tss-demo/logging"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
 	"tss-demo/service"
+	"tss-demo/service/event_handlers"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/openapi"
 )
 
 type Server struct {
-	engine *gin.Engine
+	engine      *gin.Engine
+	httpServer  *http.Server
+	spec        *openapi.Spec
+	idempotency *idempotencyCache
 }
 
 func NewServer() *Server {
@@ -16,67 +26,223 @@ func NewServer() *Server {
 
 	engine.Use(gin.Recovery())
 
+	// spec is embedded at build time and checked by the contract test
+	spec, err := OpenAPISpec()
+	if err != nil {
+		panic(err)
+	}
+
 	return &Server{
-		engine: engine,
+		engine:      engine,
+		httpServer:  &http.Server{Handler: engine},
+		spec:        spec,
+		idempotency: newIdempotencyCache(idempotencyTTL, idempotencyMaxResponses),
 	}
 }
 
 func (s *Server) InitTssDemoApiRouter() {
 
-	v1 := s.engine.Group("api/v1")
+	s.engine.GET("/health/live", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	s.engine.GET("/health/ready", func(ctx *gin.Context) {
+		if service.HealthChecker == nil {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"ready": false})
+			return
+		}
+		service.HealthChecker.ReadyHandler(ctx.Writer, ctx.Request)
+	})
+
+	v1 := s.engine.Group("api/v1", auditRequests())
 
 	health := v1.Group("/")
 	health.GET("", func(ctx *gin.Context) {
-		ctx.JSON(200, gin.H{})
+		ctx.JSON(http.StatusOK, gin.H{})
 	})
 
+	v1.GET("peers/health", authorize(auth.ReadStatus), func(ctx *gin.Context) {
+		if service.HealthProber == nil {
+			abortWithError(ctx, errNodeStarting)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"result":  service.HealthProber.Health(),
+			"message": "success",
+		})
+	})
+
+	v1.GET("events", authorize(auth.ReadStatus), watchEvents)
+
 	userInfo := v1.Group("/")
 
-	userInfo.GET("genkey", func(ctx *gin.Context) {
+	userInfo.GET("genkey", authorize(auth.Keygen), func(ctx *gin.Context) {
+		if service.KeygenEventHandler == nil {
+			abortWithError(ctx, errNodeStarting)
+			return
+		}
 		err := service.KeygenEventHandler.HandleEvents()
 		if err != nil {
-			ctx.JSON(200, gin.H{
-				"code":    500,
-				"message": fmt.Sprintf("Failed executing keygen. error: %v", err),
-			})
+			abortWithError(ctx, err)
 			return
 		}
 
-		ctx.JSON(200, gin.H{
-			"code":    200,
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
 			"result":  "result",
 			"message": "success",
 		})
 	})
-	userInfo.POST("sign", func(ctx *gin.Context) {
+	userInfo.POST("reshare", authorize(auth.Reshare), func(ctx *gin.Context) {
+		if service.ReshareEventHandler == nil {
+			abortWithError(ctx, errNodeStarting)
+			return
+		}
+		err := service.ReshareEventHandler.HandleEvents()
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"result":  event_handlers.ReshareSessionID,
+			"message": "success",
+		})
+	})
+	userInfo.POST("sessions/:sessionId/cancel", authorize(auth.Cancel), s.validateRequest(), func(ctx *gin.Context) {
+		if service.Coordinator == nil {
+			abortWithError(ctx, errNodeStarting)
+			return
+		}
+		sessionID := ctx.Param("sessionId")
+		if !service.Coordinator.Cancel(sessionID) {
+			abortWithError(ctx, errSessionNotFound)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"result":  sessionID,
+			"message": "cancelled",
+		})
+	})
+	userInfo.POST("sign", authorize(auth.Sign), s.validateRequest(), s.idempotent(), limitClients(), func(ctx *gin.Context) {
 		params := &SignRequest{}
 		if err := ctx.ShouldBindBodyWithJSON(params); err != nil {
-			msg := fmt.Sprintf("bind json error. error: %v", err)
-			logging.Log.Error(msg)
-			ctx.JSON(200, gin.H{
-				"code":    500,
-				"message": msg,
-			})
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
 			return
 		}
-		result, err := service.SignEventHandler.HandleEvents(params.Hash)
+		value, err := params.value()
 		if err != nil {
-			ctx.JSON(200, gin.H{
-				"code":    500,
-				"message": fmt.Sprintf("Failed executing sign. error: %v", err),
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidTransaction, Message: err.Error()})
+			return
+		}
+
+		if service.Approvals != nil {
+			request, err := service.Approvals.Submit(params.Hash, value, actor(ctx))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed submitting sign request")
+				abortWithError(ctx, err)
+				return
+			}
+
+			ctx.JSON(http.StatusAccepted, gin.H{
+				"code":    http.StatusAccepted,
+				"result":  request,
+				"message": string(request.Status),
 			})
 			return
 		}
 
-		ctx.JSON(200, gin.H{
-			"code":    200,
+		result, err := service.SignEventHandler.HandleEvents(params.Hash, value)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed executing sign")
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
 			"result":  result,
 			"message": "success",
 		})
 	})
+	userInfo.GET("sign/:hash", authorize(auth.ReadStatus), s.validateRequest(), func(ctx *gin.Context) {
+		if service.Approvals == nil {
+			abortWithError(ctx, errApprovalDisabled)
+			return
+		}
+		request, err := service.Approvals.Request(ctx.Param("hash"))
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"result":  request,
+			"message": string(request.Status),
+		})
+	})
+	userInfo.POST("sign/:hash/approvals", authorize(auth.Approve), s.validateRequest(), s.idempotent(), func(ctx *gin.Context) {
+		if service.Approvals == nil {
+			abortWithError(ctx, errApprovalDisabled)
+			return
+		}
+		params := &ApproveRequest{}
+		if err := ctx.ShouldBindBodyWithJSON(params); err != nil {
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+			return
+		}
+		signature, err := hex.DecodeString(strings.TrimPrefix(params.Signature, "0x"))
+		if err != nil {
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidSignature, Message: err.Error()})
+			return
+		}
+
+		request, err := service.Approvals.Approve(ctx.Param("hash"), signature)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"result":  request,
+			"message": string(request.Status),
+		})
+	})
+	userInfo.POST("tx/build", authorize(auth.Sign), s.validateRequest(), buildTx)
+	userInfo.POST("tx/send", authorize(auth.Sign), s.validateRequest(), s.idempotent(), limitClients(), sendTx)
+	userInfo.POST("tx/replace", authorize(auth.Sign), s.validateRequest(), replaceTx)
+	userInfo.GET("tx/nonces/:chainId", authorize(auth.ReadStatus), s.validateRequest(), txNonces)
+}
+
+// Routes returns the routes registered by the router
+func (s *Server) Routes() gin.RoutesInfo {
+	return s.engine.Routes()
+}
+
+// Handler returns the handler of all routes
+func (s *Server) Handler() http.Handler {
+	return s.engine
 }
 
 func (s *Server) Run(addr string) error {
+	s.httpServer.Addr = addr
+	return s.httpServer.ListenAndServe()
+}
+
+// RunTLS serves the API over TLS with certificates provided by the tls config
+func (s *Server) RunTLS(addr string, tlsConfig *tls.Config) error {
+	s.httpServer.Addr = addr
+	s.httpServer.TLSConfig = tlsConfig
+	return s.httpServer.ListenAndServeTLS("", "")
+}
 
-	return s.engine.Run(addr)
+// Shutdown stops accepting new requests and waits for running requests to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
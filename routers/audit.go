@@ -0,0 +1,42 @@
+package routers
+
+import (
+	"strconv"
+	"tss-demo/service"
+	"tss-demo/tss_util/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// auditRequests records every API call with the caller and the request body into the audit log
+func auditRequests() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if service.AuditLog == nil {
+			return
+		}
+		details := map[string]string{
+			"method": ctx.Request.Method,
+			"path":   ctx.Request.URL.Path,
+			"status": strconv.Itoa(ctx.Writer.Status()),
+		}
+		// body is cached by handlers that bind it
+		if body, ok := ctx.Get(gin.BodyBytesKey); ok {
+			details["body"] = string(body.([]byte))
+		}
+		if len(ctx.Errors) > 0 {
+			details["error"] = ctx.Errors.String()
+		}
+
+		err := service.AuditLog.Record(audit.Entry{
+			Type:    audit.APICall,
+			Actor:   actor(ctx),
+			Details: details,
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("Failed recording api call %s", ctx.Request.URL.Path)
+		}
+	}
+}
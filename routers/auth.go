@@ -0,0 +1,105 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"tss-demo/service"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/limits"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const identityKey = "identity"
+
+// authorize lets through callers whose role has the permission. All callers are let
+// through only if anonymous access is allowed, calls are denied if no callers are configured.
+func authorize(permission auth.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if service.Authenticator == nil {
+			abortWithError(ctx, errNodeStarting)
+			return
+		}
+		if !service.Authenticator.Enabled() {
+			return
+		}
+
+		identity, err := service.Authenticator.Authenticate(ctx.Request)
+		if err != nil {
+			deny(ctx, "api_auth", permission, err)
+			return
+		}
+		ctx.Set(identityKey, identity)
+		if !identity.Role.Allows(permission) {
+			deny(ctx, "api_auth", permission, &APIError{
+				Status:  http.StatusForbidden,
+				Code:    PermissionDenied,
+				Message: fmt.Sprintf("role %s is not allowed to %s", identity.Role, permission),
+			})
+		}
+	}
+}
+
+// deny aborts the request and records the decision with the caller identity
+func deny(ctx *gin.Context, policy string, permission auth.Permission, err error) {
+	caller := actor(ctx)
+	reason := err.Error()
+	log.Warn().Str("caller", caller).Str("path", ctx.Request.URL.Path).Msgf("Denied api call: %s", reason)
+
+	if service.AuditLog != nil {
+		err := service.AuditLog.Record(audit.Entry{
+			Type:    audit.PolicyDecision,
+			Actor:   caller,
+			Outcome: "denied",
+			Details: map[string]string{
+				"policy":     policy,
+				"permission": string(permission),
+				"path":       ctx.Request.URL.Path,
+				"reason":     reason,
+			},
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed recording denied api call into audit log")
+		}
+	}
+
+	abortWithError(ctx, err)
+}
+
+// actor returns authenticated caller name with the caller address
+func actor(ctx *gin.Context) string {
+	identity, ok := ctx.Get(identityKey)
+	if !ok {
+		return fmt.Sprintf("anonymous@%s", ctx.ClientIP())
+	}
+	return fmt.Sprintf("%s@%s", identity.(auth.Identity).Name, ctx.ClientIP())
+}
+
+// client returns authenticated caller name or the caller address of anonymous callers
+func client(ctx *gin.Context) string {
+	if identity, ok := ctx.Get(identityKey); ok {
+		return identity.(auth.Identity).Name
+	}
+	return ctx.ClientIP()
+}
+
+// limitClients refuses requests of API clients above their rate limit
+func limitClients() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if service.Limiter == nil {
+			return
+		}
+		err := service.Limiter.AllowClient(client(ctx))
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, limits.ErrRateLimited) {
+			log.Error().Err(err).Msg("Failed checking client rate limit")
+			return
+		}
+		deny(ctx, "rate_limit", auth.Sign, err)
+	}
+}
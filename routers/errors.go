@@ -0,0 +1,165 @@
+package routers
+
+import (
+	"errors"
+	"net/http"
+	"tss-demo/service/event_handlers"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/evm"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/limits"
+	"tss-demo/tss_util/tss"
+
+	"github.com/gin-gonic/gin"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ErrorCode is a stable machine-readable code of a failed API call
+type ErrorCode string
+
+const (
+	InvalidRequest          ErrorCode = "INVALID_REQUEST"
+	InvalidHash             ErrorCode = "INVALID_HASH"
+	InvalidTransaction      ErrorCode = "INVALID_TRANSACTION"
+	TransactionRequired     ErrorCode = "TRANSACTION_REQUIRED"
+	InvalidSignature        ErrorCode = "INVALID_SIGNATURE"
+	Unauthenticated         ErrorCode = "UNAUTHENTICATED"
+	PermissionDenied        ErrorCode = "PERMISSION_DENIED"
+	UnknownApprover         ErrorCode = "UNKNOWN_APPROVER"
+	KeyshareNotFound        ErrorCode = "KEYSHARE_NOT_FOUND"
+	SignRequestNotFound     ErrorCode = "SIGN_REQUEST_NOT_FOUND"
+	SessionNotFound         ErrorCode = "SESSION_NOT_FOUND"
+	ApprovalDisabled        ErrorCode = "APPROVAL_DISABLED"
+	SessionPending          ErrorCode = "SESSION_PENDING"
+	SignRequestNotPending   ErrorCode = "SIGN_REQUEST_NOT_PENDING"
+	SignRequestExpired      ErrorCode = "SIGN_REQUEST_EXPIRED"
+	RequestInProgress       ErrorCode = "REQUEST_IN_PROGRESS"
+	IdempotencyKeyReused    ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	RateLimited             ErrorCode = "RATE_LIMITED"
+	VelocityLimitExceeded   ErrorCode = "VELOCITY_LIMIT_EXCEEDED"
+	ThresholdNotMet         ErrorCode = "THRESHOLD_NOT_MET"
+	NodeStarting            ErrorCode = "NODE_STARTING"
+	NodeShuttingDown        ErrorCode = "NODE_SHUTTING_DOWN"
+	CulpritsIdentified      ErrorCode = "CULPRITS_IDENTIFIED"
+	PeerUnreachable         ErrorCode = "PEER_UNREACHABLE"
+	CoordinatorUnresponsive ErrorCode = "COORDINATOR_UNRESPONSIVE"
+	SessionTimeout          ErrorCode = "SESSION_TIMEOUT"
+	ChainNotFound           ErrorCode = "CHAIN_NOT_FOUND"
+	ApprovalRequired        ErrorCode = "APPROVAL_REQUIRED"
+	GasPriceTooHigh         ErrorCode = "GAS_PRICE_TOO_HIGH"
+	BroadcastFailed         ErrorCode = "BROADCAST_FAILED"
+	NonceNotPending         ErrorCode = "NONCE_NOT_PENDING"
+	Internal                ErrorCode = "INTERNAL"
+)
+
+// APIError is a failed API call with its HTTP status. Peers are the parties that
+// caused the failure of a tss session.
+type APIError struct {
+	Status  int
+	Code    ErrorCode
+	Message string
+	Peers   []peer.ID
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrorResponse is the body of failed API calls
+type ErrorResponse struct {
+	Code    int       `json:"code"`
+	Error   ErrorCode `json:"error"`
+	Message string    `json:"message"`
+	Peers   []string  `json:"peers,omitempty"`
+}
+
+var (
+	errNodeStarting     = &APIError{Status: http.StatusServiceUnavailable, Code: NodeStarting, Message: "node is starting"}
+	errApprovalDisabled = &APIError{Status: http.StatusNotFound, Code: ApprovalDisabled, Message: "sign approval is not enabled"}
+	errChainNotFound    = &APIError{Status: http.StatusNotFound, Code: ChainNotFound, Message: "chain is not configured"}
+	errSessionNotFound  = &APIError{Status: http.StatusNotFound, Code: SessionNotFound, Message: "session is not running"}
+	errApprovalRequired = &APIError{Status: http.StatusConflict, Code: ApprovalRequired, Message: "transactions can't be sent while sign requests need approval"}
+)
+
+// ToAPIError maps errors of the node to API errors. Unknown errors are internal errors.
+func ToAPIError(err error) *APIError {
+	var target *APIError
+	if errors.As(err, &target) {
+		return target
+	}
+
+	apiErr := &APIError{Status: http.StatusInternalServerError, Code: Internal, Message: err.Error()}
+
+	var culpritsErr *tss.CulpritsError
+	var commErr *comm.CommunicationError
+	var coordinatorErr *tss.CoordinatorError
+	var thresholdErr *tss.ThresholdError
+	var timeoutErr *tss.TimeoutError
+	switch {
+	case errors.As(err, &culpritsErr):
+		apiErr.Status, apiErr.Code, apiErr.Peers = http.StatusBadGateway, CulpritsIdentified, culpritsErr.Culprits
+	case errors.As(err, &commErr):
+		apiErr.Status, apiErr.Code, apiErr.Peers = http.StatusBadGateway, PeerUnreachable, []peer.ID{commErr.Peer}
+	case errors.As(err, &coordinatorErr):
+		apiErr.Status, apiErr.Code, apiErr.Peers = http.StatusBadGateway, CoordinatorUnresponsive, []peer.ID{coordinatorErr.Peer}
+	case errors.As(err, &thresholdErr):
+		apiErr.Status, apiErr.Code = http.StatusServiceUnavailable, ThresholdNotMet
+	case errors.As(err, &timeoutErr):
+		apiErr.Status, apiErr.Code = http.StatusGatewayTimeout, SessionTimeout
+	case errors.Is(err, tss.ErrSessionPending):
+		apiErr.Status, apiErr.Code = http.StatusConflict, SessionPending
+	case errors.Is(err, keyshare.ErrKeyshareNotFound):
+		apiErr.Status, apiErr.Code = http.StatusNotFound, KeyshareNotFound
+	case errors.Is(err, event_handlers.ErrInvalidHash):
+		apiErr.Status, apiErr.Code = http.StatusBadRequest, InvalidHash
+	case errors.Is(err, event_handlers.ErrShuttingDown):
+		apiErr.Status, apiErr.Code = http.StatusServiceUnavailable, NodeShuttingDown
+	case errors.Is(err, auth.ErrMissingCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+		apiErr.Status, apiErr.Code = http.StatusUnauthorized, Unauthenticated
+	case errors.Is(err, limits.ErrRateLimited):
+		apiErr.Status, apiErr.Code = http.StatusTooManyRequests, RateLimited
+	case errors.Is(err, limits.ErrVelocityExceeded):
+		apiErr.Status, apiErr.Code = http.StatusTooManyRequests, VelocityLimitExceeded
+	case errors.Is(err, limits.ErrTransactionNeeded):
+		apiErr.Status, apiErr.Code = http.StatusBadRequest, TransactionRequired
+	case errors.Is(err, limits.ErrHashMismatch):
+		apiErr.Status, apiErr.Code = http.StatusBadRequest, InvalidTransaction
+	case errors.Is(err, approval.ErrRequestNotFound):
+		apiErr.Status, apiErr.Code = http.StatusNotFound, SignRequestNotFound
+	case errors.Is(err, approval.ErrUnknownApprover):
+		apiErr.Status, apiErr.Code = http.StatusForbidden, UnknownApprover
+	case errors.Is(err, approval.ErrExpired):
+		apiErr.Status, apiErr.Code = http.StatusGone, SignRequestExpired
+	case errors.Is(err, approval.ErrNotPending):
+		apiErr.Status, apiErr.Code = http.StatusConflict, SignRequestNotPending
+	case errors.Is(err, approval.ErrInvalidSignature):
+		apiErr.Status, apiErr.Code = http.StatusBadRequest, InvalidSignature
+	case errors.Is(err, evm.ErrChainIDMismatch), errors.Is(err, evm.ErrUnsupportedTxType), errors.Is(err, evm.ErrNonceConfirmed):
+		apiErr.Status, apiErr.Code = http.StatusBadRequest, InvalidTransaction
+	case errors.Is(err, evm.ErrGasPriceTooHigh):
+		apiErr.Status, apiErr.Code = http.StatusUnprocessableEntity, GasPriceTooHigh
+	case errors.Is(err, evm.ErrBroadcast):
+		apiErr.Status, apiErr.Code = http.StatusBadGateway, BroadcastFailed
+	case errors.Is(err, evm.ErrNonceNotPending):
+		apiErr.Status, apiErr.Code = http.StatusNotFound, NonceNotPending
+	}
+	return apiErr
+}
+
+// abortWithError responds with the API error of err and adds err to the request errors
+func abortWithError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	apiErr := ToAPIError(err)
+
+	response := ErrorResponse{
+		Code:    apiErr.Status,
+		Error:   apiErr.Code,
+		Message: apiErr.Message,
+	}
+	for _, p := range apiErr.Peers {
+		response.Peers = append(response.Peers, p.String())
+	}
+	ctx.AbortWithStatusJSON(apiErr.Status, response)
+}
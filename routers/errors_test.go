@@ -0,0 +1,103 @@
+package routers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"tss-demo/routers"
+	"tss-demo/service/event_handlers"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/evm"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/limits"
+	"tss-demo/tss_util/tss"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type ToAPIErrorTestSuite struct {
+	suite.Suite
+	peerID peer.ID
+}
+
+func TestRunToAPIErrorTestSuite(t *testing.T) {
+	suite.Run(t, new(ToAPIErrorTestSuite))
+}
+
+func (s *ToAPIErrorTestSuite) SetupTest() {
+	s.peerID, _ = peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+}
+
+func (s *ToAPIErrorTestSuite) Test_ToAPIError_StatusAndCode() {
+	tests := []struct {
+		err    error
+		status int
+		code   routers.ErrorCode
+	}{
+		{fmt.Errorf("%w: /keyshares/p1.keyshare", keyshare.ErrKeyshareNotFound), http.StatusNotFound, routers.KeyshareNotFound},
+		{tss.ErrSessionPending, http.StatusConflict, routers.SessionPending},
+		{&tss.ThresholdError{Required: 2, Available: 1}, http.StatusServiceUnavailable, routers.ThresholdNotMet},
+		{&tss.TimeoutError{Timeout: time.Minute}, http.StatusGatewayTimeout, routers.SessionTimeout},
+		{fmt.Errorf("%w: odd length hex string", event_handlers.ErrInvalidHash), http.StatusBadRequest, routers.InvalidHash},
+		{event_handlers.ErrShuttingDown, http.StatusServiceUnavailable, routers.NodeShuttingDown},
+		{auth.ErrMissingCredentials, http.StatusUnauthorized, routers.Unauthenticated},
+		{limits.ErrRateLimited, http.StatusTooManyRequests, routers.RateLimited},
+		{limits.ErrVelocityExceeded, http.StatusTooManyRequests, routers.VelocityLimitExceeded},
+		{approval.ErrRequestNotFound, http.StatusNotFound, routers.SignRequestNotFound},
+		{approval.ErrExpired, http.StatusGone, routers.SignRequestExpired},
+		{fmt.Errorf("%w: 1 instead of 5", evm.ErrChainIDMismatch), http.StatusBadRequest, routers.InvalidTransaction},
+		{fmt.Errorf("%w: 200 above 100", evm.ErrGasPriceTooHigh), http.StatusUnprocessableEntity, routers.GasPriceTooHigh},
+		{fmt.Errorf("%w: nonce too low", evm.ErrBroadcast), http.StatusBadGateway, routers.BroadcastFailed},
+		{fmt.Errorf("%w 3", evm.ErrNonceNotPending), http.StatusNotFound, routers.NonceNotPending},
+		{fmt.Errorf("%w: 3", evm.ErrNonceConfirmed), http.StatusBadRequest, routers.InvalidTransaction},
+		{errors.New("disk full"), http.StatusInternalServerError, routers.Internal},
+	}
+
+	for _, test := range tests {
+		apiErr := routers.ToAPIError(test.err)
+
+		s.Equal(test.status, apiErr.Status, test.err.Error())
+		s.Equal(test.code, apiErr.Code, test.err.Error())
+		s.Equal(test.err.Error(), apiErr.Message)
+	}
+}
+
+func (s *ToAPIErrorTestSuite) Test_ToAPIError_CulpritsIdentified() {
+	err := fmt.Errorf("failed signing: %w", &tss.CulpritsError{
+		Culprits: []peer.ID{s.peerID},
+		Err:      errors.New("invalid commitment"),
+	})
+
+	apiErr := routers.ToAPIError(err)
+
+	s.Equal(http.StatusBadGateway, apiErr.Status)
+	s.Equal(routers.CulpritsIdentified, apiErr.Code)
+	s.Equal([]peer.ID{s.peerID}, apiErr.Peers)
+}
+
+func (s *ToAPIErrorTestSuite) Test_ToAPIError_CommunicationError() {
+	apiErr := routers.ToAPIError(&comm.CommunicationError{Peer: s.peerID, Err: errors.New("stream reset")})
+
+	s.Equal(http.StatusBadGateway, apiErr.Status)
+	s.Equal(routers.PeerUnreachable, apiErr.Code)
+	s.Equal([]peer.ID{s.peerID}, apiErr.Peers)
+}
+
+func (s *ToAPIErrorTestSuite) Test_ToAPIError_CoordinatorError() {
+	apiErr := routers.ToAPIError(&tss.CoordinatorError{Peer: s.peerID})
+
+	s.Equal(http.StatusBadGateway, apiErr.Status)
+	s.Equal(routers.CoordinatorUnresponsive, apiErr.Code)
+	s.Equal([]peer.ID{s.peerID}, apiErr.Peers)
+}
+
+func (s *ToAPIErrorTestSuite) Test_ToAPIError_KeepsAPIError() {
+	err := &routers.APIError{Status: http.StatusBadRequest, Code: routers.InvalidRequest, Message: "missing hash"}
+
+	s.Equal(err, routers.ToAPIError(err))
+}
@@ -0,0 +1,88 @@
+package routers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"tss-demo/service"
+	"tss-demo/tss_util/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// eventsBuffer is the number of events a subscriber can fall behind before it is disconnected
+	eventsBuffer       = 256
+	eventsWriteTimeout = 10 * time.Second
+	eventsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// watchEvents streams node events to the WebSocket subscriber as JSON text messages
+func watchEvents(ctx *gin.Context) {
+	if service.Events == nil {
+		abortWithError(ctx, errNodeStarting)
+		return
+	}
+	types, err := events.ParseEventTypes(strings.Split(ctx.Query("types"), ","))
+	if err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		return
+	}
+
+	// upgrader responds to failed upgrades itself
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed upgrading events connection")
+		return
+	}
+	defer conn.Close()
+	subscription := service.Events.Subscribe(eventsBuffer, types...)
+	defer subscription.Close()
+
+	// messages of the subscriber are discarded, reading only detects closed connections
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(eventsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			{
+				if !ok {
+					closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "node is shutting down")
+					if subscription.Lagged() {
+						closeMessage = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber fell behind")
+					}
+					_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(eventsWriteTimeout))
+					return
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			{
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteTimeout)); err != nil {
+					return
+				}
+			}
+		case <-disconnected:
+			{
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package routers_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"tss-demo/routers"
+	"tss-demo/service"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventsTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	url    string
+}
+
+func TestRunEventsTestSuite(t *testing.T) {
+	suite.Run(t, new(EventsTestSuite))
+}
+
+func (s *EventsTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	keyHash := sha256.Sum256([]byte("observer-key"))
+	var err error
+	service.Authenticator, err = auth.NewAuthenticator(relayer.AuthConfig{
+		APIKeys: []relayer.APIKey{{Name: "observer", Role: string(auth.Observer), KeyHash: hex.EncodeToString(keyHash[:])}},
+	})
+	s.Nil(err)
+	nodeID, err := peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+	s.Nil(err)
+	service.Events = events.NewBus(nodeID)
+
+	router := routers.NewServer()
+	router.InitTssDemoApiRouter()
+	s.server = httptest.NewServer(router.Handler())
+	s.url = "ws" + strings.TrimPrefix(s.server.URL, "http") + "/api/v1/events"
+}
+
+func (s *EventsTestSuite) TearDownTest() {
+	s.server.Close()
+	service.Authenticator = nil
+	service.Events = nil
+}
+
+func (s *EventsTestSuite) dial(url string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	header.Set(auth.APIKeyHeader, "observer-key")
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+func (s *EventsTestSuite) Test_StreamsSubscribedEvents() {
+	conn, _, err := s.dial(s.url + "?types=signature.ready")
+	s.Nil(err)
+	defer conn.Close()
+
+	// subscription is registered after the upgrade, events are published until one arrives
+	received := make(chan events.Event)
+	go func() {
+		event := events.Event{}
+		if conn.ReadJSON(&event) == nil {
+			received <- event
+		}
+	}()
+	timeout := time.After(time.Second)
+	for {
+		service.Events.Publish(events.Event{Type: events.SessionStarted, SessionID: "sid-sign-1"})
+		service.Events.Publish(events.Event{Type: events.SignatureReady, SessionID: "sid-sign-1", Data: map[string]string{"signature": "abcd"}})
+		select {
+		case event := <-received:
+			s.Equal(events.SignatureReady, event.Type)
+			s.Equal("abcd", event.Data["signature"])
+			return
+		case <-timeout:
+			s.Fail("no event received")
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (s *EventsTestSuite) Test_ClosedOnShutdown() {
+	conn, _, err := s.dial(s.url)
+	s.Nil(err)
+	defer conn.Close()
+
+	service.Events.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	s.True(websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
+
+func (s *EventsTestSuite) Test_UnknownEventType() {
+	_, resp, err := s.dial(s.url + "?types=signature.done")
+
+	s.NotNil(err)
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *EventsTestSuite) Test_MissingCredentials() {
+	_, resp, err := websocket.DefaultDialer.Dial(s.url, nil)
+
+	s.NotNil(err)
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
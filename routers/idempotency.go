@@ -0,0 +1,186 @@
+package routers
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyTTL           = 24 * time.Hour
+	// idempotencyMaxResponses is the number of responses kept for replay, the oldest
+	// response is dropped when a new one is stored
+	idempotencyMaxResponses = 10000
+)
+
+type idempotentCall struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+	// element is the position of the stored response in the expiration list
+	element *list.Element
+}
+
+// idempotencyCache keeps successful responses of calls with an idempotency key so
+// that retried calls don't start new sessions. Responses are kept in a list ordered
+// by their expiration, so expired and excess responses are dropped from its front.
+type idempotencyCache struct {
+	lock         sync.Mutex
+	ttl          time.Duration
+	maxResponses int
+	calls        map[string]*idempotentCall
+	responses    *list.List
+}
+
+func newIdempotencyCache(ttl time.Duration, maxResponses int) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:          ttl,
+		maxResponses: maxResponses,
+		calls:        make(map[string]*idempotentCall),
+		responses:    list.New(),
+	}
+}
+
+// begin returns the earlier call with the key or registers a new call in progress
+func (c *idempotencyCache) begin(key string, fingerprint [sha256.Size]byte) (idempotentCall, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire(time.Now())
+	call, ok := c.calls[key]
+	if ok {
+		return *call, true
+	}
+	// calls in progress are not in the expiration list until they finish
+	c.calls[key] = &idempotentCall{key: key, fingerprint: fingerprint}
+	return idempotentCall{}, false
+}
+
+// finish stores successful responses and forgets failed calls so they can be retried
+func (c *idempotencyCache) finish(key string, status int, contentType string, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	call, ok := c.calls[key]
+	if !ok {
+		return
+	}
+	if status < 200 || status >= 300 {
+		delete(c.calls, key)
+		return
+	}
+	call.done = true
+	call.status = status
+	call.contentType = contentType
+	call.body = body
+	call.expires = time.Now().Add(c.ttl)
+	// responses expire in the order they are stored as they share the ttl
+	call.element = c.responses.PushBack(call)
+	for c.responses.Len() > c.maxResponses {
+		c.remove(c.responses.Front().Value.(*idempotentCall))
+	}
+}
+
+func (c *idempotencyCache) abandon(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.calls, key)
+}
+
+// expire drops responses that expired before now
+func (c *idempotencyCache) expire(now time.Time) {
+	for element := c.responses.Front(); element != nil; element = c.responses.Front() {
+		call := element.Value.(*idempotentCall)
+		if !now.After(call.expires) {
+			return
+		}
+		c.remove(call)
+	}
+}
+
+func (c *idempotencyCache) remove(call *idempotentCall) {
+	c.responses.Remove(call.element)
+	delete(c.calls, call.key)
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent replays the successful response of an earlier call of the client with the
+// same idempotency key. Calls without the key are always executed.
+func (s *Server) idempotent() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idempotencyKey := ctx.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			return
+		}
+		body, err := requestBody(ctx)
+		if err != nil {
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+			return
+		}
+
+		key := client(ctx) + " " + ctx.Request.Method + " " + ctx.Request.URL.Path + " " + idempotencyKey
+		fingerprint := sha256.Sum256(body)
+		call, ok := s.idempotency.begin(key, fingerprint)
+		switch {
+		case ok && call.fingerprint != fingerprint:
+			abortWithError(ctx, &APIError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    IdempotencyKeyReused,
+				Message: "idempotency key was used with a different request",
+			})
+			return
+		case ok && !call.done:
+			abortWithError(ctx, &APIError{
+				Status:  http.StatusConflict,
+				Code:    RequestInProgress,
+				Message: "request with the idempotency key is in progress",
+			})
+			return
+		case ok:
+			ctx.Header(IdempotentReplayedHeader, "true")
+			ctx.Data(call.status, call.contentType, call.body)
+			ctx.Abort()
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		completed := false
+		defer func() {
+			// handler panicked
+			if !completed {
+				s.idempotency.abandon(key)
+			}
+		}()
+
+		ctx.Next()
+		completed = true
+		s.idempotency.finish(key, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	}
+}
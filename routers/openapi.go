@@ -0,0 +1,71 @@
+package routers
+
+import (
+	_ "embed"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"tss-demo/tss_util/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+var pathParamPattern = regexp.MustCompile(`:([^/]+)`)
+
+// OpenAPISpec returns the specification of the API served by the router
+func OpenAPISpec() (*openapi.Spec, error) {
+	return openapi.Load(openAPIDocument)
+}
+
+// OpenAPIPath converts the gin route path to the OpenAPI path template
+func OpenAPIPath(routePath string) string {
+	return pathParamPattern.ReplaceAllString(routePath, "{$1}")
+}
+
+// validateRequest refuses calls whose path parameters or body don't match the spec
+func (s *Server) validateRequest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body, err := requestBody(ctx)
+		if err != nil {
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+			return
+		}
+		params := make(map[string]string)
+		for _, param := range ctx.Params {
+			params[param.Key] = param.Value
+		}
+
+		err = s.spec.ValidateRequest(ctx.Request.Method, OpenAPIPath(ctx.FullPath()), params, body)
+		var validationErr *openapi.ValidationError
+		switch {
+		case errors.As(err, &validationErr) && validationErr.Field == "hash":
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidHash, Message: err.Error()})
+		case errors.As(err, &validationErr):
+			abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		case err != nil:
+			abortWithError(ctx, err)
+		}
+	}
+}
+
+// requestBody reads the request body and caches it for handlers that bind it
+func requestBody(ctx *gin.Context) ([]byte, error) {
+	if cached, ok := ctx.Get(gin.BodyBytesKey); ok {
+		return cached.([]byte), nil
+	}
+	if ctx.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		ctx.Set(gin.BodyBytesKey, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,328 @@
+package routers_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tss-demo/routers"
+	"tss-demo/service"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/evm"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/openapi"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	tsscrypto "github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/tss"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+)
+
+const hash = "b07e3536cce658dc1615e6e43ee0af85ddeef27de5b237d806a8296f83fec261"
+
+type signer struct{}
+
+type keyshares struct {
+	key *ecdsa.PrivateKey
+}
+
+func (k *keyshares) GetKeyshare() (keyshare.ECDSAKeyshare, error) {
+	share := keyshare.ECDSAKeyshare{}
+	share.Key.ECDSAPub = tsscrypto.ScalarBaseMult(tss.S256(), k.key.D)
+	return share, nil
+}
+
+func (s *signer) HandleEvents(hash string, value *big.Int) (string, error) {
+	return "signature", nil
+}
+
+// ContractTestSuite keeps the OpenAPI spec in sync with the routes and their responses
+type ContractTestSuite struct {
+	suite.Suite
+	spec     *openapi.Spec
+	server   *routers.Server
+	db       *lvldb.LVLDB
+	approver []byte
+}
+
+func TestRunContractTestSuite(t *testing.T) {
+	suite.Run(t, new(ContractTestSuite))
+}
+
+func (s *ContractTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	spec, err := routers.OpenAPISpec()
+	s.Nil(err)
+	s.spec = spec
+
+	s.server = routers.NewServer()
+	s.server.InitTssDemoApiRouter()
+
+	key, _ := crypto.GenerateKey()
+	s.approver, err = crypto.Sign(accounts.TextHash(approval.Message(hash, 1)), key)
+	s.Nil(err)
+	s.approver[crypto.RecoveryIDOffset] += 27
+
+	s.db, err = lvldb.NewLvlDB(s.T().TempDir())
+	s.Nil(err)
+	service.Approvals = approval.NewWorkflow(approval.NewStore(s.db), &signer{}, relayer.ApprovalConfig{
+		Approvers: []relayer.Approver{{Name: "alice", Address: crypto.PubkeyToAddress(key.PublicKey).Hex()}, {Name: "bob", Address: "0x0000000000000000000000000000000000000001"}},
+		Quorum:    2,
+		Deadline:  time.Hour,
+	})
+	service.Authenticator, err = auth.NewAuthenticator(relayer.AuthConfig{AllowAnonymous: true})
+	s.Nil(err)
+}
+
+func (s *ContractTestSuite) TearDownTest() {
+	service.Approvals = nil
+	service.Transactors = nil
+	service.Authenticator = nil
+	_ = s.db.Close()
+}
+
+// call sends the request to the router and validates the response against the spec
+func (s *ContractTestSuite) call(method, route, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		s.Nil(err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	recorder := httptest.NewRecorder()
+
+	s.server.Handler().ServeHTTP(recorder, req)
+
+	s.Nil(s.spec.ValidateResponse(method, route, recorder.Code, recorder.Body.Bytes()), "%s %s: %s", method, path, recorder.Body.String())
+	return recorder
+}
+
+func (s *ContractTestSuite) errorCode(recorder *httptest.ResponseRecorder) routers.ErrorCode {
+	response := routers.ErrorResponse{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Error
+}
+
+func (s *ContractTestSuite) Test_RoutesMatchSpec() {
+	registered := make(map[string]bool)
+	for _, route := range s.server.Routes() {
+		path := routers.OpenAPIPath(route.Path)
+		registered[route.Method+" "+path] = true
+
+		_, ok := s.spec.Operation(route.Method, path)
+		s.True(ok, "route %s %s is not in the spec", route.Method, path)
+	}
+	for _, route := range s.spec.Routes() {
+		s.True(registered[route.Method+" "+route.Path], "spec operation %s %s is not served", route.Method, route.Path)
+	}
+}
+
+func (s *ContractTestSuite) Test_HealthResponses() {
+	s.Equal(http.StatusOK, s.call("GET", "/health/live", "/health/live", nil, nil).Code)
+	s.Equal(http.StatusServiceUnavailable, s.call("GET", "/health/ready", "/health/ready", nil, nil).Code)
+	s.Equal(http.StatusOK, s.call("GET", "/api/v1/", "/api/v1/", nil, nil).Code)
+
+	recorder := s.call("GET", "/api/v1/peers/health", "/api/v1/peers/health", nil, nil)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Equal(routers.NodeStarting, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_KeyAdminRoutes_NodeStarting() {
+	recorder := s.call("GET", "/api/v1/genkey", "/api/v1/genkey", nil, nil)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Equal(routers.NodeStarting, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/reshare", "/api/v1/reshare", nil, nil)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Equal(routers.NodeStarting, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/sessions/{sessionId}/cancel", "/api/v1/sessions/sid-sign-1/cancel", nil, nil)
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Equal(routers.NodeStarting, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_NoCallersConfigured_Denied() {
+	var err error
+	service.Authenticator, err = auth.NewAuthenticator(relayer.AuthConfig{})
+	s.Nil(err)
+
+	recorder := s.call("POST", "/api/v1/reshare", "/api/v1/reshare", nil, nil)
+	s.Equal(http.StatusUnauthorized, recorder.Code)
+	s.Equal(routers.Unauthenticated, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_Sign_InvalidRequests() {
+	recorder := s.call("POST", "/api/v1/sign", "/api/v1/sign", map[string]interface{}{"hash": hash, "nonce": 1}, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidRequest, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/sign", "/api/v1/sign", routers.SignRequest{Hash: "0x1234"}, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidHash, s.errorCode(recorder))
+
+	recorder = s.call("GET", "/api/v1/sign/{hash}", "/api/v1/sign/1234", nil, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidHash, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_Sign_HeldForApproval() {
+	recorder := s.call("GET", "/api/v1/sign/{hash}", "/api/v1/sign/"+hash, nil, nil)
+	s.Equal(http.StatusNotFound, recorder.Code)
+	s.Equal(routers.SignRequestNotFound, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/sign", "/api/v1/sign", routers.SignRequest{Hash: hash}, nil)
+	s.Equal(http.StatusAccepted, recorder.Code)
+
+	recorder = s.call("GET", "/api/v1/sign/{hash}", "/api/v1/sign/"+hash, nil, nil)
+	s.Equal(http.StatusOK, recorder.Code)
+
+	recorder = s.call("POST", "/api/v1/sign/{hash}/approvals", "/api/v1/sign/"+hash+"/approvals", routers.ApproveRequest{
+		Signature: hex.EncodeToString(s.approver),
+	}, nil)
+	s.Equal(http.StatusOK, recorder.Code)
+
+	recorder = s.call("POST", "/api/v1/sign/{hash}/approvals", "/api/v1/sign/"+hash+"/approvals", routers.ApproveRequest{
+		Signature: "0x1234",
+	}, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+}
+
+func (s *ContractTestSuite) Test_Sign_IdempotencyKey() {
+	headers := map[string]string{routers.IdempotencyKeyHeader: "sign-1"}
+
+	first := s.call("POST", "/api/v1/sign", "/api/v1/sign", routers.SignRequest{Hash: hash}, headers)
+	replayed := s.call("POST", "/api/v1/sign", "/api/v1/sign", routers.SignRequest{Hash: hash}, headers)
+
+	s.Equal(http.StatusAccepted, replayed.Code)
+	s.Equal(first.Body.String(), replayed.Body.String())
+	s.Equal("true", replayed.Header().Get(routers.IdempotentReplayedHeader))
+
+	reused := s.call("POST", "/api/v1/sign", "/api/v1/sign", routers.SignRequest{Hash: "a" + hash[1:]}, headers)
+	s.Equal(http.StatusUnprocessableEntity, reused.Code)
+	s.Equal(routers.IdempotencyKeyReused, s.errorCode(reused))
+}
+
+// transactors sends transactions of the MPC key to the simulated backend with chain ID 1337
+func (s *ContractTestSuite) transactors() *backends.SimulatedBackend {
+	key, err := crypto.GenerateKey()
+	s.Nil(err)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1_000_000_000_000_000_000)},
+	}, 10_000_000)
+	chainID := big.NewInt(1337)
+	builder, err := evm.NewBuilder(backend, chainID, types.DynamicFeeTxType, nil)
+	s.Nil(err)
+	receipts := evm.NewReceiptTracker(backend, chainID, 1, events.NewBus(""))
+	nonces := evm.NewNonceManager(backend, chainID, evm.NewNonceStore(s.db), time.Minute)
+	service.Transactors = map[uint64]*evm.Transactor{
+		1337: evm.NewTransactor(backend, builder, &keyshares{key: key}, &signer{}, receipts, nonces),
+	}
+	return backend
+}
+
+func (s *ContractTestSuite) Test_Tx_Build() {
+	backend := s.transactors()
+	defer backend.Close()
+	chainID := big.NewInt(1337)
+
+	recorder := s.call("POST", "/api/v1/tx/build", "/api/v1/tx/build", map[string]interface{}{
+		"chainId": 1337,
+		"to":      "0x5C11a8d5a4a4A3f3D1a2b4b1b5cDd1E4F2a3b4c5",
+		"value":   1000,
+	}, nil)
+	s.Equal(http.StatusOK, recorder.Code)
+
+	response := struct {
+		Result routers.UnsignedTx `json:"result"`
+	}{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &response))
+	tx, err := evm.DecodeTx(response.Result.Tx)
+	s.Nil(err)
+	s.Equal(big.NewInt(1000), tx.Value())
+	hash, err := evm.SigningHash(tx, chainID)
+	s.Nil(err)
+	s.Equal(hex.EncodeToString(hash[:]), response.Result.Hash)
+
+	recorder = s.call("POST", "/api/v1/tx/build", "/api/v1/tx/build", map[string]interface{}{"chainId": 5}, nil)
+	s.Equal(http.StatusNotFound, recorder.Code)
+	s.Equal(routers.ChainNotFound, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/tx/build", "/api/v1/tx/build", map[string]interface{}{"chainId": 1337, "to": "0x1234"}, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidRequest, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_Tx_NoncesAndReplace() {
+	backend := s.transactors()
+	defer backend.Close()
+	recorder := s.call("POST", "/api/v1/tx/build", "/api/v1/tx/build", map[string]interface{}{
+		"chainId": 1337,
+		"to":      "0x5C11a8d5a4a4A3f3D1a2b4b1b5cDd1E4F2a3b4c5",
+	}, nil)
+	s.Equal(http.StatusOK, recorder.Code)
+
+	recorder = s.call("GET", "/api/v1/tx/nonces/{chainId}", "/api/v1/tx/nonces/1337", nil, nil)
+	s.Equal(http.StatusOK, recorder.Code)
+	response := struct {
+		Result evm.Ledger `json:"result"`
+	}{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &response))
+	s.Equal(uint64(1), response.Result.Next)
+	s.Len(response.Result.Pending, 1)
+
+	// reserved nonce has no transaction to replace until it is sent
+	recorder = s.call("POST", "/api/v1/tx/replace", "/api/v1/tx/replace", map[string]interface{}{"chainId": 1337, "nonce": 0}, nil)
+	s.Equal(http.StatusNotFound, recorder.Code)
+	s.Equal(routers.NonceNotPending, s.errorCode(recorder))
+
+	recorder = s.call("POST", "/api/v1/tx/replace", "/api/v1/tx/replace", map[string]interface{}{"chainId": 1337}, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidRequest, s.errorCode(recorder))
+
+	recorder = s.call("GET", "/api/v1/tx/nonces/{chainId}", "/api/v1/tx/nonces/5", nil, nil)
+	s.Equal(http.StatusNotFound, recorder.Code)
+	s.Equal(routers.ChainNotFound, s.errorCode(recorder))
+
+	recorder = s.call("GET", "/api/v1/tx/nonces/{chainId}", "/api/v1/tx/nonces/sepolia", nil, nil)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal(routers.InvalidRequest, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_Tx_SendRefusedWithApprovals() {
+	service.Transactors = map[uint64]*evm.Transactor{}
+
+	recorder := s.call("POST", "/api/v1/tx/send", "/api/v1/tx/send", routers.TxSendRequest{ChainID: 1337, Tx: "0x02"}, nil)
+
+	s.Equal(http.StatusConflict, recorder.Code)
+	s.Equal(routers.ApprovalRequired, s.errorCode(recorder))
+}
+
+func (s *ContractTestSuite) Test_Tx_NodeStarting() {
+	service.Approvals = nil
+
+	recorder := s.call("POST", "/api/v1/tx/send", "/api/v1/tx/send", routers.TxSendRequest{ChainID: 1337, Tx: "0x02"}, nil)
+
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Equal(routers.NodeStarting, s.errorCode(recorder))
+}
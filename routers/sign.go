@@ -1,5 +1,34 @@
 package routers
 
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"tss-demo/tss_util/limits"
+)
+
 type SignRequest struct {
 	Hash string `json:"hash" binding:"required"`
+	// Tx is the hex encoded canonical encoding of the unsigned transaction with the
+	// hash, as returned by tx/build. It is required by velocity limits.
+	Tx string `json:"tx"`
+	// ChainID is needed to hash legacy transactions
+	ChainID int64 `json:"chainId"`
+}
+
+// value returns the value of the transaction or nil if the transaction is not provided
+func (r *SignRequest) value() (*big.Int, error) {
+	if r.Tx == "" {
+		return nil, nil
+	}
+	encodedTx, err := hex.DecodeString(strings.TrimPrefix(r.Tx, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return limits.TxValue(encodedTx, big.NewInt(r.ChainID), r.Hash)
+}
+
+type ApproveRequest struct {
+	// Signature is the hex encoded personal_sign signature of the approval message
+	Signature string `json:"signature" binding:"required"`
 }
@@ -0,0 +1,221 @@
+package routers
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strconv"
+	"tss-demo/service"
+	"tss-demo/tss_util/evm"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TxBuildRequest describes the transaction sent from the MPC address. Gas is estimated,
+// fees are suggested by the chain and the nonce is the pending nonce if they are not set.
+type TxBuildRequest struct {
+	ChainID              uint64           `json:"chainId" binding:"required"`
+	To                   *common.Address  `json:"to"`
+	Value                *big.Int         `json:"value"`
+	Data                 hexutil.Bytes    `json:"data"`
+	AccessList           types.AccessList `json:"accessList"`
+	Gas                  uint64           `json:"gas"`
+	GasPrice             *big.Int         `json:"gasPrice"`
+	MaxPriorityFeePerGas *big.Int         `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *big.Int         `json:"maxFeePerGas"`
+	Nonce                *uint64          `json:"nonce"`
+}
+
+func (r *TxBuildRequest) txRequest() evm.TxRequest {
+	return evm.TxRequest{
+		To:         r.To,
+		Value:      r.Value,
+		Data:       r.Data,
+		AccessList: r.AccessList,
+		Gas:        r.Gas,
+		GasPrice:   r.GasPrice,
+		GasTipCap:  r.MaxPriorityFeePerGas,
+		GasFeeCap:  r.MaxFeePerGas,
+		Nonce:      r.Nonce,
+	}
+}
+
+// UnsignedTx is the hex encoded unsigned transaction with the hash signed by the MPC key
+type UnsignedTx struct {
+	Tx   string `json:"tx"`
+	Hash string `json:"hash"`
+}
+
+type TxSendRequest struct {
+	ChainID uint64 `json:"chainId" binding:"required"`
+	// Tx is the hex encoded unsigned transaction returned by tx/build
+	Tx string `json:"tx" binding:"required"`
+}
+
+type TxReplaceRequest struct {
+	ChainID uint64 `json:"chainId" binding:"required"`
+	// Nonce is the nonce of the pending transaction to replace
+	Nonce *uint64 `json:"nonce" binding:"required"`
+	// Cancel replaces the transaction with an empty transfer to the MPC address
+	Cancel bool `json:"cancel"`
+}
+
+// SentTx is the result of sending the transaction. TxHash is set only by the coordinator
+// of the signing session which broadcasts the transaction.
+type SentTx struct {
+	Hash   string `json:"hash"`
+	TxHash string `json:"txHash,omitempty"`
+}
+
+func transactor(chainID uint64) (*evm.Transactor, error) {
+	if service.Transactors == nil {
+		return nil, errNodeStarting
+	}
+	transactor, ok := service.Transactors[chainID]
+	if !ok {
+		return nil, errChainNotFound
+	}
+	return transactor, nil
+}
+
+// buildTx builds the unsigned transaction that every node of the signing subset is
+// requested to send
+func buildTx(ctx *gin.Context) {
+	params := &TxBuildRequest{}
+	if err := ctx.ShouldBindBodyWithJSON(params); err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		return
+	}
+	transactor, err := transactor(params.ChainID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	tx, hash, err := transactor.Build(ctx.Request.Context(), params.txRequest())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed building transaction")
+		abortWithError(ctx, err)
+		return
+	}
+	encodedTx, err := evm.EncodeTx(tx)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"result":  UnsignedTx{Tx: encodedTx, Hash: hex.EncodeToString(hash[:])},
+		"message": "success",
+	})
+}
+
+// replaceTx builds the unsigned transaction replacing the pending transaction with bumped
+// fees. It is sent like any other transaction built by tx/build.
+func replaceTx(ctx *gin.Context) {
+	params := &TxReplaceRequest{}
+	if err := ctx.ShouldBindBodyWithJSON(params); err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		return
+	}
+	transactor, err := transactor(params.ChainID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	tx, hash, err := transactor.Replace(ctx.Request.Context(), *params.Nonce, params.Cancel)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed building replacement transaction")
+		abortWithError(ctx, err)
+		return
+	}
+	encodedTx, err := evm.EncodeTx(tx)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"result":  UnsignedTx{Tx: encodedTx, Hash: hex.EncodeToString(hash[:])},
+		"message": "success",
+	})
+}
+
+// txNonces returns the nonce ledger of the MPC address on the chain
+func txNonces(ctx *gin.Context) {
+	chainID, err := strconv.ParseUint(ctx.Param("chainId"), 10, 64)
+	if err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		return
+	}
+	transactor, err := transactor(chainID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ledger, err := transactor.Nonces()
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"result":  ledger,
+		"message": "success",
+	})
+}
+
+// sendTx signs the transaction in an MPC session, coordinator of the session broadcasts it
+func sendTx(ctx *gin.Context) {
+	// approvals are given to hashes, transactions would bypass them
+	if service.Approvals != nil {
+		abortWithError(ctx, errApprovalRequired)
+		return
+	}
+	params := &TxSendRequest{}
+	if err := ctx.ShouldBindBodyWithJSON(params); err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidRequest, Message: err.Error()})
+		return
+	}
+	transactor, err := transactor(params.ChainID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+	tx, err := evm.DecodeTx(params.Tx)
+	if err != nil {
+		abortWithError(ctx, &APIError{Status: http.StatusBadRequest, Code: InvalidTransaction, Message: err.Error()})
+		return
+	}
+
+	// signed transaction is broadcast even if the caller disconnects, the context only
+	// keeps the span of the request
+	sendCtx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx.Request.Context()))
+	sent, err := transactor.Send(sendCtx, tx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed sending transaction")
+		abortWithError(ctx, err)
+		return
+	}
+	result := SentTx{Hash: hex.EncodeToString(sent.SigningHash[:])}
+	if sent.Tx != nil {
+		result.TxHash = sent.Tx.Hash().Hex()
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"result":  result,
+		"message": "success",
+	})
+}
@@ -5,61 +5,103 @@ package event_handlers
 
 import (
 	"context"
-	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"sync"
+	"tss-demo/logging"
 	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/events"
 	"tss-demo/tss_util/tss"
 	"tss-demo/tss_util/tss/ecdsa/keygen"
 )
 
+// KeygenSessionID is the ID of keygen sessions
+const KeygenSessionID = "keygen"
+
 type KeygenEventHandler struct {
+	ctx           context.Context
 	log           zerolog.Logger
 	coordinator   *tss.Coordinator
 	host          host.Host
 	communication comm.Communication
 	storer        keygen.ECDSAKeyshareStorer
 	bridgeAddress common.Address
-	threshold     int
+	sessions      *SessionTracker
+	publisher     EventPublisher
+
+	mu        sync.Mutex
+	threshold int
 }
 
 func NewKeygenEventHandler(
+	ctx context.Context,
 	logC zerolog.Context,
 	coordinator *tss.Coordinator,
 	host host.Host,
 	communication comm.Communication,
 	storer keygen.ECDSAKeyshareStorer,
 	threshold int,
+	sessions *SessionTracker,
+	publisher EventPublisher,
 ) *KeygenEventHandler {
 	return &KeygenEventHandler{
+		ctx:           ctx,
 		log:           logC.Logger(),
 		coordinator:   coordinator,
 		host:          host,
 		communication: communication,
 		storer:        storer,
 		threshold:     threshold,
+		sessions:      sessions,
+		publisher:     publisher,
 	}
 }
 
 func (eh *KeygenEventHandler) HandleEvents() error {
-	eh.log.Info().Msgf("Resolved keygen message")
+	logger := eh.log.With().Str(logging.SessionField, eh.sessionID()).Str(logging.ProcessField, "keygen").Logger()
+	logger.Info().Msgf("Resolved keygen message")
+
+	done, err := eh.sessions.Begin()
+	if err != nil {
+		return err
+	}
+	defer done()
 
 	key, err := eh.storer.GetKeyshare()
 	if (key.Threshold != 0) && (err == nil) {
-		eh.log.Info().Msgf("Already resolved keygen message")
+		logger.Info().Msgf("Already resolved keygen message")
 		return nil
 	}
 
-	keygen := keygen.NewKeygen(eh.sessionID(), eh.threshold, eh.host, eh.communication, eh.storer)
-	err = eh.coordinator.Execute(context.Background(), []tss.TssProcess{keygen}, make(chan interface{}, 1))
+	eh.mu.Lock()
+	threshold := eh.threshold
+	eh.mu.Unlock()
+
+	keygen := keygen.NewKeygen(eh.sessionID(), threshold, eh.host, eh.communication, eh.storer)
+	err = eh.coordinator.Execute(eh.ctx, []tss.TssProcess{keygen}, make(chan interface{}, 1))
 	if err != nil {
-		log.Err(err).Msgf("Failed executing keygen")
+		logger.Err(err).Msgf("Failed executing keygen")
+		return err
 	}
+
+	key, err = eh.storer.GetKeyshare()
+	if err != nil {
+		logger.Err(err).Msgf("Failed reading generated keyshare")
+		return nil
+	}
+	eh.publisher.Publish(keyEvent(events.KeyGenerated, eh.sessionID(), key))
 	return nil
 }
 
+// SetThreshold sets threshold used by following keygens
+func (eh *KeygenEventHandler) SetThreshold(threshold int) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	eh.threshold = threshold
+}
+
 func (eh *KeygenEventHandler) sessionID() string {
-	return fmt.Sprintf("keygen")
+	return KeygenSessionID
 }
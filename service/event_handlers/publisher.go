@@ -0,0 +1,27 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package event_handlers
+
+import (
+	"strconv"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/keyshare"
+)
+
+// EventPublisher publishes results of handled requests to subscribers of node events
+type EventPublisher interface {
+	Publish(event events.Event)
+}
+
+// keyEvent returns the event of the key stored by the finished keygen or resharing
+func keyEvent(eventType events.EventType, sessionID string, key keyshare.ECDSAKeyshare) events.Event {
+	return events.Event{
+		Type:      eventType,
+		SessionID: sessionID,
+		Data: map[string]string{
+			"keyId":     key.ID(),
+			"threshold": strconv.Itoa(key.Threshold),
+		},
+	}
+}
@@ -0,0 +1,96 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package event_handlers
+
+import (
+	"context"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/rs/zerolog"
+	"sync"
+	"tss-demo/logging"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/tss"
+	"tss-demo/tss_util/tss/ecdsa/resharing"
+)
+
+// ReshareSessionID is the ID of resharing sessions
+const ReshareSessionID = "resharing"
+
+type ReshareEventHandler struct {
+	ctx           context.Context
+	log           zerolog.Logger
+	coordinator   *tss.Coordinator
+	host          host.Host
+	communication comm.Communication
+	storer        resharing.SaveDataStorer
+	sessions      *SessionTracker
+	publisher     EventPublisher
+
+	mu        sync.Mutex
+	threshold int
+}
+
+func NewReshareEventHandler(
+	ctx context.Context,
+	logC zerolog.Context,
+	coordinator *tss.Coordinator,
+	host host.Host,
+	communication comm.Communication,
+	storer resharing.SaveDataStorer,
+	threshold int,
+	sessions *SessionTracker,
+	publisher EventPublisher,
+) *ReshareEventHandler {
+	return &ReshareEventHandler{
+		ctx:           ctx,
+		log:           logC.Logger(),
+		coordinator:   coordinator,
+		host:          host,
+		communication: communication,
+		storer:        storer,
+		threshold:     threshold,
+		sessions:      sessions,
+		publisher:     publisher,
+	}
+}
+
+// HandleEvents reshares the MPC key to the current peers with the current threshold
+func (eh *ReshareEventHandler) HandleEvents() error {
+	logger := eh.log.With().Str(logging.SessionField, ReshareSessionID).Str(logging.ProcessField, "resharing").Logger()
+	logger.Info().Msgf("Resolved resharing message")
+
+	done, err := eh.sessions.Begin()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	eh.mu.Lock()
+	threshold := eh.threshold
+	eh.mu.Unlock()
+
+	resharing := resharing.NewResharing(ReshareSessionID, threshold, eh.host, eh.communication, eh.storer)
+	err = eh.coordinator.Execute(eh.ctx, []tss.TssProcess{resharing}, make(chan interface{}, 1))
+	if err != nil {
+		logger.Err(err).Msgf("Failed executing resharing")
+		return err
+	}
+
+	key, err := eh.storer.GetKeyshare()
+	if err != nil {
+		logger.Err(err).Msgf("Failed reading reshared keyshare")
+		return nil
+	}
+	eh.publisher.Publish(keyEvent(events.KeyReshared, ReshareSessionID, key))
+	return nil
+}
+
+// SetThreshold sets threshold of the key after following resharings
+func (eh *ReshareEventHandler) SetThreshold(threshold int) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	eh.threshold = threshold
+}
@@ -0,0 +1,70 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package event_handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrShuttingDown = errors.New("node is shutting down")
+	ErrInvalidHash  = errors.New("invalid hash")
+)
+
+// SessionTracker tracks running tss sessions so that shutdown can wait for them to finish
+type SessionTracker struct {
+	mu      sync.Mutex
+	closing bool
+	wg      sync.WaitGroup
+}
+
+func NewSessionTracker() *SessionTracker {
+	return &SessionTracker{}
+}
+
+// Begin registers a new session. Returned function has to be called when the session
+// ends. Sessions are refused with ErrShuttingDown once draining started.
+func (t *SessionTracker) Begin() (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closing {
+		return nil, ErrShuttingDown
+	}
+	t.wg.Add(1)
+
+	var once sync.Once
+	return func() { once.Do(t.wg.Done) }, nil
+}
+
+// ShuttingDown returns true once draining started
+func (t *SessionTracker) ShuttingDown() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closing
+}
+
+// Drain stops accepting new sessions and waits until running sessions end.
+// Context error is returned if sessions are still running when the context is done.
+func (t *SessionTracker) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.closing = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package event_handlers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"tss-demo/service/event_handlers"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SessionTrackerTestSuite struct {
+	suite.Suite
+	tracker *event_handlers.SessionTracker
+}
+
+func TestRunSessionTrackerTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTrackerTestSuite))
+}
+
+func (s *SessionTrackerTestSuite) SetupTest() {
+	s.tracker = event_handlers.NewSessionTracker()
+}
+
+func (s *SessionTrackerTestSuite) Test_Drain_WaitsForRunningSessions() {
+	done, err := s.tracker.Begin()
+	s.Nil(err)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		done()
+	}()
+
+	err = s.tracker.Drain(context.Background())
+
+	s.Nil(err)
+}
+
+func (s *SessionTrackerTestSuite) Test_Drain_RefusesNewSessions() {
+	err := s.tracker.Drain(context.Background())
+	s.Nil(err)
+
+	_, err = s.tracker.Begin()
+
+	s.True(errors.Is(err, event_handlers.ErrShuttingDown))
+}
+
+func (s *SessionTrackerTestSuite) Test_Drain_GracePeriodExpired() {
+	_, err := s.tracker.Begin()
+	s.Nil(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = s.tracker.Drain(ctx)
+
+	s.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func (s *SessionTrackerTestSuite) Test_Done_CalledTwice() {
+	done, err := s.tracker.Begin()
+	s.Nil(err)
+	done()
+	done()
+
+	err = s.tracker.Drain(context.Background())
+
+	s.Nil(err)
+}
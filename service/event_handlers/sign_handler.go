@@ -10,13 +10,28 @@ import (
 	"github.com/binance-chain/tss-lib/common"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	"math/big"
+	"tss-demo/logging"
+	"tss-demo/tss_util/audit"
 	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/topology"
 	"tss-demo/tss_util/tss"
 	"tss-demo/tss_util/tss/ecdsa/signing"
 )
 
+// SignLimiter reserves a sign session of the key for the transaction value and releases
+// the reservation of a session that didn't sign
+type SignLimiter interface {
+	ReserveSign(keyID string, values ...*big.Int) error
+	ReleaseSign(keyID string, values ...*big.Int) error
+}
+
+// AuditRecorder records policy decisions into the audit log
+type AuditRecorder interface {
+	Record(entry audit.Entry) error
+}
+
 type SignEventHandler struct {
 	ctx           context.Context
 	log           zerolog.Logger
@@ -24,65 +39,172 @@ type SignEventHandler struct {
 	host          host.Host
 	communication comm.Communication
 	fetcher       signing.SaveDataFetcher
+	topologies    topology.TopologyGetter
+	sessions      *SessionTracker
+	limiter       SignLimiter
+	publisher     EventPublisher
+	auditLog      AuditRecorder
 }
 
 func NewSignEventHandler(
+	ctx context.Context,
 	logC zerolog.Context,
 	coordinator *tss.Coordinator,
 	host host.Host,
 	communication comm.Communication,
 	fetcher signing.SaveDataFetcher,
+	topologies topology.TopologyGetter,
+	sessions *SessionTracker,
+	limiter SignLimiter,
+	publisher EventPublisher,
+	auditLog AuditRecorder,
 ) *SignEventHandler {
 	return &SignEventHandler{
-		ctx:           context.Background(),
+		ctx:           ctx,
 		log:           logC.Logger(),
 		coordinator:   coordinator,
 		host:          host,
 		communication: communication,
 		fetcher:       fetcher,
+		topologies:    topologies,
+		sessions:      sessions,
+		limiter:       limiter,
+		publisher:     publisher,
+		auditLog:      auditLog,
 	}
 }
 
-func (eh *SignEventHandler) HandleEvents(hash string) (string, error) {
-	eh.log.Info().Msgf("Resolved sign message. Hash: %s", hash)
-
-	msg := big.NewInt(0)
-	hashByte, err := hex.DecodeString(hash)
+// HandleEvents signs the hash. Value is the value of the transaction with the hash or
+// nil if the hash is not a known transaction.
+func (eh *SignEventHandler) HandleEvents(hash string, value *big.Int) (string, error) {
+	signatures, err := eh.HandleBatch([]string{hash}, []*big.Int{value})
 	if err != nil {
-		log.Err(err).Msgf("Failed decoding hash. hash: %s. error: %v", hash, err)
 		return "", err
 	}
-	msg.SetBytes(hashByte)
-	sign, err := signing.NewSigning(msg, fmt.Sprintf("msgid-sign-%s", hash), eh.sessionID(hash), eh.host, eh.communication, eh.fetcher)
+	return signatures[0], nil
+}
+
+// HandleBatch signs the hashes in one session so that all of them are signed by the same
+// subset. Values are values of transactions with the hashes. Signatures are returned in
+// the order of hashes and are empty on nodes that don't coordinate the session.
+// Coordinator publishes each signature as soon as the session is finished.
+func (eh *SignEventHandler) HandleBatch(hashes []string, values []*big.Int) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("%w: no hashes to sign", ErrInvalidHash)
+	}
+	if len(values) != len(hashes) {
+		return nil, fmt.Errorf("%d values provided for %d hashes", len(values), len(hashes))
+	}
+	sessionID := SignSessionID(hashes[0])
+	logger := eh.log.With().Str(logging.SessionField, sessionID).Str(logging.ProcessField, "signing").Logger()
+	logger.Info().Msgf("Resolved sign message. Hashes: %v", hashes)
+
+	done, err := eh.sessions.Begin()
 	if err != nil {
-		log.Err(err).Msgf("Failed executing sign")
-		return "", err
+		return nil, err
 	}
-	resultChn := make(chan interface{}, 1)
-	err = eh.coordinator.Execute(context.Background(), []tss.TssProcess{sign}, resultChn)
+	defer done()
+
+	processes := make([]tss.TssProcess, len(hashes))
+	// signatures are matched to hashes by the signed message
+	indexes := make(map[string]int)
+	var keyID string
+	for i, hash := range hashes {
+		hashByte, err := hex.DecodeString(hash)
+		if err != nil {
+			logger.Err(err).Msgf("Failed decoding hash. hash: %s", hash)
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHash, err)
+		}
+		msg := new(big.Int).SetBytes(hashByte)
+		if _, ok := indexes[msg.Text(16)]; ok {
+			return nil, fmt.Errorf("%w: duplicate hash %s", ErrInvalidHash, hash)
+		}
+		indexes[msg.Text(16)] = i
+
+		sign, err := signing.NewSigning(msg, fmt.Sprintf("msgid-sign-%s", hash), SignSessionID(hash), eh.host, eh.communication, eh.fetcher, eh.topologies, eh.coordinator.Liveness)
+		if err != nil {
+			logger.Err(err).Msgf("Failed executing sign")
+			return nil, err
+		}
+		if i == 0 {
+			keyID = sign.KeyID()
+			logger = logger.With().Str(logging.KeyIDField, keyID).Logger()
+		}
+		processes[i] = sign
+	}
+
+	// limits are checked by every node joining the session, hashes of the batch are
+	// reserved together so that a refused batch doesn't use up the limits
+	err = eh.limiter.ReserveSign(keyID, values...)
 	if err != nil {
-		log.Err(err).Msgf("Failed executing sign")
-		return "", err
+		logger.Warn().Err(err).Msgf("Refused sign request")
+		eh.auditRefusal(sessionID, keyID, err)
+		return nil, err
 	}
-	for {
+
+	resultChn := make(chan interface{}, len(processes))
+	err = eh.coordinator.Execute(eh.ctx, processes, resultChn)
+	if err != nil {
+		logger.Err(err).Msgf("Failed executing sign")
+		eh.release(logger, keyID, values)
+		return nil, err
+	}
+	signatures := make([]string, len(hashes))
+	for range processes {
 		select {
 		case sig := <-resultChn:
 			{
-				eh.log.Info().Msgf("Successfully generated signature. sig: %x", sig)
-				if sig != nil {
-					sigData := sig.(*common.SignatureData)
-					return hex.EncodeToString(append(sigData.Signature, sigData.SignatureRecovery...)), nil
+				if sig == nil {
+					continue
 				}
-				return "", nil
+				sigData := sig.(*common.SignatureData)
+				logger.Info().Msgf("Successfully generated signature. sig: %x", sigData.Signature)
+				i := indexes[new(big.Int).SetBytes(sigData.M).Text(16)]
+				signatures[i] = hex.EncodeToString(append(sigData.Signature, sigData.SignatureRecovery...))
+				eh.publisher.Publish(events.Event{
+					Type:      events.SignatureReady,
+					SessionID: sessionID,
+					Data:      map[string]string{"hash": hashes[i], "signature": signatures[i]},
+				})
 			}
 		case <-eh.ctx.Done():
 			{
-				return "", fmt.Errorf("sign process shutdown")
+				eh.release(logger, keyID, values)
+				return nil, ErrShuttingDown
 			}
 		}
 	}
+	return signatures, nil
+}
+
+// release refunds the limits reserved for the batch that wasn't signed
+func (eh *SignEventHandler) release(logger zerolog.Logger, keyID string, values []*big.Int) {
+	err := eh.limiter.ReleaseSign(keyID, values...)
+	if err != nil {
+		logger.Err(err).Msgf("Failed releasing sign limits")
+	}
+}
+
+// auditRefusal records the sign request refused by the limits
+func (eh *SignEventHandler) auditRefusal(sessionID string, keyID string, reason error) {
+	err := eh.auditLog.Record(audit.Entry{
+		Type:      audit.PolicyDecision,
+		SessionID: sessionID,
+		Process:   "signing",
+		Outcome:   "refused",
+		Details: map[string]string{
+			"policy": "limits",
+			"keyId":  keyID,
+			"reason": reason.Error(),
+		},
+	})
+	if err != nil {
+		eh.log.Error().Err(err).Msg("Failed recording refused sign request into audit log")
+	}
 }
 
-func (eh *SignEventHandler) sessionID(rlp string) string {
-	return fmt.Sprintf("sid-sign-%s", rlp)
+// SignSessionID returns ID of the session signing the hash. Batches are executed in the
+// session of their first hash.
+func SignSessionID(hash string) string {
+	return fmt.Sprintf("sid-sign-%s", hash)
 }
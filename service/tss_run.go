@@ -5,39 +5,83 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
+	"strconv"
 	"strings"
-	"sync"
-	"syscall"
+	"time"
+	"tss-demo/logging"
 	"tss-demo/service/event_handlers"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/comm"
 	"tss-demo/tss_util/comm/elector"
 	"tss-demo/tss_util/comm/p2p"
+	"tss-demo/tss_util/comm/recorder"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/evm"
 	"tss-demo/tss_util/health"
 	"tss-demo/tss_util/jobs"
 	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/limits"
 	"tss-demo/tss_util/metrics"
+	"tss-demo/tss_util/sessions"
+	"tss-demo/tss_util/tlsutil"
 	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tracing"
 	"tss-demo/tss_util/tss"
+	"tss-demo/tss_util/tss/liveness"
 	"tss-demo/tss_util/tss_config"
+	"tss-demo/tss_util/tss_config/chain"
+	"tss-demo/tss_util/tss_config/relayer"
 
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
-	"github.com/sygmaprotocol/sygma-core/observability"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
 )
 
+// healthHistorySize is the number of health probes kept per peer
+const healthHistorySize = 20
+
+// approvalExpiryInterval is how often pending sign requests are checked for expiry
+const approvalExpiryInterval = time.Minute
+
+// webhookTimeout is how long a webhook can take to respond to a delivery
+const webhookTimeout = 10 * time.Second
+
 var (
 	Version string
 
-	KeygenEventHandler *event_handlers.KeygenEventHandler
-	SignEventHandler   *event_handlers.SignEventHandler
+	KeygenEventHandler  *event_handlers.KeygenEventHandler
+	SignEventHandler    *event_handlers.SignEventHandler
+	ReshareEventHandler *event_handlers.ReshareEventHandler
+	Keyshares           *keyshare.ECDSAKeyshareStore
+	// Coordinator executes tss sessions of the node
+	Coordinator *tss.Coordinator
+	// Sessions keeps phase transitions of sessions executed by the node
+	Sessions *sessions.Hub
+	// Events publishes session outcomes, signatures, keys and topology changes
+	Events        *events.Bus
+	HealthChecker *health.Checker
+	HealthProber  *health.Prober
+	AuditLog      *audit.Log
+	Authenticator *auth.Authenticator
+	// Approvals is nil if sign requests don't need approval
+	Approvals *approval.Workflow
+	Limiter   *limits.Limiter
+	// Transactors send transactions from the MPC address to configured evm chains by chain ID
+	Transactors map[uint64]*evm.Transactor
 )
 
-func Run() error {
+// LoadConfig loads the shared configuration from the network if configured and the
+// relayer configuration from the environment or the configuration file
+func LoadConfig() (*tss_config.Config, error) {
 	var err error
 
 	configFlag := viper.GetString(tss_config.ConfigFlagName)
@@ -46,34 +90,45 @@ func Run() error {
 	var configuration *tss_config.Config
 	if configURL != "" {
 		configuration, err = tss_config.GetSharedConfigFromNetwork(configURL)
-		panicOnError(err)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if strings.ToLower(configFlag) == "env" {
-		configuration, err = tss_config.GetConfigFromENV(configuration)
-		panicOnError(err)
-	} else {
-		configuration, err = tss_config.GetConfigFromFile(configFlag, configuration)
-		panicOnError(err)
+		return tss_config.GetConfigFromENV(configuration)
 	}
+	return tss_config.GetConfigFromFile(configFlag, configuration)
+}
 
-	observability.ConfigureLogger(configuration.RelayerConfig.LogLevel, os.Stdout)
+// Run starts the tss node and blocks until the context is cancelled. On shutdown new
+// sessions are refused, running sessions get the configured grace period to finish,
+// peers are notified that the node leaves and metrics are flushed. The health endpoint
+// is served with the certificate of the cert reloader if TLS is configured.
+func Run(ctx context.Context, configuration *tss_config.Config, certReloader *tlsutil.CertReloader) error {
+	var err error
+
+	err = logging.Configure(logging.Config{
+		Level:      configuration.RelayerConfig.LogLevel,
+		Format:     configuration.RelayerConfig.LogFormat,
+		File:       configuration.RelayerConfig.LogFile,
+		MaxSize:    configuration.RelayerConfig.LogMaxSize,
+		MaxBackups: configuration.RelayerConfig.LogMaxBackups,
+	})
+	panicOnError(err)
 
 	log.Info().Msg("Successfully loaded configuration")
 
-	topologyProvider, err := topology.NewNetworkTopologyProvider(configuration.RelayerConfig.MpcConfig.TopologyConfiguration, http.DefaultClient)
+	topologyConfig := configuration.RelayerConfig.MpcConfig.TopologyConfiguration
+	topologyProvider, err := topology.NewNetworkTopologyProvider(topologyConfig, http.DefaultClient)
 	panicOnError(err)
-	topologyStore := topology.NewTopologyStore(configuration.RelayerConfig.MpcConfig.TopologyConfiguration.Path)
-	networkTopology, err := topologyStore.Topology()
-	// if topology is not already in file, read from provider
-	if err != nil {
-		networkTopology, err = topologyProvider.NetworkTopology("")
-		panicOnError(err)
-
-		err = topologyStore.StoreTopology(networkTopology)
-		panicOnError(err)
-	}
-	log.Info().Msgf("Successfully loaded topology")
+	topologyStore := topology.NewTopologyStore(topologyConfig.Path)
+	keyshareStore := keyshare.NewECDSAKeyshareStore(configuration.RelayerConfig.MpcConfig.KeysharePath)
+	Keyshares = keyshareStore
+	topologyReloader := topology.NewTopologyReloader(topologyProvider, topologyStore, topologyConfig, keyshareStore)
+	networkTopology, err := topologyReloader.Load()
+	panicOnError(err)
+	log.Info().Uint64("version", networkTopology.Version).Msgf("Successfully loaded topology")
 
 	privBytes, err := crypto.ConfigDecodeKey(configuration.RelayerConfig.MpcConfig.Key)
 	panicOnError(err)
@@ -85,51 +140,142 @@ func Run() error {
 	host, err := p2p.NewHost(priv, networkTopology, connectionGate, configuration.RelayerConfig.MpcConfig.Port)
 	panicOnError(err)
 	log.Info().Str("peerID", host.ID().String()).Msg("Successfully created libp2p host")
+	relayerName := viper.GetString("name")
+	if relayerName == "" {
+		relayerName = configuration.RelayerConfig.Id
+	}
+	logging.SetNode(relayerName, host.ID())
 
-	go health.StartHealthEndpoint(configuration.RelayerConfig.HealthPort)
+	// metrics are pushed to the collector and served to prometheus scrapes on the health port
+	prometheusCollector := metrics.NewPrometheusCollector()
+	mp, err := metrics.InitMetricProvider(context.Background(), configuration.RelayerConfig.OpenTelemetryCollectorURL, prometheusCollector.Option())
+	if err != nil {
+		panic(err)
+	}
+	sygmaMetrics, err := metrics.NewSygmaMetrics(ctx, mp.Meter("relayer-metric-provider"), configuration.RelayerConfig.Env, configuration.RelayerConfig.Id, Version)
+	if err != nil {
+		panic(err)
+	}
 
-	communication := p2p.NewCommunication(host, "p2p/sygma")
-	electorFactory := elector.NewCoordinatorElectorFactory(host, configuration.RelayerConfig.BullyConfig)
+	tp, err := tracing.InitTracerProvider(ctx, configuration.RelayerConfig.TracingConfig, configuration.RelayerConfig.Id)
+	panicOnError(err)
+
+	var messageRecorder comm.MessageRecorder
+	if configuration.RelayerConfig.RecorderConfig.Path != "" {
+		messageRecorder, err = recorder.NewFileRecorder(host.ID(), configuration.RelayerConfig.RecorderConfig.Path, configuration.RelayerConfig.RecorderConfig.EncryptionKey)
+		panicOnError(err)
+		log.Info().Msgf("Recording tss sessions into %s", configuration.RelayerConfig.RecorderConfig.Path)
+	}
+	communication := p2p.NewMeteredCommunication(host, "p2p/sygma", messageRecorder, sygmaMetrics)
+	electorFactory := elector.NewCoordinatorElectorFactory(host, configuration.RelayerConfig.BullyConfig, topologyReloader)
 	coordinator := tss.NewCoordinator(host, communication, electorFactory)
+	Coordinator = coordinator
+	coordinator.Liveness = liveness.NewTracker()
+	coordinator.Metrics = sygmaMetrics
+	Sessions = sessions.NewHub(sessions.DefaultRetained)
+	Events = events.NewBus(host.ID())
+	coordinator.Observer = tss.SessionObservers{Sessions, Events}
+	AuditLog, err = audit.NewLog(configuration.RelayerConfig.AuditConfig.Path, priv)
+	panicOnError(err)
+	coordinator.Audit = AuditLog
+	Authenticator, err = auth.NewAuthenticator(configuration.RelayerConfig.AuthConfig)
+	panicOnError(err)
+	if !Authenticator.Enabled() {
+		log.Warn().Msg("Anonymous API access is allowed, API is open to every caller")
+	}
+	coordinator.ElectorType, err = elector.ParseCoordinatorElectorType(configuration.RelayerConfig.MpcConfig.CoordinatorElector)
+	panicOnError(err)
 
-	keyshareStore := keyshare.NewECDSAKeyshareStore(configuration.RelayerConfig.MpcConfig.KeysharePath)
+	// sessions are cancelled only if they don't finish within the shutdown grace period
+	sessionCtx, cancelSessions := context.WithCancel(context.Background())
+	defer cancelSessions()
+	sessionTracker := event_handlers.NewSessionTracker()
 
-	// wait until executions are done and then stop further executions before exiting
-	exitLock := &sync.RWMutex{}
-	defer exitLock.Lock()
+	// webhooks get events published while running sessions are drained on shutdown
+	webhooks, err := events.NewWebhookDispatcher(Events, &http.Client{Timeout: webhookTimeout}, configuration.RelayerConfig.EventsConfig)
+	panicOnError(err)
+	go webhooks.Start(sessionCtx)
 
-	mp, err := observability.InitMetricProvider(context.Background(), configuration.RelayerConfig.OpenTelemetryCollectorURL)
-	if err != nil {
-		panic(err)
+	healthCheckRefresh := make(chan struct{}, 1)
+	// peer reachability is needed for readiness right after start
+	healthCheckRefresh <- struct{}{}
+	HealthProber = health.NewProber(host.ID(), p2p.NewCommunication(host, "p2p/health"), keyshareStore, healthHistorySize)
+	go HealthProber.Start(ctx)
+	go jobs.StartCommunicationHealthCheckJob(host, configuration.RelayerConfig.MpcConfig.CommHealthCheckInterval, sygmaMetrics, healthCheckRefresh, topologyReloader, coordinator.Liveness, HealthProber)
+
+	limitsConfig := configuration.RelayerConfig.LimitsConfig
+	var limitsStore *limits.Store
+	if limitsConfig.Enabled() {
+		db, err := lvldb.NewLvlDB(limitsConfig.StorePath)
+		panicOnError(err)
+		limitsStore = limits.NewStore(db)
 	}
-	defer func() {
-		if err := mp.Shutdown(context.Background()); err != nil {
-			log.Error().Msgf("Error shutting down meter provider: %v", err)
-		}
-	}()
+	Limiter = limits.NewLimiter(limitsStore, limitsConfig)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	l := log.With().Str("Module", "event_handler")
+	KeygenEventHandler = event_handlers.NewKeygenEventHandler(sessionCtx, l, coordinator, host, communication, keyshareStore, networkTopology.Threshold, sessionTracker, Events)
+	SignEventHandler = event_handlers.NewSignEventHandler(sessionCtx, l, coordinator, host, communication, keyshareStore, topologyReloader, sessionTracker, Limiter, Events, AuditLog)
+	ReshareEventHandler = event_handlers.NewReshareEventHandler(sessionCtx, l, coordinator, host, communication, keyshareStore, networkTopology.Threshold, sessionTracker, Events)
 
-	sygmaMetrics, err := metrics.NewSygmaMetrics(ctx, mp.Meter("relayer-metric-provider"), configuration.RelayerConfig.Env, configuration.RelayerConfig.Id, Version)
-	if err != nil {
-		panic(err)
+	approvalConfig := configuration.RelayerConfig.ApprovalConfig
+	if len(approvalConfig.Approvers) > 0 {
+		db, err := lvldb.NewLvlDB(approvalConfig.StorePath)
+		panicOnError(err)
+		Approvals = approval.NewWorkflow(approval.NewStore(db), SignEventHandler, approvalConfig)
+		Approvals.Audit = AuditLog
+		go Approvals.Start(ctx, approvalExpiryInterval)
+		log.Info().Msgf("Sign requests require %d of %d approvals", approvalConfig.Quorum, len(approvalConfig.Approvers))
 	}
 
-	go jobs.StartCommunicationHealthCheckJob(host, configuration.RelayerConfig.MpcConfig.CommHealthCheckInterval, sygmaMetrics)
+	transactors := make(map[uint64]*evm.Transactor)
+	var nonceStore *evm.NonceStore
+	nonceConfig := configuration.RelayerConfig.NonceConfig
+	for _, chainConfig := range configuration.ChainConfigs {
+		if chainConfig["type"] != chain.EVMType {
+			continue
+		}
+		evmConfig, err := chain.NewEVMConfig(chainConfig)
+		panicOnError(err)
+		if nonceStore == nil {
+			db, err := lvldb.NewLvlDB(nonceConfig.StorePath)
+			panicOnError(err)
+			nonceStore = evm.NewNonceStore(db)
+		}
+		transactor, err := newTransactor(ctx, evmConfig, nonceConfig, nonceStore, keyshareStore, SignEventHandler, Events)
+		panicOnError(err)
+		go transactor.Start(ctx, evmConfig.ReceiptPollInterval)
+		transactors[transactor.ChainID().Uint64()] = transactor
+		log.Info().Str("chainId", transactor.ChainID().String()).Msgf("Sending %s transactions to chain %s", evmConfig.TxType, evmConfig.Name)
+	}
+	Transactors = transactors
 
-	l := log.With().Str("chain", fmt.Sprintf("%v", "name"))
-	KeygenEventHandler = event_handlers.NewKeygenEventHandler(l, coordinator, host, communication, keyshareStore, networkTopology.Threshold)
-	SignEventHandler = event_handlers.NewSignEventHandler(l, coordinator, host, communication, keyshareStore)
+	// sessions running longer than both retry timeouts are considered stuck
+	HealthChecker = health.NewChecker(host.ID(), keyshareStore, coordinator.Liveness, coordinator, topologyReloader, sessionTracker, 2*coordinator.TssTimeout, healthCheckRefresh)
+	// probes of orchestrators can't present client certificates
+	var healthTLS *tls.Config
+	if certReloader != nil {
+		healthTLS = certReloader.ProbeTLSConfig()
+	}
+	go health.StartHealthEndpoint(configuration.RelayerConfig.HealthPort, healthTLS, HealthChecker, prometheusCollector.Handler())
 
-	sysErr := make(chan os.Signal, 1)
-	signal.Notify(sysErr,
-		syscall.SIGTERM,
-		syscall.SIGINT,
-		syscall.SIGHUP,
-		syscall.SIGQUIT)
+	sygmaMetrics.TrackTopologyVersion(networkTopology.Version)
+	topologyReloader.OnChange(func(change topology.TopologyChange) {
+		p2p.ApplyTopologyChange(host, connectionGate, change)
+		KeygenEventHandler.SetThreshold(change.Current.Threshold)
+		ReshareEventHandler.SetThreshold(change.Current.Threshold)
+		sygmaMetrics.TrackTopologyChange(change)
+		auditTopologyChange(AuditLog, change)
+		publishTopologyChange(Events, change)
+		select {
+		case healthCheckRefresh <- struct{}{}:
+		default:
+		}
+	})
+	topologyReloader.OnRejected(func(rejected *topology.NetworkTopology, err error) {
+		auditTopologyRejection(AuditLog, rejected, err)
+	})
+	go topologyReloader.Start(ctx, configuration.RelayerConfig.MpcConfig.TopologyRefreshInterval)
 
-	relayerName := viper.GetString("name")
 	log.Info().Msgf("Started relayer: %s with PID: %s. Version: v%s", relayerName, host.ID().Pretty(), Version)
 
 	key, err := keyshareStore.GetKeyshare()
@@ -139,10 +285,75 @@ func Run() error {
 		log.Info().Msgf("MPC key address: %s", ethcrypto.PubkeyToAddress(*key.Key.ECDSAPub.ToBtcecPubKey().ToECDSA()))
 	}
 
-	sig := <-sysErr
-	log.Info().Msgf("terminating got ` [%v] signal", sig)
-	return nil
+	<-ctx.Done()
+	log.Info().Msgf("Shutting down, waiting up to %s for running sessions", configuration.RelayerConfig.ShutdownGracePeriod)
+
+	// peers elect a new coordinator for sessions coordinated by this node while it drains
+	electorFactory.Leave()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), configuration.RelayerConfig.ShutdownGracePeriod)
+	defer cancelDrain()
+	if Approvals != nil {
+		err = Approvals.Drain(drainCtx)
+		if err != nil {
+			log.Warn().Msg("Approved sign requests still signing after shutdown grace period, they are resumed after restart")
+		}
+	}
+	drainErr := sessionTracker.Drain(drainCtx)
+	if drainErr != nil {
+		log.Warn().Msg("Sessions still running after shutdown grace period, handing them off to peers")
+		drainErr = fmt.Errorf("tss sessions aborted on shutdown: %w", drainErr)
+	}
+	// event subscribers are disconnected once queued events are sent
+	Events.Close()
+	cancelSessions()
+
+	err = host.Close()
+	if err != nil {
+		log.Error().Err(err).Msg("Error closing libp2p host")
+	}
+	err = mp.Shutdown(context.Background())
+	if err != nil {
+		log.Error().Msgf("Error shutting down meter provider: %v", err)
+	}
+	err = tp.Shutdown(context.Background())
+	if err != nil {
+		log.Error().Msgf("Error shutting down tracer provider: %v", err)
+	}
+
+	log.Info().Msg("Relayer stopped")
+	return drainErr
+}
 
+// newTransactor connects to the RPC of the chain and creates the transactor sending
+// transactions to it with the tracker of their receipts and the manager of their nonces
+func newTransactor(
+	ctx context.Context,
+	config *chain.EVMConfig,
+	nonceConfig relayer.NonceConfig,
+	nonceStore *evm.NonceStore,
+	keyshares evm.KeyshareGetter,
+	signer evm.MPCSigner,
+	publisher evm.EventPublisher,
+) (*evm.Transactor, error) {
+	client, err := ethclient.DialContext(ctx, config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to chain %s: %w", config.Name, err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch chain ID of chain %s: %w", config.Name, err)
+	}
+	txType, err := evm.ParseTxType(config.TxType)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := evm.NewBuilder(client, chainID, txType, config.MaxGasPrice)
+	if err != nil {
+		return nil, err
+	}
+	receipts := evm.NewReceiptTracker(client, chainID, config.BlockConfirmations, publisher)
+	nonces := evm.NewNonceManager(client, chainID, nonceStore, nonceConfig.ReservationTimeout)
+	return evm.NewTransactor(client, builder, keyshares, signer, receipts, nonces), nil
 }
 
 func panicOnError(err error) {
@@ -150,3 +361,56 @@ func panicOnError(err error) {
 		panic(err)
 	}
 }
+
+func auditTopologyChange(auditLog *audit.Log, change topology.TopologyChange) {
+	err := auditLog.Record(audit.Entry{
+		Type:    audit.TopologyChange,
+		Outcome: "applied",
+		Details: map[string]string{
+			"version":   strconv.FormatUint(change.Current.Version, 10),
+			"threshold": strconv.Itoa(change.Current.Threshold),
+			"added":     peerList(change.Added),
+			"removed":   peerList(change.Removed),
+			"updated":   peerList(change.Updated),
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed recording topology change into audit log")
+	}
+}
+
+func publishTopologyChange(bus *events.Bus, change topology.TopologyChange) {
+	bus.Publish(events.Event{
+		Type: events.TopologyChanged,
+		Data: map[string]string{
+			"version":   strconv.FormatUint(change.Current.Version, 10),
+			"threshold": strconv.Itoa(change.Current.Threshold),
+			"added":     peerList(change.Added),
+			"removed":   peerList(change.Removed),
+			"updated":   peerList(change.Updated),
+		},
+	})
+}
+
+func auditTopologyRejection(auditLog *audit.Log, rejected *topology.NetworkTopology, reason error) {
+	err := auditLog.Record(audit.Entry{
+		Type:    audit.PolicyDecision,
+		Outcome: "rejected",
+		Details: map[string]string{
+			"policy":  "topology",
+			"version": strconv.FormatUint(rejected.Version, 10),
+			"reason":  reason.Error(),
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed recording topology rejection into audit log")
+	}
+}
+
+func peerList(peers peer.IDSlice) string {
+	ids := make([]string, len(peers))
+	for i, p := range peers {
+		ids[i] = p.Pretty()
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,91 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type Status string
+
+const (
+	// Pending requests wait for approvals
+	Pending  Status = "pending_approval"
+	Approved Status = "approved"
+	Signed   Status = "signed"
+	Failed   Status = "failed"
+	Expired  Status = "expired"
+)
+
+var (
+	ErrRequestNotFound  = errors.New("sign request not found")
+	ErrNotPending       = errors.New("sign request is not pending approval")
+	ErrUnknownApprover  = errors.New("signature is not from a configured approver")
+	ErrInvalidSignature = errors.New("invalid approval signature")
+)
+
+// Approval is an approval of a sign request by a single approver
+type Approval struct {
+	Approver   common.Address `json:"approver"`
+	Name       string         `json:"name"`
+	Signature  string         `json:"signature"`
+	ApprovedAt time.Time      `json:"approvedAt"`
+}
+
+// Request is a sign request of the hash. Attempt is increased every time an expired or
+// failed request is submitted again so that old approvals can't be replayed.
+type Request struct {
+	Hash      string     `json:"hash"`
+	Attempt   uint32     `json:"attempt"`
+	Status    Status     `json:"status"`
+	Requester string     `json:"requester"`
+	Value     *big.Int   `json:"value,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	Approvals []Approval `json:"approvals"`
+	Signature string     `json:"signature,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Approved returns true if the address already approved the request
+func (r *Request) Approved(address common.Address) bool {
+	for _, a := range r.Approvals {
+		if a.Approver == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Message returns the text approvers sign with personal_sign (EIP-191) to approve
+// the attempt of signing the hash
+func Message(hash string, attempt uint32) []byte {
+	return []byte(fmt.Sprintf("tss-demo sign approval\nhash: %s\nattempt: %d", hash, attempt))
+}
+
+// RecoverApprover recovers the address that signed the approval message
+func RecoverApprover(hash string, attempt uint32, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("approval signature must be %d bytes long", crypto.SignatureLength)
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	// wallets return recovery id as 27 or 28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash(Message(hash, attempt)), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,94 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sygmaprotocol/sygma-core/store"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	requestKey = "approval:request:%s"
+	// openKey indexes requests that are pending or approved but not yet signed
+	openKey = "approval:open"
+)
+
+// Store persists sign requests with their approvals
+type Store struct {
+	db store.KeyValueReaderWriter
+}
+
+func NewStore(db store.KeyValueReaderWriter) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// StoreRequest stores the request and updates the index of open requests
+func (s *Store) StoreRequest(request *Request) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	err = s.db.SetByKey([]byte(fmt.Sprintf(requestKey, request.Hash)), data)
+	if err != nil {
+		return err
+	}
+
+	open, err := s.OpenRequests()
+	if err != nil {
+		return err
+	}
+	hashes := make([]string, 0, len(open)+1)
+	for _, hash := range open {
+		if hash != request.Hash {
+			hashes = append(hashes, hash)
+		}
+	}
+	if request.Status == Pending || request.Status == Approved {
+		hashes = append(hashes, request.Hash)
+	}
+	data, err = json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	return s.db.SetByKey([]byte(openKey), data)
+}
+
+// Request returns the request of the hash or ErrRequestNotFound
+func (s *Store) Request(hash string) (*Request, error) {
+	data, err := s.db.GetByKey([]byte(fmt.Sprintf(requestKey, hash)))
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+
+	request := &Request{}
+	err = json.Unmarshal(data, request)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// OpenRequests returns hashes of requests that are pending or approved
+func (s *Store) OpenRequests() ([]string, error) {
+	data, err := s.db.GetByKey([]byte(openKey))
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	var hashes []string
+	err = json.Unmarshal(data, &hashes)
+	return hashes, err
+}
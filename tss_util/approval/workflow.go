@@ -0,0 +1,344 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package approval
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrExpired = errors.New("sign request expired")
+
+// Signer runs the MPC signing session of the hash
+type Signer interface {
+	HandleEvents(hash string, value *big.Int) (string, error)
+}
+
+// Auditor records approval decisions into the audit log
+type Auditor interface {
+	Record(entry audit.Entry) error
+}
+
+// Workflow holds sign requests until the approval quorum is met and then starts signing
+type Workflow struct {
+	// Audit records approval decisions, decisions are not audited by default
+	Audit Auditor
+
+	store     *Store
+	signer    Signer
+	approvers map[common.Address]string
+	quorum    int
+	deadline  time.Duration
+
+	lock    sync.Mutex
+	closing bool
+	signing sync.WaitGroup
+}
+
+func NewWorkflow(store *Store, signer Signer, config relayer.ApprovalConfig) *Workflow {
+	approvers := make(map[common.Address]string)
+	for _, approver := range config.Approvers {
+		approvers[common.HexToAddress(approver.Address)] = approver.Name
+	}
+	return &Workflow{
+		Audit:     noopAuditor{},
+		store:     store,
+		signer:    signer,
+		approvers: approvers,
+		quorum:    config.Quorum,
+		deadline:  config.Deadline,
+	}
+}
+
+// Enabled returns false if no approvers are configured and requests are signed right away
+func (w *Workflow) Enabled() bool {
+	return len(w.approvers) > 0
+}
+
+// Submit creates a pending request for the hash. Open and signed requests of the
+// hash are returned as they are, expired and failed ones are started as a new attempt.
+// Value is the transaction value or nil if the hash is not a known transaction.
+func (w *Workflow) Submit(hash string, value *big.Int, requester string) (*Request, error) {
+	hash = strings.ToLower(strings.TrimPrefix(hash, "0x"))
+	if _, err := hex.DecodeString(hash); err != nil {
+		return nil, fmt.Errorf("invalid hash %s: %w", hash, err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var attempt uint32 = 1
+	request, err := w.store.Request(hash)
+	switch {
+	case errors.Is(err, ErrRequestNotFound):
+	case err != nil:
+		return nil, err
+	case request.Status == Expired || request.Status == Failed:
+		attempt = request.Attempt + 1
+	default:
+		return request, nil
+	}
+
+	now := time.Now().UTC()
+	request = &Request{
+		Hash:      hash,
+		Attempt:   attempt,
+		Status:    Pending,
+		Requester: requester,
+		Value:     value,
+		CreatedAt: now,
+		ExpiresAt: now.Add(w.deadline),
+		Approvals: []Approval{},
+	}
+	err = w.store.StoreRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("hash", hash).Msgf("Sign request attempt %d waits for %d approvals", attempt, w.quorum)
+	return request, nil
+}
+
+// Approve adds the approval signed by an approver to the pending request of the hash.
+// Signing starts once the quorum of approvers approved the request.
+func (w *Workflow) Approve(hash string, signature []byte) (*Request, error) {
+	hash = strings.ToLower(strings.TrimPrefix(hash, "0x"))
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	request, err := w.store.Request(hash)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != Pending {
+		return request, ErrNotPending
+	}
+	if time.Now().After(request.ExpiresAt) {
+		request.Status = Expired
+		err = w.store.StoreRequest(request)
+		if err != nil {
+			log.Error().Err(err).Str("hash", hash).Msg("Failed storing expired sign request")
+		}
+		w.record(request, "", "expired", nil)
+		return request, ErrExpired
+	}
+
+	approver, err := RecoverApprover(hash, request.Attempt, signature)
+	if err != nil {
+		return request, fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	name, ok := w.approvers[approver]
+	if !ok {
+		w.record(request, approver.Hex(), "rejected", map[string]string{"reason": ErrUnknownApprover.Error()})
+		return request, ErrUnknownApprover
+	}
+	if request.Approved(approver) {
+		return request, nil
+	}
+
+	request.Approvals = append(request.Approvals, Approval{
+		Approver:   approver,
+		Name:       name,
+		Signature:  hex.EncodeToString(signature),
+		ApprovedAt: time.Now().UTC(),
+	})
+	if len(request.Approvals) >= w.quorum {
+		request.Status = Approved
+	}
+	err = w.store.StoreRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("hash", hash).Msgf("Sign request approved by %s (%d/%d)", name, len(request.Approvals), w.quorum)
+	if request.Status == Approved {
+		w.record(request, name, "approved", nil)
+		w.startSigning(request)
+	}
+	return request, nil
+}
+
+// Request returns the sign request of the hash
+func (w *Workflow) Request(hash string) (*Request, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.store.Request(strings.ToLower(strings.TrimPrefix(hash, "0x")))
+}
+
+// Start resumes signing of approved requests and expires pending requests past their
+// deadline every interval until the context is cancelled
+func (w *Workflow) Start(ctx context.Context, interval time.Duration) {
+	w.resume()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := w.Expire(time.Now())
+			if err != nil {
+				log.Error().Err(err).Msg("Failed expiring sign requests")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Expire marks pending requests with deadline before now as expired
+func (w *Workflow) Expire(now time.Time) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	hashes, err := w.store.OpenRequests()
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		request, err := w.store.Request(hash)
+		if err != nil {
+			return err
+		}
+		if request.Status != Pending || !now.After(request.ExpiresAt) {
+			continue
+		}
+
+		request.Status = Expired
+		err = w.store.StoreRequest(request)
+		if err != nil {
+			return err
+		}
+		log.Warn().Str("hash", hash).Msgf("Sign request expired with %d/%d approvals", len(request.Approvals), w.quorum)
+		w.record(request, "", "expired", nil)
+	}
+	return nil
+}
+
+// resume restarts signing of requests that were approved before the node restarted
+func (w *Workflow) resume() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	hashes, err := w.store.OpenRequests()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed reading open sign requests")
+		return
+	}
+	for _, hash := range hashes {
+		request, err := w.store.Request(hash)
+		if err != nil || request.Status != Approved {
+			continue
+		}
+		w.startSigning(request)
+	}
+}
+
+// Drain stops signing of newly approved requests and waits until signing of approved
+// requests finishes or the context is done. Requests that are not signed before the
+// node stops stay approved and are resumed after restart.
+func (w *Workflow) Drain(ctx context.Context) error {
+	w.lock.Lock()
+	w.closing = true
+	w.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.signing.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startSigning signs the approved request in the background. It has to be called
+// with the lock held.
+func (w *Workflow) startSigning(request *Request) {
+	if w.closing {
+		return
+	}
+
+	hash := request.Hash
+	attempt := request.Attempt
+	w.signing.Add(1)
+	go func() {
+		defer w.signing.Done()
+		w.sign(hash, attempt)
+	}()
+}
+
+func (w *Workflow) sign(hash string, attempt uint32) {
+	request, err := w.store.Request(hash)
+	if err != nil {
+		log.Error().Err(err).Str("hash", hash).Msg("Failed reading approved sign request")
+		return
+	}
+	signature, signErr := w.signer.HandleEvents(hash, request.Value)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	request, err = w.store.Request(hash)
+	if err != nil || request.Attempt != attempt || request.Status != Approved {
+		return
+	}
+	// sessions fail when the node shuts down, the request is signed after restart
+	if signErr != nil && w.closing {
+		log.Warn().Err(signErr).Str("hash", hash).Msg("Sign request signing interrupted by shutdown")
+		return
+	}
+	if signErr != nil {
+		request.Status = Failed
+		request.Error = signErr.Error()
+		w.record(request, "", "failed", map[string]string{"reason": request.Error})
+	} else {
+		request.Status = Signed
+		request.Signature = signature
+		w.record(request, "", "signed", nil)
+	}
+	err = w.store.StoreRequest(request)
+	if err != nil {
+		log.Error().Err(err).Str("hash", hash).Msg("Failed storing sign request result")
+	}
+}
+
+// record records the decision on the request attempt into the audit log
+func (w *Workflow) record(request *Request, actor string, outcome string, details map[string]string) {
+	if details == nil {
+		details = make(map[string]string)
+	}
+	details["policy"] = "approval"
+	details["hash"] = request.Hash
+	details["attempt"] = strconv.FormatUint(uint64(request.Attempt), 10)
+	details["approvals"] = fmt.Sprintf("%d/%d", len(request.Approvals), w.quorum)
+	err := w.Audit.Record(audit.Entry{
+		Type:    audit.PolicyDecision,
+		Actor:   actor,
+		Outcome: outcome,
+		Details: details,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hash", request.Hash).Msg("Failed recording approval decision into audit log")
+	}
+}
+
+type noopAuditor struct{}
+
+func (noopAuditor) Record(entry audit.Entry) error { return nil }
@@ -0,0 +1,293 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package approval_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+	"tss-demo/tss_util/approval"
+	"tss-demo/tss_util/audit"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+)
+
+const hash = "b07e3536cce658dc1615e6e43ee0af85ddeef27de5b237d806a8296f83fec261"
+
+type signer struct {
+	hashes    chan string
+	block     chan struct{}
+	value     *big.Int
+	signature string
+	err       error
+}
+
+func (s *signer) HandleEvents(hash string, value *big.Int) (string, error) {
+	s.value = value
+	s.hashes <- hash
+	if s.block != nil {
+		<-s.block
+	}
+	return s.signature, s.err
+}
+
+type auditor struct {
+	lock     sync.Mutex
+	outcomes []string
+}
+
+func (a *auditor) Record(entry audit.Entry) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.outcomes = append(a.outcomes, entry.Outcome)
+	return nil
+}
+
+func (a *auditor) Outcomes() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]string{}, a.outcomes...)
+}
+
+type WorkflowTestSuite struct {
+	suite.Suite
+	db        *lvldb.LVLDB
+	store     *approval.Store
+	signer    *signer
+	approvers []*ecdsa.PrivateKey
+	config    relayer.ApprovalConfig
+	auditor   *auditor
+	workflow  *approval.Workflow
+}
+
+func TestRunWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkflowTestSuite))
+}
+
+func (s *WorkflowTestSuite) SetupTest() {
+	var err error
+	s.db, err = lvldb.NewLvlDB(s.T().TempDir())
+	s.Nil(err)
+	s.store = approval.NewStore(s.db)
+	s.signer = &signer{hashes: make(chan string, 1), signature: "sig"}
+
+	s.approvers = nil
+	s.config = relayer.ApprovalConfig{Quorum: 2, Deadline: time.Hour}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		key, _ := crypto.GenerateKey()
+		s.approvers = append(s.approvers, key)
+		s.config.Approvers = append(s.config.Approvers, relayer.Approver{
+			Name:    name,
+			Address: crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		})
+	}
+	s.auditor = &auditor{}
+	s.workflow = approval.NewWorkflow(s.store, s.signer, s.config)
+	s.workflow.Audit = s.auditor
+}
+
+func (s *WorkflowTestSuite) TearDownTest() {
+	_ = s.db.Close()
+}
+
+func (s *WorkflowTestSuite) approve(key *ecdsa.PrivateKey, attempt uint32) []byte {
+	sig, err := crypto.Sign(accounts.TextHash(approval.Message(hash, attempt)), key)
+	s.Nil(err)
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig
+}
+
+func (s *WorkflowTestSuite) waitForStatus(status approval.Status) *approval.Request {
+	var request *approval.Request
+	s.Eventually(func() bool {
+		request, _ = s.workflow.Request(hash)
+		return request.Status == status
+	}, time.Second, 10*time.Millisecond)
+	return request
+}
+
+func (s *WorkflowTestSuite) Test_Submit_CreatesPendingRequest() {
+	request, err := s.workflow.Submit("0x"+hash, nil, "wallet")
+
+	s.Nil(err)
+	s.Equal(hash, request.Hash)
+	s.Equal(approval.Pending, request.Status)
+	s.Equal(uint32(1), request.Attempt)
+
+	stored, err := s.store.Request(hash)
+	s.Nil(err)
+	s.Equal(request.ExpiresAt.Unix(), stored.ExpiresAt.Unix())
+	open, _ := s.store.OpenRequests()
+	s.Equal([]string{hash}, open)
+}
+
+func (s *WorkflowTestSuite) Test_Submit_InvalidHash() {
+	_, err := s.workflow.Submit("not-a-hash", nil, "wallet")
+
+	s.NotNil(err)
+}
+
+func (s *WorkflowTestSuite) Test_Approve_QuorumStartsSigning() {
+	_, err := s.workflow.Submit(hash, big.NewInt(1000), "wallet")
+	s.Nil(err)
+
+	request, err := s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	s.Nil(err)
+	s.Equal(approval.Pending, request.Status)
+	s.Equal("alice", request.Approvals[0].Name)
+	s.Empty(s.signer.hashes)
+
+	request, err = s.workflow.Approve(hash, s.approve(s.approvers[1], 1))
+	s.Nil(err)
+	s.Equal(approval.Approved, request.Status)
+
+	s.Equal(hash, <-s.signer.hashes)
+	s.Equal(big.NewInt(1000), s.signer.value)
+	request = s.waitForStatus(approval.Signed)
+	s.Equal("sig", request.Signature)
+	s.Equal([]string{"approved", "signed"}, s.auditor.Outcomes())
+	open, _ := s.store.OpenRequests()
+	s.Empty(open)
+}
+
+func (s *WorkflowTestSuite) Test_Approve_DuplicateApprovalCountedOnce() {
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+
+	_, err = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	s.Nil(err)
+	request, err := s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+
+	s.Nil(err)
+	s.Len(request.Approvals, 1)
+	s.Equal(approval.Pending, request.Status)
+}
+
+func (s *WorkflowTestSuite) Test_Approve_UnknownApprover() {
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	key, _ := crypto.GenerateKey()
+
+	_, err = s.workflow.Approve(hash, s.approve(key, 1))
+
+	s.Equal(approval.ErrUnknownApprover, err)
+	s.Equal([]string{"rejected"}, s.auditor.Outcomes())
+}
+
+func (s *WorkflowTestSuite) Test_Approve_InvalidSignature() {
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+
+	_, err = s.workflow.Approve(hash, []byte("signature"))
+
+	s.True(errors.Is(err, approval.ErrInvalidSignature))
+}
+
+func (s *WorkflowTestSuite) Test_Approve_PreviousAttemptNotReplayed() {
+	s.config.Deadline = -time.Second
+	s.workflow = approval.NewWorkflow(s.store, s.signer, s.config)
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	s.Nil(s.workflow.Expire(time.Now()))
+	s.config.Deadline = time.Hour
+	s.workflow = approval.NewWorkflow(s.store, s.signer, s.config)
+
+	request, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	s.Equal(uint32(2), request.Attempt)
+
+	_, err = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	s.Equal(approval.ErrUnknownApprover, err)
+}
+
+func (s *WorkflowTestSuite) Test_Approve_ExpiredRequest() {
+	s.config.Deadline = -time.Second
+	s.workflow = approval.NewWorkflow(s.store, s.signer, s.config)
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+
+	s.workflow.Audit = s.auditor
+	request, err := s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+
+	s.True(errors.Is(err, approval.ErrExpired))
+	s.Equal(approval.Expired, request.Status)
+	s.Equal([]string{"expired"}, s.auditor.Outcomes())
+}
+
+func (s *WorkflowTestSuite) Test_Expire() {
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+
+	s.Nil(s.workflow.Expire(time.Now()))
+	request, _ := s.workflow.Request(hash)
+	s.Equal(approval.Pending, request.Status)
+
+	s.Nil(s.workflow.Expire(time.Now().Add(2 * time.Hour)))
+	request, _ = s.workflow.Request(hash)
+	s.Equal(approval.Expired, request.Status)
+	s.Equal([]string{"expired"}, s.auditor.Outcomes())
+	_, err = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	s.Equal(approval.ErrNotPending, err)
+}
+
+func (s *WorkflowTestSuite) Test_FailedSigning() {
+	s.signer.err = errors.New("timeout")
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	_, _ = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	_, _ = s.workflow.Approve(hash, s.approve(s.approvers[2], 1))
+
+	<-s.signer.hashes
+	request := s.waitForStatus(approval.Failed)
+	s.Equal("timeout", request.Error)
+	s.Equal([]string{"approved", "failed"}, s.auditor.Outcomes())
+}
+
+func (s *WorkflowTestSuite) Test_Drain_WaitsForSigning() {
+	s.signer.block = make(chan struct{})
+	s.signer.err = errors.New("session cancelled")
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	_, _ = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+	_, _ = s.workflow.Approve(hash, s.approve(s.approvers[1], 1))
+	<-s.signer.hashes
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Equal(context.DeadlineExceeded, s.workflow.Drain(ctx))
+	close(s.signer.block)
+	s.Nil(s.workflow.Drain(context.Background()))
+
+	// signing interrupted by shutdown is resumed after restart
+	request, _ := s.workflow.Request(hash)
+	s.Equal(approval.Approved, request.Status)
+}
+
+func (s *WorkflowTestSuite) Test_Drain_StopsNewSigning() {
+	s.Nil(s.workflow.Drain(context.Background()))
+	_, err := s.workflow.Submit(hash, nil, "wallet")
+	s.Nil(err)
+	_, _ = s.workflow.Approve(hash, s.approve(s.approvers[0], 1))
+
+	request, err := s.workflow.Approve(hash, s.approve(s.approvers[1], 1))
+
+	s.Nil(err)
+	s.Equal(approval.Approved, request.Status)
+	s.Nil(s.workflow.Drain(context.Background()))
+	s.Empty(s.signer.hashes)
+}
+
+func (s *WorkflowTestSuite) Test_Request_NotFound() {
+	_, err := s.workflow.Request(hash)
+
+	s.Equal(approval.ErrRequestNotFound, err)
+}
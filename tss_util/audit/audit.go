@@ -0,0 +1,244 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+type EventType string
+
+const (
+	APICall        EventType = "api_call"
+	SessionStart   EventType = "session_start"
+	SessionFinish  EventType = "session_finish"
+	TopologyChange EventType = "topology_change"
+	PolicyDecision EventType = "policy_decision"
+)
+
+// Entry is a single audit log record. Hash is SHA-256 of the entry with empty Hash and
+// Signature and PrevHash is the hash of the previous entry, so changing, removing or
+// reordering entries breaks the chain. Signature is the signature of the hash by the
+// node libp2p key, so the chain can't be rebuilt without the node key.
+type Entry struct {
+	Sequence  uint64            `json:"sequence"`
+	Timestamp time.Time         `json:"timestamp"`
+	Node      peer.ID           `json:"node"`
+	Type      EventType         `json:"type"`
+	Actor     string            `json:"actor,omitempty"`
+	SessionID string            `json:"sessionID,omitempty"`
+	Process   string            `json:"process,omitempty"`
+	Outcome   string            `json:"outcome,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	PrevHash  string            `json:"prevHash"`
+	Hash      string            `json:"hash"`
+	Signature string            `json:"signature"`
+}
+
+// ComputeHash returns hash of the entry content including the previous hash
+func (e Entry) ComputeHash() (string, error) {
+	e.Hash = ""
+	e.Signature = ""
+	eb, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(eb)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Sign computes hash of the entry and signs it with the node key
+func (e *Entry) Sign(key crypto.PrivKey) error {
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return err
+	}
+	signature, err := key.Sign([]byte(hash))
+	if err != nil {
+		return err
+	}
+
+	e.Hash = hash
+	e.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// VerifySignature checks that the entry hash is signed by the public key
+func (e Entry) VerifySignature(publicKey crypto.PubKey) (bool, error) {
+	signature, err := base64.StdEncoding.DecodeString(e.Signature)
+	if err != nil {
+		return false, err
+	}
+	return publicKey.Verify([]byte(e.Hash), signature)
+}
+
+// Log is an append-only audit log stored as JSON lines. Every entry is synced
+// to disk before Record returns.
+type Log struct {
+	node peer.ID
+	key  crypto.PrivKey
+
+	mu       sync.Mutex
+	file     *os.File
+	sequence uint64
+	lastHash string
+}
+
+// NewLog opens audit log at path and continues the hash chain of existing entries.
+// Entries are signed with the node libp2p key.
+func NewLog(path string, key crypto.PrivKey) (*Log, error) {
+	node, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = truncatePartialEntry(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	l := &Log{node: node, key: key}
+	entries, err := ReadEntries(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		l.sequence = last.Sequence
+		l.lastHash = last.Hash
+	}
+
+	l.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record chains the entry to the previous one and appends it to the log
+func (l *Log) Record(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = l.sequence + 1
+	entry.Timestamp = time.Now().UTC()
+	entry.Node = l.node
+	entry.PrevHash = l.lastHash
+	err := entry.Sign(l.key)
+	if err != nil {
+		return err
+	}
+
+	eb, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = l.file.Write(append(eb, '\n'))
+	if err != nil {
+		return err
+	}
+	err = l.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	l.sequence = entry.Sequence
+	l.lastHash = entry.Hash
+	return nil
+}
+
+// SessionStarted implements tss.SessionAuditor
+func (l *Log) SessionStarted(sessionID string, process string) {
+	l.record(Entry{
+		Type:      SessionStart,
+		SessionID: sessionID,
+		Process:   process,
+	})
+}
+
+// SessionFinished implements tss.SessionAuditor
+func (l *Log) SessionFinished(sessionID string, process string, outcome string, err error) {
+	entry := Entry{
+		Type:      SessionFinish,
+		SessionID: sessionID,
+		Process:   process,
+		Outcome:   outcome,
+	}
+	if err != nil {
+		entry.Details = map[string]string{"error": err.Error()}
+	}
+	l.record(entry)
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// record logs failures of callers that can't handle them
+func (l *Log) record(entry Entry) {
+	err := l.Record(entry)
+	if err != nil {
+		log.Error().Err(err).Str("SessionID", entry.SessionID).Msgf("Failed recording %s audit entry", entry.Type)
+	}
+}
+
+// ReadEntries reads all audit log entries from file
+func ReadEntries(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := Entry{}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit entry on line %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// truncatePartialEntry removes the last line of the log if it was not completely
+// written, e.g. because the node crashed while recording it. Complete entries always
+// end with a new line as they are written together with it.
+func truncatePartialEntry(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	end := bytes.LastIndexByte(content, '\n') + 1
+	if end == len(content) {
+		return nil
+	}
+
+	log.Warn().Str("path", path).Msgf("Removing partially written audit entry: %s", content[end:])
+	return os.Truncate(path, int64(end))
+}
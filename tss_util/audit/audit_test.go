@@ -0,0 +1,163 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package audit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"tss-demo/tss_util/audit"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditLogTestSuite struct {
+	suite.Suite
+	path string
+	key  crypto.PrivKey
+	node peer.ID
+}
+
+func TestRunAuditLogTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditLogTestSuite))
+}
+
+func (s *AuditLogTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "audit", "audit.jsonl")
+	s.key, _, _ = crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	s.node, _ = peer.IDFromPrivateKey(s.key)
+}
+
+func (s *AuditLogTestSuite) record(entries ...audit.Entry) []audit.Entry {
+	l, err := audit.NewLog(s.path, s.key)
+	s.Nil(err)
+	for _, entry := range entries {
+		s.Nil(l.Record(entry))
+	}
+	s.Nil(l.Close())
+
+	recorded, err := audit.ReadEntries(s.path)
+	s.Nil(err)
+	return recorded
+}
+
+func (s *AuditLogTestSuite) Test_Record_ChainsEntries() {
+	entries := s.record(
+		audit.Entry{Type: audit.APICall, Actor: "127.0.0.1", Details: map[string]string{"path": "/api/v1/sign"}},
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-1", Process: "signing"},
+	)
+
+	s.Len(entries, 2)
+	s.Equal(uint64(1), entries[0].Sequence)
+	s.Equal("", entries[0].PrevHash)
+	s.Equal(s.node, entries[0].Node)
+	s.Equal(uint64(2), entries[1].Sequence)
+	s.Equal(entries[0].Hash, entries[1].PrevHash)
+	s.Nil(audit.Verify(entries, s.key.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_NewLog_ContinuesExistingChain() {
+	s.record(audit.Entry{Type: audit.SessionStart, SessionID: "keygen"})
+
+	entries := s.record(audit.Entry{Type: audit.SessionFinish, SessionID: "keygen", Outcome: "success"})
+
+	s.Len(entries, 2)
+	s.Equal(uint64(2), entries[1].Sequence)
+	s.Nil(audit.Verify(entries, s.key.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_SessionAuditor() {
+	l, err := audit.NewLog(s.path, s.key)
+	s.Nil(err)
+
+	l.SessionStarted("sid-sign-1", "signing")
+	l.SessionFinished("sid-sign-1", "signing", "failure", errors.New("timeout"))
+
+	entries, err := audit.ReadEntries(s.path)
+	s.Nil(err)
+	s.Equal(audit.SessionStart, entries[0].Type)
+	s.Equal(audit.SessionFinish, entries[1].Type)
+	s.Equal("failure", entries[1].Outcome)
+	s.Equal("timeout", entries[1].Details["error"])
+}
+
+func (s *AuditLogTestSuite) Test_Verify_DetectsTampering() {
+	entries := s.record(
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-1"},
+		audit.Entry{Type: audit.SessionFinish, SessionID: "sid-sign-1", Outcome: "failure"},
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-2"},
+	)
+
+	modified := append([]audit.Entry{}, entries...)
+	modified[1].Outcome = "success"
+	s.Equal(&audit.ChainError{Sequence: 2, Reason: "entry content doesn't match its hash"}, audit.Verify(modified, s.key.GetPublic()))
+
+	removed := []audit.Entry{entries[0], entries[2]}
+	s.Equal(&audit.ChainError{Sequence: 3, Reason: "expected sequence 2"}, audit.Verify(removed, s.key.GetPublic()))
+
+	rehashed := append([]audit.Entry{}, entries...)
+	rehashed[1].Outcome = "success"
+	rehashed[1].Hash, _ = rehashed[1].ComputeHash()
+	s.Equal(&audit.ChainError{Sequence: 2, Reason: "entry isn't signed by the node key"}, audit.Verify(rehashed, s.key.GetPublic()))
+
+	resigned := append([]audit.Entry{}, entries...)
+	resigned[1].Outcome = "success"
+	_ = resigned[1].Sign(s.key)
+	s.Equal(&audit.ChainError{Sequence: 3, Reason: "previous hash doesn't match previous entry"}, audit.Verify(resigned, s.key.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_Verify_RebuiltChainWithOtherKey() {
+	entries := s.record(
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-1"},
+		audit.Entry{Type: audit.SessionFinish, SessionID: "sid-sign-1", Outcome: "failure"},
+	)
+	otherKey, _, _ := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+
+	rebuilt := append([]audit.Entry{}, entries...)
+	rebuilt[1].Outcome = "success"
+	_ = rebuilt[1].Sign(otherKey)
+
+	s.Equal(&audit.ChainError{Sequence: 2, Reason: "entry isn't signed by the node key"}, audit.Verify(rebuilt, s.key.GetPublic()))
+	s.NotNil(audit.Verify(entries, otherKey.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_NewLog_TruncatesPartialEntry() {
+	s.record(audit.Entry{Type: audit.SessionStart, SessionID: "keygen"})
+	f, _ := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	_, _ = f.WriteString(`{"sequence":2,"type":"sess`)
+	f.Close()
+
+	entries := s.record(audit.Entry{Type: audit.SessionFinish, SessionID: "keygen", Outcome: "success"})
+
+	s.Len(entries, 2)
+	s.Equal(uint64(2), entries[1].Sequence)
+	s.Nil(audit.Verify(entries, s.key.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_Verify_ExportedRange() {
+	entries := s.record(
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-1"},
+		audit.Entry{Type: audit.SessionFinish, SessionID: "sid-sign-1"},
+		audit.Entry{Type: audit.SessionStart, SessionID: "sid-sign-2"},
+	)
+
+	s.Nil(audit.Verify(entries[1:], s.key.GetPublic()))
+}
+
+func (s *AuditLogTestSuite) Test_Filter() {
+	entries := s.record(
+		audit.Entry{Type: audit.SessionStart},
+		audit.Entry{Type: audit.SessionFinish},
+		audit.Entry{Type: audit.TopologyChange},
+	)
+
+	s.Equal(entries[1:], audit.Filter(entries, audit.Range{From: 2}))
+	s.Equal(entries[:2], audit.Filter(entries, audit.Range{To: 2}))
+	s.Equal(entries, audit.Filter(entries, audit.Range{Since: entries[0].Timestamp, Until: time.Now()}))
+	s.Empty(audit.Filter(entries, audit.Range{Since: time.Now().Add(time.Hour)}))
+}
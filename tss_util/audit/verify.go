@@ -0,0 +1,98 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ChainError describes the first entry that breaks the hash chain
+type ChainError struct {
+	Sequence uint64
+	Reason   string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at entry %d: %s", e.Sequence, e.Reason)
+}
+
+// Verify checks that entries form an unbroken hash chain signed by the node with the
+// public key. The first entry is trusted as the start of the chain so exported ranges
+// can be verified, full logs should start with the first entry. Removal of trailing
+// entries can only be detected by comparing the last hash with a previously exported one.
+func Verify(entries []Entry, publicKey crypto.PubKey) error {
+	node, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return &ChainError{Sequence: entry.Sequence, Reason: err.Error()}
+		}
+		if hash != entry.Hash {
+			return &ChainError{Sequence: entry.Sequence, Reason: "entry content doesn't match its hash"}
+		}
+		if entry.Node != node {
+			return &ChainError{Sequence: entry.Sequence, Reason: fmt.Sprintf("entry recorded by node %s", entry.Node)}
+		}
+		valid, err := entry.VerifySignature(publicKey)
+		if err != nil || !valid {
+			return &ChainError{Sequence: entry.Sequence, Reason: "entry isn't signed by the node key"}
+		}
+
+		if i == 0 {
+			if entry.Sequence == 1 && entry.PrevHash != "" {
+				return &ChainError{Sequence: entry.Sequence, Reason: "first entry references previous entry"}
+			}
+			continue
+		}
+
+		previous := entries[i-1]
+		if entry.Sequence != previous.Sequence+1 {
+			return &ChainError{
+				Sequence: entry.Sequence,
+				Reason:   fmt.Sprintf("expected sequence %d", previous.Sequence+1),
+			}
+		}
+		if entry.PrevHash != previous.Hash {
+			return &ChainError{Sequence: entry.Sequence, Reason: "previous hash doesn't match previous entry"}
+		}
+	}
+	return nil
+}
+
+// Range selects entries for export. Zero values don't limit the range.
+type Range struct {
+	From  uint64
+	To    uint64
+	Since time.Time
+	Until time.Time
+}
+
+// Filter returns entries within the range
+func Filter(entries []Entry, r Range) []Entry {
+	filtered := make([]Entry, 0)
+	for _, entry := range entries {
+		if r.From != 0 && entry.Sequence < r.From {
+			continue
+		}
+		if r.To != 0 && entry.Sequence > r.To {
+			continue
+		}
+		if !r.Since.IsZero() && entry.Timestamp.Before(r.Since) {
+			continue
+		}
+		if !r.Until.IsZero() && entry.Timestamp.After(r.Until) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
@@ -0,0 +1,177 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	minSecretLength = 32
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+type Role string
+
+const (
+	// Observer can read node status
+	Observer Role = "observer"
+	// Signer can request signatures
+	Signer Role = "signer"
+	// KeyAdmin manages the MPC key with keygen and resharing and cancels sessions
+	KeyAdmin Role = "key_admin"
+	// Approver submits approvals of pending sign requests
+	Approver Role = "approver"
+)
+
+type Permission string
+
+const (
+	ReadStatus Permission = "read_status"
+	Sign       Permission = "sign"
+	Keygen     Permission = "keygen"
+	Reshare    Permission = "reshare"
+	Cancel     Permission = "cancel"
+	Approve    Permission = "approve"
+)
+
+var permissions = map[Role][]Permission{
+	Observer: {ReadStatus},
+	Signer:   {ReadStatus, Sign},
+	KeyAdmin: {ReadStatus, Keygen, Reshare, Cancel},
+	Approver: {ReadStatus, Approve},
+}
+
+// Allows returns true if the role has the permission
+func (r Role) Allows(permission Permission) bool {
+	for _, p := range permissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Identity is an authenticated caller
+type Identity struct {
+	Name string
+	Role Role
+}
+
+type claims struct {
+	Role Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator authenticates API callers with API keys or JWTs from the configuration
+type Authenticator struct {
+	keys      map[string]Identity
+	secret    []byte
+	anonymous bool
+}
+
+// NewAuthenticator creates authenticator of the configured callers. Anonymous access
+// can't be combined with callers as it would let through callers without credentials.
+func NewAuthenticator(config relayer.AuthConfig) (*Authenticator, error) {
+	if config.AllowAnonymous && (len(config.APIKeys) > 0 || config.JWTSecret != "") {
+		return nil, errors.New("anonymous access can't be allowed together with api keys or jwt secret")
+	}
+
+	a := &Authenticator{
+		keys:      make(map[string]Identity),
+		anonymous: config.AllowAnonymous,
+	}
+	for _, key := range config.APIKeys {
+		role := Role(key.Role)
+		if _, ok := permissions[role]; !ok {
+			return nil, fmt.Errorf("unknown role %s of api key %s", key.Role, key.Name)
+		}
+		hash, err := hex.DecodeString(key.KeyHash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %s hash must be hex encoded SHA-256", key.Name)
+		}
+		a.keys[hex.EncodeToString(hash)] = Identity{Name: key.Name, Role: role}
+	}
+
+	if config.JWTSecret != "" {
+		if len(config.JWTSecret) < minSecretLength {
+			return nil, fmt.Errorf("jwt secret must be at least %d bytes long", minSecretLength)
+		}
+		a.secret = []byte(config.JWTSecret)
+	}
+	return a, nil
+}
+
+// Enabled returns false if anonymous access is allowed and the API is open. If no
+// callers are configured authentication fails for every caller.
+func (a *Authenticator) Enabled() bool {
+	return !a.anonymous
+}
+
+// Authenticate returns identity of the caller from the API key header or from
+// the bearer token
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	return a.AuthenticateCredentials(r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+}
+
+// AuthenticateCredentials returns identity of the caller from the API key or from the
+// bearer token of the authorization value
+func (a *Authenticator) AuthenticateCredentials(apiKey string, authorization string) (Identity, error) {
+	if apiKey != "" {
+		return a.authenticateKey(apiKey)
+	}
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return a.authenticateToken(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	return Identity{}, ErrMissingCredentials
+}
+
+func (a *Authenticator) authenticateKey(key string) (Identity, error) {
+	// keys are looked up by hash so lookup timing doesn't reveal the keys
+	hash := sha256.Sum256([]byte(key))
+	identity, ok := a.keys[hex.EncodeToString(hash[:])]
+	if !ok {
+		return Identity{}, ErrInvalidCredentials
+	}
+	return identity, nil
+}
+
+func (a *Authenticator) authenticateToken(token string) (Identity, error) {
+	if a.secret == nil {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(token, c, func(t *jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	if c.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	// tokens without expiration could never be revoked without rotating the secret
+	if c.ExpiresAt == nil {
+		return Identity{}, fmt.Errorf("%w: token has no expiration", ErrInvalidCredentials)
+	}
+	if _, ok := permissions[c.Role]; !ok {
+		return Identity{}, fmt.Errorf("%w: unknown role %s", ErrInvalidCredentials, c.Role)
+	}
+	return Identity{Name: c.Subject, Role: c.Role}, nil
+}
@@ -0,0 +1,174 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"tss-demo/tss_util/auth"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+type AuthenticatorTestSuite struct {
+	suite.Suite
+	authenticator *auth.Authenticator
+}
+
+func TestRunAuthenticatorTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthenticatorTestSuite))
+}
+
+func hash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func (s *AuthenticatorTestSuite) SetupTest() {
+	var err error
+	s.authenticator, err = auth.NewAuthenticator(relayer.AuthConfig{
+		APIKeys: []relayer.APIKey{
+			{Name: "wallet", KeyHash: hash("signer-key"), Role: "signer"},
+			{Name: "ops", KeyHash: hash("admin-key"), Role: "key_admin"},
+		},
+		JWTSecret: secret,
+	})
+	s.Nil(err)
+}
+
+func (s *AuthenticatorTestSuite) request(header, value string) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "/api/v1/sign", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func (s *AuthenticatorTestSuite) token(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	s.Nil(err)
+	return "Bearer " + token
+}
+
+func (s *AuthenticatorTestSuite) Test_NewAuthenticator_InvalidConfig() {
+	_, err := auth.NewAuthenticator(relayer.AuthConfig{
+		APIKeys: []relayer.APIKey{{Name: "wallet", KeyHash: hash("key"), Role: "admin"}},
+	})
+	s.NotNil(err)
+
+	_, err = auth.NewAuthenticator(relayer.AuthConfig{
+		APIKeys: []relayer.APIKey{{Name: "wallet", KeyHash: "signer-key", Role: "signer"}},
+	})
+	s.NotNil(err)
+
+	_, err = auth.NewAuthenticator(relayer.AuthConfig{JWTSecret: "short"})
+	s.NotNil(err)
+
+	_, err = auth.NewAuthenticator(relayer.AuthConfig{JWTSecret: secret, AllowAnonymous: true})
+	s.NotNil(err)
+}
+
+func (s *AuthenticatorTestSuite) Test_Enabled() {
+	open, err := auth.NewAuthenticator(relayer.AuthConfig{AllowAnonymous: true})
+	s.Nil(err)
+	unconfigured, err := auth.NewAuthenticator(relayer.AuthConfig{})
+	s.Nil(err)
+
+	s.False(open.Enabled())
+	s.True(unconfigured.Enabled())
+	s.True(s.authenticator.Enabled())
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_NoCallersConfigured() {
+	unconfigured, _ := auth.NewAuthenticator(relayer.AuthConfig{})
+
+	_, err := unconfigured.Authenticate(s.request(auth.APIKeyHeader, "signer-key"))
+
+	s.True(errors.Is(err, auth.ErrInvalidCredentials))
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_APIKey() {
+	identity, err := s.authenticator.Authenticate(s.request(auth.APIKeyHeader, "signer-key"))
+
+	s.Nil(err)
+	s.Equal(auth.Identity{Name: "wallet", Role: auth.Signer}, identity)
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_InvalidAPIKey() {
+	_, err := s.authenticator.Authenticate(s.request(auth.APIKeyHeader, "unknown-key"))
+
+	s.Equal(auth.ErrInvalidCredentials, err)
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_MissingCredentials() {
+	_, err := s.authenticator.Authenticate(s.request("", ""))
+
+	s.Equal(auth.ErrMissingCredentials, err)
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_JWT() {
+	token := s.token(jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+		"sub":  "ops-team",
+		"role": "key_admin",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+
+	identity, err := s.authenticator.Authenticate(s.request("Authorization", token))
+
+	s.Nil(err)
+	s.Equal(auth.Identity{Name: "ops-team", Role: auth.KeyAdmin}, identity)
+}
+
+func (s *AuthenticatorTestSuite) Test_Authenticate_InvalidJWT() {
+	tokens := map[string]string{
+		"expired": s.token(jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+			"sub": "ops-team", "role": "key_admin", "exp": time.Now().Add(-time.Hour).Unix(),
+		}),
+		"wrong secret": s.token(jwt.SigningMethodHS256, []byte("fedcba9876543210fedcba9876543210"), jwt.MapClaims{
+			"sub": "ops-team", "role": "key_admin", "exp": time.Now().Add(time.Hour).Unix(),
+		}),
+		"wrong algorithm": s.token(jwt.SigningMethodHS512, []byte(secret), jwt.MapClaims{
+			"sub": "ops-team", "role": "key_admin", "exp": time.Now().Add(time.Hour).Unix(),
+		}),
+		"unknown role": s.token(jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+			"sub": "ops-team", "role": "root", "exp": time.Now().Add(time.Hour).Unix(),
+		}),
+		"missing subject": s.token(jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+			"role": "key_admin", "exp": time.Now().Add(time.Hour).Unix(),
+		}),
+		"missing expiration": s.token(jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+			"sub": "ops-team", "role": "key_admin",
+		}),
+	}
+
+	for name, token := range tokens {
+		_, err := s.authenticator.Authenticate(s.request("Authorization", token))
+
+		s.True(errors.Is(err, auth.ErrInvalidCredentials), name)
+	}
+}
+
+func (s *AuthenticatorTestSuite) Test_RolePermissions() {
+	s.True(auth.Observer.Allows(auth.ReadStatus))
+	s.False(auth.Observer.Allows(auth.Sign))
+
+	s.True(auth.Signer.Allows(auth.Sign))
+	s.False(auth.Signer.Allows(auth.Keygen))
+
+	s.True(auth.KeyAdmin.Allows(auth.Keygen))
+	s.True(auth.KeyAdmin.Allows(auth.Reshare))
+	s.True(auth.KeyAdmin.Allows(auth.Cancel))
+	s.False(auth.KeyAdmin.Allows(auth.Sign))
+	s.True(auth.Approver.Allows(auth.Approve))
+	s.False(auth.Approver.Allows(auth.Sign))
+	s.False(auth.Role("root").Allows(auth.ReadStatus))
+}
@@ -13,6 +13,9 @@ type WrappedMessage struct {
 	SessionID   string      `json:"message_id"`
 	Payload     []byte      `json:"payload"`
 	From        peer.ID     `json:"-"`
+	// TraceContext carries trace context of the sender session span so that spans
+	// of all participants belong to the same trace
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // Communication defines methods for communicating between peers
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 	comm2 "tss-demo/tss_util/comm"
+	"tss-demo/tss_util/topology"
 	util2 "tss-demo/tss_util/tss/util"
 	"tss-demo/tss_util/tss_config/relayer"
 
@@ -16,71 +17,147 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// bullyCoordinatorElector is used to execute bully coordinator discovery
+// bullyCoordinatorElector is used to execute bully coordinator discovery.
+// After the election it keeps coordinator lease by pinging the elected coordinator
+// and re-elects coordinator if it stops answering or leaves the session.
 type bullyCoordinatorElector struct {
-	sessionID    string
-	receiveChan  chan *comm2.WrappedMessage
-	electionChan chan *comm2.WrappedMessage
-	msgChan      chan *comm2.WrappedMessage
-	pingChan     chan *comm2.WrappedMessage
-	comm         comm2.Communication
-	hostID       peer.ID
-	conf         relayer.BullyConfig
-	mu           *sync.RWMutex
-	coordinator  peer.ID
-	sortedPeers  util2.SortablePeerSlice
+	sessionID     string
+	receiveChan   chan *comm2.WrappedMessage
+	electionChan  chan *comm2.WrappedMessage
+	msgChan       chan *comm2.WrappedMessage
+	pingChan      chan *comm2.WrappedMessage
+	leaveChan     chan peer.ID
+	changeChan    chan peer.ID
+	comm          comm2.Communication
+	hostID        peer.ID
+	conf          relayer.BullyConfig
+	mu            *sync.RWMutex
+	coordinator   peer.ID
+	peers         peer.IDSlice
+	sortedPeers   util2.SortablePeerSlice
+	topologies    topology.TopologyGetter
+	subscriptions []comm2.SubscriptionID
+	started       bool
+	done          bool
+	release       func()
 }
 
 func NewBullyCoordinatorElector(
-	sessionID string, host host.Host, config relayer.BullyConfig, communication comm2.Communication,
+	sessionID string, host host.Host, config relayer.BullyConfig, communication comm2.Communication, topologies topology.TopologyGetter,
 ) CoordinatorElector {
+	return newBullyCoordinatorElector(sessionID, host, config, communication, topologies)
+}
+
+func newBullyCoordinatorElector(
+	sessionID string, host host.Host, config relayer.BullyConfig, communication comm2.Communication, topologies topology.TopologyGetter,
+) *bullyCoordinatorElector {
 	bully := &bullyCoordinatorElector{
 		sessionID:    sessionID,
 		receiveChan:  make(chan *comm2.WrappedMessage),
 		electionChan: make(chan *comm2.WrappedMessage, 1),
 		msgChan:      make(chan *comm2.WrappedMessage),
-		pingChan:     make(chan *comm2.WrappedMessage),
+		pingChan:     make(chan *comm2.WrappedMessage, 1),
+		leaveChan:    make(chan peer.ID, 1),
+		changeChan:   make(chan peer.ID, 1),
 		comm:         communication,
 		conf:         config,
 		hostID:       host.ID(),
 		mu:           &sync.RWMutex{},
 		coordinator:  host.ID(),
+		topologies:   topologies,
+		release:      func() {},
 	}
 
 	return bully
 }
 
 // Coordinator starts coordinator discovery using bully algorithm and returns current leader
-// Bully coordination is executed on provided peers
+// Bully coordination is executed on provided peers. Peers that are not coordinator eligible
+// don't take part in the election and only wait for the elected coordinator.
+// Coordinator lease is kept until the context is cancelled.
 func (bc *bullyCoordinatorElector) Coordinator(ctx context.Context, peers peer.IDSlice) (peer.ID, error) {
 	log.Info().Str("SessionID", bc.sessionID).Msgf("Starting bully process")
 
-	ctx, cancel := context.WithCancel(ctx)
+	bc.mu.Lock()
+	if bc.started {
+		bc.mu.Unlock()
+		return bc.getCoordinator(), nil
+	}
+	bc.started = true
+	bc.peers = peers
+	bc.sortedPeers = coordinatorCandidates(peers, bc.sessionID, bc.topologies)
+	if !isCandidate(bc.sortedPeers, bc.hostID) {
+		bc.coordinator = peer.ID("")
+	}
+	bc.mu.Unlock()
+
+	bc.subscribe()
 	go bc.listen(ctx)
-	defer cancel()
 
-	bc.sortedPeers = util2.SortPeersForSession(peers, bc.sessionID)
 	errChan := make(chan error)
-	go bc.startBullyCoordination(errChan)
+	go bc.startBullyCoordination(ctx, errChan)
 
 	select {
 	case err := <-errChan:
 		return "", err
+	case <-ctx.Done():
+		return "", ctx.Err()
 	case <-time.After(bc.conf.BullyWaitTime):
 		break
 	}
 
-	return bc.getCoordinator(), nil
+	coordinator := bc.getCoordinator()
+	log.Info().Str("SessionID", bc.sessionID).Msgf("Elected coordinator %s", topology.Current(bc.topologies).PeerName(coordinator))
+
+	go bc.keepLease(ctx)
+	return coordinator, nil
+}
+
+// Changes returns coordinators re-elected after the previous coordinator stopped
+// answering pings or left the session
+func (bc *bullyCoordinatorElector) Changes() <-chan peer.ID {
+	return bc.changeChan
+}
+
+// leave notifies session peers that this host is going offline
+func (bc *bullyCoordinatorElector) leave() {
+	bc.mu.RLock()
+	if !bc.started || bc.done {
+		bc.mu.RUnlock()
+		return
+	}
+	peers := bc.peers
+	bc.mu.RUnlock()
+
+	log.Info().Str("SessionID", bc.sessionID).Msgf("Leaving bully process")
+	_ = bc.comm.Broadcast(peers, []byte{}, comm2.CoordinatorLeaveMsg, bc.sessionID)
+}
+
+func (bc *bullyCoordinatorElector) subscribe() {
+	bc.subscriptions = []comm2.SubscriptionID{
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorPingMsg, bc.msgChan),
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorElectionMsg, bc.msgChan),
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorAliveMsg, bc.msgChan),
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorPingResponseMsg, bc.msgChan),
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorSelectMsg, bc.msgChan),
+		bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorLeaveMsg, bc.msgChan),
+	}
+}
+
+func (bc *bullyCoordinatorElector) unsubscribe() {
+	for _, subscriptionID := range bc.subscriptions {
+		bc.comm.UnSubscribe(subscriptionID)
+	}
+
+	bc.mu.Lock()
+	bc.done = true
+	bc.mu.Unlock()
+	bc.release()
 }
 
 // listen starts listening for coordinator relevant messages
 func (bc *bullyCoordinatorElector) listen(ctx context.Context) {
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorPingMsg, bc.msgChan)
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorElectionMsg, bc.msgChan)
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorAliveMsg, bc.msgChan)
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorPingResponseMsg, bc.msgChan)
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorSelectMsg, bc.msgChan)
-	bc.comm.Subscribe(bc.sessionID, comm2.CoordinatorLeaveMsg, bc.msgChan)
+	defer bc.unsubscribe()
 
 	for {
 		select {
@@ -99,16 +176,31 @@ func (bc *bullyCoordinatorElector) listen(ctx context.Context) {
 						break
 					}
 				}
-			case comm2.CoordinatorSelectMsg:
-				bc.receiveChan <- msg
-			case comm2.CoordinatorElectionMsg:
-				bc.receiveChan <- msg
+			case comm2.CoordinatorSelectMsg, comm2.CoordinatorElectionMsg:
+				select {
+				case bc.receiveChan <- msg:
+				case <-ctx.Done():
+					return
+				}
 			case comm2.CoordinatorPingResponseMsg:
-				bc.pingChan <- msg
+				select {
+				case bc.pingChan <- msg:
+				default:
+				}
 			case comm2.CoordinatorPingMsg:
 				_ = bc.comm.Broadcast(
 					[]peer.ID{msg.From}, nil, comm2.CoordinatorPingResponseMsg, bc.sessionID,
 				)
+			case comm2.CoordinatorLeaveMsg:
+				log.Info().Str("SessionID", bc.sessionID).Msgf("Peer %s left bully process", topology.Current(bc.topologies).PeerName(msg.From))
+				if msg.From == bc.getCoordinator() {
+					select {
+					case bc.leaveChan <- msg.From:
+					default:
+					}
+				} else {
+					bc.removePeer(msg.From)
+				}
 			default:
 				break
 			}
@@ -117,7 +209,11 @@ func (bc *bullyCoordinatorElector) listen(ctx context.Context) {
 }
 
 func (bc *bullyCoordinatorElector) elect(errChan chan error) {
-	for _, p := range bc.sortedPeers {
+	if !bc.isCandidate(bc.hostID) {
+		return
+	}
+
+	for _, p := range bc.candidates() {
 		if bc.isPeerIDHigher(p.ID, bc.hostID) {
 			_ = bc.comm.Broadcast(peer.IDSlice{p.ID}, nil, comm2.CoordinatorElectionMsg, bc.sessionID)
 		}
@@ -128,45 +224,195 @@ func (bc *bullyCoordinatorElector) elect(errChan chan error) {
 		return
 	case <-time.After(bc.conf.ElectionWaitTime):
 		bc.setCoordinator(bc.hostID)
-		_ = bc.comm.Broadcast(bc.sortedPeers.GetPeerIDs(), []byte{}, comm2.CoordinatorSelectMsg, bc.sessionID)
+		_ = bc.comm.Broadcast(bc.getPeers(), []byte{}, comm2.CoordinatorSelectMsg, bc.sessionID)
 		return
 	}
 }
 
-func (bc *bullyCoordinatorElector) startBullyCoordination(errChan chan error) {
+func (bc *bullyCoordinatorElector) startBullyCoordination(ctx context.Context, errChan chan error) {
 	bc.elect(errChan)
-	for msg := range bc.receiveChan {
-		if msg.MessageType == comm2.CoordinatorElectionMsg && !bc.isPeerIDHigher(msg.From, bc.hostID) {
-			_ = bc.comm.Broadcast([]peer.ID{msg.From}, []byte{}, comm2.CoordinatorAliveMsg, bc.sessionID)
-			bc.elect(errChan)
-		} else if msg.MessageType == comm2.CoordinatorSelectMsg {
-			bc.setCoordinator(msg.From)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-bc.receiveChan:
+			if msg.MessageType == comm2.CoordinatorElectionMsg && !bc.isPeerIDHigher(msg.From, bc.hostID) {
+				_ = bc.comm.Broadcast([]peer.ID{msg.From}, []byte{}, comm2.CoordinatorAliveMsg, bc.sessionID)
+				bc.elect(errChan)
+			} else if msg.MessageType == comm2.CoordinatorSelectMsg {
+				bc.setCoordinator(msg.From)
+			}
 		}
 	}
 }
 
-func (bc *bullyCoordinatorElector) isPeerIDHigher(p1 peer.ID, p2 peer.ID) bool {
-	var i1, i2 int
-	for i := range bc.sortedPeers {
-		if p1 == bc.sortedPeers[i].ID {
-			i1 = i
+// keepLease pings elected coordinator on every ping interval. Coordinator that doesn't
+// answer after a retry or leaves the session is removed and new coordinator is elected.
+func (bc *bullyCoordinatorElector) keepLease(ctx context.Context) {
+	ticker := time.NewTicker(bc.conf.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case coordinator := <-bc.leaveChan:
+			bc.reelect(ctx, coordinator)
+		case <-ticker.C:
+			coordinator := bc.getCoordinator()
+			if coordinator == "" || coordinator == bc.hostID {
+				continue
+			}
+			if bc.ping(ctx, coordinator) {
+				continue
+			}
+
+			log.Warn().Str("SessionID", bc.sessionID).Msgf("Coordinator %s lease expired", topology.Current(bc.topologies).PeerName(coordinator))
+			bc.reelect(ctx, coordinator)
 		}
-		if p2 == bc.sortedPeers[i].ID {
-			i2 = i
+	}
+}
+
+// ping returns true if coordinator answered the ping within ping wait time.
+// Ping is retried once after the ping back off.
+func (bc *bullyCoordinatorElector) ping(ctx context.Context, coordinator peer.ID) bool {
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(bc.conf.PingBackOff):
+			case <-ctx.Done():
+				return true
+			}
+		}
+
+		_ = bc.comm.Broadcast(peer.IDSlice{coordinator}, nil, comm2.CoordinatorPingMsg, bc.sessionID)
+		timeout := time.After(bc.conf.PingWaitTime)
+	waitForResponse:
+		for {
+			select {
+			case msg := <-bc.pingChan:
+				if msg.From == coordinator {
+					return true
+				}
+			case <-timeout:
+				break waitForResponse
+			case <-ctx.Done():
+				return true
+			}
 		}
 	}
-	return i1 < i2
+	return false
+}
+
+// reelect removes lost coordinator and executes new election between the remaining peers
+func (bc *bullyCoordinatorElector) reelect(ctx context.Context, lost peer.ID) {
+	bc.removePeer(lost)
+	bc.mu.Lock()
+	if bc.coordinator == lost {
+		bc.coordinator = peer.ID("")
+		if isCandidate(bc.sortedPeers, bc.hostID) {
+			bc.coordinator = bc.hostID
+		}
+	}
+	bc.mu.Unlock()
+
+	bc.elect(nil)
+	select {
+	case <-time.After(bc.conf.BullyWaitTime):
+	case <-ctx.Done():
+		return
+	}
+
+	coordinator := bc.getCoordinator()
+	log.Info().Str("SessionID", bc.sessionID).Msgf("Re-elected coordinator %s", topology.Current(bc.topologies).PeerName(coordinator))
+
+	// only the latest coordinator is relevant for the listener
+	select {
+	case <-bc.changeChan:
+	default:
+	}
+	bc.changeChan <- coordinator
+}
+
+func (bc *bullyCoordinatorElector) removePeer(p peer.ID) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	peers := make(peer.IDSlice, 0)
+	for _, existing := range bc.peers {
+		if existing != p {
+			peers = append(peers, existing)
+		}
+	}
+	sortedPeers := make(util2.SortablePeerSlice, 0)
+	for _, existing := range bc.sortedPeers {
+		if existing.ID != p {
+			sortedPeers = append(sortedPeers, existing)
+		}
+	}
+	bc.peers = peers
+	bc.sortedPeers = sortedPeers
+}
+
+func (bc *bullyCoordinatorElector) isPeerIDHigher(p1 peer.ID, p2 peer.ID) bool {
+	return isPeerIDHigher(bc.candidates(), p1, p2)
 }
 
 func (bc *bullyCoordinatorElector) setCoordinator(ID peer.ID) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
-	if bc.isPeerIDHigher(ID, bc.coordinator) || ID == bc.hostID {
+	if !isCandidate(bc.sortedPeers, ID) {
+		return
+	}
+	if bc.coordinator == "" || isPeerIDHigher(bc.sortedPeers, ID, bc.coordinator) || ID == bc.hostID {
 		bc.coordinator = ID
 	}
 }
 
 func (bc *bullyCoordinatorElector) getCoordinator() peer.ID {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
 	return bc.coordinator
 }
+
+func (bc *bullyCoordinatorElector) getPeers() peer.IDSlice {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.peers
+}
+
+func (bc *bullyCoordinatorElector) candidates() util2.SortablePeerSlice {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.sortedPeers
+}
+
+func (bc *bullyCoordinatorElector) isCandidate(p peer.ID) bool {
+	return isCandidate(bc.candidates(), p)
+}
+
+func isPeerIDHigher(sortedPeers util2.SortablePeerSlice, p1 peer.ID, p2 peer.ID) bool {
+	var i1, i2 int
+	for i := range sortedPeers {
+		if p1 == sortedPeers[i].ID {
+			i1 = i
+		}
+		if p2 == sortedPeers[i].ID {
+			i2 = i
+		}
+	}
+	return i1 < i2
+}
+
+func isCandidate(sortedPeers util2.SortablePeerSlice, p peer.ID) bool {
+	for _, candidate := range sortedPeers {
+		if candidate.ID == p {
+			return true
+		}
+	}
+	return false
+}
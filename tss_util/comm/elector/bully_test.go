@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"testing"
 	"time"
+	comm2 "tss-demo/tss_util/comm"
 	elector2 "tss-demo/tss_util/comm/elector"
+	mock_comm "tss-demo/tss_util/comm/mock"
 	p2p2 "tss-demo/tss_util/comm/p2p"
 	"tss-demo/tss_util/topology"
 	"tss-demo/tss_util/tss/util"
@@ -110,7 +112,7 @@ func (s *BullyTestSuite) SetupIndividualTest(c BullyTestCase) ([]elector2.Coordi
 				PingInterval:     1 * time.Second,
 				ElectionWaitTime: 2 * time.Second,
 				BullyWaitTime:    25 * time.Second,
-			}, com)
+			}, com, nil)
 			testBullyCoordinators = append(testBullyCoordinators, b)
 		}
 	}
@@ -334,3 +336,147 @@ func (s *BullyTestSuite) TestBully_GetCoordinator_OneDelay() {
 		})
 	}
 }
+
+var leaseConfig = relayer.BullyConfig{
+	PingWaitTime:     250 * time.Millisecond,
+	PingBackOff:      250 * time.Millisecond,
+	PingInterval:     500 * time.Millisecond,
+	ElectionWaitTime: 500 * time.Millisecond,
+	BullyWaitTime:    2 * time.Second,
+}
+
+// reelectTimeout is the longest re-election with leaseConfig: the lease expires after
+// the next ping and its retry, then the election and the bully wait time pass
+var reelectTimeout = leaseConfig.PingInterval + 2*leaseConfig.PingWaitTime + leaseConfig.PingBackOff +
+	leaseConfig.ElectionWaitTime + leaseConfig.BullyWaitTime + time.Second
+
+func (s *BullyTestSuite) setupHosts(numberOfHosts int) ([]host.Host, peer.IDSlice) {
+	topology := &topology.NetworkTopology{
+		Peers: []*peer.AddrInfo{},
+	}
+	privateKeys := []crypto.PrivKey{}
+	for i := 0; i < numberOfHosts; i++ {
+		privKeyForHost, _, _ := crypto.GenerateKeyPair(crypto.ECDSA, 1)
+		privateKeys = append(privateKeys, privKeyForHost)
+		peerID, _ := peer.IDFromPrivateKey(privKeyForHost)
+		addrInfoForHost, _ := peer.AddrInfoFromString(fmt.Sprintf(
+			"/ip4/127.0.0.1/tcp/%d/p2p/%s", 4000+s.portOffset+i, peerID.Pretty(),
+		))
+		topology.Peers = append(topology.Peers, addrInfoForHost)
+	}
+
+	hosts := []host.Host{}
+	peers := peer.IDSlice{}
+	for i := 0; i < numberOfHosts; i++ {
+		connectionGate := p2p2.NewConnectionGate(topology)
+		newHost, _ := p2p2.NewHost(privateKeys[i], topology, connectionGate, uint16(4000+s.portOffset+i))
+		hosts = append(hosts, newHost)
+		peers = append(peers, newHost.ID())
+	}
+	s.portOffset += numberOfHosts
+	return hosts, peers
+}
+
+func (s *BullyTestSuite) electCoordinators(ctx context.Context, electors []elector2.CoordinatorElector, peers peer.IDSlice) {
+	resultChan := make(chan peer.ID)
+	for _, e := range electors {
+		e := e
+		go func() {
+			c, err := e.Coordinator(ctx, peers)
+			s.Nil(err)
+			resultChan <- c
+		}()
+	}
+
+	expectedCoordinator := util.SortPeersForSession(peers, s.testSessionID)[0].ID
+	for range electors {
+		s.Equal(expectedCoordinator, <-resultChan)
+	}
+}
+
+func (s *BullyTestSuite) TestBully_CoordinatorStopsAnswering_Reelected() {
+	hosts, peers := s.setupHosts(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	electors := []elector2.CoordinatorElector{}
+	for _, h := range hosts {
+		electors = append(electors, elector2.NewBullyCoordinatorElector(s.testSessionID, h, leaseConfig, p2p2.NewCommunication(h, s.testProtocolID), nil))
+	}
+	s.electCoordinators(ctx, electors, peers)
+
+	sortedPeers := util.SortPeersForSession(peers, s.testSessionID)
+	for i, h := range hosts {
+		if h.ID() == sortedPeers[0].ID {
+			_ = h.Close()
+			electors = append(electors[:i], electors[i+1:]...)
+			break
+		}
+	}
+
+	for _, e := range electors {
+		select {
+		case c := <-e.(elector2.CoordinatorWatcher).Changes():
+			s.Equal(sortedPeers[1].ID, c)
+		case <-time.After(reelectTimeout):
+			s.Fail("coordinator not re-elected")
+		}
+	}
+}
+
+func (s *BullyTestSuite) TestBully_CoordinatorLeaves_Reelected() {
+	hosts, peers := s.setupHosts(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factories := []*elector2.CoordinatorElectorFactory{}
+	electors := []elector2.CoordinatorElector{}
+	for _, h := range hosts {
+		factory := elector2.NewCoordinatorElectorFactory(h, leaseConfig, nil)
+		factories = append(factories, factory)
+		electors = append(electors, factory.CoordinatorElector(s.testSessionID, elector2.Bully))
+	}
+	s.electCoordinators(ctx, electors, peers)
+
+	sortedPeers := util.SortPeersForSession(peers, s.testSessionID)
+	for i, h := range hosts {
+		if h.ID() == sortedPeers[0].ID {
+			factories[i].Leave()
+			electors = append(electors[:i], electors[i+1:]...)
+			break
+		}
+	}
+
+	for _, e := range electors {
+		select {
+		case c := <-e.(elector2.CoordinatorWatcher).Changes():
+			s.Equal(sortedPeers[1].ID, c)
+		case <-time.After(reelectTimeout):
+			s.Fail("coordinator not re-elected after leave")
+		}
+	}
+}
+
+func (s *BullyTestSuite) TestBully_ContextCancelled_Unsubscribed() {
+	hosts, peers := s.setupHosts(1)
+	communication := mock_comm.NewMockCommunication(gomock.NewController(s.T()))
+	communication.EXPECT().Subscribe(s.testSessionID, gomock.Any(), gomock.Any()).Return(comm2.SubscriptionID("sub")).Times(6)
+	communication.EXPECT().Broadcast(gomock.Any(), gomock.Any(), gomock.Any(), s.testSessionID).Return(nil).AnyTimes()
+	unsubscribed := make(chan struct{}, 6)
+	communication.EXPECT().UnSubscribe(comm2.SubscriptionID("sub")).Do(func(comm2.SubscriptionID) {
+		unsubscribed <- struct{}{}
+	}).Times(6)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c, err := elector2.NewBullyCoordinatorElector(s.testSessionID, hosts[0], leaseConfig, communication, nil).Coordinator(ctx, peers)
+	s.Nil(err)
+	s.Equal(hosts[0].ID(), c)
+	cancel()
+
+	for i := 0; i < 6; i++ {
+		select {
+		case <-unsubscribed:
+		case <-time.After(time.Second):
+			s.Fail("subscriptions not cleaned up")
+			return
+		}
+	}
+}
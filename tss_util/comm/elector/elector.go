@@ -5,8 +5,12 @@ package elector
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"tss-demo/tss_util/comm"
 	"tss-demo/tss_util/comm/p2p"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss/util"
 	"tss-demo/tss_util/tss_config/relayer"
 
 	"github.com/libp2p/go-libp2p/core/host"
@@ -19,43 +23,127 @@ type CoordinatorElectorType int
 const (
 	Static CoordinatorElectorType = iota
 	Bully
+	RoundRobin
+	Weighted
 )
 
+// electorTypes are coordinator electors selectable in the configuration by name
+var electorTypes = map[string]CoordinatorElectorType{
+	"static":      Static,
+	"round-robin": RoundRobin,
+	"weighted":    Weighted,
+}
+
+// ParseCoordinatorElectorType returns elector type from its configuration name
+func ParseCoordinatorElectorType(name string) (CoordinatorElectorType, error) {
+	if name == "" {
+		return Static, nil
+	}
+	electorType, ok := electorTypes[name]
+	if !ok {
+		return Static, fmt.Errorf("unknown coordinator elector %s", name)
+	}
+	return electorType, nil
+}
+
 const ProtocolID protocol.ID = "/sygma/coordinator/1.0.0"
 
 type CoordinatorElector interface {
 	Coordinator(ctx context.Context, peers peer.IDSlice) (peer.ID, error)
 }
 
+// CoordinatorWatcher is implemented by electors that keep coordinator lease after the election.
+// Changes receives newly elected coordinator when the previous one stops answering or leaves.
+type CoordinatorWatcher interface {
+	Changes() <-chan peer.ID
+}
+
 // CoordinatorElectorFactory is used to create multiple instances of CoordinatorElector
 // that are using same communication stream
 type CoordinatorElectorFactory struct {
-	h      host.Host
-	comm   comm.Communication
-	config relayer.BullyConfig
+	h          host.Host
+	comm       comm.Communication
+	config     relayer.BullyConfig
+	topologies topology.TopologyGetter
+
+	mu       *sync.Mutex
+	electors map[*bullyCoordinatorElector]struct{}
 }
 
-// NewCoordinatorElectorFactory creates new CoordinatorElectorFactory
-func NewCoordinatorElectorFactory(h host.Host, config relayer.BullyConfig) *CoordinatorElectorFactory {
+// NewCoordinatorElectorFactory creates new CoordinatorElectorFactory.
+// Peer roles and weights from topology are used to choose coordinator if topology is provided.
+func NewCoordinatorElectorFactory(h host.Host, config relayer.BullyConfig, topologies topology.TopologyGetter) *CoordinatorElectorFactory {
 	communication := p2p.NewCommunication(h, ProtocolID)
 
 	return &CoordinatorElectorFactory{
-		h:      h,
-		comm:   communication,
-		config: config,
+		h:          h,
+		comm:       communication,
+		config:     config,
+		topologies: topologies,
+		mu:         &sync.Mutex{},
+		electors:   make(map[*bullyCoordinatorElector]struct{}),
 	}
 }
 
+// Topology returns topology used to choose coordinators
+func (c *CoordinatorElectorFactory) Topology() *topology.NetworkTopology {
+	return topology.Current(c.topologies)
+}
+
 // CoordinatorElector creates CoordinatorElector for a specific session
 func (c *CoordinatorElectorFactory) CoordinatorElector(
 	sessionID string, electorType CoordinatorElectorType,
 ) CoordinatorElector {
 	switch electorType {
 	case Static:
-		return NewCoordinatorElector(sessionID)
+		return NewCoordinatorElector(sessionID, c.topologies)
+	case RoundRobin:
+		return NewRoundRobinCoordinatorElector(sessionID, c.topologies)
+	case Weighted:
+		return NewWeightedCoordinatorElector(sessionID, c.topologies)
 	case Bully:
-		return NewBullyCoordinatorElector(sessionID, c.h, c.config, c.comm)
+		bully := newBullyCoordinatorElector(sessionID, c.h, c.config, c.comm, c.topologies)
+		c.mu.Lock()
+		c.electors[bully] = struct{}{}
+		c.mu.Unlock()
+		bully.release = func() {
+			c.mu.Lock()
+			delete(c.electors, bully)
+			c.mu.Unlock()
+		}
+		return bully
 	default:
 		return nil
 	}
 }
+
+// Leave notifies peers of all sessions with pending bully election that this host is going
+// offline so that they can elect a new coordinator without waiting for the lease to expire.
+func (c *CoordinatorElectorFactory) Leave() {
+	c.mu.Lock()
+	electors := make([]*bullyCoordinatorElector, 0, len(c.electors))
+	for bully := range c.electors {
+		electors = append(electors, bully)
+	}
+	c.mu.Unlock()
+
+	for _, bully := range electors {
+		bully.leave()
+	}
+}
+
+// coordinatorCandidates returns coordinator eligible peers ordered by preference for the session.
+// Peers with higher weight are preferred and peers with equal weight are ordered by session hash.
+func coordinatorCandidates(peers peer.IDSlice, sessionID string, topologies topology.TopologyGetter) util.SortablePeerSlice {
+	nt := topology.Current(topologies)
+	candidates := make(peer.IDSlice, 0)
+	for _, p := range peers {
+		if nt.PeerMetadata(p).CanCoordinate() {
+			candidates = append(candidates, p)
+		}
+	}
+
+	return util.SortPeersByWeight(util.SortPeersForSession(candidates, sessionID), func(p peer.ID) int {
+		return nt.PeerMetadata(p).Weight
+	})
+}
@@ -0,0 +1,72 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package elector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// roundRobinCoordinatorElector rotates coordinator role between coordinator eligible
+// peers. Session IDs don't carry a counter shared by the nodes, so the rotation slot
+// is derived from the hash of the session ID which every node of the session knows.
+type roundRobinCoordinatorElector struct {
+	sessionID  string
+	topologies topology.TopologyGetter
+}
+
+func NewRoundRobinCoordinatorElector(sessionID string, topologies topology.TopologyGetter) CoordinatorElector {
+	return &roundRobinCoordinatorElector{sessionID: sessionID, topologies: topologies}
+}
+
+func (r *roundRobinCoordinatorElector) Coordinator(ctx context.Context, peers peer.IDSlice) (peer.ID, error) {
+	coordinator, err := RoundRobinCoordinator(peers, r.topologies, RotationSlot(r.sessionID))
+	if err != nil {
+		return coordinator, err
+	}
+
+	log.Debug().Str("SessionID", r.sessionID).Msgf("Round robin coordinator %s", topology.Current(r.topologies).PeerName(coordinator))
+	return coordinator, nil
+}
+
+// RoundRobinCoordinator returns the coordinator eligible peer whose turn it is in the slot
+func RoundRobinCoordinator(peers peer.IDSlice, topologies topology.TopologyGetter, slot uint64) (peer.ID, error) {
+	if len(peers) == 0 {
+		return peer.ID(""), nil
+	}
+
+	candidates := eligiblePeers(peers, topologies)
+	if len(candidates) == 0 {
+		return peer.ID(""), errors.New("no coordinator eligible peers")
+	}
+	return candidates[slot%uint64(len(candidates))], nil
+}
+
+// RotationSlot returns rotation slot of the session. All nodes derive the same slot
+// from the session ID and sessions are spread evenly over the eligible peers.
+func RotationSlot(sessionID string) uint64 {
+	hash := sha256.Sum256([]byte(sessionID))
+	return binary.BigEndian.Uint64(hash[:8])
+}
+
+// eligiblePeers returns coordinator eligible peers sorted by peer ID so that
+// all nodes iterate the same order independent of the session
+func eligiblePeers(peers peer.IDSlice, topologies topology.TopologyGetter) peer.IDSlice {
+	nt := topology.Current(topologies)
+	candidates := make(peer.IDSlice, 0)
+	for _, p := range peers {
+		if nt.PeerMetadata(p).CanCoordinate() {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.Sort(candidates)
+	return candidates
+}
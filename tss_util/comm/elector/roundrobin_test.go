@@ -0,0 +1,115 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package elector_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"tss-demo/tss_util/comm/elector"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+func generatePeers(n int) peer.IDSlice {
+	peers := peer.IDSlice{}
+	for i := 0; i < n; i++ {
+		privKey, _, _ := crypto.GenerateKeyPair(crypto.ECDSA, 1)
+		peerID, _ := peer.IDFromPrivateKey(privKey)
+		peers = append(peers, peerID)
+	}
+	return peers
+}
+
+// nodePeers returns peers in the order a node with the given index would list them
+func nodePeers(peers peer.IDSlice, node int) peer.IDSlice {
+	ordered := append(peer.IDSlice{}, peers[node:]...)
+	return append(ordered, peers[:node]...)
+}
+
+type RoundRobinCoordinatorElectorTestSuite struct {
+	suite.Suite
+	peers peer.IDSlice
+}
+
+func TestRunRoundRobinCoordinatorElectorTestSuite(t *testing.T) {
+	suite.Run(t, new(RoundRobinCoordinatorElectorTestSuite))
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) SetupTest() {
+	s.peers = generatePeers(4)
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_AllNodesAgree() {
+	for i := 0; i < 20; i++ {
+		sessionID := fmt.Sprintf("sid-sign-%x", i*7919)
+		coordinators := make(map[peer.ID]bool)
+		for node := range s.peers {
+			coordinator, err := elector.NewRoundRobinCoordinatorElector(sessionID, nil).Coordinator(context.Background(), nodePeers(s.peers, node))
+			s.Nil(err)
+			coordinators[coordinator] = true
+		}
+
+		s.Len(coordinators, 1)
+	}
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_RotatesEverySlot() {
+	coordinators := peer.IDSlice{}
+	for slot := uint64(0); slot < uint64(2*len(s.peers)); slot++ {
+		coordinator, err := elector.RoundRobinCoordinator(s.peers, nil, slot)
+		s.Nil(err)
+		coordinators = append(coordinators, coordinator)
+	}
+
+	s.ElementsMatch(s.peers, coordinators[:len(s.peers)])
+	s.Equal(coordinators[:len(s.peers)], coordinators[len(s.peers):])
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_SkipsPeersThatCantCoordinate() {
+	networkTopology := &topology.NetworkTopology{
+		Metadata: map[peer.ID]topology.PeerMetadata{
+			s.peers[0]: {Role: topology.RoleSigner},
+			s.peers[1]: {Role: topology.RoleObserver},
+		},
+	}
+
+	for slot := uint64(0); slot < uint64(2*len(s.peers)); slot++ {
+		coordinator, err := elector.RoundRobinCoordinator(s.peers, networkTopology, slot)
+		s.Nil(err)
+		s.Contains(s.peers[2:], coordinator)
+	}
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_RotationSlot() {
+	s.Equal(elector.RotationSlot("sid-sign-1"), elector.RotationSlot("sid-sign-1"))
+	s.NotEqual(elector.RotationSlot("sid-sign-1"), elector.RotationSlot("sid-sign-2"))
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_SessionsRotateCoordinators() {
+	coordinators := make(map[peer.ID]bool)
+	for i := 0; i < 100; i++ {
+		coordinator, err := elector.NewRoundRobinCoordinatorElector(fmt.Sprintf("sid-sign-%d", i), nil).Coordinator(context.Background(), s.peers)
+		s.Nil(err)
+		coordinators[coordinator] = true
+	}
+
+	s.Len(coordinators, len(s.peers))
+}
+
+func (s *RoundRobinCoordinatorElectorTestSuite) Test_ParseCoordinatorElectorType() {
+	electorType, err := elector.ParseCoordinatorElectorType("round-robin")
+	s.Nil(err)
+	s.Equal(elector.RoundRobin, electorType)
+
+	electorType, err = elector.ParseCoordinatorElectorType("")
+	s.Nil(err)
+	s.Equal(elector.Static, electorType)
+
+	_, err = elector.ParseCoordinatorElectorType("bully")
+	s.NotNil(err)
+}
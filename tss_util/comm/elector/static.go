@@ -5,23 +5,33 @@ package elector
 
 import (
 	"context"
-	"tss-demo/tss_util/tss/util"
+	"errors"
+	"tss-demo/tss_util/topology"
 
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
 )
 
 type staticCoordinatorElector struct {
-	sessionID string
+	sessionID  string
+	topologies topology.TopologyGetter
 }
 
-func NewCoordinatorElector(sessionID string) CoordinatorElector {
-	return &staticCoordinatorElector{sessionID: sessionID}
+func NewCoordinatorElector(sessionID string, topologies topology.TopologyGetter) CoordinatorElector {
+	return &staticCoordinatorElector{sessionID: sessionID, topologies: topologies}
 }
 
 func (s *staticCoordinatorElector) Coordinator(ctx context.Context, peers peer.IDSlice) (peer.ID, error) {
-	sortedPeers := util.SortPeersForSession(peers, s.sessionID)
-	if len(sortedPeers) == 0 {
+	if len(peers) == 0 {
 		return peer.ID(""), nil
 	}
-	return util.SortPeersForSession(peers, s.sessionID)[0].ID, nil
+
+	sortedPeers := coordinatorCandidates(peers, s.sessionID, s.topologies)
+	if len(sortedPeers) == 0 {
+		return peer.ID(""), errors.New("no coordinator eligible peers")
+	}
+
+	coordinator := sortedPeers[0].ID
+	log.Debug().Str("SessionID", s.sessionID).Msgf("Static coordinator %s", topology.Current(s.topologies).PeerName(coordinator))
+	return coordinator, nil
 }
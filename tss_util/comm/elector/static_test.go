@@ -73,10 +73,39 @@ func (s *CoordinatorElectorTestSuite) TearDownTest() {
 }
 
 func (s *CoordinatorElectorTestSuite) TestStaticCommunicationCoordinator_GetCoordinator_Success() {
-	staticCommunicationCoordinator := elector.NewCoordinatorElector("1")
+	staticCommunicationCoordinator := elector.NewCoordinatorElector("1", nil)
 
 	coordinator1, err := staticCommunicationCoordinator.Coordinator(context.Background(), s.testPeers)
 	s.Nil(err)
 	s.NotNil(coordinator1)
 	s.Contains(s.testPeers, coordinator1)
 }
+
+func (s *CoordinatorElectorTestSuite) TestStaticCommunicationCoordinator_GetCoordinator_RespectsRolesAndWeights() {
+	networkTopology := &topology.NetworkTopology{
+		Metadata: map[peer.ID]topology.PeerMetadata{
+			s.testPeers[0]: {Name: "signer", Role: topology.RoleSigner, Weight: 10},
+			s.testPeers[1]: {Name: "preferred", Role: topology.RoleCoordinator, Weight: 5},
+			s.testPeers[2]: {Name: "regular", Role: topology.RoleCoordinator, Weight: 1},
+		},
+	}
+	staticCommunicationCoordinator := elector.NewCoordinatorElector("1", networkTopology)
+
+	coordinator, err := staticCommunicationCoordinator.Coordinator(context.Background(), s.testPeers)
+
+	s.Nil(err)
+	s.Equal(s.testPeers[1], coordinator)
+}
+
+func (s *CoordinatorElectorTestSuite) TestStaticCommunicationCoordinator_GetCoordinator_NoEligiblePeers() {
+	networkTopology := &topology.NetworkTopology{
+		Metadata: map[peer.ID]topology.PeerMetadata{
+			s.testPeers[0]: {Role: topology.RoleObserver},
+		},
+	}
+	staticCommunicationCoordinator := elector.NewCoordinatorElector("1", networkTopology)
+
+	_, err := staticCommunicationCoordinator.Coordinator(context.Background(), s.testPeers[:1])
+
+	s.NotNil(err)
+}
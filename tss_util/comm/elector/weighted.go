@@ -0,0 +1,63 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package elector
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"tss-demo/tss_util/topology"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// weightedCoordinatorElector chooses coordinator randomly with probability proportional to
+// peer topology weight. Randomness is seeded by the session ID so that every node computes
+// the same coordinator without exchanging messages.
+type weightedCoordinatorElector struct {
+	sessionID  string
+	topologies topology.TopologyGetter
+}
+
+func NewWeightedCoordinatorElector(sessionID string, topologies topology.TopologyGetter) CoordinatorElector {
+	return &weightedCoordinatorElector{sessionID: sessionID, topologies: topologies}
+}
+
+func (w *weightedCoordinatorElector) Coordinator(ctx context.Context, peers peer.IDSlice) (peer.ID, error) {
+	if len(peers) == 0 {
+		return peer.ID(""), nil
+	}
+
+	nt := topology.Current(w.topologies)
+	candidates := eligiblePeers(peers, w.topologies)
+	if len(candidates) == 0 {
+		return peer.ID(""), errors.New("no coordinator eligible peers")
+	}
+
+	var totalWeight uint64
+	for _, p := range candidates {
+		totalWeight += uint64(nt.PeerMetadata(p).Weight)
+	}
+	seed := binary.BigEndian.Uint64(crypto.Keccak256([]byte(w.sessionID)))
+	// peers with zero weight are chosen only if no other peer is eligible
+	if totalWeight == 0 {
+		return candidates[seed%uint64(len(candidates))], nil
+	}
+
+	seed %= totalWeight
+	coordinator := candidates[len(candidates)-1]
+	for _, p := range candidates {
+		weight := uint64(nt.PeerMetadata(p).Weight)
+		if seed < weight {
+			coordinator = p
+			break
+		}
+		seed -= weight
+	}
+
+	log.Debug().Str("SessionID", w.sessionID).Msgf("Weighted coordinator %s", nt.PeerName(coordinator))
+	return coordinator, nil
+}
@@ -0,0 +1,82 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package elector_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"tss-demo/tss_util/comm/elector"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type WeightedCoordinatorElectorTestSuite struct {
+	suite.Suite
+	peers    peer.IDSlice
+	topology *topology.NetworkTopology
+}
+
+func TestRunWeightedCoordinatorElectorTestSuite(t *testing.T) {
+	suite.Run(t, new(WeightedCoordinatorElectorTestSuite))
+}
+
+func (s *WeightedCoordinatorElectorTestSuite) SetupTest() {
+	s.peers = generatePeers(3)
+	s.topology = &topology.NetworkTopology{
+		Metadata: map[peer.ID]topology.PeerMetadata{
+			s.peers[0]: {Role: topology.RoleCoordinator, Weight: 8},
+			s.peers[1]: {Role: topology.RoleCoordinator, Weight: 2},
+			s.peers[2]: {Role: topology.RoleCoordinator, Weight: 0},
+		},
+	}
+}
+
+func (s *WeightedCoordinatorElectorTestSuite) Test_AllNodesAgree() {
+	for i := 0; i < 20; i++ {
+		sessionID := fmt.Sprintf("sid-sign-%d", i)
+		coordinators := make(map[peer.ID]bool)
+		for node := range s.peers {
+			coordinator, err := elector.NewWeightedCoordinatorElector(sessionID, s.topology).Coordinator(context.Background(), nodePeers(s.peers, node))
+			s.Nil(err)
+			coordinators[coordinator] = true
+		}
+
+		s.Len(coordinators, 1)
+	}
+}
+
+func (s *WeightedCoordinatorElectorTestSuite) Test_ChoosesProportionallyToWeight() {
+	elected := make(map[peer.ID]int)
+	for i := 0; i < 1000; i++ {
+		coordinator, err := elector.NewWeightedCoordinatorElector(fmt.Sprintf("session-%d", i), s.topology).Coordinator(context.Background(), s.peers)
+		s.Nil(err)
+		elected[coordinator]++
+	}
+
+	s.Zero(elected[s.peers[2]])
+	s.Greater(elected[s.peers[0]], 3*elected[s.peers[1]])
+	s.Greater(elected[s.peers[1]], 0)
+}
+
+func (s *WeightedCoordinatorElectorTestSuite) Test_OnlyZeroWeightPeers() {
+	coordinator, err := elector.NewWeightedCoordinatorElector("session", s.topology).Coordinator(context.Background(), s.peers[2:])
+
+	s.Nil(err)
+	s.Equal(s.peers[2], coordinator)
+}
+
+func (s *WeightedCoordinatorElectorTestSuite) Test_NoEligiblePeers() {
+	networkTopology := &topology.NetworkTopology{
+		Metadata: map[peer.ID]topology.PeerMetadata{
+			s.peers[0]: {Role: topology.RoleObserver},
+		},
+	}
+
+	_, err := elector.NewWeightedCoordinatorElector("session", networkTopology).Coordinator(context.Background(), s.peers[:1])
+
+	s.NotNil(err)
+}
@@ -33,6 +33,10 @@ const (
 	CoordinatorPingMsg
 	// CoordinatorPingResponseMsg message type used to respond on CoordinatorPingMsg message.
 	CoordinatorPingResponseMsg
+	// HealthPingMsg message type used to probe peer health and measure round-trip time.
+	HealthPingMsg
+	// HealthPongMsg message type used to respond on HealthPingMsg with peer protocol version and public key.
+	HealthPongMsg
 	// Unknown message type
 	Unknown
 )
@@ -66,6 +70,10 @@ func (msgType MessageType) String() string {
 		return "CoordinatorPingMsg"
 	case CoordinatorPingResponseMsg:
 		return "CoordinatorPingResponseMsg"
+	case HealthPingMsg:
+		return "HealthPingMsg"
+	case HealthPongMsg:
+		return "HealthPongMsg"
 	default:
 		return "UnknownMsg"
 	}
@@ -0,0 +1,12 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package comm
+
+// MessageMeter measures traffic exchanged through Communication
+type MessageMeter interface {
+	// TrackMessage counts message of provided size in bytes sent or received by the host
+	TrackMessage(direction Direction, msgType MessageType, size int)
+	// TrackQueueDepth sets number of messages waiting to be delivered to subscribers
+	TrackQueueDepth(msgType MessageType, depth int64)
+}
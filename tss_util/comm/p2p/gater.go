@@ -4,6 +4,8 @@
 package p2p
 
 import (
+	"sync"
+
 	"github.com/libp2p/go-libp2p/core/control"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -14,6 +16,7 @@ import (
 // ConnectionGate implements libp2p ConnectionGater to prevent inbound and
 // outbound requests to peers not specified in topology
 type ConnectionGate struct {
+	mu       sync.RWMutex
 	topology *topology.NetworkTopology
 }
 
@@ -24,15 +27,18 @@ func NewConnectionGate(topology *topology.NetworkTopology) *ConnectionGate {
 }
 
 func (cg *ConnectionGate) SetTopology(topology *topology.NetworkTopology) {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
 	cg.topology = topology
 }
 
 func (cg *ConnectionGate) InterceptPeerDial(p peer.ID) (allow bool) {
-	return cg.topology.IsAllowedPeer(p)
+	return cg.isAllowedPeer(p)
 }
 
 func (cg *ConnectionGate) InterceptSecured(nd network.Direction, p peer.ID, cm network.ConnMultiaddrs) (allow bool) {
-	return cg.topology.IsAllowedPeer(p)
+	return cg.isAllowedPeer(p)
 }
 
 func (cg *ConnectionGate) InterceptAddrDial(peer.ID, ma.Multiaddr) (allow bool) {
@@ -46,3 +52,10 @@ func (cg *ConnectionGate) InterceptAccept(network.ConnMultiaddrs) (allow bool) {
 func (cg *ConnectionGate) InterceptUpgraded(network.Conn) (allow bool, reason control.DisconnectReason) {
 	return true, 0
 }
+
+func (cg *ConnectionGate) isAllowedPeer(p peer.ID) bool {
+	cg.mu.RLock()
+	defer cg.mu.RUnlock()
+
+	return cg.topology.IsAllowedPeer(p)
+}
@@ -56,3 +56,18 @@ func LoadPeers(h host.Host, peers []*peer.AddrInfo) {
 		h.Peerstore().AddAddr(p.ID, p.Addrs[0], peerstore.PermanentAddrTTL)
 	}
 }
+
+// ApplyTopologyChange updates peerstore and connection gate with the new topology
+// and closes connections to peers removed from the topology
+func ApplyTopologyChange(h host.Host, cg *ConnectionGate, change topology.TopologyChange) {
+	cg.SetTopology(change.Current)
+	LoadPeers(h, change.Current.Peers)
+
+	for _, p := range change.Removed {
+		log.Info().Msgf("Closing connection to removed peer %s", p.Pretty())
+		err := h.Network().ClosePeer(p)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to close connection to peer %s", p.Pretty())
+		}
+	}
+}
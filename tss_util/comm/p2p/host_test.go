@@ -118,3 +118,19 @@ func (s *LoadPeersTestSuite) Test_LoadPeers_RemovesOldAndSetsNewPeers() {
 	s.Equal(peerInSlice(newP2.ID, s.host.Peerstore().Peers()), true)
 	s.Equal(len(s.host.Peerstore().Peers()), 2)
 }
+
+func (s *LoadPeersTestSuite) Test_ApplyTopologyChange_UpdatesPeersAndConnectionGate() {
+	p1, _ := peer.AddrInfoFromString("/ip4/127.0.0.1/tcp/4000/p2p/QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR")
+	p2, _ := peer.AddrInfoFromString("/ip4/127.0.0.1/tcp/4002/p2p/QmeWhpY8tknHS29gzf9TAsNEwfejTCNJ7vFpmkV6rNUgyq")
+	p3, _ := peer.AddrInfoFromString("/dns4/relayer3/tcp/9002/p2p/QmYAYuLUPNwYEBYJaKHcE7NKjUhiUV8txx2xDXHvcYa1xK")
+	previous := &topology.NetworkTopology{Peers: []*peer.AddrInfo{p1, p2}}
+	current := &topology.NetworkTopology{Peers: []*peer.AddrInfo{p1, p3}, Version: 1}
+	cg := p2p2.NewConnectionGate(previous)
+
+	p2p2.ApplyTopologyChange(s.host, cg, topology.Diff(previous, current))
+
+	s.True(peerInSlice(p3.ID, s.host.Peerstore().Peers()))
+	s.False(peerInSlice(p2.ID, s.host.Peerstore().Peers()))
+	s.True(cg.InterceptPeerDial(p3.ID))
+	s.False(cg.InterceptPeerDial(p2.ID))
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	comm2 "tss-demo/tss_util/comm"
+	"tss-demo/tss_util/tracing"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -30,9 +31,24 @@ type Libp2pCommunication struct {
 	protocolID    protocol.ID
 	logger        zerolog.Logger
 	streamManager *StreamManager
+	recorder      comm2.MessageRecorder
+	meter         comm2.MessageMeter
+	queues        *queueDepths
 }
 
 func NewCommunication(h host.Host, protocolID protocol.ID) Libp2pCommunication {
+	return NewRecordingCommunication(h, protocolID, nil)
+}
+
+// NewRecordingCommunication creates communication that passes every inbound and outbound
+// message to the provided recorder. Recording is disabled if recorder is nil.
+func NewRecordingCommunication(h host.Host, protocolID protocol.ID, recorder comm2.MessageRecorder) Libp2pCommunication {
+	return NewMeteredCommunication(h, protocolID, recorder, nil)
+}
+
+// NewMeteredCommunication creates recording communication that measures message traffic and
+// subscription queues with the provided meter. Metering is disabled if meter is nil.
+func NewMeteredCommunication(h host.Host, protocolID protocol.ID, recorder comm2.MessageRecorder, meter comm2.MessageMeter) Libp2pCommunication {
 	logger := log.With().Str("Module", "communication").Str("Peer", h.ID().Pretty()).Logger()
 	c := Libp2pCommunication{
 		SessionSubscriptionManager: NewSessionSubscriptionManager(),
@@ -40,6 +56,9 @@ func NewCommunication(h host.Host, protocolID protocol.ID) Libp2pCommunication {
 		protocolID:                 protocolID,
 		logger:                     logger,
 		streamManager:              NewStreamManager(),
+		recorder:                   recorder,
+		meter:                      meter,
+		queues:                     newQueueDepths(meter),
 	}
 
 	// start processing incoming messages
@@ -51,6 +70,9 @@ func NewCommunication(h host.Host, protocolID protocol.ID) Libp2pCommunication {
 
 func (c Libp2pCommunication) CloseSession(sessionID string) {
 	c.streamManager.ReleaseStreams(sessionID)
+	if c.recorder != nil {
+		c.recorder.CloseSession(sessionID)
+	}
 }
 
 func (c Libp2pCommunication) Broadcast(
@@ -65,6 +87,8 @@ func (c Libp2pCommunication) Broadcast(
 		SessionID:   sessionID,
 		Payload:     msg,
 		From:        hostID,
+		// session trace context is looked up as communication doesn't receive context
+		TraceContext: tracing.SessionCarrier(sessionID),
 	}
 	marshaledMsg, err := json.Marshal(wMsg)
 	if err != nil {
@@ -74,6 +98,9 @@ func (c Libp2pCommunication) Broadcast(
 	c.logger.Debug().Str("MsgType", msgType.String()).Str("SessionID", sessionID).Msg(
 		"broadcasting message",
 	)
+	if c.recorder != nil {
+		c.recorder.Record(comm2.Outbound, peers, &wMsg)
+	}
 
 	p := pool.New().WithErrors().WithFirstError()
 	for _, peerID := range peers {
@@ -156,12 +183,20 @@ func (c Libp2pCommunication) ProcessMessagesFromStream(s network.Stream) {
 			"SessionID", wrappedMsg.SessionID).Msg(
 			"processed message",
 		)
+		if c.recorder != nil {
+			c.recorder.Record(comm2.Inbound, peer.IDSlice{remotePeerID}, &wrappedMsg)
+		}
+		if c.meter != nil {
+			c.meter.TrackMessage(comm2.Inbound, wrappedMsg.MessageType, len(msgBytes))
+		}
 
 		subscribers := c.GetSubscribers(wrappedMsg.SessionID, wrappedMsg.MessageType)
 		for _, sub := range subscribers {
 			sub := sub
+			c.queues.add(wrappedMsg.MessageType, 1)
 			go func() {
 				sub <- &wrappedMsg
+				c.queues.add(wrappedMsg.MessageType, -1)
 			}()
 		}
 	}
@@ -194,6 +229,9 @@ func (c Libp2pCommunication) sendMessage(
 		c.logger.Error().Str("To", to.String()).Err(err).Msg("unable to send message")
 		return err
 	}
+	if c.meter != nil {
+		c.meter.TrackMessage(comm2.Outbound, msgType, len(msg))
+	}
 	c.logger.Trace().Str(
 		"To", to.Pretty()).Str(
 		"MsgType", msgType.String()).Str(
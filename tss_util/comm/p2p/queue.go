@@ -0,0 +1,34 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package p2p
+
+import (
+	"sync"
+	comm2 "tss-demo/tss_util/comm"
+)
+
+// queueDepths counts received messages that are not yet delivered to subscribers
+type queueDepths struct {
+	lock   sync.Mutex
+	depths map[comm2.MessageType]int64
+	meter  comm2.MessageMeter
+}
+
+func newQueueDepths(meter comm2.MessageMeter) *queueDepths {
+	return &queueDepths{
+		depths: make(map[comm2.MessageType]int64),
+		meter:  meter,
+	}
+}
+
+func (q *queueDepths) add(msgType comm2.MessageType, delta int64) {
+	if q.meter == nil {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.depths[msgType] += delta
+	q.meter.TrackQueueDepth(msgType, q.depths[msgType])
+}
@@ -0,0 +1,24 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package comm
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Direction represents if message was sent or received by the host
+type Direction string
+
+const (
+	Inbound  Direction = "inbound"
+	Outbound Direction = "outbound"
+)
+
+// MessageRecorder records messages exchanged through Communication
+type MessageRecorder interface {
+	// Record stores message sent to or received from provided peers
+	Record(direction Direction, peers peer.IDSlice, msg *WrappedMessage)
+	// CloseSession releases all resources held for the session
+	CloseSession(sessionID string)
+}
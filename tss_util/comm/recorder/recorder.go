@@ -0,0 +1,273 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recorder
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/tss/message"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	fileExtension = ".jsonl"
+	// closedSessionTimeout is how long late messages of a closed session are dropped
+	closedSessionTimeout = 10 * time.Minute
+)
+
+// Entry is a single recorded message
+type Entry struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	Direction   comm.Direction   `json:"direction"`
+	Host        peer.ID          `json:"host"`
+	Peers       peer.IDSlice     `json:"peers"`
+	MessageType comm.MessageType `json:"messageType"`
+	SessionID   string           `json:"sessionID"`
+	Payload     []byte           `json:"payload,omitempty"`
+	Encrypted   bool             `json:"encrypted,omitempty"`
+	Redacted    bool             `json:"redacted,omitempty"`
+}
+
+// FileRecorder writes every recorded message as a JSON line into
+// a separate file for each session.
+//
+// Payloads of point-to-point tss messages can contain secret shares and are
+// encrypted with the configured key, or redacted if the key is not provided.
+//
+// Messages that arrive after the session is closed are dropped for a while, unless
+// a new session with the same ID is started, and health messages are not recorded.
+type FileRecorder struct {
+	host   peer.ID
+	dir    string
+	aead   cipher.AEAD
+	mu     sync.Mutex
+	files  map[string]*os.File
+	closed map[string]time.Time
+}
+
+func NewFileRecorder(host peer.ID, dir string, encryptionKey string) (*FileRecorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var aead cipher.AEAD
+	if encryptionKey != "" {
+		var err error
+		aead, err = newAEAD([]byte(encryptionKey))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileRecorder{
+		host:   host,
+		dir:    dir,
+		aead:   aead,
+		files:  make(map[string]*os.File),
+		closed: make(map[string]time.Time),
+	}, nil
+}
+
+// Record appends message to the session recording
+func (r *FileRecorder) Record(direction comm.Direction, peers peer.IDSlice, msg *comm.WrappedMessage) {
+	if msg.MessageType == comm.HealthPingMsg || msg.MessageType == comm.HealthPongMsg {
+		return
+	}
+
+	entry := Entry{
+		Timestamp:   time.Now(),
+		Direction:   direction,
+		Host:        r.host,
+		Peers:       peers,
+		MessageType: msg.MessageType,
+		SessionID:   msg.SessionID,
+		Payload:     msg.Payload,
+	}
+	if IsSecret(msg.MessageType, msg.Payload) {
+		r.protect(&entry)
+	}
+
+	eb, err := json.Marshal(entry)
+	if err != nil {
+		log.Err(err).Str("SessionID", msg.SessionID).Msg("unable to marshal recorded message")
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if closedAt, ok := r.closed[msg.SessionID]; ok && time.Since(closedAt) < closedSessionTimeout {
+		// late messages of peers don't reopen the recording, messages sent by this
+		// node or the initiate message start the next session with the same ID
+		if direction == comm.Inbound && msg.MessageType != comm.TssInitiateMsg {
+			return
+		}
+	}
+	delete(r.closed, msg.SessionID)
+	f, err := r.file(msg.SessionID)
+	if err != nil {
+		log.Err(err).Str("SessionID", msg.SessionID).Msg("unable to open session recording")
+		return
+	}
+	_, err = f.Write(append(eb, '\n'))
+	if err != nil {
+		log.Err(err).Str("SessionID", msg.SessionID).Msg("unable to record message")
+	}
+}
+
+// CloseSession closes session recording file
+func (r *FileRecorder) CloseSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.files[sessionID]
+	if !ok {
+		return
+	}
+	_ = f.Close()
+	delete(r.files, sessionID)
+	now := time.Now()
+	for id, closedAt := range r.closed {
+		if now.Sub(closedAt) >= closedSessionTimeout {
+			delete(r.closed, id)
+		}
+	}
+	r.closed[sessionID] = now
+}
+
+// SessionPath returns path of the recording file for provided session
+func (r *FileRecorder) SessionPath(sessionID string) string {
+	return filepath.Join(r.dir, sanitize(sessionID)+fileExtension)
+}
+
+func (r *FileRecorder) file(sessionID string) (*os.File, error) {
+	f, ok := r.files[sessionID]
+	if ok {
+		return f, nil
+	}
+
+	f, err := os.OpenFile(r.SessionPath(sessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	r.files[sessionID] = f
+	return f, nil
+}
+
+func (r *FileRecorder) protect(entry *Entry) {
+	if r.aead == nil {
+		entry.Payload = nil
+		entry.Redacted = true
+		return
+	}
+
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		entry.Payload = nil
+		entry.Redacted = true
+		return
+	}
+	entry.Payload = r.aead.Seal(nonce, nonce, entry.Payload, []byte(entry.SessionID))
+	entry.Encrypted = true
+}
+
+// IsSecret returns true if message payload can contain secret material.
+// Only broadcasted tss messages are considered safe to be stored in plain text.
+func IsSecret(msgType comm.MessageType, payload []byte) bool {
+	switch msgType {
+	case comm.TssKeyGenMsg, comm.TssKeySignMsg, comm.TssReshareMsg:
+		msg, err := message.UnmarshalTssMessage(payload)
+		if err != nil {
+			return true
+		}
+		return !msg.IsBroadcast
+	default:
+		return false
+	}
+}
+
+// LoadSession reads recorded session entries from file and decrypts
+// encrypted payloads if encryption key is provided.
+func LoadSession(path string, encryptionKey string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var aead cipher.AEAD
+	if encryptionKey != "" {
+		aead, err = newAEAD([]byte(encryptionKey))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		entry := Entry{}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording entry %d: %w", len(entries), err)
+		}
+
+		if entry.Encrypted && aead != nil {
+			entry.Payload, err = open(aead, entry)
+			if err != nil {
+				return nil, err
+			}
+			entry.Encrypted = false
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+func open(aead cipher.AEAD, entry Entry) ([]byte, error) {
+	if len(entry.Payload) < aead.NonceSize() {
+		return nil, errors.New("encrypted payload too short")
+	}
+
+	nonce := entry.Payload[:aead.NonceSize()]
+	pt, err := aead.Open(nil, nonce, entry.Payload[aead.NonceSize():], []byte(entry.SessionID))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt recorded payload: %w", err)
+	}
+	return pt, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sanitize(sessionID string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, sessionID)
+}
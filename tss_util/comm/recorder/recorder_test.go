@@ -0,0 +1,206 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recorder_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/comm/recorder"
+	"tss-demo/tss_util/tss/message"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type FileRecorderTestSuite struct {
+	suite.Suite
+	dir   string
+	host  peer.ID
+	peer1 peer.ID
+}
+
+func TestRunFileRecorderTestSuite(t *testing.T) {
+	suite.Run(t, new(FileRecorderTestSuite))
+}
+
+func (s *FileRecorderTestSuite) SetupTest() {
+	s.dir, _ = os.MkdirTemp("", "recorder")
+	s.host, _ = peer.Decode("QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR")
+	s.peer1, _ = peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+}
+
+func (s *FileRecorderTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *FileRecorderTestSuite) record(r *recorder.FileRecorder) (broadcast []byte, p2p []byte) {
+	broadcast, _ = message.MarshalTssMessage([]byte("commitment"), true)
+	p2p, _ = message.MarshalTssMessage([]byte("secret share"), false)
+	r.Record(comm.Outbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.TssKeySignMsg, SessionID: "sid-sign/1", Payload: broadcast,
+	})
+	r.Record(comm.Inbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.TssKeySignMsg, SessionID: "sid-sign/1", Payload: p2p, From: s.peer1,
+	})
+	r.CloseSession("sid-sign/1")
+	return broadcast, p2p
+}
+
+func (s *FileRecorderTestSuite) Test_SecretPayloadRedactedWithoutKey() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "")
+	s.Nil(err)
+	broadcast, _ := s.record(r)
+
+	entries, err := recorder.LoadSession(r.SessionPath("sid-sign/1"), "")
+
+	s.Nil(err)
+	s.Len(entries, 2)
+	s.Equal(broadcast, entries[0].Payload)
+	s.Equal(comm.Outbound, entries[0].Direction)
+	s.True(entries[1].Redacted)
+	s.Nil(entries[1].Payload)
+}
+
+func (s *FileRecorderTestSuite) Test_SecretPayloadEncryptedWithKey() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "qwertyuiopasdfgh")
+	s.Nil(err)
+	_, p2p := s.record(r)
+
+	raw, err := recorder.LoadSession(r.SessionPath("sid-sign/1"), "")
+	s.Nil(err)
+	s.True(raw[1].Encrypted)
+	s.NotEqual(p2p, raw[1].Payload)
+
+	entries, err := recorder.LoadSession(r.SessionPath("sid-sign/1"), "qwertyuiopasdfgh")
+	s.Nil(err)
+	s.False(entries[1].Encrypted)
+	s.Equal(p2p, entries[1].Payload)
+}
+
+func (s *FileRecorderTestSuite) Test_LateMessagesOfClosedSessionDropped() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "")
+	s.Nil(err)
+	s.record(r)
+
+	r.Record(comm.Inbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.TssFailMsg, SessionID: "sid-sign/1", From: s.peer1,
+	})
+	entries, err := recorder.LoadSession(r.SessionPath("sid-sign/1"), "")
+
+	s.Nil(err)
+	s.Len(entries, 2)
+}
+
+func (s *FileRecorderTestSuite) Test_NewSessionWithSameIDRecorded() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "")
+	s.Nil(err)
+	s.record(r)
+
+	r.Record(comm.Inbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.TssInitiateMsg, SessionID: "sid-sign/1", From: s.peer1,
+	})
+	r.CloseSession("sid-sign/1")
+	entries, err := recorder.LoadSession(r.SessionPath("sid-sign/1"), "")
+
+	s.Nil(err)
+	s.Len(entries, 3)
+	s.Equal(comm.TssInitiateMsg, entries[2].MessageType)
+}
+
+func (s *FileRecorderTestSuite) Test_HealthMessagesNotRecorded() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "")
+	s.Nil(err)
+
+	r.Record(comm.Outbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.HealthPingMsg, SessionID: "healthping",
+	})
+	r.Record(comm.Inbound, peer.IDSlice{s.peer1}, &comm.WrappedMessage{
+		MessageType: comm.HealthPongMsg, SessionID: "healthpong", From: s.peer1,
+	})
+
+	_, err = os.Stat(r.SessionPath("healthping"))
+	s.True(os.IsNotExist(err))
+	_, err = os.Stat(r.SessionPath("healthpong"))
+	s.True(os.IsNotExist(err))
+}
+
+func (s *FileRecorderTestSuite) Test_InvalidKey() {
+	r, err := recorder.NewFileRecorder(s.host, s.dir, "qwertyuiopasdfgh")
+	s.Nil(err)
+	s.record(r)
+
+	_, err = recorder.LoadSession(r.SessionPath("sid-sign/1"), "invalidinvalidin")
+
+	s.NotNil(err)
+}
+
+type ReplayCommunicationTestSuite struct {
+	suite.Suite
+	host  peer.ID
+	peer1 peer.ID
+	peer2 peer.ID
+}
+
+func TestRunReplayCommunicationTestSuite(t *testing.T) {
+	suite.Run(t, new(ReplayCommunicationTestSuite))
+}
+
+func (s *ReplayCommunicationTestSuite) SetupTest() {
+	s.host, _ = peer.Decode("QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR")
+	s.peer1, _ = peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+	s.peer2, _ = peer.Decode("QmYayosTHxL2xa4jyrQ2PmbhGbrkSxsGM1kzXLTT8SsLVy")
+}
+
+func (s *ReplayCommunicationTestSuite) entries() []recorder.Entry {
+	start := time.Now()
+	return []recorder.Entry{
+		{Timestamp: start, Direction: comm.Outbound, Host: s.host, Peers: peer.IDSlice{s.peer1, s.peer2}, MessageType: comm.TssInitiateMsg, SessionID: "1"},
+		{Timestamp: start.Add(time.Second), Direction: comm.Inbound, Host: s.host, Peers: peer.IDSlice{s.peer1}, MessageType: comm.TssReadyMsg, SessionID: "1"},
+		{Timestamp: start.Add(5 * time.Second), Direction: comm.Inbound, Host: s.host, Peers: peer.IDSlice{s.peer2}, MessageType: comm.TssReadyMsg, SessionID: "1"},
+	}
+}
+
+func (s *ReplayCommunicationTestSuite) Test_DeliversPendingMessagesOnSubscribe() {
+	rc := recorder.NewReplayCommunication(s.host, s.entries(), 0)
+	err := rc.Start(context.Background())
+	s.Nil(err)
+
+	readyChn := make(chan *comm.WrappedMessage)
+	rc.Subscribe("1", comm.TssReadyMsg, readyChn)
+
+	from := []peer.ID{(<-readyChn).From, (<-readyChn).From}
+	s.ElementsMatch([]peer.ID{s.peer1, s.peer2}, from)
+}
+
+func (s *ReplayCommunicationTestSuite) Test_KeepsSentMessages() {
+	rc := recorder.NewReplayCommunication(s.host, s.entries(), 0)
+
+	_ = rc.Broadcast(peer.IDSlice{s.peer1}, []byte{}, comm.TssReadyMsg, "1")
+
+	s.Len(rc.Sent(), 1)
+	s.Equal(comm.TssReadyMsg, rc.Sent()[0].MessageType)
+}
+
+func (s *ReplayCommunicationTestSuite) Test_EncryptedRecording() {
+	entries := s.entries()
+	entries[1].Encrypted = true
+	rc := recorder.NewReplayCommunication(s.host, entries, 0)
+
+	err := rc.Start(context.Background())
+
+	s.NotNil(err)
+}
+
+func (s *ReplayCommunicationTestSuite) Test_Timeline() {
+	timeline := recorder.Timeline(s.entries())
+
+	s.Len(timeline, 2)
+	s.Equal(s.peer2, timeline[0].Peer)
+	s.Equal(5*time.Second, timeline[0].LastSeen)
+	s.Equal(1, timeline[0].Sent)
+	s.Equal(1, timeline[0].Received)
+}
@@ -0,0 +1,232 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"tss-demo/tss_util/comm"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+type subscriptionKey struct {
+	sessionID string
+	msgType   comm.MessageType
+}
+
+// ReplayCommunication implements comm.Communication by delivering recorded inbound
+// messages to local subscribers with the original timing.
+//
+// Messages received before anyone subscribed to them are held back until a subscription
+// appears so that slower local computation doesn't drop messages. Outbound messages
+// are not sent anywhere and are kept for comparison with the recording.
+type ReplayCommunication struct {
+	host    peer.ID
+	entries []Entry
+	speed   float64
+
+	lock          sync.Mutex
+	subscriptions map[subscriptionKey]map[comm.SubscriptionID]chan *comm.WrappedMessage
+	pending       map[subscriptionKey][]*comm.WrappedMessage
+	sent          []Entry
+	counter       int
+}
+
+// NewReplayCommunication creates replay communication for provided recording.
+// Speed scales delays between messages, e.g. 2 replays the session twice as fast and 0 disables delays.
+func NewReplayCommunication(host peer.ID, entries []Entry, speed float64) *ReplayCommunication {
+	return &ReplayCommunication{
+		host:          host,
+		entries:       entries,
+		speed:         speed,
+		subscriptions: make(map[subscriptionKey]map[comm.SubscriptionID]chan *comm.WrappedMessage),
+		pending:       make(map[subscriptionKey][]*comm.WrappedMessage),
+		sent:          make([]Entry, 0),
+	}
+}
+
+// Start delivers inbound recorded messages until all of them are replayed or context is cancelled.
+func (rc *ReplayCommunication) Start(ctx context.Context) error {
+	var previous time.Time
+	for _, entry := range rc.entries {
+		if entry.Direction != comm.Inbound || len(entry.Peers) == 0 {
+			continue
+		}
+		if entry.Redacted {
+			log.Warn().Str("SessionID", entry.SessionID).Msgf(
+				"skipping redacted %s message from %s", entry.MessageType, entry.Peers[0].Pretty(),
+			)
+			continue
+		}
+		if entry.Encrypted {
+			return fmt.Errorf("recording contains encrypted payloads, decryption key is required")
+		}
+
+		if !previous.IsZero() && rc.speed > 0 {
+			delay := time.Duration(float64(entry.Timestamp.Sub(previous)) / rc.speed)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		previous = entry.Timestamp
+
+		rc.deliver(&comm.WrappedMessage{
+			MessageType: entry.MessageType,
+			SessionID:   entry.SessionID,
+			Payload:     entry.Payload,
+			From:        entry.Peers[0],
+		})
+	}
+	return nil
+}
+
+// Sent returns outbound messages produced by the local process during replay
+func (rc *ReplayCommunication) Sent() []Entry {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	sent := make([]Entry, len(rc.sent))
+	copy(sent, rc.sent)
+	return sent
+}
+
+func (rc *ReplayCommunication) Broadcast(
+	peers peer.IDSlice,
+	msg []byte,
+	msgType comm.MessageType,
+	sessionID string,
+) error {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.sent = append(rc.sent, Entry{
+		Timestamp:   time.Now(),
+		Direction:   comm.Outbound,
+		Host:        rc.host,
+		Peers:       peers,
+		MessageType: msgType,
+		SessionID:   sessionID,
+		Payload:     msg,
+	})
+	return nil
+}
+
+func (rc *ReplayCommunication) Subscribe(
+	sessionID string,
+	msgType comm.MessageType,
+	channel chan *comm.WrappedMessage,
+) comm.SubscriptionID {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	key := subscriptionKey{sessionID: sessionID, msgType: msgType}
+	_, ok := rc.subscriptions[key]
+	if !ok {
+		rc.subscriptions[key] = make(map[comm.SubscriptionID]chan *comm.WrappedMessage)
+	}
+
+	rc.counter++
+	subID := comm.SubscriptionID(fmt.Sprintf("%s-%d-%d", sessionID, msgType, rc.counter))
+	rc.subscriptions[key][subID] = channel
+
+	for _, msg := range rc.pending[key] {
+		msg := msg
+		go func() { channel <- msg }()
+	}
+	delete(rc.pending, key)
+	return subID
+}
+
+func (rc *ReplayCommunication) UnSubscribe(subID comm.SubscriptionID) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	for _, subs := range rc.subscriptions {
+		delete(subs, subID)
+	}
+}
+
+func (rc *ReplayCommunication) CloseSession(sessionID string) {}
+
+func (rc *ReplayCommunication) deliver(msg *comm.WrappedMessage) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	key := subscriptionKey{sessionID: msg.SessionID, msgType: msg.MessageType}
+	subs := rc.subscriptions[key]
+	if len(subs) == 0 {
+		rc.pending[key] = append(rc.pending[key], msg)
+		return
+	}
+
+	for _, sub := range subs {
+		sub := sub
+		go func() { sub <- msg }()
+	}
+}
+
+// PeerActivity summarizes messages exchanged with a single peer during the session
+type PeerActivity struct {
+	Peer      peer.ID
+	Received  int
+	Sent      int
+	FirstSeen time.Duration
+	LastSeen  time.Duration
+	MaxGap    time.Duration
+}
+
+// Timeline summarizes recorded session per peer with offsets relative to the first
+// recorded message. Peers with large LastSeen or MaxGap values are the ones that
+// slowed down the session.
+func Timeline(entries []Entry) []PeerActivity {
+	if len(entries) == 0 {
+		return []PeerActivity{}
+	}
+
+	start := entries[0].Timestamp
+	activity := make(map[peer.ID]*PeerActivity)
+	lastReceived := make(map[peer.ID]time.Duration)
+	for _, entry := range entries {
+		offset := entry.Timestamp.Sub(start)
+		for _, p := range entry.Peers {
+			if p == entry.Host {
+				continue
+			}
+
+			a, ok := activity[p]
+			if !ok {
+				a = &PeerActivity{Peer: p, FirstSeen: offset}
+				activity[p] = a
+			}
+
+			if entry.Direction == comm.Outbound {
+				a.Sent++
+				continue
+			}
+
+			a.Received++
+			if last, ok := lastReceived[p]; ok && offset-last > a.MaxGap {
+				a.MaxGap = offset - last
+			}
+			lastReceived[p] = offset
+			a.LastSeen = offset
+		}
+	}
+
+	timeline := make([]PeerActivity, 0, len(activity))
+	for _, a := range activity {
+		timeline = append(timeline, *a)
+	}
+	sort.Slice(timeline, func(i, j int) bool {
+		return timeline[i].LastSeen > timeline[j].LastSeen
+	})
+	return timeline
+}
@@ -0,0 +1,245 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package events publishes outcomes of sessions and changes of the node to webhooks and
+// WebSocket subscribers
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"tss-demo/tss_util/tss"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type EventType string
+
+const (
+	SessionStarted       EventType = "session.started"
+	SessionCompleted     EventType = "session.completed"
+	SessionFailed        EventType = "session.failed"
+	SessionCancelled     EventType = "session.cancelled"
+	SignatureReady       EventType = "signature.ready"
+	KeyGenerated         EventType = "key.generated"
+	KeyReshared          EventType = "key.reshared"
+	TopologyChanged      EventType = "topology.changed"
+	TransactionSent      EventType = "transaction.sent"
+	TransactionConfirmed EventType = "transaction.confirmed"
+	TransactionReverted  EventType = "transaction.reverted"
+	TransactionReplaced  EventType = "transaction.replaced"
+)
+
+var eventTypes = map[EventType]bool{
+	SessionStarted:       true,
+	SessionCompleted:     true,
+	SessionFailed:        true,
+	SessionCancelled:     true,
+	SignatureReady:       true,
+	KeyGenerated:         true,
+	KeyReshared:          true,
+	TopologyChanged:      true,
+	TransactionSent:      true,
+	TransactionConfirmed: true,
+	TransactionReverted:  true,
+	TransactionReplaced:  true,
+}
+
+var sessionEventTypes = map[tss.SessionEventType]EventType{
+	tss.EventStarted:   SessionStarted,
+	tss.EventCompleted: SessionCompleted,
+	tss.EventFailed:    SessionFailed,
+	tss.EventCancelled: SessionCancelled,
+}
+
+// ParseEventTypes parses event type names, no names stand for all event types
+func ParseEventTypes(names []string) ([]EventType, error) {
+	types := make([]EventType, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !eventTypes[EventType(name)] {
+			return nil, fmt.Errorf("unknown event type %s", name)
+		}
+		types = append(types, EventType(name))
+	}
+	return types, nil
+}
+
+// Event is published by the node. ID is unique per event and is kept across webhook
+// delivery retries so that receivers can drop duplicates.
+type Event struct {
+	ID        string            `json:"id"`
+	Type      EventType         `json:"type"`
+	Time      time.Time         `json:"time"`
+	Node      string            `json:"node"`
+	SessionID string            `json:"sessionId,omitempty"`
+	Data      map[string]string `json:"data,omitempty"`
+}
+
+// Bus sends events published by the node to subscribers. It implements
+// tss.SessionObserver to publish starts and outcomes of sessions.
+type Bus struct {
+	node peer.ID
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+func NewBus(node peer.ID) *Bus {
+	return &Bus{
+		node:        node,
+		subscribers: make(map[*Subscription]bool),
+	}
+}
+
+// Publish sends the event to subscribers of its type. ID, time and node of the event
+// are set if they are empty. Subscribers that don't keep up are dropped.
+func (b *Bus) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Node == "" {
+		event.Node = b.node.Pretty()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscribers {
+		if !subscription.accepts(event.Type) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.lagged = true
+			b.unsubscribe(subscription)
+		}
+	}
+}
+
+// ObserveSession publishes start and outcome of the session, phases within the session
+// are only streamed by the session watchers
+func (b *Bus) ObserveSession(event tss.SessionEvent) {
+	eventType, ok := sessionEventTypes[event.Type]
+	if !ok {
+		return
+	}
+
+	data := map[string]string{"process": event.Process}
+	if event.Coordinator != "" {
+		data["coordinator"] = event.Coordinator.Pretty()
+	}
+	if len(event.Culprits) > 0 {
+		culprits := make([]string, len(event.Culprits))
+		for i, culprit := range event.Culprits {
+			culprits[i] = culprit.Pretty()
+		}
+		data["culprits"] = strings.Join(culprits, ",")
+	}
+	if event.Err != nil {
+		data["error"] = event.Err.Error()
+	}
+	b.Publish(Event{
+		Type:      eventType,
+		Time:      event.Time,
+		SessionID: event.SessionID,
+		Data:      data,
+	})
+}
+
+// Subscribe subscribes to events of the types, or to all events if no types are given.
+// The events channel is closed when the subscriber falls behind by more than buffer
+// events or when the bus is closed.
+func (b *Bus) Subscribe(buffer int, types ...EventType) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &Subscription{
+		bus:    b,
+		events: make(chan Event, buffer),
+		types:  make(map[EventType]bool),
+	}
+	for _, t := range types {
+		subscription.types[t] = true
+	}
+	if b.closed {
+		subscription.closed = true
+		close(subscription.events)
+		return subscription
+	}
+	b.subscribers[subscription] = true
+	return subscription
+}
+
+// Close ends all subscriptions, events published afterwards are dropped
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscribers {
+		b.unsubscribe(subscription)
+	}
+}
+
+func (b *Bus) unsubscribe(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	close(subscription.events)
+	delete(b.subscribers, subscription)
+}
+
+// Subscription streams events published on the bus
+type Subscription struct {
+	bus    *Bus
+	events chan Event
+	types  map[EventType]bool
+	// lagged and closed are guarded by the bus lock
+	lagged bool
+	closed bool
+}
+
+// Events returns the channel of published events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged returns true if the subscription was dropped because it fell behind
+func (s *Subscription) Lagged() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	return s.lagged
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.unsubscribe(s)
+}
+
+func (s *Subscription) accepts(eventType EventType) bool {
+	return len(s.types) == 0 || s.types[eventType]
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
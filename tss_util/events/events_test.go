@@ -0,0 +1,121 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/tss"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	node    = "QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR"
+	culprit = "QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54"
+)
+
+type BusTestSuite struct {
+	suite.Suite
+	bus *events.Bus
+}
+
+func TestRunBusTestSuite(t *testing.T) {
+	suite.Run(t, new(BusTestSuite))
+}
+
+func (s *BusTestSuite) SetupTest() {
+	nodeID, err := peer.Decode(node)
+	s.Nil(err)
+	s.bus = events.NewBus(nodeID)
+}
+
+func (s *BusTestSuite) Test_Publish_SetsIDTimeAndNode() {
+	subscription := s.bus.Subscribe(1)
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady, SessionID: "sid-sign-1"})
+
+	event := <-subscription.Events()
+	s.NotEmpty(event.ID)
+	s.False(event.Time.IsZero())
+	s.Equal(node, event.Node)
+	s.Equal("sid-sign-1", event.SessionID)
+}
+
+func (s *BusTestSuite) Test_Subscribe_FiltersTypes() {
+	subscription := s.bus.Subscribe(2, events.SessionFailed)
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+	s.bus.Publish(events.Event{Type: events.SessionFailed})
+
+	event := <-subscription.Events()
+	s.Equal(events.SessionFailed, event.Type)
+	s.Len(subscription.Events(), 0)
+}
+
+func (s *BusTestSuite) Test_ObserveSession_PublishesOutcomes() {
+	culpritID, err := peer.Decode(culprit)
+	s.Nil(err)
+	subscription := s.bus.Subscribe(4)
+
+	s.bus.ObserveSession(tss.SessionEvent{SessionID: "keygen", Process: "keygen", Type: tss.EventElection})
+	s.bus.ObserveSession(tss.SessionEvent{SessionID: "keygen", Process: "keygen", Type: tss.EventStarted, Time: time.Now()})
+	s.bus.ObserveSession(tss.SessionEvent{SessionID: "keygen", Process: "keygen", Type: tss.EventRound, Round: 1})
+	s.bus.ObserveSession(tss.SessionEvent{
+		SessionID: "keygen",
+		Process:   "keygen",
+		Type:      tss.EventFailed,
+		Time:      time.Now(),
+		Culprits:  []peer.ID{culpritID},
+		Err:       errors.New("invalid share"),
+	})
+
+	started := <-subscription.Events()
+	s.Equal(events.SessionStarted, started.Type)
+	failed := <-subscription.Events()
+	s.Equal(events.SessionFailed, failed.Type)
+	s.Equal("keygen", failed.SessionID)
+	s.Equal(map[string]string{"process": "keygen", "culprits": culprit, "error": "invalid share"}, failed.Data)
+	s.Len(subscription.Events(), 0)
+}
+
+func (s *BusTestSuite) Test_Publish_DropsLaggingSubscriber() {
+	lagging := s.bus.Subscribe(1)
+	subscription := s.bus.Subscribe(2)
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+
+	<-lagging.Events()
+	_, ok := <-lagging.Events()
+	s.False(ok)
+	s.True(lagging.Lagged())
+	s.Len(subscription.Events(), 2)
+	s.False(subscription.Lagged())
+}
+
+func (s *BusTestSuite) Test_Close_EndsSubscriptions() {
+	subscription := s.bus.Subscribe(1)
+
+	s.bus.Close()
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+
+	_, ok := <-subscription.Events()
+	s.False(ok)
+	s.False(subscription.Lagged())
+	_, ok = <-s.bus.Subscribe(1).Events()
+	s.False(ok)
+}
+
+func (s *BusTestSuite) Test_ParseEventTypes() {
+	types, err := events.ParseEventTypes([]string{"signature.ready", " session.failed"})
+	s.Nil(err)
+	s.Equal([]events.EventType{events.SignatureReady, events.SessionFailed}, types)
+
+	_, err = events.ParseEventTypes([]string{"signature.done"})
+	s.NotNil(err)
+}
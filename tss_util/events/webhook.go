@@ -0,0 +1,181 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	EventHeader     = "X-Tss-Event"
+	EventIDHeader   = "X-Tss-Event-Id"
+	TimestampHeader = "X-Tss-Timestamp"
+	// SignatureHeader holds "sha256=" followed by the hex encoded signature of the delivery
+	SignatureHeader = "X-Tss-Signature"
+
+	// webhookQueue is the number of events a webhook can fall behind before events are dropped
+	webhookQueue = 1024
+)
+
+// Signature returns the hex encoded HMAC-SHA256 of the delivery timestamp and payload
+// joined with a dot. Receivers should also check that the timestamp is recent.
+func Signature(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature header of the delivery
+func VerifySignature(secret string, timestamp string, payload []byte, header string) bool {
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(Signature(secret, timestamp, payload))
+	return hmac.Equal(signature, expected)
+}
+
+type webhook struct {
+	relayer.Webhook
+	types        []EventType
+	subscription *Subscription
+}
+
+// WebhookDispatcher posts events published on the bus to webhooks. Each webhook receives
+// events in the order they were published, an event is delivered before the next one is
+// sent or until its retries run out.
+type WebhookDispatcher struct {
+	bus      *Bus
+	client   *http.Client
+	config   relayer.EventsConfig
+	webhooks []*webhook
+}
+
+// NewWebhookDispatcher subscribes webhooks to the bus, events published from now on are
+// delivered once the dispatcher is started
+func NewWebhookDispatcher(bus *Bus, client *http.Client, config relayer.EventsConfig) (*WebhookDispatcher, error) {
+	webhooks := make([]*webhook, len(config.Webhooks))
+	for i, w := range config.Webhooks {
+		types, err := ParseEventTypes(w.Events)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", w.URL, err)
+		}
+		webhooks[i] = &webhook{
+			Webhook:      w,
+			types:        types,
+			subscription: bus.Subscribe(webhookQueue, types...),
+		}
+	}
+
+	return &WebhookDispatcher{
+		bus:      bus,
+		client:   client,
+		config:   config,
+		webhooks: webhooks,
+	}, nil
+}
+
+// Start delivers events until the context is cancelled or the bus is closed
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, w := range d.webhooks {
+		wg.Add(1)
+		go func(w *webhook) {
+			defer wg.Done()
+			d.run(ctx, w)
+		}(w)
+	}
+	wg.Wait()
+}
+
+func (d *WebhookDispatcher) run(ctx context.Context, w *webhook) {
+	for {
+		select {
+		case event, ok := <-w.subscription.Events():
+			{
+				if !ok {
+					if !w.subscription.Lagged() {
+						return
+					}
+					log.Warn().Str("url", w.URL).Msg("Webhook fell behind, events were dropped")
+					w.subscription = d.bus.Subscribe(webhookQueue, w.types...)
+					continue
+				}
+
+				err := d.deliver(ctx, w, event)
+				if err != nil {
+					log.Error().Err(err).Str("url", w.URL).Str("event", event.ID).Msgf("Failed delivering %s event to webhook", event.Type)
+				}
+			}
+		case <-ctx.Done():
+			{
+				w.subscription.Close()
+				return
+			}
+		}
+	}
+}
+
+// deliver posts the event and retries with exponential backoff until the webhook accepts
+// it. Requests refused by the webhook with a client error are not retried.
+func (d *WebhookDispatcher) deliver(ctx context.Context, w *webhook, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = d.config.RetryInterval
+	b.MaxElapsedTime = d.config.MaxElapsedTime
+	return backoff.Retry(func() error {
+		return d.post(ctx, w, event, payload)
+	}, backoff.WithContext(backoff.WithMaxRetries(b, d.config.MaxRetries), ctx))
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, w *webhook, event Event, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Signature(w.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return backoff.Permanent(fmt.Errorf("webhook refused event with status %d", resp.StatusCode))
+	}
+}
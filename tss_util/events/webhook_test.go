@@ -0,0 +1,175 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+const secret = "webhook-secret"
+
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+type WebhookTestSuite struct {
+	suite.Suite
+	bus      *events.Bus
+	server   *httptest.Server
+	statuses []int
+
+	mu         sync.Mutex
+	deliveries []delivery
+	attempts   int
+}
+
+func TestRunWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
+
+func (s *WebhookTestSuite) SetupTest() {
+	nodeID, err := peer.Decode(node)
+	s.Nil(err)
+	s.bus = events.NewBus(nodeID)
+	s.statuses = nil
+	s.deliveries = nil
+	s.attempts = 0
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		status := http.StatusOK
+		if s.attempts < len(s.statuses) {
+			status = s.statuses[s.attempts]
+		}
+		s.attempts++
+		if status == http.StatusOK {
+			s.deliveries = append(s.deliveries, delivery{header: r.Header.Clone(), body: body})
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func (s *WebhookTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+// start delivers events to the test webhook until the returned function is called
+func (s *WebhookTestSuite) start(eventTypes ...string) func() {
+	dispatcher, err := events.NewWebhookDispatcher(s.bus, s.server.Client(), relayer.EventsConfig{
+		Webhooks:       []relayer.Webhook{{URL: s.server.URL, Secret: secret, Events: eventTypes}},
+		MaxRetries:     3,
+		RetryInterval:  time.Millisecond,
+		MaxElapsedTime: time.Second,
+	})
+	s.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Start(ctx)
+		close(stopped)
+	}()
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+func (s *WebhookTestSuite) waitForAttempts(attempts int) {
+	s.Eventually(func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.attempts >= attempts
+	}, time.Second, time.Millisecond)
+}
+
+func (s *WebhookTestSuite) Test_DeliversSignedEvent() {
+	stop := s.start()
+	defer stop()
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady, SessionID: "sid-sign-1", Data: map[string]string{"signature": "abcd"}})
+	s.waitForAttempts(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Len(s.deliveries, 1)
+	d := s.deliveries[0]
+	s.Equal(string(events.SignatureReady), d.header.Get(events.EventHeader))
+	s.True(events.VerifySignature(secret, d.header.Get(events.TimestampHeader), d.body, d.header.Get(events.SignatureHeader)))
+	s.False(events.VerifySignature("other-secret", d.header.Get(events.TimestampHeader), d.body, d.header.Get(events.SignatureHeader)))
+
+	event := events.Event{}
+	s.Nil(json.Unmarshal(d.body, &event))
+	s.Equal(d.header.Get(events.EventIDHeader), event.ID)
+	s.Equal("abcd", event.Data["signature"])
+}
+
+func (s *WebhookTestSuite) Test_RetriesFailedDelivery() {
+	s.statuses = []int{http.StatusInternalServerError, http.StatusTooManyRequests}
+	stop := s.start()
+	defer stop()
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+	s.waitForAttempts(3)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Equal(3, s.attempts)
+	s.Len(s.deliveries, 1)
+}
+
+func (s *WebhookTestSuite) Test_RefusedEventIsNotRetried() {
+	s.statuses = []int{http.StatusBadRequest}
+	stop := s.start()
+	defer stop()
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady, SessionID: "sid-sign-1"})
+	s.bus.Publish(events.Event{Type: events.SignatureReady, SessionID: "sid-sign-2"})
+	s.waitForAttempts(2)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Equal(2, s.attempts)
+	s.Len(s.deliveries, 1)
+	event := events.Event{}
+	s.Nil(json.Unmarshal(s.deliveries[0].body, &event))
+	s.Equal("sid-sign-2", event.SessionID)
+}
+
+func (s *WebhookTestSuite) Test_DeliversSubscribedTypes() {
+	stop := s.start(string(events.SessionFailed))
+	defer stop()
+
+	s.bus.Publish(events.Event{Type: events.SignatureReady})
+	s.bus.Publish(events.Event{Type: events.SessionFailed})
+	s.waitForAttempts(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Len(s.deliveries, 1)
+	s.Equal(string(events.SessionFailed), s.deliveries[0].header.Get(events.EventHeader))
+}
+
+func (s *WebhookTestSuite) Test_UnknownEventType() {
+	_, err := events.NewWebhookDispatcher(s.bus, http.DefaultClient, relayer.EventsConfig{
+		Webhooks: []relayer.Webhook{{URL: s.server.URL, Secret: secret, Events: []string{"signature.done"}}},
+	})
+
+	s.NotNil(err)
+}
@@ -0,0 +1,262 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ReplacementFeeBump is the percentage by which fees of a replacement transaction exceed
+// fees of the replaced transaction, the minimum accepted by geth transaction pools
+const ReplacementFeeBump = 10
+
+// TxRequest describes the transaction to build. Gas is estimated, fees are suggested by
+// the chain and the nonce is the pending nonce of the sender if they are not set.
+type TxRequest struct {
+	To         *common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	Gas        uint64
+	GasPrice   *big.Int
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Nonce      *uint64
+}
+
+// Builder builds unsigned transactions of the configured type for the chain
+type Builder struct {
+	backend     Backend
+	chainID     *big.Int
+	txType      uint8
+	maxGasPrice *big.Int
+}
+
+// NewBuilder creates a builder of transactions of the type. Transactions paying more
+// than maxGasPrice per gas are refused, nil maxGasPrice doesn't cap fees.
+func NewBuilder(backend Backend, chainID *big.Int, txType uint8, maxGasPrice *big.Int) (*Builder, error) {
+	if _, err := NewSigner(txType, chainID); err != nil {
+		return nil, err
+	}
+	return &Builder{
+		backend:     backend,
+		chainID:     chainID,
+		txType:      txType,
+		maxGasPrice: maxGasPrice,
+	}, nil
+}
+
+// ChainID returns the ID of the chain transactions are built for
+func (b *Builder) ChainID() *big.Int {
+	return b.chainID
+}
+
+// Build builds the unsigned transaction sent from the address
+func (b *Builder) Build(ctx context.Context, from common.Address, req TxRequest) (*types.Transaction, error) {
+	if req.AccessList != nil && b.txType == types.LegacyTxType {
+		return nil, fmt.Errorf("%w: legacy transactions have no access list", ErrUnsupportedTxType)
+	}
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	var nonce uint64
+	if req.Nonce != nil {
+		nonce = *req.Nonce
+	} else {
+		var err error
+		nonce, err = b.backend.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching nonce: %w", err)
+		}
+	}
+
+	gas := req.Gas
+	if gas == 0 {
+		var err error
+		gas, err = b.backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:       from,
+			To:         req.To,
+			Value:      value,
+			Data:       req.Data,
+			AccessList: req.AccessList,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed estimating gas: %w", err)
+		}
+	}
+
+	switch b.txType {
+	case types.DynamicFeeTxType:
+		{
+			tipCap, feeCap, err := b.dynamicFees(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			err = b.checkGasPrice(feeCap)
+			if err != nil {
+				return nil, err
+			}
+			return types.NewTx(&types.DynamicFeeTx{
+				ChainID:    b.chainID,
+				Nonce:      nonce,
+				GasTipCap:  tipCap,
+				GasFeeCap:  feeCap,
+				Gas:        gas,
+				To:         req.To,
+				Value:      value,
+				Data:       req.Data,
+				AccessList: req.AccessList,
+			}), nil
+		}
+	case types.AccessListTxType:
+		{
+			gasPrice, err := b.gasPrice(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			err = b.checkGasPrice(gasPrice)
+			if err != nil {
+				return nil, err
+			}
+			return types.NewTx(&types.AccessListTx{
+				ChainID:    b.chainID,
+				Nonce:      nonce,
+				GasPrice:   gasPrice,
+				Gas:        gas,
+				To:         req.To,
+				Value:      value,
+				Data:       req.Data,
+				AccessList: req.AccessList,
+			}), nil
+		}
+	default:
+		{
+			gasPrice, err := b.gasPrice(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			err = b.checkGasPrice(gasPrice)
+			if err != nil {
+				return nil, err
+			}
+			return types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: gasPrice,
+				Gas:      gas,
+				To:       req.To,
+				Value:    value,
+				Data:     req.Data,
+			}), nil
+		}
+	}
+}
+
+func (b *Builder) gasPrice(ctx context.Context, req TxRequest) (*big.Int, error) {
+	gasPrice := req.GasPrice
+	if gasPrice == nil {
+		var err error
+		gasPrice, err = b.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching gas price: %w", err)
+		}
+	}
+	return gasPrice, nil
+}
+
+// dynamicFees returns the tip and the fee cap. Suggested fee cap covers the base fee
+// doubling so that the transaction stays includable for several full blocks.
+func (b *Builder) dynamicFees(ctx context.Context, req TxRequest) (*big.Int, *big.Int, error) {
+	tipCap := req.GasTipCap
+	if tipCap == nil {
+		var err error
+		tipCap, err = b.backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed fetching gas tip cap: %w", err)
+		}
+	}
+	feeCap := req.GasFeeCap
+	if feeCap == nil {
+		head, err := b.backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed fetching latest header: %w", err)
+		}
+		if head.BaseFee == nil {
+			return nil, nil, errors.New("chain doesn't support dynamic fee transactions")
+		}
+		feeCap = new(big.Int).Add(tipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+	if feeCap.Cmp(tipCap) < 0 {
+		return nil, nil, fmt.Errorf("gas fee cap %s is lower than gas tip cap %s", feeCap, tipCap)
+	}
+	return tipCap, feeCap, nil
+}
+
+// Replacement builds the transaction replacing the pending transaction with the same
+// nonce. Fees are bumped by ReplacementFeeBump percent, as nodes require to accept the
+// replacement, or raised to the suggested fees if they are higher. Cancelling replaces
+// the transaction with an empty transfer to the sender.
+func (b *Builder) Replacement(ctx context.Context, from common.Address, previous *types.Transaction, cancel bool) (*types.Transaction, error) {
+	nonce := previous.Nonce()
+	req := TxRequest{
+		To:         previous.To(),
+		Value:      previous.Value(),
+		Data:       previous.Data(),
+		AccessList: previous.AccessList(),
+		Gas:        previous.Gas(),
+		Nonce:      &nonce,
+	}
+	if cancel {
+		req = TxRequest{To: &from, Gas: params.TxGas, Nonce: &nonce}
+	}
+	if b.txType == types.LegacyTxType {
+		req.AccessList = nil
+	}
+
+	if b.txType == types.DynamicFeeTxType {
+		tipCap, feeCap, err := b.dynamicFees(ctx, TxRequest{})
+		if err != nil {
+			return nil, err
+		}
+		req.GasTipCap = maxBig(tipCap, bumpFee(previous.GasTipCap()))
+		req.GasFeeCap = maxBig(feeCap, bumpFee(previous.GasFeeCap()))
+	} else {
+		gasPrice, err := b.gasPrice(ctx, TxRequest{})
+		if err != nil {
+			return nil, err
+		}
+		req.GasPrice = maxBig(gasPrice, bumpFee(previous.GasPrice()))
+	}
+	return b.Build(ctx, from, req)
+}
+
+// bumpFee raises the fee by ReplacementFeeBump percent rounded up
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+ReplacementFeeBump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func (b *Builder) checkGasPrice(gasPrice *big.Int) error {
+	if b.maxGasPrice != nil && gasPrice.Cmp(b.maxGasPrice) > 0 {
+		return fmt.Errorf("%w: %s above %s", ErrGasPriceTooHigh, gasPrice, b.maxGasPrice)
+	}
+	return nil
+}
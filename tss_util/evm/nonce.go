@@ -0,0 +1,374 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNonceNotPending = errors.New("no pending transaction with the nonce")
+	ErrNonceConfirmed  = errors.New("nonce is already confirmed")
+)
+
+// NonceManager hands out nonces of the MPC address on one chain. Nonces stay in the
+// ledger until their transaction is confirmed. Nonces that are not used by a sent
+// transaction within the reservation timeout are handed out again, so that later
+// transactions don't wait behind a gap.
+type NonceManager struct {
+	backend            Backend
+	chainID            *big.Int
+	store              *NonceStore
+	reservationTimeout time.Duration
+
+	lock sync.Mutex
+}
+
+func NewNonceManager(backend Backend, chainID *big.Int, store *NonceStore, reservationTimeout time.Duration) *NonceManager {
+	return &NonceManager{
+		backend:            backend,
+		chainID:            chainID,
+		store:              store,
+		reservationTimeout: reservationTimeout,
+	}
+}
+
+// Reserve hands out the lowest released nonce of the address or the next nonce. Nonces
+// used by transactions sent around the manager are skipped.
+func (m *NonceManager) Reserve(ctx context.Context, address common.Address) (uint64, error) {
+	// chain is queried before locking so that reservations don't wait for each other's
+	// calls, a stale nonce only moves Next less far
+	chainNonce, err := m.backend.PendingNonceAt(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("failed fetching nonce: %w", err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return 0, err
+	}
+	ledger.advance(chainNonce)
+
+	var nonce uint64
+	if len(ledger.Released) > 0 {
+		nonce = ledger.Released[0]
+		ledger.Released = ledger.Released[1:]
+	} else {
+		nonce = ledger.Next
+		ledger.Next++
+	}
+	ledger.setPending(PendingTx{Nonce: nonce, Updated: time.Now()})
+	return nonce, m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// Release hands out the nonce again if no transaction with the nonce was broadcast
+func (m *NonceManager) Release(address common.Address, nonce uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return err
+	}
+	pending := ledger.pending(nonce)
+	if pending == nil || len(pending.Signed) > 0 {
+		return nil
+	}
+	ledger.removePending(nonce)
+	ledger.release(nonce)
+	return m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// Submit records the unsigned transaction sent from the address. Transactions with the
+// nonce of a broadcast transaction replace it. Nonces skipped by the transaction are
+// reserved, as they are handed out by another node.
+func (m *NonceManager) Submit(address common.Address, tx *types.Transaction) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return err
+	}
+	nonce := tx.Nonce()
+	if nonce < ledger.Confirmed {
+		return fmt.Errorf("%w: %d", ErrNonceConfirmed, nonce)
+	}
+	encodedTx, err := EncodeTx(tx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for ; ledger.Next < nonce; ledger.Next++ {
+		ledger.setPending(PendingTx{Nonce: ledger.Next, Updated: now})
+	}
+	if ledger.Next == nonce {
+		ledger.Next++
+	}
+	ledger.unrelease(nonce)
+
+	pending := PendingTx{Nonce: nonce}
+	if existing := ledger.pending(nonce); existing != nil {
+		pending = *existing
+	}
+	pending.Tx = encodedTx
+	pending.Updated = now
+	ledger.setPending(pending)
+	return m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// Reject forgets the signed transaction rejected by the chain. Nonce is handed out again
+// unless an earlier version was broadcast.
+func (m *NonceManager) Reject(address common.Address, signedTx *types.Transaction) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return err
+	}
+	encodedTx, err := EncodeTx(signedTx)
+	if err != nil {
+		return err
+	}
+	pending := ledger.pending(signedTx.Nonce())
+	if pending == nil {
+		return nil
+	}
+	signed := make([]string, 0, len(pending.Signed))
+	for _, s := range pending.Signed {
+		if s != encodedTx {
+			signed = append(signed, s)
+		}
+	}
+	pending.Signed = signed
+	if len(signed) == 0 {
+		ledger.removePending(signedTx.Nonce())
+		ledger.release(signedTx.Nonce())
+	}
+	return m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// Broadcast records the signed transaction broadcast by this node. It is recorded before
+// the broadcast, so that its nonce is kept if the node stops before the chain answers.
+func (m *NonceManager) Broadcast(address common.Address, signedTx *types.Transaction) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return err
+	}
+	encodedTx, err := EncodeTx(signedTx)
+	if err != nil {
+		return err
+	}
+	pending := PendingTx{Nonce: signedTx.Nonce()}
+	if existing := ledger.pending(signedTx.Nonce()); existing != nil {
+		pending = *existing
+	}
+	pending.Signed = append(pending.Signed, encodedTx)
+	pending.Updated = time.Now()
+	ledger.setPending(pending)
+	return m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// Ledger returns the ledger of the address
+func (m *NonceManager) Ledger(address common.Address) (*Ledger, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.store.Ledger(m.chainID, address)
+}
+
+// Pending returns the last version of the pending transaction with the nonce, the signed
+// version if this node broadcast it
+func (m *NonceManager) Pending(address common.Address, nonce uint64) (*types.Transaction, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return nil, err
+	}
+	pending := ledger.pending(nonce)
+	switch {
+	case pending == nil || (pending.Tx == "" && len(pending.Signed) == 0):
+		return nil, fmt.Errorf("%w %d", ErrNonceNotPending, nonce)
+	case len(pending.Signed) > 0:
+		return DecodeTx(pending.Signed[len(pending.Signed)-1])
+	default:
+		return DecodeTx(pending.Tx)
+	}
+}
+
+// Sync updates the ledger of the address from the chain. Transactions included in blocks
+// are confirmed and reservations that timed out before a transaction was sent with them
+// are released. Sent transactions stay pending until they are confirmed or replaced, as
+// they could be broadcast by another node. Nonces abandoned below the highest transaction
+// broadcast by this node block it, so they are reserved again and returned as gaps to be
+// filled instead of released. Returns versions of pending transactions broadcast by this
+// node and the gaps.
+func (m *NonceManager) Sync(ctx context.Context, address common.Address) ([]*types.Transaction, []uint64, error) {
+	confirmed, err := m.backend.NonceAt(ctx, address, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed fetching nonce: %w", err)
+	}
+	chainNonce, err := m.backend.PendingNonceAt(ctx, address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed fetching nonce: %w", err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ledger, err := m.store.Ledger(m.chainID, address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if confirmed > ledger.Confirmed {
+		ledger.Confirmed = confirmed
+	}
+	ledger.advance(chainNonce)
+
+	// nonces below the highest broadcast transaction are gaps, nothing is a gap without one
+	var highestBroadcast uint64
+	for _, p := range ledger.Pending {
+		if len(p.Signed) > 0 && p.Nonce >= ledger.Confirmed && p.Nonce > highestBroadcast {
+			highestBroadcast = p.Nonce
+		}
+	}
+	isGap := func(nonce uint64) bool {
+		return nonce < highestBroadcast
+	}
+
+	now := time.Now()
+	pending := make([]PendingTx, 0, len(ledger.Pending))
+	released := []uint64{}
+	gaps := []uint64{}
+	signed := []*types.Transaction{}
+	for _, p := range ledger.Pending {
+		if p.Nonce < ledger.Confirmed {
+			continue
+		}
+		abandoned := len(p.Signed) == 0 && p.Nonce >= chainNonce && now.Sub(p.Updated) > m.reservationTimeout
+		switch {
+		case abandoned && isGap(p.Nonce):
+			p.Updated = now
+			gaps = append(gaps, p.Nonce)
+		case abandoned && p.Tx == "":
+			released = append(released, p.Nonce)
+			continue
+		}
+		pending = append(pending, p)
+		for _, encodedTx := range p.Signed {
+			tx, err := DecodeTx(encodedTx)
+			if err != nil {
+				return nil, nil, err
+			}
+			signed = append(signed, tx)
+		}
+	}
+	ledger.Pending = pending
+	for _, nonce := range ledger.Released {
+		if isGap(nonce) {
+			gaps = append(gaps, nonce)
+		}
+	}
+	for _, nonce := range gaps {
+		ledger.unrelease(nonce)
+		if ledger.pending(nonce) == nil {
+			ledger.setPending(PendingTx{Nonce: nonce, Updated: now})
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	// highest nonces are released first so that Next moves back over all of them
+	for i := len(released) - 1; i >= 0; i-- {
+		log.Warn().Str("chainId", m.chainID.String()).Msgf("Releasing nonce %d reserved without sent transaction", released[i])
+		ledger.release(released[i])
+	}
+	return signed, gaps, m.store.StoreLedger(m.chainID, address, ledger)
+}
+
+// advance skips nonces below the pending nonce of the chain, they are used by
+// transactions in blocks or in the transaction pool
+func (l *Ledger) advance(chainNonce uint64) {
+	if l.Next < chainNonce {
+		l.Next = chainNonce
+	}
+	released := make([]uint64, 0, len(l.Released))
+	for _, nonce := range l.Released {
+		if nonce >= chainNonce {
+			released = append(released, nonce)
+		}
+	}
+	l.Released = released
+}
+
+func (l *Ledger) pending(nonce uint64) *PendingTx {
+	for i := range l.Pending {
+		if l.Pending[i].Nonce == nonce {
+			return &l.Pending[i]
+		}
+	}
+	return nil
+}
+
+func (l *Ledger) setPending(pending PendingTx) {
+	if existing := l.pending(pending.Nonce); existing != nil {
+		*existing = pending
+		return
+	}
+	l.Pending = append(l.Pending, pending)
+	sort.Slice(l.Pending, func(i, j int) bool { return l.Pending[i].Nonce < l.Pending[j].Nonce })
+}
+
+func (l *Ledger) removePending(nonce uint64) {
+	pending := make([]PendingTx, 0, len(l.Pending))
+	for _, p := range l.Pending {
+		if p.Nonce != nonce {
+			pending = append(pending, p)
+		}
+	}
+	l.Pending = pending
+}
+
+// release hands out the nonce again. Released nonces at the end of the sequence move
+// Next back instead.
+func (l *Ledger) release(nonce uint64) {
+	if nonce+1 != l.Next {
+		l.unrelease(nonce)
+		l.Released = append(l.Released, nonce)
+		sort.Slice(l.Released, func(i, j int) bool { return l.Released[i] < l.Released[j] })
+		return
+	}
+	l.Next--
+	for len(l.Released) > 0 && l.Released[len(l.Released)-1]+1 == l.Next {
+		l.Released = l.Released[:len(l.Released)-1]
+		l.Next--
+	}
+}
+
+func (l *Ledger) unrelease(nonce uint64) {
+	released := make([]uint64, 0, len(l.Released))
+	for _, n := range l.Released {
+		if n != nonce {
+			released = append(released, n)
+		}
+	}
+	l.Released = released
+}
@@ -0,0 +1,81 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sygmaprotocol/sygma-core/store"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const ledgerKey = "evm:nonces:%s:%s"
+
+// Ledger is the local record of nonces of an address on a chain. Next is the nonce after
+// the highest nonce handed out and Confirmed is the nonce of the first transaction not yet
+// included in a block. Released nonces were handed out without being used and are handed
+// out again before Next.
+type Ledger struct {
+	Next      uint64      `json:"next"`
+	Confirmed uint64      `json:"confirmed"`
+	Pending   []PendingTx `json:"pending"`
+	Released  []uint64    `json:"released"`
+}
+
+// PendingTx is a nonce handed out and not yet confirmed. Tx is the last unsigned
+// transaction sent with the nonce and is empty while the nonce is only reserved. Signed
+// are hex encoded versions of the transaction broadcast by this node, each replacing the
+// previous one.
+type PendingTx struct {
+	Nonce   uint64    `json:"nonce"`
+	Tx      string    `json:"tx,omitempty"`
+	Signed  []string  `json:"signed,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// NonceStore persists nonce ledgers so that nonces handed out survive restarts
+type NonceStore struct {
+	db store.KeyValueReaderWriter
+}
+
+func NewNonceStore(db store.KeyValueReaderWriter) *NonceStore {
+	return &NonceStore{
+		db: db,
+	}
+}
+
+// Ledger returns the ledger of the address on the chain, an empty ledger if there is none
+func (s *NonceStore) Ledger(chainID *big.Int, address common.Address) (*Ledger, error) {
+	ledger := &Ledger{}
+	data, err := s.db.GetByKey([]byte(fmt.Sprintf(ledgerKey, chainID, address.Hex())))
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, ledger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ledger.Pending == nil {
+		ledger.Pending = []PendingTx{}
+	}
+	if ledger.Released == nil {
+		ledger.Released = []uint64{}
+	}
+	return ledger, nil
+}
+
+func (s *NonceStore) StoreLedger(chainID *big.Int, address common.Address, ledger *Ledger) error {
+	data, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	return s.db.SetByKey([]byte(fmt.Sprintf(ledgerKey, chainID, address.Hex())), data)
+}
@@ -0,0 +1,335 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"tss-demo/tss_util/evm"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+)
+
+type NonceManagerTestSuite struct {
+	suite.Suite
+	key     *ecdsa.PrivateKey
+	address common.Address
+	backend *backends.SimulatedBackend
+	db      *lvldb.LVLDB
+	store   *evm.NonceStore
+	nonces  *evm.NonceManager
+}
+
+func TestRunNonceManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(NonceManagerTestSuite))
+}
+
+func (s *NonceManagerTestSuite) SetupTest() {
+	var err error
+	s.key, err = ethcrypto.GenerateKey()
+	s.Nil(err)
+	s.address = ethcrypto.PubkeyToAddress(s.key.PublicKey)
+	s.backend = backends.NewSimulatedBackend(core.GenesisAlloc{
+		s.address: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)},
+	}, 10_000_000)
+	s.db, err = lvldb.NewLvlDB(s.T().TempDir())
+	s.Nil(err)
+	s.store = evm.NewNonceStore(s.db)
+	s.nonces = evm.NewNonceManager(s.backend, simulatedChainID, s.store, time.Hour)
+}
+
+func (s *NonceManagerTestSuite) TearDownTest() {
+	_ = s.backend.Close()
+	_ = s.db.Close()
+}
+
+func (s *NonceManagerTestSuite) tx(nonce uint64) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(params.GWei),
+		Gas:      params.TxGas,
+		To:       &s.address,
+	})
+}
+
+// send signs and broadcasts the transaction around the nonce manager
+func (s *NonceManagerTestSuite) send(tx *types.Transaction) *types.Transaction {
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(simulatedChainID), s.key)
+	s.Nil(err)
+	s.Nil(s.backend.SendTransaction(context.Background(), signedTx))
+	return signedTx
+}
+
+func (s *NonceManagerTestSuite) Test_Reserve_ConcurrentReservationsAreUnique() {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	reserved := []uint64{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := s.nonces.Reserve(context.Background(), s.address)
+			s.Nil(err)
+			lock.Lock()
+			reserved = append(reserved, nonce)
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(reserved, func(i, j int) bool { return reserved[i] < reserved[j] })
+	for i, nonce := range reserved {
+		s.Equal(uint64(i), nonce)
+	}
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Equal(uint64(20), ledger.Next)
+	s.Len(ledger.Pending, 20)
+}
+
+func (s *NonceManagerTestSuite) Test_Reserve_SkipsNoncesUsedAroundManager() {
+	s.send(s.tx(0))
+
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+
+	s.Nil(err)
+	s.Equal(uint64(1), nonce)
+}
+
+func (s *NonceManagerTestSuite) Test_Release_FillsGap() {
+	for i := 0; i < 3; i++ {
+		_, err := s.nonces.Reserve(context.Background(), s.address)
+		s.Nil(err)
+	}
+
+	s.Nil(s.nonces.Release(s.address, 1))
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(1), nonce)
+
+	s.Nil(s.nonces.Release(s.address, 2))
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Equal(uint64(2), ledger.Next)
+	s.Len(ledger.Released, 0)
+}
+
+func (s *NonceManagerTestSuite) Test_Release_KeepsBroadcastNonce() {
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	s.Nil(s.nonces.Broadcast(s.address, s.send(tx)))
+
+	s.Nil(s.nonces.Release(s.address, nonce))
+
+	next, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(1), next)
+}
+
+func (s *NonceManagerTestSuite) Test_Submit_ReservesSkippedNonces() {
+	s.Nil(s.nonces.Submit(s.address, s.tx(2)))
+
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Equal(uint64(3), ledger.Next)
+	s.Len(ledger.Pending, 3)
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(3), nonce)
+}
+
+func (s *NonceManagerTestSuite) Test_Submit_ConfirmedNonce() {
+	s.send(s.tx(0))
+	s.backend.Commit()
+	_, _, err := s.nonces.Sync(context.Background(), s.address)
+	s.Nil(err)
+
+	err = s.nonces.Submit(s.address, s.tx(0))
+
+	s.True(errors.Is(err, evm.ErrNonceConfirmed))
+}
+
+func (s *NonceManagerTestSuite) Test_Sync_ReleasesTimedOutReservations() {
+	s.nonces = evm.NewNonceManager(s.backend, simulatedChainID, s.store, 0)
+	for i := 0; i < 3; i++ {
+		_, err := s.nonces.Reserve(context.Background(), s.address)
+		s.Nil(err)
+	}
+	tx := s.tx(0)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	s.Nil(s.nonces.Broadcast(s.address, s.send(tx)))
+	s.Nil(s.nonces.Submit(s.address, s.tx(2)))
+	time.Sleep(time.Millisecond)
+
+	_, _, err := s.nonces.Sync(context.Background(), s.address)
+	s.Nil(err)
+
+	// nonce 1 was never sent and blocks nonce 2
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Equal([]uint64{1}, ledger.Released)
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(1), nonce)
+}
+
+func (s *NonceManagerTestSuite) Test_Sync_ReturnsGapsBelowBroadcastTransaction() {
+	s.nonces = evm.NewNonceManager(s.backend, simulatedChainID, s.store, 0)
+	for i := 0; i < 4; i++ {
+		_, err := s.nonces.Reserve(context.Background(), s.address)
+		s.Nil(err)
+	}
+	s.Nil(s.nonces.Release(s.address, 0))
+	tx := s.tx(2)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(simulatedChainID), s.key)
+	s.Nil(err)
+	s.Nil(s.nonces.Broadcast(s.address, signedTx))
+	time.Sleep(time.Millisecond)
+
+	_, gaps, err := s.nonces.Sync(context.Background(), s.address)
+
+	// released nonce 0 and abandoned nonce 1 block nonce 2, nonce 3 is released
+	s.Nil(err)
+	s.Equal([]uint64{0, 1}, gaps)
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Len(ledger.Released, 0)
+	s.Equal(uint64(3), ledger.Next)
+	s.Len(ledger.Pending, 3)
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(3), nonce)
+}
+
+func (s *NonceManagerTestSuite) Test_Reject_ReleasesNonce() {
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(simulatedChainID), s.key)
+	s.Nil(err)
+	s.Nil(s.nonces.Broadcast(s.address, signedTx))
+
+	s.Nil(s.nonces.Reject(s.address, signedTx))
+
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Len(ledger.Pending, 0)
+	next, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(nonce, next)
+}
+
+func (s *NonceManagerTestSuite) Test_Reject_KeepsEarlierBroadcast() {
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	s.Nil(s.nonces.Broadcast(s.address, s.send(tx)))
+	replacement, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(params.GWei + 1),
+		Gas:      params.TxGas,
+		To:       &s.address,
+	}), types.NewEIP155Signer(simulatedChainID), s.key)
+	s.Nil(err)
+	s.Nil(s.nonces.Broadcast(s.address, replacement))
+
+	s.Nil(s.nonces.Reject(s.address, replacement))
+
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Len(ledger.Pending, 1)
+	s.Len(ledger.Pending[0].Signed, 1)
+	next, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(nonce+1, next)
+}
+
+func (s *NonceManagerTestSuite) Test_Sync_ConfirmsIncludedTransactions() {
+	for i := uint64(0); i < 2; i++ {
+		nonce, err := s.nonces.Reserve(context.Background(), s.address)
+		s.Nil(err)
+		tx := s.tx(nonce)
+		s.Nil(s.nonces.Submit(s.address, tx))
+		s.Nil(s.nonces.Broadcast(s.address, s.send(tx)))
+		s.backend.Commit()
+	}
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	signedTx := s.send(tx)
+	s.Nil(s.nonces.Broadcast(s.address, signedTx))
+
+	pending, _, err := s.nonces.Sync(context.Background(), s.address)
+
+	s.Nil(err)
+	s.Len(pending, 1)
+	s.Equal(signedTx.Hash(), pending[0].Hash())
+	ledger, err := s.nonces.Ledger(s.address)
+	s.Nil(err)
+	s.Equal(uint64(2), ledger.Confirmed)
+	s.Equal(uint64(3), ledger.Next)
+	s.Len(ledger.Pending, 1)
+}
+
+func (s *NonceManagerTestSuite) Test_Sync_RecoversLedgerAfterRestart() {
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	signedTx := s.send(tx)
+	s.Nil(s.nonces.Broadcast(s.address, signedTx))
+	_, err = s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+
+	restarted := evm.NewNonceManager(s.backend, simulatedChainID, s.store, time.Hour)
+	pending, _, err := restarted.Sync(context.Background(), s.address)
+
+	s.Nil(err)
+	s.Len(pending, 1)
+	s.Equal(signedTx.Hash(), pending[0].Hash())
+	next, err := restarted.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(2), next)
+}
+
+func (s *NonceManagerTestSuite) Test_Pending_ReturnsLastVersion() {
+	nonce, err := s.nonces.Reserve(context.Background(), s.address)
+	s.Nil(err)
+	_, err = s.nonces.Pending(s.address, nonce)
+	s.True(errors.Is(err, evm.ErrNonceNotPending))
+
+	tx := s.tx(nonce)
+	s.Nil(s.nonces.Submit(s.address, tx))
+	pending, err := s.nonces.Pending(s.address, nonce)
+	s.Nil(err)
+	s.Equal(tx.Hash(), pending.Hash())
+
+	signedTx := s.send(tx)
+	s.Nil(s.nonces.Broadcast(s.address, signedTx))
+	pending, err = s.nonces.Pending(s.address, nonce)
+	s.Nil(err)
+	s.Equal(signedTx.Hash(), pending.Hash())
+
+	_, err = s.nonces.Pending(s.address, nonce+1)
+	s.True(errors.Is(err, evm.ErrNonceNotPending))
+}
@@ -0,0 +1,178 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"tss-demo/tss_util/events"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+// EventPublisher publishes sent transactions and their outcomes to subscribers of node
+// events
+type EventPublisher interface {
+	Publish(event events.Event)
+}
+
+// ReceiptTracker follows broadcast transactions until their receipts have the configured
+// number of block confirmations and publishes their outcome
+type ReceiptTracker struct {
+	backend       Backend
+	chainID       *big.Int
+	confirmations uint64
+	publisher     EventPublisher
+
+	lock    sync.Mutex
+	pending map[common.Hash]*types.Transaction
+}
+
+func NewReceiptTracker(backend Backend, chainID *big.Int, confirmations uint64, publisher EventPublisher) *ReceiptTracker {
+	return &ReceiptTracker{
+		backend:       backend,
+		chainID:       chainID,
+		confirmations: confirmations,
+		publisher:     publisher,
+		pending:       make(map[common.Hash]*types.Transaction),
+	}
+}
+
+// Track follows the broadcast transaction until it is confirmed
+func (t *ReceiptTracker) Track(tx *types.Transaction) {
+	t.lock.Lock()
+	t.pending[tx.Hash()] = tx
+	t.lock.Unlock()
+
+	t.publisher.Publish(t.event(events.TransactionSent, tx, nil))
+}
+
+// Resume follows transactions broadcast before the restart without publishing them again
+func (t *ReceiptTracker) Resume(txs []*types.Transaction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, tx := range txs {
+		t.pending[tx.Hash()] = tx
+	}
+}
+
+// Pending returns the number of transactions waiting for confirmations
+func (t *ReceiptTracker) Pending() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.pending)
+}
+
+// Check publishes the outcome of tracked transactions with enough confirmations.
+// Transactions without a receipt stay tracked until a transaction with the same nonce
+// is included in a block.
+func (t *ReceiptTracker) Check(ctx context.Context) error {
+	t.lock.Lock()
+	pending := make([]*types.Transaction, 0, len(t.pending))
+	for _, tx := range t.pending {
+		pending = append(pending, tx)
+	}
+	t.lock.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	head, err := t.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// nonces are fetched before receipts, a transaction without a receipt below the nonce
+	// was replaced
+	nonces, err := t.nonces(ctx, pending)
+	if err != nil {
+		return err
+	}
+	for _, tx := range pending {
+		receipt, err := t.backend.TransactionReceipt(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			sender, err := Sender(tx, t.chainID)
+			if err != nil {
+				return err
+			}
+			if tx.Nonce() < nonces[sender] {
+				t.remove(tx)
+				log.Info().Str("chainId", t.chainID.String()).Str("txHash", tx.Hash().Hex()).Msgf("Transaction %s", events.TransactionReplaced)
+				t.publisher.Publish(t.event(events.TransactionReplaced, tx, nil))
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if confirmations(head, receipt) < t.confirmations {
+			continue
+		}
+
+		t.remove(tx)
+
+		eventType := events.TransactionConfirmed
+		if receipt.Status == types.ReceiptStatusFailed {
+			eventType = events.TransactionReverted
+		}
+		log.Info().Str("chainId", t.chainID.String()).Str("txHash", tx.Hash().Hex()).Msgf("Transaction %s", eventType)
+		t.publisher.Publish(t.event(eventType, tx, receipt))
+	}
+	return nil
+}
+
+func (t *ReceiptTracker) remove(tx *types.Transaction) {
+	t.lock.Lock()
+	delete(t.pending, tx.Hash())
+	t.lock.Unlock()
+}
+
+// nonces returns the nonces of senders of the transactions in the latest block
+func (t *ReceiptTracker) nonces(ctx context.Context, txs []*types.Transaction) (map[common.Address]uint64, error) {
+	nonces := make(map[common.Address]uint64)
+	for _, tx := range txs {
+		sender, err := Sender(tx, t.chainID)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := nonces[sender]; ok {
+			continue
+		}
+		nonce, err := t.backend.NonceAt(ctx, sender, nil)
+		if err != nil {
+			return nil, err
+		}
+		nonces[sender] = nonce
+	}
+	return nonces, nil
+}
+
+// confirmations returns the number of blocks including the block of the receipt
+func confirmations(head *types.Header, receipt *types.Receipt) uint64 {
+	if head.Number.Cmp(receipt.BlockNumber) < 0 {
+		return 0
+	}
+	return new(big.Int).Sub(head.Number, receipt.BlockNumber).Uint64() + 1
+}
+
+func (t *ReceiptTracker) event(eventType events.EventType, tx *types.Transaction, receipt *types.Receipt) events.Event {
+	data := map[string]string{
+		"chainId": t.chainID.String(),
+		"txHash":  tx.Hash().Hex(),
+		"nonce":   strconv.FormatUint(tx.Nonce(), 10),
+	}
+	if receipt != nil {
+		data["blockNumber"] = receipt.BlockNumber.String()
+		data["gasUsed"] = strconv.FormatUint(receipt.GasUsed, 10)
+	}
+	return events.Event{
+		Type: eventType,
+		Data: data,
+	}
+}
@@ -0,0 +1,352 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"tss-demo/tss_util/keyshare"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// MPCSigner signs the hash in an MPC session. Value is the value of the transaction with
+// the hash. Signature is empty on nodes that don't coordinate the session.
+type MPCSigner interface {
+	HandleEvents(hash string, value *big.Int) (string, error)
+}
+
+// KeyshareGetter returns the keyshare of the MPC key sending transactions
+type KeyshareGetter interface {
+	GetKeyshare() (keyshare.ECDSAKeyshare, error)
+}
+
+// SentTx is the transaction signed by the MPC key. Tx is nil on nodes that don't
+// coordinate the signing session, only the coordinator broadcasts the transaction.
+type SentTx struct {
+	SigningHash common.Hash
+	Tx          *types.Transaction
+}
+
+// Transactor sends transactions from the MPC address to the chain
+type Transactor struct {
+	backend   Backend
+	builder   *Builder
+	keyshares KeyshareGetter
+	signer    MPCSigner
+	receipts  *ReceiptTracker
+	nonces    *NonceManager
+}
+
+func NewTransactor(backend Backend, builder *Builder, keyshares KeyshareGetter, signer MPCSigner, receipts *ReceiptTracker, nonces *NonceManager) *Transactor {
+	return &Transactor{
+		backend:   backend,
+		builder:   builder,
+		keyshares: keyshares,
+		signer:    signer,
+		receipts:  receipts,
+		nonces:    nonces,
+	}
+}
+
+// ChainID returns the ID of the chain transactions are sent to
+func (t *Transactor) ChainID() *big.Int {
+	return t.builder.ChainID()
+}
+
+// Address returns the MPC address sending transactions
+func (t *Transactor) Address() (common.Address, error) {
+	key, err := t.keyshares.GetKeyshare()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if key.ID() == "" {
+		return common.Address{}, fmt.Errorf("%w: keyshare has no key", keyshare.ErrKeyshareNotFound)
+	}
+	return common.HexToAddress(key.ID()), nil
+}
+
+// Nonces returns the nonce ledger of the MPC address
+func (t *Transactor) Nonces() (*Ledger, error) {
+	from, err := t.Address()
+	if err != nil {
+		return nil, err
+	}
+	return t.nonces.Ledger(from)
+}
+
+// Build builds the unsigned transaction sent from the MPC address and returns it with
+// the hash signed by the MPC key. Nonce is reserved by the nonce manager if it is not
+// set, so that concurrently built transactions don't share nonces.
+func (t *Transactor) Build(ctx context.Context, req TxRequest) (*types.Transaction, common.Hash, error) {
+	from, err := t.Address()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	reserved := req.Nonce == nil
+	if reserved {
+		nonce, err := t.nonces.Reserve(ctx, from)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		req.Nonce = &nonce
+	}
+
+	tx, err := t.builder.Build(ctx, from, req)
+	if err != nil {
+		if reserved {
+			t.release(from, *req.Nonce)
+		}
+		return nil, common.Hash{}, err
+	}
+	hash, err := SigningHash(tx, t.ChainID())
+	if err != nil {
+		if reserved {
+			t.release(from, *req.Nonce)
+		}
+		return nil, common.Hash{}, err
+	}
+	return tx, hash, nil
+}
+
+// Replace builds the transaction replacing the pending transaction with the nonce with
+// bumped fees. Cancelling replaces it with an empty transfer to the MPC address.
+func (t *Transactor) Replace(ctx context.Context, nonce uint64, cancel bool) (*types.Transaction, common.Hash, error) {
+	from, err := t.Address()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	previous, err := t.nonces.Pending(from, nonce)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	tx, err := t.builder.Replacement(ctx, from, previous, cancel)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	hash, err := SigningHash(tx, t.ChainID())
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return tx, hash, nil
+}
+
+func (t *Transactor) release(from common.Address, nonce uint64) {
+	err := t.nonces.Release(from, nonce)
+	if err != nil {
+		log.Error().Err(err).Str("chainId", t.ChainID().String()).Msgf("Failed releasing nonce %d", nonce)
+	}
+}
+
+// Send signs the unsigned transaction in an MPC session. Coordinator of the session
+// broadcasts the signed transaction and tracks its receipt. Every node of the signing
+// subset has to be requested to send the same transaction, so that every node records
+// its nonce as used.
+func (t *Transactor) Send(ctx context.Context, tx *types.Transaction) (*SentTx, error) {
+	chainID := t.ChainID()
+	hash, err := SigningHash(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	sent := &SentTx{SigningHash: hash}
+	if err := t.builder.checkGasPrice(tx.GasFeeCap()); err != nil {
+		return nil, err
+	}
+	from, err := t.Address()
+	if err != nil {
+		return nil, err
+	}
+	err = t.nonces.Submit(from, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := t.sign(tx, hash, from)
+	if err != nil {
+		// nonce is handed out again unless an earlier version was broadcast
+		t.release(from, tx.Nonce())
+		return nil, err
+	}
+	if signedTx == nil {
+		return sent, nil
+	}
+	err = t.nonces.Broadcast(from, signedTx)
+	if err != nil {
+		t.release(from, tx.Nonce())
+		return nil, fmt.Errorf("failed recording transaction with nonce %d: %w", signedTx.Nonce(), err)
+	}
+	err = t.broadcast(ctx, from, signedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent.Tx = signedTx
+	return sent, nil
+}
+
+// sign signs the transaction in an MPC session. Returns nil transaction on nodes that
+// don't coordinate the session.
+func (t *Transactor) sign(tx *types.Transaction, hash common.Hash, from common.Address) (*types.Transaction, error) {
+	chainID := t.ChainID()
+	signature, err := t.signer.HandleEvents(hex.EncodeToString(hash[:]), tx.Value())
+	if err != nil {
+		return nil, err
+	}
+	if signature == "" {
+		return nil, nil
+	}
+	signatureBytes, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MPC signature: %w", err)
+	}
+	signedTx, err := WithSignature(tx, chainID, signatureBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := Sender(signedTx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if sender != from {
+		return nil, fmt.Errorf("%w: signed by %s instead of %s", ErrWrongSender, sender, from)
+	}
+	return signedTx, nil
+}
+
+// broadcast sends the recorded transaction to the chain. Nonce is handed out again only
+// if the node rejects the transaction. Transaction could have reached the transaction
+// pool if the call failed otherwise, so its nonce is kept and its receipt is tracked.
+func (t *Transactor) broadcast(ctx context.Context, from common.Address, signedTx *types.Transaction) error {
+	chainID := t.ChainID()
+	err := t.backend.SendTransaction(ctx, signedTx)
+	switch {
+	case err == nil || alreadyKnown(err):
+		log.Info().Str("chainId", chainID.String()).Str("txHash", signedTx.Hash().Hex()).Msgf("Broadcast transaction with nonce %d", signedTx.Nonce())
+		t.receipts.Track(signedTx)
+		return nil
+	case rejected(err):
+		rejectErr := t.nonces.Reject(from, signedTx)
+		if rejectErr != nil {
+			log.Error().Err(rejectErr).Str("chainId", chainID.String()).Msgf("Failed releasing nonce %d of rejected transaction", signedTx.Nonce())
+		}
+		return fmt.Errorf("%w: %s", ErrBroadcast, err)
+	default:
+		log.Warn().Err(err).Str("chainId", chainID.String()).Str("txHash", signedTx.Hash().Hex()).Msgf("Broadcast of transaction with nonce %d is unknown, tracking it", signedTx.Nonce())
+		t.receipts.Track(signedTx)
+		return fmt.Errorf("%w: %s", ErrBroadcast, err)
+	}
+}
+
+// rejected returns true if the node answered the broadcast with an error, network
+// failures and timeouts leave the outcome unknown
+func rejected(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr)
+}
+
+// alreadyKnown returns true if the transaction is already in the transaction pool
+func alreadyKnown(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "already known") || strings.Contains(message, "known transaction")
+}
+
+// Start recovers pending transactions of the MPC address from the nonce ledger and the
+// chain, then checks receipts and syncs nonces every interval until the context is
+// cancelled
+func (t *Transactor) Start(ctx context.Context, interval time.Duration) {
+	recovered := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !recovered {
+			recovered = t.recover(ctx)
+		}
+		err := t.receipts.Check(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("chainId", t.ChainID().String()).Msg("Failed checking transaction receipts")
+		}
+		if recovered {
+			_, err = t.sync(ctx)
+			if err != nil {
+				log.Error().Err(err).Str("chainId", t.ChainID().String()).Msg("Failed syncing nonces")
+			}
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recover tracks transactions broadcast before the restart again. Recovery waits for the
+// keyshare of the MPC key if the key is not generated yet.
+func (t *Transactor) recover(ctx context.Context) bool {
+	txs, err := t.sync(ctx)
+	if errors.Is(err, keyshare.ErrKeyshareNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Error().Err(err).Str("chainId", t.ChainID().String()).Msg("Failed recovering pending transactions")
+		return false
+	}
+	t.receipts.Resume(txs)
+	if len(txs) > 0 {
+		log.Info().Str("chainId", t.ChainID().String()).Msgf("Recovered %d pending transactions", len(txs))
+	}
+	return true
+}
+
+// sync syncs nonces of the MPC address and fills gaps below broadcast transactions.
+// Returns pending transactions broadcast by this node.
+func (t *Transactor) sync(ctx context.Context) ([]*types.Transaction, error) {
+	from, err := t.Address()
+	if err != nil {
+		return nil, err
+	}
+	signed, gaps, err := t.nonces.Sync(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	for _, nonce := range gaps {
+		t.fillGap(ctx, from, nonce)
+	}
+	return signed, nil
+}
+
+// fillGap sends a cancelling self-transfer with the nonce abandoned below a broadcast
+// transaction, so that the later transactions can be included
+func (t *Transactor) fillGap(ctx context.Context, from common.Address, nonce uint64) {
+	logger := log.With().Str("chainId", t.ChainID().String()).Logger()
+	previous, err := t.nonces.Pending(from, nonce)
+	if errors.Is(err, ErrNonceNotPending) {
+		// reserved nonces have no transaction, the cancel is sent with suggested fees
+		previous = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: new(big.Int)})
+	} else if err != nil {
+		logger.Error().Err(err).Msgf("Failed reading pending transaction with nonce %d", nonce)
+		return
+	}
+
+	tx, err := t.builder.Replacement(ctx, from, previous, true)
+	if err != nil {
+		logger.Error().Err(err).Msgf("Failed building transaction filling nonce %d", nonce)
+		return
+	}
+	logger.Warn().Msgf("Filling nonce %d abandoned below a broadcast transaction", nonce)
+	_, err = t.Send(ctx, tx)
+	if err != nil {
+		logger.Error().Err(err).Msgf("Failed sending transaction filling nonce %d", nonce)
+	}
+}
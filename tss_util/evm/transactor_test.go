@@ -0,0 +1,523 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"tss-demo/tss_util/events"
+	"tss-demo/tss_util/evm"
+	"tss-demo/tss_util/keyshare"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/tss"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+)
+
+// simulatedChainID is the chain ID of the go-ethereum simulated backend
+var simulatedChainID = big.NewInt(1337)
+
+type fakeKeyshares struct {
+	key *ecdsa.PrivateKey
+}
+
+func (k *fakeKeyshares) GetKeyshare() (keyshare.ECDSAKeyshare, error) {
+	share := keyshare.ECDSAKeyshare{}
+	share.Key.ECDSAPub = crypto.ScalarBaseMult(tss.S256(), k.key.D)
+	return share, nil
+}
+
+// fakeSigner signs hashes with the key instead of running MPC sessions
+type fakeSigner struct {
+	key         *ecdsa.PrivateKey
+	coordinator bool
+	values      []*big.Int
+}
+
+func (s *fakeSigner) HandleEvents(hash string, value *big.Int) (string, error) {
+	s.values = append(s.values, value)
+	if !s.coordinator {
+		return "", nil
+	}
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil {
+		return "", err
+	}
+	signature, err := ethcrypto.Sign(hashBytes, s.key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signature), nil
+}
+
+type fakePublisher struct {
+	lock   sync.Mutex
+	events []events.Event
+}
+
+func (p *fakePublisher) Publish(event events.Event) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *fakePublisher) types() []events.EventType {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	types := make([]events.EventType, len(p.events))
+	for i, event := range p.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// droppingBackend loses broadcast transactions while drop is set, like transactions
+// evicted from the transaction pool. Broadcasts fail with sendErr if it is set and
+// beforeSend is called before every broadcast.
+type droppingBackend struct {
+	*backends.SimulatedBackend
+	drop       bool
+	sendErr    error
+	beforeSend func(tx *types.Transaction)
+}
+
+func (b *droppingBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if b.beforeSend != nil {
+		b.beforeSend(tx)
+	}
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+	if b.drop {
+		return nil
+	}
+	return b.SimulatedBackend.SendTransaction(ctx, tx)
+}
+
+// rejection is the error response of the node like the rpc client returns it
+type rejection struct {
+	message string
+}
+
+func (e *rejection) Error() string {
+	return e.message
+}
+
+func (e *rejection) ErrorCode() int {
+	return -32000
+}
+
+type TransactorTestSuite struct {
+	suite.Suite
+	key       *ecdsa.PrivateKey
+	address   common.Address
+	recipient common.Address
+	backend   *backends.SimulatedBackend
+	rpc       *droppingBackend
+	signer    *fakeSigner
+	publisher *fakePublisher
+	db        *lvldb.LVLDB
+	nonces    *evm.NonceStore
+	// reservationTimeout is the reservation timeout of created nonce managers
+	reservationTimeout time.Duration
+}
+
+func TestRunTransactorTestSuite(t *testing.T) {
+	suite.Run(t, new(TransactorTestSuite))
+}
+
+func (s *TransactorTestSuite) SetupTest() {
+	var err error
+	s.key, err = ethcrypto.GenerateKey()
+	s.Nil(err)
+	s.address = ethcrypto.PubkeyToAddress(s.key.PublicKey)
+	s.recipient = common.HexToAddress("0x5C11a8d5a4a4A3f3D1a2b4b1b5cDd1E4F2a3b4c5")
+	s.backend = backends.NewSimulatedBackend(core.GenesisAlloc{
+		s.address: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)},
+	}, 10_000_000)
+	s.rpc = &droppingBackend{SimulatedBackend: s.backend}
+	s.signer = &fakeSigner{key: s.key, coordinator: true}
+	s.publisher = &fakePublisher{}
+	s.db, err = lvldb.NewLvlDB(s.T().TempDir())
+	s.Nil(err)
+	s.nonces = evm.NewNonceStore(s.db)
+	s.reservationTimeout = time.Minute
+}
+
+func (s *TransactorTestSuite) TearDownTest() {
+	_ = s.backend.Close()
+	_ = s.db.Close()
+}
+
+func (s *TransactorTestSuite) transactor(txType string, maxGasPrice *big.Int, confirmations uint64) (*evm.Transactor, *evm.ReceiptTracker) {
+	parsedType, err := evm.ParseTxType(txType)
+	s.Nil(err)
+	builder, err := evm.NewBuilder(s.rpc, simulatedChainID, parsedType, maxGasPrice)
+	s.Nil(err)
+	receipts := evm.NewReceiptTracker(s.rpc, simulatedChainID, confirmations, s.publisher)
+	nonces := evm.NewNonceManager(s.rpc, simulatedChainID, s.nonces, s.reservationTimeout)
+	return evm.NewTransactor(s.rpc, builder, &fakeKeyshares{key: s.key}, s.signer, receipts, nonces), receipts
+}
+
+func (s *TransactorTestSuite) Test_Send_BroadcastsEveryTxType() {
+	for i, txType := range []string{"legacy", "access-list", "dynamic-fee"} {
+		transactor, receipts := s.transactor(txType, nil, 1)
+
+		tx, hash, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1000)})
+		s.Nil(err)
+		sent, err := transactor.Send(context.Background(), tx)
+		s.Nil(err)
+		s.Equal(hash, sent.SigningHash)
+		s.Equal(uint64(i), sent.Tx.Nonce())
+		s.backend.Commit()
+
+		receipt, err := s.backend.TransactionReceipt(context.Background(), sent.Tx.Hash())
+		s.Nil(err)
+		s.Equal(types.ReceiptStatusSuccessful, receipt.Status)
+		s.Equal(tx.Type(), receipt.Type)
+		s.Nil(receipts.Check(context.Background()))
+		s.Equal(0, receipts.Pending())
+	}
+
+	balance, err := s.backend.BalanceAt(context.Background(), s.recipient, nil)
+	s.Nil(err)
+	s.Equal(big.NewInt(3000), balance)
+	s.Equal(big.NewInt(1000), s.signer.values[0])
+	s.Equal([]events.EventType{
+		events.TransactionSent, events.TransactionConfirmed,
+		events.TransactionSent, events.TransactionConfirmed,
+		events.TransactionSent, events.TransactionConfirmed,
+	}, s.publisher.types())
+}
+
+func (s *TransactorTestSuite) Test_Send_NotCoordinator() {
+	s.signer.coordinator = false
+	transactor, receipts := s.transactor("dynamic-fee", nil, 1)
+	tx, hash, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1000)})
+	s.Nil(err)
+
+	sent, err := transactor.Send(context.Background(), tx)
+
+	s.Nil(err)
+	s.Equal(hash, sent.SigningHash)
+	s.Nil(sent.Tx)
+	s.Equal(0, receipts.Pending())
+	nonce, err := s.backend.PendingNonceAt(context.Background(), s.address)
+	s.Nil(err)
+	s.Equal(uint64(0), nonce)
+}
+
+func (s *TransactorTestSuite) Test_Send_WrongSender() {
+	otherKey, err := ethcrypto.GenerateKey()
+	s.Nil(err)
+	s.signer.key = otherKey
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+	s.Nil(err)
+
+	_, err = transactor.Send(context.Background(), tx)
+
+	s.True(errors.Is(err, evm.ErrWrongSender))
+}
+
+func (s *TransactorTestSuite) Test_Send_OtherChain() {
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       21000,
+		To:        &s.recipient,
+	})
+
+	_, err := transactor.Send(context.Background(), tx)
+
+	s.True(errors.Is(err, evm.ErrChainIDMismatch))
+	s.Len(s.signer.values, 0)
+}
+
+func (s *TransactorTestSuite) Test_Send_BroadcastFailed() {
+	transactor, receipts := s.transactor("legacy", nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+	s.Nil(err)
+	s.rpc.sendErr = &rejection{message: "insufficient funds for gas * price + value"}
+
+	_, err = transactor.Send(context.Background(), tx)
+
+	s.True(errors.Is(err, evm.ErrBroadcast))
+	s.Equal(0, receipts.Pending())
+	// nonce of the rejected transaction is handed out again
+	nonces, err := transactor.Nonces()
+	s.Nil(err)
+	s.Len(nonces.Pending, 0)
+	s.Equal(uint64(0), nonces.Next)
+	s.rpc.sendErr = nil
+	next, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+	s.Nil(err)
+	s.Equal(tx.Nonce(), next.Nonce())
+}
+
+func (s *TransactorTestSuite) Test_Send_BroadcastTimedOut() {
+	transactor, receipts := s.transactor("dynamic-fee", nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+	s.Nil(err)
+	s.rpc.sendErr = context.DeadlineExceeded
+	// transaction is recorded before it is broadcast
+	recorded := false
+	s.rpc.beforeSend = func(signedTx *types.Transaction) {
+		nonces, err := transactor.Nonces()
+		s.Nil(err)
+		recorded = len(nonces.Pending) == 1 && len(nonces.Pending[0].Signed) == 1
+	}
+
+	_, err = transactor.Send(context.Background(), tx)
+
+	s.True(errors.Is(err, evm.ErrBroadcast))
+	s.True(recorded)
+	// transaction could be in the transaction pool, its nonce is kept
+	s.Equal(1, receipts.Pending())
+	nonces, err := transactor.Nonces()
+	s.Nil(err)
+	s.Len(nonces.Pending, 1)
+	s.Equal(tx.Nonce(), nonces.Pending[0].Nonce)
+	s.Len(nonces.Pending[0].Signed, 1)
+	s.Len(nonces.Released, 0)
+	s.rpc.sendErr = nil
+	s.rpc.beforeSend = nil
+	next, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+	s.Nil(err)
+	s.Equal(tx.Nonce()+1, next.Nonce())
+}
+
+func (s *TransactorTestSuite) Test_Build_GasPriceAboveMaximum() {
+	transactor, _ := s.transactor("dynamic-fee", big.NewInt(1), 1)
+
+	_, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+
+	s.True(errors.Is(err, evm.ErrGasPriceTooHigh))
+}
+
+func (s *TransactorTestSuite) Test_Build_LegacyAccessList() {
+	transactor, _ := s.transactor("legacy", nil, 1)
+
+	_, _, err := transactor.Build(context.Background(), evm.TxRequest{
+		To:         &s.recipient,
+		AccessList: types.AccessList{{Address: s.recipient}},
+	})
+
+	s.True(errors.Is(err, evm.ErrUnsupportedTxType))
+}
+
+func (s *TransactorTestSuite) Test_Check_WaitsForConfirmations() {
+	transactor, receipts := s.transactor("dynamic-fee", nil, 2)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1)})
+	s.Nil(err)
+	_, err = transactor.Send(context.Background(), tx)
+	s.Nil(err)
+
+	s.backend.Commit()
+	s.Nil(receipts.Check(context.Background()))
+	s.Equal(1, receipts.Pending())
+
+	s.backend.Commit()
+	s.Nil(receipts.Check(context.Background()))
+	s.Equal(0, receipts.Pending())
+	s.Equal([]events.EventType{events.TransactionSent, events.TransactionConfirmed}, s.publisher.types())
+}
+
+func (s *TransactorTestSuite) Test_Check_PublishesRevertedTransaction() {
+	transactor, receipts := s.transactor("dynamic-fee", nil, 1)
+	// contract creation reverting with PUSH1 0 PUSH1 0 REVERT
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{Data: common.FromHex("0x60006000fd"), Gas: 100000})
+	s.Nil(err)
+	sent, err := transactor.Send(context.Background(), tx)
+	s.Nil(err)
+	s.backend.Commit()
+
+	s.Nil(receipts.Check(context.Background()))
+
+	s.Equal([]events.EventType{events.TransactionSent, events.TransactionReverted}, s.publisher.types())
+	s.Equal(sent.Tx.Hash().Hex(), s.publisher.events[1].Data["txHash"])
+}
+
+func (s *TransactorTestSuite) Test_Build_ConcurrentTransactionsGetUniqueNonces() {
+	transactor, receipts := s.transactor("dynamic-fee", nil, 1)
+	txs := make([]*types.Transaction, 10)
+	var wg sync.WaitGroup
+	for i := range txs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1)})
+			s.Nil(err)
+			txs[i] = tx
+		}(i)
+	}
+	wg.Wait()
+
+	// chain accepts transactions in nonce order
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce() < txs[j].Nonce() })
+	for i, tx := range txs {
+		s.Equal(uint64(i), tx.Nonce())
+		_, err := transactor.Send(context.Background(), tx)
+		s.Nil(err)
+	}
+	s.backend.Commit()
+	s.Nil(receipts.Check(context.Background()))
+	s.Equal(0, receipts.Pending())
+}
+
+func (s *TransactorTestSuite) Test_Build_ReleasesNonceOnFailure() {
+	transactor, _ := s.transactor("legacy", nil, 1)
+	_, _, err := transactor.Build(context.Background(), evm.TxRequest{
+		To:         &s.recipient,
+		AccessList: types.AccessList{{Address: s.recipient}},
+	})
+	s.NotNil(err)
+
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient})
+
+	s.Nil(err)
+	s.Equal(uint64(0), tx.Nonce())
+}
+
+func (s *TransactorTestSuite) Test_Replace_SpeedsUpDroppedLegacyTransaction() {
+	s.speedUpDroppedTransaction("legacy")
+}
+
+func (s *TransactorTestSuite) Test_Replace_SpeedsUpDroppedDynamicFeeTransaction() {
+	s.speedUpDroppedTransaction("dynamic-fee")
+}
+
+func (s *TransactorTestSuite) speedUpDroppedTransaction(txType string) {
+	transactor, receipts := s.transactor(txType, nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1000)})
+	s.Nil(err)
+	s.rpc.drop = true
+	dropped, err := transactor.Send(context.Background(), tx)
+	s.Nil(err)
+	s.rpc.drop = false
+
+	replacement, hash, err := transactor.Replace(context.Background(), tx.Nonce(), false)
+	s.Nil(err)
+	s.Equal(tx.Nonce(), replacement.Nonce())
+	s.Equal(tx.Value(), replacement.Value())
+	s.Equal(tx.To(), replacement.To())
+	s.True(replacement.GasFeeCap().Cmp(new(big.Int).Div(new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(110)), big.NewInt(100))) >= 0)
+	s.True(replacement.GasTipCap().Cmp(new(big.Int).Div(new(big.Int).Mul(tx.GasTipCap(), big.NewInt(110)), big.NewInt(100))) >= 0)
+	sent, err := transactor.Send(context.Background(), replacement)
+	s.Nil(err)
+	s.Equal(hash, sent.SigningHash)
+	s.backend.Commit()
+
+	s.Nil(receipts.Check(context.Background()))
+	s.Equal(0, receipts.Pending())
+	s.ElementsMatch([]events.EventType{
+		events.TransactionSent, events.TransactionSent, events.TransactionConfirmed, events.TransactionReplaced,
+	}, s.publisher.types())
+	for _, event := range s.publisher.events {
+		if event.Type == events.TransactionReplaced {
+			s.Equal(dropped.Tx.Hash().Hex(), event.Data["txHash"])
+		}
+	}
+	nonces, err := transactor.Nonces()
+	s.Nil(err)
+	s.Len(nonces.Pending, 1)
+	s.Len(nonces.Pending[0].Signed, 2)
+}
+
+func (s *TransactorTestSuite) Test_Replace_CancelsTransaction() {
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1000), Data: []byte{1}})
+	s.Nil(err)
+	_, err = transactor.Send(context.Background(), tx)
+	s.Nil(err)
+
+	cancel, _, err := transactor.Replace(context.Background(), tx.Nonce(), true)
+
+	s.Nil(err)
+	s.Equal(tx.Nonce(), cancel.Nonce())
+	s.Equal(s.address, *cancel.To())
+	s.Equal(big.NewInt(0), cancel.Value())
+	s.Len(cancel.Data(), 0)
+	s.Equal(uint64(21000), cancel.Gas())
+}
+
+func (s *TransactorTestSuite) Test_Replace_NonceNotPending() {
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+
+	_, _, err := transactor.Replace(context.Background(), 3, false)
+
+	s.True(errors.Is(err, evm.ErrNonceNotPending))
+}
+
+func (s *TransactorTestSuite) Test_Start_RecoversPendingTransactions() {
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1)})
+	s.Nil(err)
+	sent, err := transactor.Send(context.Background(), tx)
+	s.Nil(err)
+
+	// transactor of the restarted node tracks the transaction again
+	restarted, receipts := s.transactor("dynamic-fee", nil, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go restarted.Start(ctx, 10*time.Millisecond)
+	s.Eventually(func() bool { return receipts.Pending() == 1 }, time.Second, 10*time.Millisecond)
+
+	s.backend.Commit()
+	s.Eventually(func() bool { return len(s.publisher.types()) == 2 }, time.Second, 10*time.Millisecond)
+	s.Equal([]events.EventType{events.TransactionSent, events.TransactionConfirmed}, s.publisher.types())
+	s.Equal(sent.Tx.Hash().Hex(), s.publisher.events[1].Data["txHash"])
+	s.Equal(0, receipts.Pending())
+	s.Eventually(func() bool {
+		nonces, err := restarted.Nonces()
+		return err == nil && nonces.Confirmed == 1 && len(nonces.Pending) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TransactorTestSuite) Test_Start_FillsAbandonedNonces() {
+	s.reservationTimeout = 0
+	transactor, _ := s.transactor("dynamic-fee", nil, 1)
+	abandoned, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1)})
+	s.Nil(err)
+	tx, _, err := transactor.Build(context.Background(), evm.TxRequest{To: &s.recipient, Value: big.NewInt(1)})
+	s.Nil(err)
+	// nonce 0 is never sent, the chain waits for it before including nonce 1
+	s.rpc.drop = true
+	_, err = transactor.Send(context.Background(), tx)
+	s.Nil(err)
+	s.rpc.drop = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go transactor.Start(ctx, 10*time.Millisecond)
+
+	s.Eventually(func() bool {
+		nonce, err := s.backend.PendingNonceAt(context.Background(), s.address)
+		return err == nil && nonce == 1
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	nonces, err := transactor.Nonces()
+	s.Nil(err)
+	s.Equal(abandoned.Nonce(), nonces.Pending[0].Nonce)
+	s.Len(nonces.Pending[0].Signed, 1)
+	filled, err := evm.DecodeTx(nonces.Pending[0].Signed[0])
+	s.Nil(err)
+	s.Equal(s.address, *filled.To())
+	s.Equal(big.NewInt(0), filled.Value())
+}
@@ -0,0 +1,126 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package evm builds transactions sent from the MPC address, signs them in MPC sessions,
+// broadcasts them to the chain and tracks their receipts
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	ErrUnsupportedTxType = errors.New("unsupported transaction type")
+	ErrChainIDMismatch   = errors.New("transaction chain ID doesn't match the chain")
+	ErrGasPriceTooHigh   = errors.New("gas price exceeds the maximum of the chain")
+	ErrWrongSender       = errors.New("transaction is not signed by the MPC key")
+	ErrBroadcast         = errors.New("failed broadcasting transaction")
+)
+
+// Backend is the RPC of the chain. It is implemented by ethclient.Client and by the
+// simulated backend of go-ethereum.
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+var txTypes = map[string]uint8{
+	"legacy":      types.LegacyTxType,
+	"access-list": types.AccessListTxType,
+	"dynamic-fee": types.DynamicFeeTxType,
+}
+
+// ParseTxType parses the configured transaction type: legacy, access-list (EIP-2930) or
+// dynamic-fee (EIP-1559)
+func ParseTxType(name string) (uint8, error) {
+	txType, ok := txTypes[name]
+	if !ok {
+		return 0, fmt.Errorf("%w %s", ErrUnsupportedTxType, name)
+	}
+	return txType, nil
+}
+
+// NewSigner returns the signer hashing transactions of the type
+func NewSigner(txType uint8, chainID *big.Int) (types.Signer, error) {
+	switch txType {
+	case types.LegacyTxType:
+		return types.NewEIP155Signer(chainID), nil
+	case types.AccessListTxType:
+		return types.NewEIP2930Signer(chainID), nil
+	case types.DynamicFeeTxType:
+		return types.NewLondonSigner(chainID), nil
+	default:
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedTxType, txType)
+	}
+}
+
+// SigningHash returns the hash of the transaction signed by the MPC key. Typed
+// transactions must be built for the chain.
+func SigningHash(tx *types.Transaction, chainID *big.Int) (common.Hash, error) {
+	if tx.Type() != types.LegacyTxType && tx.ChainId().Cmp(chainID) != 0 {
+		return common.Hash{}, fmt.Errorf("%w: %s instead of %s", ErrChainIDMismatch, tx.ChainId(), chainID)
+	}
+	signer, err := NewSigner(tx.Type(), chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return signer.Hash(tx), nil
+}
+
+// WithSignature returns the transaction signed with the MPC signature in the
+// [R || S || V] format where V is the recovery ID
+func WithSignature(tx *types.Transaction, chainID *big.Int, signature []byte) (*types.Transaction, error) {
+	signer, err := NewSigner(tx.Type(), chainID)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, signature)
+}
+
+// Sender returns the address that signed the transaction
+func Sender(tx *types.Transaction, chainID *big.Int) (common.Address, error) {
+	signer, err := NewSigner(tx.Type(), chainID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return types.Sender(signer, tx)
+}
+
+// EncodeTx returns the hex encoded canonical encoding of the transaction, the RLP of legacy
+// transactions and the type prefixed RLP of typed transactions
+func EncodeTx(tx *types.Transaction) (string, error) {
+	encoded, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(encoded), nil
+}
+
+// DecodeTx decodes the transaction encoded by EncodeTx
+func DecodeTx(encoded string) (*types.Transaction, error) {
+	encodedTx, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	err = tx.UnmarshalBinary(encodedTx)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,71 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package evm_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"tss-demo/tss_util/evm"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/suite"
+)
+
+type TxTestSuite struct {
+	suite.Suite
+}
+
+func TestRunTxTestSuite(t *testing.T) {
+	suite.Run(t, new(TxTestSuite))
+}
+
+func (s *TxTestSuite) Test_SignedTxRecoversSender() {
+	key, err := ethcrypto.GenerateKey()
+	s.Nil(err)
+	to := common.HexToAddress("0x5C11a8d5a4a4A3f3D1a2b4b1b5cDd1E4F2a3b4c5")
+	chainID := big.NewInt(5)
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+		types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(10), Gas: 21000, To: &to}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &to}),
+	}
+
+	for _, tx := range txs {
+		encoded, err := evm.EncodeTx(tx)
+		s.Nil(err)
+		decoded, err := evm.DecodeTx(encoded)
+		s.Nil(err)
+		hash, err := evm.SigningHash(decoded, chainID)
+		s.Nil(err)
+		signature, err := ethcrypto.Sign(hash[:], key)
+		s.Nil(err)
+
+		signed, err := evm.WithSignature(decoded, chainID, signature)
+		s.Nil(err)
+		sender, err := evm.Sender(signed, chainID)
+		s.Nil(err)
+		s.Equal(ethcrypto.PubkeyToAddress(key.PublicKey), sender)
+		s.Equal(chainID, signed.ChainId())
+	}
+}
+
+func (s *TxTestSuite) Test_SigningHash_OtherChain() {
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)})
+
+	_, err := evm.SigningHash(tx, big.NewInt(5))
+
+	s.True(errors.Is(err, evm.ErrChainIDMismatch))
+}
+
+func (s *TxTestSuite) Test_ParseTxType() {
+	txType, err := evm.ParseTxType("access-list")
+	s.Nil(err)
+	s.Equal(uint8(types.AccessListTxType), txType)
+
+	_, err = evm.ParseTxType("blob")
+	s.True(errors.Is(err, evm.ErrUnsupportedTxType))
+}
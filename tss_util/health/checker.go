@@ -0,0 +1,244 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss/liveness"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type KeyshareReader interface {
+	GetKeyshare() (keyshare.ECDSAKeyshare, error)
+}
+
+// PeerStats returns liveness statistics gathered by communication health checks
+type PeerStats interface {
+	Stats() map[peer.ID]liveness.PeerStats
+}
+
+// SessionMonitor returns running tss sessions with their duration
+type SessionMonitor interface {
+	PendingSessions() map[string]time.Duration
+}
+
+// TopologyVersions returns applied topology and the latest version fetched from the topology source
+type TopologyVersions interface {
+	Topology() *topology.NetworkTopology
+	LatestVersion() uint64
+}
+
+type ShutdownState interface {
+	ShuttingDown() bool
+}
+
+type KeyshareStatus struct {
+	OK        bool   `json:"ok"`
+	Threshold int    `json:"threshold,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type PeersStatus struct {
+	OK          bool     `json:"ok"`
+	Reachable   int      `json:"reachable"`
+	Required    int      `json:"required"`
+	Total       int      `json:"total"`
+	Unreachable []string `json:"unreachable,omitempty"`
+}
+
+type SessionsStatus struct {
+	OK           bool     `json:"ok"`
+	Pending      int      `json:"pending"`
+	Stuck        []string `json:"stuck,omitempty"`
+	ShuttingDown bool     `json:"shuttingDown,omitempty"`
+}
+
+type TopologyStatus struct {
+	OK            bool   `json:"ok"`
+	Version       uint64 `json:"version"`
+	LatestVersion uint64 `json:"latestVersion"`
+}
+
+// Report is readiness breakdown of the node
+type Report struct {
+	Ready    bool           `json:"ready"`
+	Keyshare KeyshareStatus `json:"keyshare"`
+	Peers    PeersStatus    `json:"peers"`
+	Sessions SessionsStatus `json:"sessions"`
+	Topology TopologyStatus `json:"topology"`
+}
+
+// PeerStatsMaxAge is how old the latest health check of a peer can get before a readiness
+// check requests a new health check of peers
+var PeerStatsMaxAge = 30 * time.Second
+
+// Checker reports whether the node is able to take part in signing. Node is ready
+// if its keyshare is readable, at least threshold+1 peers including itself are
+// reachable, no session is stuck and the node is not shutting down. Outdated
+// topology is reported but doesn't make the node unready.
+type Checker struct {
+	hostID     peer.ID
+	keyshares  KeyshareReader
+	peers      PeerStats
+	sessions   SessionMonitor
+	topologies TopologyVersions
+	shutdown   ShutdownState
+	stuckAfter time.Duration
+	refresh    chan<- struct{}
+}
+
+func NewChecker(
+	hostID peer.ID,
+	keyshares KeyshareReader,
+	peers PeerStats,
+	sessions SessionMonitor,
+	topologies TopologyVersions,
+	shutdown ShutdownState,
+	stuckAfter time.Duration,
+	refresh chan<- struct{},
+) *Checker {
+	return &Checker{
+		hostID:     hostID,
+		keyshares:  keyshares,
+		peers:      peers,
+		sessions:   sessions,
+		topologies: topologies,
+		shutdown:   shutdown,
+		stuckAfter: stuckAfter,
+		refresh:    refresh,
+	}
+}
+
+// Ready returns readiness breakdown of the node
+func (c *Checker) Ready() Report {
+	nt := c.topologies.Topology()
+	report := Report{
+		Keyshare: c.keyshareStatus(),
+		Sessions: c.sessionsStatus(),
+		Topology: c.topologyStatus(nt),
+	}
+
+	threshold := report.Keyshare.Threshold
+	if !report.Keyshare.OK && nt != nil {
+		threshold = nt.Threshold
+	}
+	report.Peers = c.peersStatus(nt, threshold)
+	report.Ready = report.Keyshare.OK && report.Peers.OK && report.Sessions.OK
+	return report
+}
+
+func (c *Checker) keyshareStatus() KeyshareStatus {
+	key, err := c.keyshares.GetKeyshare()
+	if err != nil {
+		return KeyshareStatus{Error: err.Error()}
+	}
+	return KeyshareStatus{OK: true, Threshold: key.Threshold}
+}
+
+// peersStatus counts peers whose latest health check succeeded, the node itself is always
+// reachable. Peers are checked again in the background if the latest check of any peer
+// is older than PeerStatsMaxAge, so readiness follows peers recovering or failing between
+// the periodic health checks.
+func (c *Checker) peersStatus(nt *topology.NetworkTopology, threshold int) PeersStatus {
+	status := PeersStatus{
+		Reachable: 1,
+		Required:  threshold + 1,
+		Total:     1,
+	}
+	if nt != nil {
+		stats := c.peers.Stats()
+		stale := false
+		for _, p := range nt.Peers {
+			if p.ID == c.hostID {
+				continue
+			}
+
+			status.Total++
+			peerStats, ok := stats[p.ID]
+			if ok && peerStats.LastSeen.After(peerStats.LastFailure) {
+				status.Reachable++
+			} else {
+				status.Unreachable = append(status.Unreachable, nt.PeerName(p.ID))
+			}
+			if !ok || time.Since(latest(peerStats.LastSeen, peerStats.LastFailure)) > PeerStatsMaxAge {
+				stale = true
+			}
+		}
+		if stale {
+			c.refreshPeers()
+		}
+	}
+	status.OK = status.Reachable >= status.Required
+	return status
+}
+
+// refreshPeers requests a health check of peers unless one is already requested
+func (c *Checker) refreshPeers() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (c *Checker) sessionsStatus() SessionsStatus {
+	pending := c.sessions.PendingSessions()
+	status := SessionsStatus{
+		Pending:      len(pending),
+		ShuttingDown: c.shutdown.ShuttingDown(),
+	}
+	for sessionID, duration := range pending {
+		if duration > c.stuckAfter {
+			status.Stuck = append(status.Stuck, sessionID)
+		}
+	}
+	sort.Strings(status.Stuck)
+	status.OK = len(status.Stuck) == 0 && !status.ShuttingDown
+	return status
+}
+
+func (c *Checker) topologyStatus(nt *topology.NetworkTopology) TopologyStatus {
+	if nt == nil {
+		return TopologyStatus{}
+	}
+	latest := c.topologies.LatestVersion()
+	return TopologyStatus{
+		OK:            nt.Version >= latest,
+		Version:       nt.Version,
+		LatestVersion: latest,
+	}
+}
+
+// LiveHandler responds while the process is able to serve requests
+func (c *Checker) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadyHandler responds with readiness breakdown and 503 status if the node is not ready
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready()
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,223 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tss-demo/tss_util/health"
+	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss/liveness"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeKeyshares struct {
+	key keyshare.ECDSAKeyshare
+	err error
+}
+
+func (f *fakeKeyshares) GetKeyshare() (keyshare.ECDSAKeyshare, error) {
+	return f.key, f.err
+}
+
+type fakePeerStats struct {
+	stats map[peer.ID]liveness.PeerStats
+}
+
+func (f *fakePeerStats) Stats() map[peer.ID]liveness.PeerStats {
+	return f.stats
+}
+
+type fakeSessions struct {
+	pending map[string]time.Duration
+}
+
+func (f *fakeSessions) PendingSessions() map[string]time.Duration {
+	return f.pending
+}
+
+type fakeTopologies struct {
+	topology *topology.NetworkTopology
+	latest   uint64
+}
+
+func (f *fakeTopologies) Topology() *topology.NetworkTopology {
+	return f.topology
+}
+
+func (f *fakeTopologies) LatestVersion() uint64 {
+	return f.latest
+}
+
+type fakeShutdown struct {
+	shuttingDown bool
+}
+
+func (f *fakeShutdown) ShuttingDown() bool {
+	return f.shuttingDown
+}
+
+type CheckerTestSuite struct {
+	suite.Suite
+	keyshares  *fakeKeyshares
+	peerStats  *fakePeerStats
+	sessions   *fakeSessions
+	topologies *fakeTopologies
+	shutdown   *fakeShutdown
+	refresh    chan struct{}
+	checker    *health.Checker
+	peers      []peer.ID
+}
+
+func TestRunCheckerTestSuite(t *testing.T) {
+	suite.Run(t, new(CheckerTestSuite))
+}
+
+func (s *CheckerTestSuite) SetupTest() {
+	s.peers = []peer.ID{peer.ID("peer1"), peer.ID("peer2"), peer.ID("peer3")}
+	s.keyshares = &fakeKeyshares{key: keyshare.ECDSAKeyshare{Threshold: 1}}
+	now := time.Now()
+	s.peerStats = &fakePeerStats{stats: map[peer.ID]liveness.PeerStats{
+		s.peers[1]: {LastSeen: now},
+		s.peers[2]: {LastSeen: now.Add(-time.Minute), LastFailure: now},
+	}}
+	s.sessions = &fakeSessions{pending: map[string]time.Duration{}}
+	s.topologies = &fakeTopologies{
+		topology: &topology.NetworkTopology{
+			Peers: []*peer.AddrInfo{
+				{ID: s.peers[0]}, {ID: s.peers[1]}, {ID: s.peers[2]},
+			},
+			Threshold: 1,
+			Version:   2,
+		},
+		latest: 2,
+	}
+	s.shutdown = &fakeShutdown{}
+	s.refresh = make(chan struct{}, 1)
+	s.checker = health.NewChecker(s.peers[0], s.keyshares, s.peerStats, s.sessions, s.topologies, s.shutdown, time.Minute, s.refresh)
+}
+
+func (s *CheckerTestSuite) Test_Ready_EnoughPeersReachable() {
+	report := s.checker.Ready()
+
+	s.True(report.Ready)
+	s.Equal(health.PeersStatus{
+		OK:          true,
+		Reachable:   2,
+		Required:    2,
+		Total:       3,
+		Unreachable: []string{s.peers[2].Pretty()},
+	}, report.Peers)
+	s.True(report.Topology.OK)
+}
+
+func (s *CheckerTestSuite) Test_Ready_RecentPeerStatsNotRefreshed() {
+	s.checker.Ready()
+
+	s.Empty(s.refresh)
+}
+
+func (s *CheckerTestSuite) Test_Ready_StalePeerStatsRefreshed() {
+	s.peerStats.stats[s.peers[1]] = liveness.PeerStats{LastSeen: time.Now().Add(-2 * health.PeerStatsMaxAge)}
+
+	report := s.checker.Ready()
+	s.checker.Ready()
+
+	s.True(report.Ready)
+	s.Len(s.refresh, 1)
+}
+
+func (s *CheckerTestSuite) Test_Ready_UncheckedPeerRefreshed() {
+	delete(s.peerStats.stats, s.peers[1])
+
+	s.checker.Ready()
+
+	s.Len(s.refresh, 1)
+}
+
+func (s *CheckerTestSuite) Test_Ready_NotEnoughPeersReachable() {
+	s.keyshares.key.Threshold = 2
+
+	report := s.checker.Ready()
+
+	s.False(report.Ready)
+	s.False(report.Peers.OK)
+	s.Equal(3, report.Peers.Required)
+}
+
+func (s *CheckerTestSuite) Test_Ready_MissingKeyshare() {
+	s.keyshares.err = errors.New("missing keyshare")
+
+	report := s.checker.Ready()
+
+	s.False(report.Ready)
+	s.Equal(health.KeyshareStatus{Error: "missing keyshare"}, report.Keyshare)
+	s.Equal(2, report.Peers.Required)
+}
+
+func (s *CheckerTestSuite) Test_Ready_StuckSession() {
+	s.sessions.pending = map[string]time.Duration{
+		"stuck":   2 * time.Minute,
+		"running": time.Second,
+	}
+
+	report := s.checker.Ready()
+
+	s.False(report.Ready)
+	s.Equal(health.SessionsStatus{
+		Pending: 2,
+		Stuck:   []string{"stuck"},
+	}, report.Sessions)
+}
+
+func (s *CheckerTestSuite) Test_Ready_ShuttingDown() {
+	s.shutdown.shuttingDown = true
+
+	report := s.checker.Ready()
+
+	s.False(report.Ready)
+	s.True(report.Sessions.ShuttingDown)
+}
+
+func (s *CheckerTestSuite) Test_Ready_OutdatedTopologyStillReady() {
+	s.topologies.latest = 3
+
+	report := s.checker.Ready()
+
+	s.True(report.Ready)
+	s.Equal(health.TopologyStatus{
+		OK:            false,
+		Version:       2,
+		LatestVersion: 3,
+	}, report.Topology)
+}
+
+func (s *CheckerTestSuite) Test_ReadyHandler_NotReady() {
+	s.keyshares.err = errors.New("missing keyshare")
+	recorder := httptest.NewRecorder()
+
+	s.checker.ReadyHandler(recorder, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	report := health.Report{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &report))
+	s.False(report.Ready)
+	s.False(report.Keyshare.OK)
+}
+
+func (s *CheckerTestSuite) Test_ReadyHandler_Ready() {
+	recorder := httptest.NewRecorder()
+
+	s.checker.ReadyHandler(recorder, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("application/json", recorder.Header().Get("Content-Type"))
+}
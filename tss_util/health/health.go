@@ -4,18 +4,40 @@
 package health
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
 )
 
-// StartHealthEndpoint starts /health endpoint on provided port that returns ok on invocation
-func StartHealthEndpoint(port uint16) {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+// StartHealthEndpoint starts /health endpoint that returns ok on invocation together with
+// /health/live and /health/ready endpoints served by the checker and /metrics endpoint
+// served by the metrics handler on provided port. Endpoints are served over TLS if
+// tlsConfig is provided.
+func StartHealthEndpoint(port uint16, tlsConfig *tls.Config, checker *Checker, metrics http.Handler) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("ok"))
 	})
+	mux.HandleFunc("/health/live", checker.LiveHandler)
+	mux.HandleFunc("/health/ready", checker.ReadyHandler)
+	mux.Handle("/metrics", metrics)
 
-	_ = http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%d", port),
+		Handler:   mux,
+		TLSConfig: tlsConfig,
+	}
 	log.Info().Msgf("started /health endpoint on port %d", port)
+	var err error
+	if tlsConfig != nil {
+		// certificates are provided by the tls config
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("health endpoint on port %d stopped", port)
+	}
 }
@@ -0,0 +1,321 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package health
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"tss-demo/tss_util/comm"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+// ProtocolVersion is the version of peer communication. It has to be increased on every
+// change that makes tss messages incompatible with nodes running the previous version.
+const ProtocolVersion = 1
+
+const (
+	// PingSessionID and PongSessionID are sessions used by the health protocol. Pongs are sent on
+	// a separate session so that pinging and responding don't release each other streams.
+	PingSessionID = "healthping"
+	PongSessionID = "healthpong"
+)
+
+type ProbeStatus string
+
+const (
+	ProbeHealthy          ProbeStatus = "healthy"
+	ProbeUnreachable      ProbeStatus = "unreachable"
+	ProbeVersionMismatch  ProbeStatus = "version_mismatch"
+	ProbeKeyshareMismatch ProbeStatus = "keyshare_mismatch"
+)
+
+type PingMessage struct {
+	Nonce   string `json:"nonce"`
+	Version int    `json:"version"`
+}
+
+type PongMessage struct {
+	Nonce     string `json:"nonce"`
+	Version   int    `json:"version"`
+	PublicKey string `json:"publicKey"`
+}
+
+// ProbeResult is the outcome of a single ping to a peer. RTT is in nanoseconds.
+type ProbeResult struct {
+	Peer      peer.ID       `json:"peer"`
+	Time      time.Time     `json:"time"`
+	Status    ProbeStatus   `json:"status"`
+	RTT       time.Duration `json:"rtt,omitempty"`
+	Version   int           `json:"version,omitempty"`
+	PublicKey string        `json:"publicKey,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// PeerHealth summarizes probe history of a peer. History is ordered from the oldest probe.
+type PeerHealth struct {
+	Peer        peer.ID       `json:"peer"`
+	Status      ProbeStatus   `json:"status"`
+	AverageRTT  time.Duration `json:"averageRtt"`
+	SuccessRate float64       `json:"successRate"`
+	History     []ProbeResult `json:"history"`
+}
+
+// Prober implements ping/pong health protocol. Every ping is answered with the protocol
+// version and keyshare public key of the responder so that the prober can detect peers
+// that are reachable but unable to take part in signing.
+type Prober struct {
+	hostID      peer.ID
+	comm        comm.Communication
+	keyshares   KeyshareReader
+	historySize int
+
+	historyLock sync.RWMutex
+	history     map[peer.ID][]ProbeResult
+
+	Timeout time.Duration
+}
+
+func NewProber(hostID peer.ID, communication comm.Communication, keyshares KeyshareReader, historySize int) *Prober {
+	return &Prober{
+		hostID:      hostID,
+		comm:        communication,
+		keyshares:   keyshares,
+		historySize: historySize,
+		history:     make(map[peer.ID][]ProbeResult),
+		Timeout:     comm.HealthTimeout,
+	}
+}
+
+// Start responds to pings of other peers until the context is cancelled
+func (p *Prober) Start(ctx context.Context) {
+	msgChan := make(chan *comm.WrappedMessage)
+	subID := p.comm.Subscribe(PingSessionID, comm.HealthPingMsg, msgChan)
+	defer p.comm.UnSubscribe(subID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-msgChan:
+			p.respond(msg)
+		}
+	}
+}
+
+func (p *Prober) respond(msg *comm.WrappedMessage) {
+	var ping PingMessage
+	err := json.Unmarshal(msg.Payload, &ping)
+	if err != nil {
+		log.Warn().Err(err).Str("peer", msg.From.Pretty()).Msg("Received invalid health ping")
+		return
+	}
+	if ping.Version != ProtocolVersion {
+		log.Warn().Str("peer", msg.From.Pretty()).Msgf("Peer runs protocol version %d, local version is %d", ping.Version, ProtocolVersion)
+	}
+
+	pong, _ := json.Marshal(PongMessage{
+		Nonce:     ping.Nonce,
+		Version:   ProtocolVersion,
+		PublicKey: p.publicKey(),
+	})
+	// pongs are sent one by one so releasing the streams can't interrupt another response
+	defer p.comm.CloseSession(PongSessionID)
+	err = p.comm.Broadcast(peer.IDSlice{msg.From}, pong, comm.HealthPongMsg, PongSessionID)
+	if err != nil {
+		log.Warn().Err(err).Str("peer", msg.From.Pretty()).Msg("Failed responding to health ping")
+	}
+}
+
+// Probe pings peers and waits for their responses until the timeout. Results are
+// stored to the peer history and returned in the order of provided peers.
+func (p *Prober) Probe(peers peer.IDSlice) []ProbeResult {
+	nonce, err := newNonce()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed generating health ping nonce")
+		return nil
+	}
+	ping, _ := json.Marshal(PingMessage{
+		Nonce:   nonce,
+		Version: ProtocolVersion,
+	})
+	publicKey := p.publicKey()
+
+	msgChan := make(chan *comm.WrappedMessage, len(peers))
+	subID := p.comm.Subscribe(PongSessionID, comm.HealthPongMsg, msgChan)
+	defer p.comm.UnSubscribe(subID)
+	defer p.comm.CloseSession(PingSessionID)
+
+	// peers are pinged concurrently so that unreachable peers don't delay the others
+	sentAt := make(map[peer.ID]time.Time)
+	results := make(map[peer.ID]ProbeResult)
+	failed := make(chan ProbeResult, len(peers))
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	for _, peerID := range peers {
+		if _, ok := sentAt[peerID]; ok || peerID == p.hostID {
+			continue
+		}
+
+		sentAt[peerID] = time.Now()
+		wg.Add(1)
+		go func(peerID peer.ID, start time.Time) {
+			defer wg.Done()
+			err := p.comm.Broadcast(peer.IDSlice{peerID}, ping, comm.HealthPingMsg, PingSessionID)
+			if err != nil {
+				failed <- ProbeResult{
+					Peer:   peerID,
+					Time:   start,
+					Status: ProbeUnreachable,
+					Error:  err.Error(),
+				}
+			}
+		}(peerID, sentAt[peerID])
+	}
+
+	timeout := time.NewTimer(p.Timeout)
+	defer timeout.Stop()
+	for len(results) < len(sentAt) {
+		select {
+		case result := <-failed:
+			if _, done := results[result.Peer]; !done {
+				results[result.Peer] = result
+			}
+		case msg := <-msgChan:
+			start, ok := sentAt[msg.From]
+			if _, done := results[msg.From]; !ok || done {
+				continue
+			}
+
+			var pong PongMessage
+			err := json.Unmarshal(msg.Payload, &pong)
+			if err != nil || pong.Nonce != nonce {
+				continue
+			}
+			results[msg.From] = checkPong(msg.From, start, pong, publicKey)
+		case <-timeout.C:
+			for peerID, start := range sentAt {
+				if _, ok := results[peerID]; ok {
+					continue
+				}
+
+				results[peerID] = ProbeResult{
+					Peer:   peerID,
+					Time:   start,
+					Status: ProbeUnreachable,
+					Error:  fmt.Sprintf("no response within %s", p.Timeout),
+				}
+			}
+		}
+	}
+
+	ordered := make([]ProbeResult, 0, len(results))
+	for _, peerID := range peers {
+		result, ok := results[peerID]
+		if ok {
+			ordered = append(ordered, result)
+		}
+	}
+	p.record(ordered)
+	return ordered
+}
+
+// Health returns probe history summary of all probed peers ordered by peer ID
+func (p *Prober) Health() []PeerHealth {
+	p.historyLock.RLock()
+	defer p.historyLock.RUnlock()
+
+	peers := make([]PeerHealth, 0, len(p.history))
+	for peerID, history := range p.history {
+		peers = append(peers, summarize(peerID, history))
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Peer < peers[j].Peer
+	})
+	return peers
+}
+
+func (p *Prober) record(results []ProbeResult) {
+	p.historyLock.Lock()
+	defer p.historyLock.Unlock()
+
+	for _, result := range results {
+		history := append(p.history[result.Peer], result)
+		if len(history) > p.historySize {
+			history = history[len(history)-p.historySize:]
+		}
+		p.history[result.Peer] = history
+	}
+}
+
+// publicKey returns hex encoded compressed public key of the local keyshare or
+// an empty string if the node has no keyshare yet
+func (p *Prober) publicKey() string {
+	key, err := p.keyshares.GetKeyshare()
+	if err != nil || key.Key.ECDSAPub == nil {
+		return ""
+	}
+	return hex.EncodeToString(key.Key.ECDSAPub.ToBtcecPubKey().SerializeCompressed())
+}
+
+func checkPong(peerID peer.ID, start time.Time, pong PongMessage, publicKey string) ProbeResult {
+	result := ProbeResult{
+		Peer:      peerID,
+		Time:      start,
+		Status:    ProbeHealthy,
+		RTT:       time.Since(start),
+		Version:   pong.Version,
+		PublicKey: pong.PublicKey,
+	}
+	switch {
+	case pong.Version != ProtocolVersion:
+		result.Status = ProbeVersionMismatch
+		result.Error = fmt.Sprintf("peer runs protocol version %d, local version is %d", pong.Version, ProtocolVersion)
+	case publicKey != "" && pong.PublicKey != publicKey:
+		result.Status = ProbeKeyshareMismatch
+		result.Error = fmt.Sprintf("peer keyshare public key %q differs from local %q", pong.PublicKey, publicKey)
+	}
+	return result
+}
+
+func summarize(peerID peer.ID, history []ProbeResult) PeerHealth {
+	health := PeerHealth{
+		Peer:    peerID,
+		History: append([]ProbeResult{}, history...),
+	}
+	if len(history) == 0 {
+		return health
+	}
+
+	health.Status = history[len(history)-1].Status
+	var healthy int
+	var rtt time.Duration
+	for _, result := range history {
+		if result.Status == ProbeHealthy {
+			healthy++
+			rtt += result.RTT
+		}
+	}
+	health.SuccessRate = float64(healthy) / float64(len(history))
+	if healthy > 0 {
+		health.AverageRTT = rtt / time.Duration(healthy)
+	}
+	return health
+}
+
+func newNonce() (string, error) {
+	nonce := make([]byte, 8)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
@@ -0,0 +1,235 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package health_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+	"tss-demo/tss_util/comm"
+	mock_comm "tss-demo/tss_util/comm/mock"
+	"tss-demo/tss_util/health"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/tss"
+	"github.com/golang/mock/gomock"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProberTestSuite struct {
+	suite.Suite
+	mockCommunication *mock_comm.MockCommunication
+	keyshares         *fakeKeyshares
+	prober            *health.Prober
+	publicKey         string
+	peers             peer.IDSlice
+	pongs             chan *comm.WrappedMessage
+}
+
+func TestRunProberTestSuite(t *testing.T) {
+	suite.Run(t, new(ProberTestSuite))
+}
+
+func (s *ProberTestSuite) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.mockCommunication = mock_comm.NewMockCommunication(ctrl)
+	s.peers = peer.IDSlice{peer.ID("peer1"), peer.ID("peer2"), peer.ID("peer3")}
+
+	s.keyshares = &fakeKeyshares{}
+	s.keyshares.key.Key.ECDSAPub = crypto.ScalarBaseMult(tss.S256(), big.NewInt(1))
+	s.publicKey = hex.EncodeToString(s.keyshares.key.Key.ECDSAPub.ToBtcecPubKey().SerializeCompressed())
+
+	s.prober = health.NewProber(s.peers[0], s.mockCommunication, s.keyshares, 2)
+	s.prober.Timeout = 100 * time.Millisecond
+
+	s.mockCommunication.EXPECT().Subscribe(health.PongSessionID, comm.HealthPongMsg, gomock.Any()).DoAndReturn(
+		func(sessionID string, msgType comm.MessageType, channel chan *comm.WrappedMessage) comm.SubscriptionID {
+			s.pongs = channel
+			return comm.NewSubscriptionID(sessionID, msgType)
+		}).AnyTimes()
+	s.mockCommunication.EXPECT().UnSubscribe(gomock.Any()).AnyTimes()
+	s.mockCommunication.EXPECT().CloseSession(health.PingSessionID).AnyTimes()
+}
+
+// respondWith answers every ping with a pong built from the ping
+func (s *ProberTestSuite) respondWith(pong func(p peer.ID, ping health.PingMessage) *health.PongMessage) {
+	s.mockCommunication.EXPECT().Broadcast(gomock.Any(), gomock.Any(), comm.HealthPingMsg, health.PingSessionID).DoAndReturn(
+		func(peers peer.IDSlice, msg []byte, msgType comm.MessageType, sessionID string) error {
+			var ping health.PingMessage
+			_ = json.Unmarshal(msg, &ping)
+			response := pong(peers[0], ping)
+			if response == nil {
+				return nil
+			}
+
+			payload, _ := json.Marshal(response)
+			s.pongs <- &comm.WrappedMessage{
+				MessageType: comm.HealthPongMsg,
+				SessionID:   sessionID,
+				Payload:     payload,
+				From:        peers[0],
+			}
+			return nil
+		}).AnyTimes()
+}
+
+func (s *ProberTestSuite) Test_Probe_AllHealthy() {
+	s.respondWith(func(p peer.ID, ping health.PingMessage) *health.PongMessage {
+		return &health.PongMessage{Nonce: ping.Nonce, Version: health.ProtocolVersion, PublicKey: s.publicKey}
+	})
+
+	results := s.prober.Probe(s.peers)
+
+	s.Len(results, 2)
+	s.Equal(s.peers[1], results[0].Peer)
+	s.Equal(s.peers[2], results[1].Peer)
+	for _, result := range results {
+		s.Equal(health.ProbeHealthy, result.Status)
+		s.Equal(s.publicKey, result.PublicKey)
+		s.Empty(result.Error)
+	}
+}
+
+func (s *ProberTestSuite) Test_Probe_DetectsMismatchesAndUnreachablePeers() {
+	s.peers = append(s.peers, peer.ID("peer4"))
+	s.respondWith(func(p peer.ID, ping health.PingMessage) *health.PongMessage {
+		switch p {
+		case s.peers[1]:
+			return &health.PongMessage{Nonce: ping.Nonce, Version: health.ProtocolVersion + 1, PublicKey: s.publicKey}
+		case s.peers[2]:
+			return &health.PongMessage{Nonce: ping.Nonce, Version: health.ProtocolVersion, PublicKey: "other"}
+		default:
+			return nil
+		}
+	})
+
+	results := s.prober.Probe(s.peers)
+
+	s.Len(results, 3)
+	s.Equal(health.ProbeVersionMismatch, results[0].Status)
+	s.Equal(health.ProbeKeyshareMismatch, results[1].Status)
+	s.Equal(health.ProbeUnreachable, results[2].Status)
+	s.NotEmpty(results[2].Error)
+}
+
+func (s *ProberTestSuite) Test_Probe_StalePongIgnored() {
+	s.respondWith(func(p peer.ID, ping health.PingMessage) *health.PongMessage {
+		return &health.PongMessage{Nonce: "stale", Version: health.ProtocolVersion, PublicKey: s.publicKey}
+	})
+
+	results := s.prober.Probe(s.peers[:2])
+
+	s.Len(results, 1)
+	s.Equal(health.ProbeUnreachable, results[0].Status)
+}
+
+func (s *ProberTestSuite) Test_Probe_BroadcastFailure() {
+	s.mockCommunication.EXPECT().Broadcast(gomock.Any(), gomock.Any(), comm.HealthPingMsg, health.PingSessionID).Return(
+		&comm.CommunicationError{Peer: s.peers[1], Err: errors.New("error")},
+	)
+
+	results := s.prober.Probe(s.peers[:2])
+
+	s.Len(results, 1)
+	s.Equal(health.ProbeUnreachable, results[0].Status)
+}
+
+func (s *ProberTestSuite) Test_Probe_PingsPeersConcurrently() {
+	pinged := make(chan struct{})
+	s.mockCommunication.EXPECT().Broadcast(peer.IDSlice{s.peers[1]}, gomock.Any(), comm.HealthPingMsg, health.PingSessionID).DoAndReturn(
+		func(peers peer.IDSlice, msg []byte, msgType comm.MessageType, sessionID string) error {
+			select {
+			case <-pinged:
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("blocked")
+			}
+		})
+	s.mockCommunication.EXPECT().Broadcast(peer.IDSlice{s.peers[2]}, gomock.Any(), comm.HealthPingMsg, health.PingSessionID).DoAndReturn(
+		func(peers peer.IDSlice, msg []byte, msgType comm.MessageType, sessionID string) error {
+			close(pinged)
+			return errors.New("error")
+		})
+
+	results := s.prober.Probe(s.peers)
+
+	s.Len(results, 2)
+	s.Equal("no response within 100ms", results[0].Error)
+	s.Equal("error", results[1].Error)
+}
+
+func (s *ProberTestSuite) Test_Probe_MissingLocalKeyshare_SkipsKeyCheck() {
+	s.keyshares.err = errors.New("missing keyshare")
+	s.respondWith(func(p peer.ID, ping health.PingMessage) *health.PongMessage {
+		return &health.PongMessage{Nonce: ping.Nonce, Version: health.ProtocolVersion, PublicKey: "other"}
+	})
+
+	results := s.prober.Probe(s.peers[:2])
+
+	s.Equal(health.ProbeHealthy, results[0].Status)
+}
+
+func (s *ProberTestSuite) Test_Health_KeepsLimitedHistory() {
+	healthy := true
+	s.respondWith(func(p peer.ID, ping health.PingMessage) *health.PongMessage {
+		if !healthy {
+			return nil
+		}
+		return &health.PongMessage{Nonce: ping.Nonce, Version: health.ProtocolVersion, PublicKey: s.publicKey}
+	})
+
+	s.prober.Probe(s.peers[:2])
+	s.prober.Probe(s.peers[:2])
+	healthy = false
+	s.prober.Probe(s.peers[:2])
+
+	peers := s.prober.Health()
+	s.Len(peers, 1)
+	s.Equal(s.peers[1], peers[0].Peer)
+	s.Equal(health.ProbeUnreachable, peers[0].Status)
+	s.Len(peers[0].History, 2)
+	s.Equal(0.5, peers[0].SuccessRate)
+	s.Equal(peers[0].History[0].RTT, peers[0].AverageRTT)
+}
+
+func (s *ProberTestSuite) Test_Start_RespondsToPing() {
+	pings := make(chan chan *comm.WrappedMessage, 1)
+	s.mockCommunication.EXPECT().Subscribe(health.PingSessionID, comm.HealthPingMsg, gomock.Any()).DoAndReturn(
+		func(sessionID string, msgType comm.MessageType, channel chan *comm.WrappedMessage) comm.SubscriptionID {
+			pings <- channel
+			return comm.NewSubscriptionID(sessionID, msgType)
+		})
+	sent := make(chan health.PongMessage, 1)
+	s.mockCommunication.EXPECT().Broadcast(peer.IDSlice{s.peers[1]}, gomock.Any(), comm.HealthPongMsg, health.PongSessionID).DoAndReturn(
+		func(peers peer.IDSlice, msg []byte, msgType comm.MessageType, sessionID string) error {
+			var pong health.PongMessage
+			_ = json.Unmarshal(msg, &pong)
+			sent <- pong
+			return nil
+		})
+	s.mockCommunication.EXPECT().CloseSession(health.PongSessionID).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.prober.Start(ctx)
+
+	ping, _ := json.Marshal(health.PingMessage{Nonce: "nonce", Version: health.ProtocolVersion})
+	(<-pings) <- &comm.WrappedMessage{
+		MessageType: comm.HealthPingMsg,
+		SessionID:   health.PingSessionID,
+		Payload:     ping,
+		From:        s.peers[1],
+	}
+
+	s.Equal(health.PongMessage{
+		Nonce:     "nonce",
+		Version:   health.ProtocolVersion,
+		PublicKey: s.publicKey,
+	}, <-sent)
+}
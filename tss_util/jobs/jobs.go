@@ -5,8 +5,9 @@ package jobs
 
 import (
 	"time"
-	"tss-demo/tss_util/comm"
-	"tss-demo/tss_util/comm/p2p"
+	"tss-demo/tss_util/health"
+	"tss-demo/tss_util/topology"
+	"tss-demo/tss_util/tss/liveness"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -15,23 +16,52 @@ import (
 
 type RelayerStatusMeter interface {
 	TrackRelayerStatus(unavailable peer.IDSlice, all peer.IDSlice)
+	TrackPeerStatus(peer peer.ID, metadata topology.PeerMetadata, available bool)
+	TrackPeerProbe(peer peer.ID, metadata topology.PeerMetadata, status string, rtt time.Duration)
 }
 
-func StartCommunicationHealthCheckJob(h host.Host, interval time.Duration, metrics RelayerStatusMeter) {
-	healthComm := p2p.NewCommunication(h, "p2p/health")
+type HealthProber interface {
+	Probe(peers peer.IDSlice) []health.ProbeResult
+}
+
+// StartCommunicationHealthCheckJob probes all peers from peerstore on every interval. Check
+// is executed immediately on refresh, e.g. after topology changes. Round-trip times and
+// failures are recorded to the liveness tracker used to choose signing subsets.
+func StartCommunicationHealthCheckJob(
+	h host.Host,
+	interval time.Duration,
+	metrics RelayerStatusMeter,
+	refresh <-chan struct{},
+	topologies topology.TopologyGetter,
+	tracker *liveness.Tracker,
+	prober HealthProber,
+) {
 	for {
-		time.Sleep(interval)
+		select {
+		case <-time.After(interval):
+		case <-refresh:
+		}
 		log.Info().Msg("Starting communication health check")
 
+		nt := topology.Current(topologies)
 		all := h.Peerstore().Peers()
 		unavailable := make(peer.IDSlice, 0)
 
-		communicationErrors := comm.ExecuteCommHealthCheck(healthComm, h.Peerstore().Peers())
-		for _, cerr := range communicationErrors {
-			log.Err(cerr).Msg("communication error on ExecuteCommHealthCheck")
-			unavailable = append(unavailable, cerr.Peer)
-		}
+		results := prober.Probe(all)
+		for _, result := range results {
+			metadata := nt.PeerMetadata(result.Peer)
+			metrics.TrackPeerProbe(result.Peer, metadata, string(result.Status), result.RTT)
 
+			available := result.Status == health.ProbeHealthy
+			if available {
+				tracker.RecordResponse(result.Peer, result.RTT)
+			} else {
+				log.Warn().Str("peer", nt.PeerName(result.Peer)).Str("status", string(result.Status)).Msgf("health probe failed: %s", result.Error)
+				tracker.RecordFailure(result.Peer)
+				unavailable = append(unavailable, result.Peer)
+			}
+			metrics.TrackPeerStatus(result.Peer, metadata, available)
+		}
 		metrics.TrackRelayerStatus(unavailable, all)
 	}
 }
@@ -5,14 +5,19 @@ package keyshare
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 
 	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+// ErrKeyshareNotFound is returned if the node has no keyshare yet
+var ErrKeyshareNotFound = errors.New("keyshare not found")
+
 // Keyshare stores key received from keygen or resharing
 // and treshold and peers from current signing committee
 type ECDSAKeyshare struct {
@@ -29,6 +34,15 @@ func NewECDSAKeyshare(key keygen.LocalPartySaveData, threshold int, peers []peer
 	}
 }
 
+// ID returns address of the MPC public key used to identify the key in logs or an
+// empty string if the keyshare has no key
+func (k ECDSAKeyshare) ID() string {
+	if k.Key.ECDSAPub == nil {
+		return ""
+	}
+	return ethcrypto.PubkeyToAddress(*k.Key.ECDSAPub.ToBtcecPubKey().ToECDSA()).Hex()
+}
+
 type ECDSAKeyshareStore struct {
 	mu   sync.Mutex
 	path string
@@ -47,6 +61,12 @@ func (ks *ECDSAKeyshareStore) LockKeyshare() {
 	ks.mu.Lock()
 }
 
+// TryLockKeyshare locks keyshare if it is not already locked
+// by a pending tss process and reports whether it succeeded.
+func (ks *ECDSAKeyshareStore) TryLockKeyshare() bool {
+	return ks.mu.TryLock()
+}
+
 // UnlockKeyshare unlocks keyshare to allow for tss processes to continue
 func (ks *ECDSAKeyshareStore) UnlockKeyshare() {
 	ks.mu.Unlock()
@@ -76,6 +96,9 @@ func (ks *ECDSAKeyshareStore) GetKeyshare() (ECDSAKeyshare, error) {
 	k := ECDSAKeyshare{}
 
 	kb, err := os.ReadFile(ks.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, fmt.Errorf("%w: %s", ErrKeyshareNotFound, ks.path)
+	}
 	if err != nil {
 		return k, fmt.Errorf("error on reading keyshare file: %s", err)
 	}
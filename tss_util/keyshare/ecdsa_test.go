@@ -4,11 +4,14 @@
 package keyshare_test
 
 import (
+	"math/big"
 	"os"
 	"testing"
 	"tss-demo/tss_util/keyshare"
 
+	"github.com/binance-chain/tss-lib/crypto"
 	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/suite"
 )
@@ -51,3 +54,11 @@ func (s *ECDSAKeyshareStoreTestSuite) Test_StoreAndRetrieveShare() {
 
 	s.Equal(keyshare, storedKeyshare)
 }
+
+func (s *ECDSAKeyshareStoreTestSuite) Test_ID() {
+	key := keygen.NewLocalPartySaveData(1)
+	s.Equal("", keyshare.NewECDSAKeyshare(key, 1, []peer.ID{}).ID())
+
+	key.ECDSAPub = crypto.ScalarBaseMult(tss.S256(), big.NewInt(1))
+	s.Equal("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", keyshare.NewECDSAKeyshare(key, 1, []peer.ID{}).ID())
+}
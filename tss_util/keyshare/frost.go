@@ -4,7 +4,9 @@
 package keyshare
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -22,6 +24,15 @@ type FrostKeyshare struct {
 	Peers     []peer.ID
 }
 
+// ID returns hex encoded taproot public key used to identify the key in logs or an
+// empty string if the keyshare has no key
+func (k FrostKeyshare) ID() string {
+	if k.Key == nil {
+		return ""
+	}
+	return hex.EncodeToString(k.Key.PublicKey)
+}
+
 type frostKey struct {
 	ID                 party.ID
 	Threshold          int
@@ -63,6 +74,12 @@ func (ks *FrostKeyshareStore) LockKeyshare() {
 	ks.mu.Lock()
 }
 
+// TryLockKeyshare locks keyshare if it is not already locked
+// by a pending tss process and reports whether it succeeded.
+func (ks *FrostKeyshareStore) TryLockKeyshare() bool {
+	return ks.mu.TryLock()
+}
+
 // UnlockKeyshare unlocks keyshare to allow for tss processes to continue
 func (ks *FrostKeyshareStore) UnlockKeyshare() {
 	ks.mu.Unlock()
@@ -118,6 +135,9 @@ func (ks *FrostKeyshareStore) GetKeyshare() (FrostKeyshare, error) {
 	k := FrostKeyshare{}
 
 	kb, err := os.ReadFile(ks.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, fmt.Errorf("%w: %s", ErrKeyshareNotFound, ks.path)
+	}
 	if err != nil {
 		return k, fmt.Errorf("error on reading keyshare file: %s", err)
 	}
@@ -0,0 +1,38 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits
+
+import (
+	"math"
+	"time"
+)
+
+// Bucket is a token bucket refilled at a constant rate. Zero bucket is full.
+type Bucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// take refills the bucket at ratePerMinute up to burst tokens and takes a token from it.
+// Returns false if the bucket is empty.
+func (b *Bucket) take(now time.Time, ratePerMinute int, burst int) bool {
+	if b.Updated.IsZero() {
+		b.Tokens = float64(burst)
+		b.Updated = now
+	} else if now.After(b.Updated) {
+		b.Tokens = math.Min(float64(burst), b.Tokens+now.Sub(b.Updated).Minutes()*float64(ratePerMinute))
+		b.Updated = now
+	}
+
+	if b.Tokens < 1 {
+		return false
+	}
+	b.Tokens--
+	return true
+}
+
+// refund returns a taken token to the bucket up to burst tokens
+func (b *Bucket) refund(burst int) {
+	b.Tokens = math.Min(float64(burst), b.Tokens+1)
+}
@@ -0,0 +1,189 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+	"tss-demo/tss_util/tss_config/relayer"
+)
+
+var (
+	ErrRateLimited       = errors.New("rate limit exceeded")
+	ErrVelocityExceeded  = errors.New("velocity limit exceeded")
+	ErrTransactionNeeded = errors.New("transaction is required to check velocity limits")
+)
+
+// Spend is the value of a signed transaction
+type Spend struct {
+	Value *big.Int  `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// Limiter enforces rate limits per API client and rate and velocity limits per key.
+// Counters are persisted in the store after every accepted request.
+type Limiter struct {
+	store  *Store
+	config relayer.LimitsConfig
+
+	lock sync.Mutex
+}
+
+// NewLimiter creates the limiter. Store is only used if config enables any of the limits.
+func NewLimiter(store *Store, config relayer.LimitsConfig) *Limiter {
+	return &Limiter{
+		store:  store,
+		config: config,
+	}
+}
+
+// AllowClient takes a request token of the API client
+func (l *Limiter) AllowClient(client string) error {
+	if l.config.ClientRatePerMinute == 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	bucket, err := l.store.clientBucket(client)
+	if err != nil {
+		return err
+	}
+	if !bucket.take(time.Now(), l.config.ClientRatePerMinute, l.config.ClientBurst) {
+		return fmt.Errorf("%w: client %s is limited to %d requests per minute", ErrRateLimited, client, l.config.ClientRatePerMinute)
+	}
+	return l.store.storeClientBucket(client, bucket)
+}
+
+// ReserveSign takes a session token of the key for every value and adds the transaction
+// values to the velocity windows of the key. Values of a batch are reserved together and
+// nothing is reserved if any of the limits is exceeded. Value is nil if the signed hash
+// is not a known transaction.
+func (l *Limiter) ReserveSign(keyID string, values ...*big.Int) error {
+	if l.config.KeyRatePerMinute == 0 && len(l.config.Velocity) == 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	var bucket *Bucket
+	if l.config.KeyRatePerMinute > 0 {
+		var err error
+		bucket, err = l.store.keyBucket(keyID)
+		if err != nil {
+			return err
+		}
+		for range values {
+			if !bucket.take(now, l.config.KeyRatePerMinute, l.config.KeyBurst) {
+				return fmt.Errorf("%w: key %s is limited to %d sessions per minute", ErrRateLimited, keyID, l.config.KeyRatePerMinute)
+			}
+		}
+	}
+
+	var spends []Spend
+	if len(l.config.Velocity) > 0 {
+		var err error
+		spends, err = l.store.spends(keyID)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if value == nil {
+				return ErrTransactionNeeded
+			}
+			spends, err = l.spend(spends, now, value)
+			if err != nil {
+				return fmt.Errorf("%w: key %s %s", ErrVelocityExceeded, keyID, err)
+			}
+		}
+	}
+
+	if bucket != nil {
+		err := l.store.storeKeyBucket(keyID, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	if spends != nil {
+		return l.store.storeSpends(keyID, spends)
+	}
+	return nil
+}
+
+// ReleaseSign returns session tokens and removes spends reserved by ReserveSign for the
+// values when the session didn't produce signatures. Spends of equal values are
+// interchangeable, so the latest spend of each value is removed.
+func (l *Limiter) ReleaseSign(keyID string, values ...*big.Int) error {
+	if l.config.KeyRatePerMinute == 0 && len(l.config.Velocity) == 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.config.KeyRatePerMinute > 0 {
+		bucket, err := l.store.keyBucket(keyID)
+		if err != nil {
+			return err
+		}
+		for range values {
+			bucket.refund(l.config.KeyBurst)
+		}
+		err = l.store.storeKeyBucket(keyID, bucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(l.config.Velocity) > 0 {
+		spends, err := l.store.spends(keyID)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			for i := len(spends) - 1; i >= 0; i-- {
+				if value != nil && spends[i].Value.Cmp(value) == 0 {
+					spends = append(spends[:i], spends[i+1:]...)
+					break
+				}
+			}
+		}
+		return l.store.storeSpends(keyID, spends)
+	}
+	return nil
+}
+
+// spend checks that the value fits every velocity window and returns spends within the
+// longest window together with the new spend
+func (l *Limiter) spend(spends []Spend, now time.Time, value *big.Int) ([]Spend, error) {
+	var longest time.Duration
+	for _, limit := range l.config.Velocity {
+		total := new(big.Int).Set(value)
+		for _, s := range spends {
+			if now.Sub(s.Time) < limit.Window {
+				total.Add(total, s.Value)
+			}
+		}
+		if total.Cmp(limit.MaxValue) > 0 {
+			return nil, fmt.Errorf("would sign %s wei within %s, limit is %s", total, limit.Window, limit.MaxValue)
+		}
+		if limit.Window > longest {
+			longest = limit.Window
+		}
+	}
+
+	kept := make([]Spend, 0, len(spends)+1)
+	for _, s := range spends {
+		if now.Sub(s.Time) < longest {
+			kept = append(kept, s)
+		}
+	}
+	return append(kept, Spend{Value: value, Time: now}), nil
+}
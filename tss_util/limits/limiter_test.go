@@ -0,0 +1,175 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+	"tss-demo/tss_util/limits"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/sygmaprotocol/sygma-core/store/lvldb"
+)
+
+type LimiterTestSuite struct {
+	suite.Suite
+	path string
+	dbs  []*lvldb.LVLDB
+}
+
+func TestRunLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
+
+func (s *LimiterTestSuite) SetupTest() {
+	s.path = s.T().TempDir()
+	s.dbs = nil
+}
+
+func (s *LimiterTestSuite) TearDownTest() {
+	for _, db := range s.dbs {
+		_ = db.Close()
+	}
+}
+
+// limiter opens the counters database, closing the previously opened one to simulate restart
+func (s *LimiterTestSuite) limiter(config relayer.LimitsConfig) *limits.Limiter {
+	if len(s.dbs) > 0 {
+		_ = s.dbs[len(s.dbs)-1].Close()
+	}
+	db, err := lvldb.NewLvlDB(s.path)
+	s.Nil(err)
+	s.dbs = append(s.dbs, db)
+	return limits.NewLimiter(limits.NewStore(db), config)
+}
+
+func (s *LimiterTestSuite) Test_DisabledLimits() {
+	limiter := limits.NewLimiter(nil, relayer.LimitsConfig{})
+
+	for i := 0; i < 10; i++ {
+		s.Nil(limiter.AllowClient("wallet"))
+		s.Nil(limiter.ReserveSign("key", nil))
+	}
+}
+
+func (s *LimiterTestSuite) Test_AllowClient_BurstPerClient() {
+	limiter := s.limiter(relayer.LimitsConfig{ClientRatePerMinute: 1, ClientBurst: 2})
+
+	s.Nil(limiter.AllowClient("wallet"))
+	s.Nil(limiter.AllowClient("wallet"))
+	s.True(errors.Is(limiter.AllowClient("wallet"), limits.ErrRateLimited))
+	s.Nil(limiter.AllowClient("ops"))
+}
+
+func (s *LimiterTestSuite) Test_AllowClient_Refill() {
+	limiter := s.limiter(relayer.LimitsConfig{ClientRatePerMinute: 6000, ClientBurst: 1})
+
+	s.Nil(limiter.AllowClient("wallet"))
+	s.NotNil(limiter.AllowClient("wallet"))
+	time.Sleep(20 * time.Millisecond)
+	s.Nil(limiter.AllowClient("wallet"))
+}
+
+func (s *LimiterTestSuite) Test_ReserveSign_KeyRate() {
+	limiter := s.limiter(relayer.LimitsConfig{KeyRatePerMinute: 1, KeyBurst: 1})
+
+	s.Nil(limiter.ReserveSign("key-1", nil))
+	s.True(errors.Is(limiter.ReserveSign("key-1", nil), limits.ErrRateLimited))
+	s.Nil(limiter.ReserveSign("key-2", nil))
+}
+
+func (s *LimiterTestSuite) Test_ReserveSign_Velocity() {
+	limiter := s.limiter(relayer.LimitsConfig{
+		Velocity: []relayer.VelocityLimit{
+			{Window: time.Hour, MaxValue: big.NewInt(100)},
+			{Window: time.Millisecond, MaxValue: big.NewInt(70)},
+		},
+	})
+
+	s.Nil(limiter.ReserveSign("key", big.NewInt(60)))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(50)), limits.ErrVelocityExceeded))
+	// spend leaves the short window but is still counted in the long one
+	time.Sleep(5 * time.Millisecond)
+	s.Nil(limiter.ReserveSign("key", big.NewInt(40)))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(1)), limits.ErrVelocityExceeded))
+	s.Equal(limits.ErrTransactionNeeded, limiter.ReserveSign("key", nil))
+}
+
+func (s *LimiterTestSuite) Test_ReserveSign_RejectedSpendNotCounted() {
+	limiter := s.limiter(relayer.LimitsConfig{
+		KeyRatePerMinute: 1,
+		KeyBurst:         2,
+		Velocity:         []relayer.VelocityLimit{{Window: time.Hour, MaxValue: big.NewInt(100)}},
+	})
+
+	s.NotNil(limiter.ReserveSign("key", big.NewInt(101)))
+
+	s.Nil(limiter.ReserveSign("key", big.NewInt(100)))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(0)))
+}
+
+func (s *LimiterTestSuite) Test_ReserveSign_BatchReservedTogether() {
+	limiter := s.limiter(relayer.LimitsConfig{
+		KeyRatePerMinute: 1,
+		KeyBurst:         3,
+		Velocity:         []relayer.VelocityLimit{{Window: time.Hour, MaxValue: big.NewInt(100)}},
+	})
+
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(60), big.NewInt(50)), limits.ErrVelocityExceeded))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1)), limits.ErrRateLimited))
+	s.Equal(limits.ErrTransactionNeeded, limiter.ReserveSign("key", big.NewInt(1), nil))
+
+	s.Nil(limiter.ReserveSign("key", big.NewInt(60), big.NewInt(40)))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(1)), limits.ErrVelocityExceeded))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(0)))
+}
+
+func (s *LimiterTestSuite) Test_CountersSurviveRestart() {
+	config := relayer.LimitsConfig{
+		ClientRatePerMinute: 1,
+		ClientBurst:         1,
+		KeyRatePerMinute:    1,
+		KeyBurst:            2,
+		Velocity:            []relayer.VelocityLimit{{Window: time.Hour, MaxValue: big.NewInt(100)}},
+	}
+	limiter := s.limiter(config)
+	s.Nil(limiter.AllowClient("wallet"))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(80)))
+
+	limiter = s.limiter(config)
+
+	s.True(errors.Is(limiter.AllowClient("wallet"), limits.ErrRateLimited))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(30)), limits.ErrVelocityExceeded))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(20)))
+}
+
+func (s *LimiterTestSuite) Test_ReleaseSign_RefundsReservation() {
+	limiter := s.limiter(relayer.LimitsConfig{
+		KeyRatePerMinute: 1,
+		KeyBurst:         2,
+		Velocity:         []relayer.VelocityLimit{{Window: time.Hour, MaxValue: big.NewInt(100)}},
+	})
+	s.Nil(limiter.ReserveSign("key", big.NewInt(60), big.NewInt(40)))
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(1)), limits.ErrRateLimited))
+
+	s.Nil(limiter.ReleaseSign("key", big.NewInt(60), big.NewInt(40)))
+
+	s.Nil(limiter.ReserveSign("key", big.NewInt(70), big.NewInt(30)))
+}
+
+func (s *LimiterTestSuite) Test_ReleaseSign_KeepsOtherSpends() {
+	limiter := s.limiter(relayer.LimitsConfig{
+		Velocity: []relayer.VelocityLimit{{Window: time.Hour, MaxValue: big.NewInt(100)}},
+	})
+	s.Nil(limiter.ReserveSign("key", big.NewInt(60)))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(40)))
+
+	s.Nil(limiter.ReleaseSign("key", big.NewInt(40)))
+
+	s.True(errors.Is(limiter.ReserveSign("key", big.NewInt(41)), limits.ErrVelocityExceeded))
+	s.Nil(limiter.ReserveSign("key", big.NewInt(40)))
+}
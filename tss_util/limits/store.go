@@ -0,0 +1,77 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sygmaprotocol/sygma-core/store"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	clientBucketKey = "limits:client:%s"
+	keyBucketKey    = "limits:key:%s"
+	velocityKey     = "limits:velocity:%s"
+)
+
+// Store persists limit counters so that they survive restarts
+type Store struct {
+	db store.KeyValueReaderWriter
+}
+
+func NewStore(db store.KeyValueReaderWriter) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// get decodes the value of the key into v. Missing keys leave v unchanged.
+func (s *Store) get(key string, v interface{}) error {
+	data, err := s.db.GetByKey([]byte(key))
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *Store) set(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.SetByKey([]byte(key), data)
+}
+
+func (s *Store) clientBucket(client string) (*Bucket, error) {
+	bucket := &Bucket{}
+	return bucket, s.get(fmt.Sprintf(clientBucketKey, client), bucket)
+}
+
+func (s *Store) storeClientBucket(client string, bucket *Bucket) error {
+	return s.set(fmt.Sprintf(clientBucketKey, client), bucket)
+}
+
+func (s *Store) keyBucket(keyID string) (*Bucket, error) {
+	bucket := &Bucket{}
+	return bucket, s.get(fmt.Sprintf(keyBucketKey, keyID), bucket)
+}
+
+func (s *Store) storeKeyBucket(keyID string, bucket *Bucket) error {
+	return s.set(fmt.Sprintf(keyBucketKey, keyID), bucket)
+}
+
+func (s *Store) spends(keyID string) ([]Spend, error) {
+	spends := []Spend{}
+	return spends, s.get(fmt.Sprintf(velocityKey, keyID), &spends)
+}
+
+func (s *Store) storeSpends(keyID string, spends []Spend) error {
+	return s.set(fmt.Sprintf(velocityKey, keyID), spends)
+}
@@ -0,0 +1,46 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var ErrHashMismatch = errors.New("hash is not the signing hash of the transaction")
+
+// TxValue decodes the canonical encoding of the unsigned transaction, the RLP of legacy
+// transactions and the type prefixed RLP of typed transactions, and returns its value
+// after checking that the hash is the signing hash of the transaction. Legacy
+// transactions are hashed with the provided chain ID, typed transactions with their own.
+func TxValue(encodedTx []byte, chainID *big.Int, hash string) (*big.Int, error) {
+	tx := new(types.Transaction)
+	err := tx.UnmarshalBinary(encodedTx)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding transaction: %w", err)
+	}
+
+	hasChainID := chainID != nil && chainID.Sign() != 0
+	if tx.Type() == types.LegacyTxType {
+		if !hasChainID {
+			return nil, errors.New("chain ID is required for legacy transactions")
+		}
+	} else {
+		if hasChainID && chainID.Cmp(tx.ChainId()) != 0 {
+			return nil, fmt.Errorf("transaction chain ID %s differs from chain ID %s", tx.ChainId(), chainID)
+		}
+		chainID = tx.ChainId()
+	}
+
+	signingHash := types.LatestSignerForChainID(chainID).Hash(tx)
+	if hex.EncodeToString(signingHash[:]) != strings.ToLower(strings.TrimPrefix(hash, "0x")) {
+		return nil, ErrHashMismatch
+	}
+	return tx.Value(), nil
+}
@@ -0,0 +1,88 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package limits_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"tss-demo/tss_util/limits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/suite"
+)
+
+type TxValueTestSuite struct {
+	suite.Suite
+	to common.Address
+}
+
+func TestRunTxValueTestSuite(t *testing.T) {
+	suite.Run(t, new(TxValueTestSuite))
+}
+
+func (s *TxValueTestSuite) SetupTest() {
+	s.to = common.HexToAddress("0x9591bB8DaBe3291377f2dd4C5F3fe71fDe58957B")
+}
+
+func (s *TxValueTestSuite) Test_DynamicFeeTx() {
+	inner := &types.DynamicFeeTx{ChainID: big.NewInt(11155111), Nonce: 1, To: &s.to, Value: big.NewInt(1000), Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}
+	encoded, _ := types.NewTx(inner).MarshalBinary()
+	hash := types.NewLondonSigner(inner.ChainID).Hash(types.NewTx(inner))
+
+	value, err := limits.TxValue(encoded, nil, "0x"+hex.EncodeToString(hash[:]))
+
+	s.Nil(err)
+	s.Equal(big.NewInt(1000), value)
+}
+
+func (s *TxValueTestSuite) Test_LegacyTx() {
+	inner := &types.LegacyTx{Nonce: 1, To: &s.to, Value: big.NewInt(5), Gas: 21000, GasPrice: big.NewInt(1)}
+	encoded, _ := types.NewTx(inner).MarshalBinary()
+	hash := types.NewEIP155Signer(big.NewInt(1)).Hash(types.NewTx(inner))
+
+	value, err := limits.TxValue(encoded, big.NewInt(1), hex.EncodeToString(hash[:]))
+	s.Nil(err)
+	s.Equal(big.NewInt(5), value)
+
+	_, err = limits.TxValue(encoded, nil, hex.EncodeToString(hash[:]))
+	s.NotNil(err)
+}
+
+func (s *TxValueTestSuite) Test_AccessListTx() {
+	inner := &types.AccessListTx{
+		ChainID:    big.NewInt(5),
+		Nonce:      2,
+		To:         &s.to,
+		Value:      big.NewInt(7),
+		Gas:        30000,
+		GasPrice:   big.NewInt(1),
+		AccessList: types.AccessList{{Address: s.to, StorageKeys: []common.Hash{{1}}}},
+	}
+	encoded, _ := types.NewTx(inner).MarshalBinary()
+	hash := types.LatestSignerForChainID(inner.ChainID).Hash(types.NewTx(inner))
+
+	value, err := limits.TxValue(encoded, big.NewInt(5), hex.EncodeToString(hash[:]))
+	s.Nil(err)
+	s.Equal(big.NewInt(7), value)
+
+	_, err = limits.TxValue(encoded, big.NewInt(1), hex.EncodeToString(hash[:]))
+	s.NotNil(err)
+}
+
+func (s *TxValueTestSuite) Test_HashMismatch() {
+	inner := &types.DynamicFeeTx{ChainID: big.NewInt(1), To: &s.to, Value: big.NewInt(1), GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}
+	encoded, _ := types.NewTx(inner).MarshalBinary()
+
+	_, err := limits.TxValue(encoded, nil, "b07e3536cce658dc1615e6e43ee0af85ddeef27de5b237d806a8296f83fec261")
+
+	s.Equal(limits.ErrHashMismatch, err)
+}
+
+func (s *TxValueTestSuite) Test_InvalidTx() {
+	_, err := limits.TxValue([]byte("transaction"), nil, "")
+
+	s.NotNil(err)
+}
@@ -0,0 +1,59 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"time"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+type HealthMetrics struct {
+	opts         metric.MeasurementOption
+	peerRTT      api.Float64Histogram
+	healthProbes api.Int64Counter
+}
+
+// NewHealthMetrics initializes metrics related to peer health probes
+func NewHealthMetrics(ctx context.Context, meter metric.Meter, opts metric.MeasurementOption) (*HealthMetrics, error) {
+	peerRTT, err := meter.Float64Histogram(
+		"relayer.PeerRTT",
+		api.WithUnit("s"),
+		api.WithDescription("Round-trip time of health pings to each peer"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	healthProbes, err := meter.Int64Counter(
+		"relayer.HealthProbes",
+		api.WithDescription("Number of health probes to each peer by result status"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HealthMetrics{
+		opts:         opts,
+		peerRTT:      peerRTT,
+		healthProbes: healthProbes,
+	}, nil
+}
+
+// TrackPeerProbe counts health probe result of a peer and records its round-trip time
+// if the peer responded
+func (m *HealthMetrics) TrackPeerProbe(p peer.ID, metadata topology.PeerMetadata, status string, rtt time.Duration) {
+	peerAttributes := api.WithAttributes(
+		attribute.String("peer", p.Pretty()),
+		attribute.String("name", metadata.Name),
+	)
+	m.healthProbes.Add(context.Background(), 1, m.opts, peerAttributes, api.WithAttributes(attribute.String("status", status)))
+	if rtt > 0 {
+		m.peerRTT.Record(context.Background(), rtt.Seconds(), m.opts, peerAttributes)
+	}
+}
@@ -15,6 +15,10 @@ type SygmaMetrics struct {
 	*observability.RelayerMetrics
 	*MpcMetrics
 	*HostMetrics
+	*TopologyMetrics
+	*HealthMetrics
+	*SessionMetrics
+	*P2PMetrics
 }
 
 // NewSygmaMetrics creates an instance of metrics
@@ -36,9 +40,33 @@ func NewSygmaMetrics(ctx context.Context, meter api.Meter, env, relayerID, versi
 		return nil, err
 	}
 
+	topologyMetrics, err := NewTopologyMetrics(ctx, meter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	healthMetrics, err := NewHealthMetrics(ctx, meter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionMetrics, err := NewSessionMetrics(ctx, meter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p2pMetrics, err := NewP2PMetrics(ctx, meter, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	return &SygmaMetrics{
-		RelayerMetrics: relayerMetrics,
-		MpcMetrics:     mpcMetrics,
-		HostMetrics:    hostMetrics,
+		RelayerMetrics:  relayerMetrics,
+		MpcMetrics:      mpcMetrics,
+		HostMetrics:     hostMetrics,
+		TopologyMetrics: topologyMetrics,
+		HealthMetrics:   healthMetrics,
+		SessionMetrics:  sessionMetrics,
+		P2PMetrics:      p2pMetrics,
 	}, nil
 }
@@ -2,21 +2,37 @@ package metrics
 
 import (
 	"context"
+	"sync"
+	"tss-demo/tss_util/topology"
 
 	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	api "go.opentelemetry.io/otel/metric"
+	"golang.org/x/exp/slices"
 )
 
+type peerStatus struct {
+	metadata  topology.PeerMetadata
+	available bool
+}
+
 type MpcMetrics struct {
 	totalRelayersGauge     api.Int64ObservableGauge
 	availableRelayersGauge api.Int64ObservableGauge
+	peerAvailableGauge     api.Int64ObservableGauge
 	totalRelayerCount      *int64
 	availableRelayerCount  *int64
+
+	peerStatusLock sync.Mutex
+	peerStatus     map[peer.ID]peerStatus
 }
 
 // NewMpcMetrics initializes metrics related to the MPC set
 func NewMpcMetrics(ctx context.Context, meter metric.Meter, opts metric.MeasurementOption) (*MpcMetrics, error) {
+	m := &MpcMetrics{
+		peerStatus: make(map[peer.ID]peerStatus),
+	}
 	totalRelayerCount := new(int64)
 	availableRelayerCount := new(int64)
 	totalRelayersGauge, err := meter.Int64ObservableGauge(
@@ -42,15 +58,62 @@ func NewMpcMetrics(ctx context.Context, meter metric.Meter, opts metric.Measurem
 		return nil, err
 	}
 
-	return &MpcMetrics{
-		totalRelayersGauge:     totalRelayersGauge,
-		availableRelayersGauge: availableRelayersGauge,
-		totalRelayerCount:      totalRelayerCount,
-		availableRelayerCount:  availableRelayerCount,
-	}, nil
+	peerAvailableGauge, err := meter.Int64ObservableGauge(
+		"relayer.PeerAvailable",
+		api.WithInt64Callback(func(context context.Context, result api.Int64Observer) error {
+			m.observePeerStatus(result, opts)
+			return nil
+		}),
+		api.WithDescription("Availability of each peer from topology on the last communication health check"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.totalRelayersGauge = totalRelayersGauge
+	m.availableRelayersGauge = availableRelayersGauge
+	m.peerAvailableGauge = peerAvailableGauge
+	m.totalRelayerCount = totalRelayerCount
+	m.availableRelayerCount = availableRelayerCount
+	return m, nil
 }
 
 func (m *MpcMetrics) TrackRelayerStatus(unavailable peer.IDSlice, all peer.IDSlice) {
 	*m.totalRelayerCount = int64(len(all))
 	*m.availableRelayerCount = int64(len(all) - len(unavailable))
+
+	// drop peers removed from topology
+	m.peerStatusLock.Lock()
+	defer m.peerStatusLock.Unlock()
+	for p := range m.peerStatus {
+		if !slices.Contains(all, p) {
+			delete(m.peerStatus, p)
+		}
+	}
+}
+
+// TrackPeerStatus stores availability of a single peer labeled with its topology metadata
+func (m *MpcMetrics) TrackPeerStatus(p peer.ID, metadata topology.PeerMetadata, available bool) {
+	m.peerStatusLock.Lock()
+	defer m.peerStatusLock.Unlock()
+
+	m.peerStatus[p] = peerStatus{metadata: metadata, available: available}
+}
+
+func (m *MpcMetrics) observePeerStatus(result api.Int64Observer, opts metric.MeasurementOption) {
+	m.peerStatusLock.Lock()
+	defer m.peerStatusLock.Unlock()
+
+	for p, status := range m.peerStatus {
+		var available int64
+		if status.available {
+			available = 1
+		}
+		result.Observe(available, opts, api.WithAttributes(
+			attribute.String("peer", p.Pretty()),
+			attribute.String("name", status.metadata.Name),
+			attribute.String("operator", status.metadata.Operator),
+			attribute.String("role", string(status.metadata.Role)),
+		))
+	}
 }
@@ -0,0 +1,89 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"tss-demo/tss_util/comm"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+type P2PMetrics struct {
+	opts            metric.MeasurementOption
+	messages        api.Int64Counter
+	bytes           api.Int64Counter
+	queueDepthGauge api.Int64ObservableGauge
+
+	queueDepthLock sync.Mutex
+	queueDepth     map[comm.MessageType]int64
+}
+
+// NewP2PMetrics initializes metrics related to peer communication
+func NewP2PMetrics(ctx context.Context, meter metric.Meter, opts metric.MeasurementOption) (*P2PMetrics, error) {
+	m := &P2PMetrics{
+		opts:       opts,
+		queueDepth: make(map[comm.MessageType]int64),
+	}
+	messages, err := meter.Int64Counter(
+		"relayer.P2PMessages",
+		api.WithDescription("Number of p2p messages by direction and message type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := meter.Int64Counter(
+		"relayer.P2PBytes",
+		api.WithDescription("Size of p2p messages in bytes by direction and message type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	queueDepthGauge, err := meter.Int64ObservableGauge(
+		"relayer.SubscriptionQueueDepth",
+		api.WithInt64Callback(func(context context.Context, result api.Int64Observer) error {
+			m.observeQueueDepth(result)
+			return nil
+		}),
+		api.WithDescription("Number of received messages waiting to be delivered to subscribers by message type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.messages = messages
+	m.bytes = bytes
+	m.queueDepthGauge = queueDepthGauge
+	return m, nil
+}
+
+// TrackMessage counts message sent or received by the host
+func (m *P2PMetrics) TrackMessage(direction comm.Direction, msgType comm.MessageType, size int) {
+	attributes := api.WithAttributes(
+		attribute.String("direction", string(direction)),
+		attribute.String("type", msgType.String()),
+	)
+	m.messages.Add(context.Background(), 1, m.opts, attributes)
+	m.bytes.Add(context.Background(), int64(size), m.opts, attributes)
+}
+
+// TrackQueueDepth sets number of messages waiting for subscribers
+func (m *P2PMetrics) TrackQueueDepth(msgType comm.MessageType, depth int64) {
+	m.queueDepthLock.Lock()
+	defer m.queueDepthLock.Unlock()
+
+	m.queueDepth[msgType] = depth
+}
+
+func (m *P2PMetrics) observeQueueDepth(result api.Int64Observer) {
+	m.queueDepthLock.Lock()
+	defer m.queueDepthLock.Unlock()
+
+	for msgType, depth := range m.queueDepth {
+		result.Observe(depth, m.opts, api.WithAttributes(attribute.String("type", msgType.String())))
+	}
+}
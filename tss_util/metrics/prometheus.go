@@ -0,0 +1,168 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// PrometheusCollector exposes metrics recorded through the OpenTelemetry meter provider
+// to Prometheus scrapes, next to the periodic push to the collector
+type PrometheusCollector struct {
+	reader sdkmetric.Reader
+}
+
+func NewPrometheusCollector() *PrometheusCollector {
+	return &PrometheusCollector{
+		reader: sdkmetric.NewManualReader(),
+	}
+}
+
+// Option registers the collector reader with the meter provider
+func (c *PrometheusCollector) Option() sdkmetric.Option {
+	return sdkmetric.WithReader(c.reader)
+}
+
+// Handler returns /metrics handler serving relayer metrics together with go runtime
+// and process metrics
+func (c *PrometheusCollector) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		c,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// Describe implements prometheus.Collector. Metrics are created dynamically so the
+// collector is unchecked.
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	rm := metricdata.ResourceMetrics{}
+	err := c.reader.Collect(context.Background(), &rm)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed collecting metrics for prometheus")
+		return
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				collectSum(ch, m, data.DataPoints, data.IsMonotonic)
+			case metricdata.Sum[float64]:
+				collectSum(ch, m, data.DataPoints, data.IsMonotonic)
+			case metricdata.Gauge[int64]:
+				collectGauge(ch, m, data.DataPoints)
+			case metricdata.Gauge[float64]:
+				collectGauge(ch, m, data.DataPoints)
+			case metricdata.Histogram[int64]:
+				collectHistogram(ch, m, data.DataPoints)
+			case metricdata.Histogram[float64]:
+				collectHistogram(ch, m, data.DataPoints)
+			}
+		}
+	}
+}
+
+func collectSum[N int64 | float64](ch chan<- prometheus.Metric, m metricdata.Metrics, points []metricdata.DataPoint[N], monotonic bool) {
+	valueType := prometheus.GaugeValue
+	name := metricName(m)
+	if monotonic {
+		valueType = prometheus.CounterValue
+		name += "_total"
+	}
+	for _, dp := range points {
+		keys, values := labels(dp.Attributes)
+		desc := prometheus.NewDesc(name, m.Description, keys, nil)
+		metric, err := prometheus.NewConstMetric(desc, valueType, float64(dp.Value), values...)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Invalid metric %s", name)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+func collectGauge[N int64 | float64](ch chan<- prometheus.Metric, m metricdata.Metrics, points []metricdata.DataPoint[N]) {
+	name := metricName(m)
+	for _, dp := range points {
+		keys, values := labels(dp.Attributes)
+		desc := prometheus.NewDesc(name, m.Description, keys, nil)
+		metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(dp.Value), values...)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Invalid metric %s", name)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+func collectHistogram[N int64 | float64](ch chan<- prometheus.Metric, m metricdata.Metrics, points []metricdata.HistogramDataPoint[N]) {
+	name := metricName(m)
+	for _, dp := range points {
+		// prometheus buckets are cumulative
+		buckets := make(map[float64]uint64, len(dp.Bounds))
+		var count uint64
+		for i, bound := range dp.Bounds {
+			count += dp.BucketCounts[i]
+			buckets[bound] = count
+		}
+
+		keys, values := labels(dp.Attributes)
+		desc := prometheus.NewDesc(name, m.Description, keys, nil)
+		metric, err := prometheus.NewConstHistogram(desc, dp.Count, float64(dp.Sum), buckets, values...)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Invalid metric %s", name)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// metricName converts instrument name to a valid prometheus name, e.g.
+// relayer.SessionDuration with unit s becomes relayer_SessionDuration_seconds
+func metricName(m metricdata.Metrics) string {
+	name := sanitize(m.Name)
+	if m.Unit == "s" {
+		name += "_seconds"
+	}
+	return name
+}
+
+func labels(attributes attribute.Set) ([]string, []string) {
+	keys := make([]string, 0, attributes.Len())
+	values := make([]string, 0, attributes.Len())
+	iter := attributes.Iter()
+	for iter.Next() {
+		kv := iter.Attribute()
+		keys = append(keys, sanitize(string(kv.Key)))
+		values = append(values, kv.Value.Emit())
+	}
+	return keys, values
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
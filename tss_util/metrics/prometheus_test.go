@@ -0,0 +1,101 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tss-demo/tss_util/comm"
+	"tss-demo/tss_util/metrics"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+type PrometheusCollectorTestSuite struct {
+	suite.Suite
+	collector *metrics.PrometheusCollector
+	metrics   *metrics.SygmaMetrics
+}
+
+func TestRunPrometheusCollectorTestSuite(t *testing.T) {
+	suite.Run(t, new(PrometheusCollectorTestSuite))
+}
+
+func (s *PrometheusCollectorTestSuite) SetupTest() {
+	s.collector = metrics.NewPrometheusCollector()
+	mp := sdkmetric.NewMeterProvider(s.collector.Option(), metrics.Views())
+	m, err := metrics.NewSygmaMetrics(context.Background(), mp.Meter("test"), "test", "relayer1", "v1")
+	s.Nil(err)
+	s.metrics = m
+}
+
+func (s *PrometheusCollectorTestSuite) scrape() string {
+	recorder := httptest.NewRecorder()
+	s.collector.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Equal(http.StatusOK, recorder.Code)
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func (s *PrometheusCollectorTestSuite) Test_Scrape_SessionMetrics() {
+	s.metrics.TrackSession("signing", "success", time.Second)
+	s.metrics.TrackSession("signing", "success", time.Second)
+	s.metrics.TrackSessionPhase("signing", "election", 2*time.Second)
+	s.metrics.TrackSessionRetry("keygen")
+
+	body := s.scrape()
+
+	s.Contains(body, `relayer_Sessions_total{env="test",outcome="success",process="signing",relayerid="relayer1",version="v1"} 2`)
+	s.Contains(body, `relayer_SessionPhaseDuration_seconds_bucket{env="test",phase="election",process="signing",relayerid="relayer1",version="v1",le="+Inf"} 1`)
+	s.Contains(body, `relayer_SessionPhaseDuration_seconds_sum{env="test",phase="election",process="signing",relayerid="relayer1",version="v1"} 2`)
+	s.Contains(body, `relayer_SessionRetries_total{env="test",process="keygen",relayerid="relayer1",version="v1"} 1`)
+}
+
+func (s *PrometheusCollectorTestSuite) Test_Scrape_SessionDurationBuckets() {
+	s.metrics.TrackSession("signing", "success", 20*time.Second)
+	s.metrics.TrackSessionPhase("signing", "round", 200*time.Millisecond)
+
+	body := s.scrape()
+
+	s.Contains(body, `relayer_SessionDuration_seconds_bucket{env="test",outcome="success",process="signing",relayerid="relayer1",version="v1",le="10"} 0`)
+	s.Contains(body, `relayer_SessionDuration_seconds_bucket{env="test",outcome="success",process="signing",relayerid="relayer1",version="v1",le="30"} 1`)
+	s.Contains(body, `relayer_SessionDuration_seconds_bucket{env="test",outcome="success",process="signing",relayerid="relayer1",version="v1",le="600"} 1`)
+	s.Contains(body, `relayer_SessionPhaseDuration_seconds_bucket{env="test",phase="round",process="signing",relayerid="relayer1",version="v1",le="0.25"} 1`)
+	s.NotContains(body, `relayer_SessionDuration_seconds_bucket{env="test",outcome="success",process="signing",relayerid="relayer1",version="v1",le="1000"}`)
+}
+
+func (s *PrometheusCollectorTestSuite) Test_Scrape_OtherDurationsUseSecondBuckets() {
+	s.metrics.TrackPeerProbe(peer.ID("peer1"), topology.PeerMetadata{Name: "relayer1"}, "healthy", 2*time.Millisecond)
+
+	body := s.scrape()
+
+	s.Contains(body, `le="0.005"} 1`)
+	s.Contains(body, `le="10000"} 1`)
+}
+
+func (s *PrometheusCollectorTestSuite) Test_Scrape_P2PMetrics() {
+	s.metrics.TrackMessage(comm.Outbound, comm.TssKeySignMsg, 100)
+	s.metrics.TrackMessage(comm.Outbound, comm.TssKeySignMsg, 50)
+	s.metrics.TrackQueueDepth(comm.TssKeySignMsg, 3)
+
+	body := s.scrape()
+
+	s.Contains(body, `relayer_P2PMessages_total{direction="outbound",env="test",relayerid="relayer1",type="TssKeySignMsg",version="v1"} 2`)
+	s.Contains(body, `relayer_P2PBytes_total{direction="outbound",env="test",relayerid="relayer1",type="TssKeySignMsg",version="v1"} 150`)
+	s.Contains(body, `relayer_SubscriptionQueueDepth{env="test",relayerid="relayer1",type="TssKeySignMsg",version="v1"} 3`)
+}
+
+func (s *PrometheusCollectorTestSuite) Test_Scrape_RuntimeMetrics() {
+	body := s.scrape()
+
+	s.Contains(body, "go_goroutines")
+	s.Contains(body, "relayer_StartTimeSeconds")
+}
@@ -0,0 +1,97 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// SessionDurationBuckets are histogram boundaries in seconds of tss sessions and their
+// phases. Rounds take from tens of milliseconds, while whole sessions can take minutes.
+var SessionDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// secondBuckets are histogram boundaries of other duration instruments
+var secondBuckets = []float64{0.000001, 0.00001, 0.0001, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 100, 1000, 10000}
+
+var gasBuckets = []float64{10000, 20000, 50000, 100000, 500000, 1000000, 5000000, 10000000, 15000000, 30000000}
+
+var sessionDurationInstruments = map[string]bool{
+	"relayer.SessionDuration":      true,
+	"relayer.SessionPhaseDuration": true,
+}
+
+// InitMetricProvider creates meter provider that pushes metrics to the collector
+// on agentURL next to the readers provided with opts
+func InitMetricProvider(ctx context.Context, agentURL string, opts ...sdkmetric.Option) (*sdkmetric.MeterProvider, error) {
+	collectorURL, err := url.Parse(agentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	metricOptions := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithURLPath(collectorURL.Path),
+		otlpmetrichttp.WithEndpoint(collectorURL.Host),
+	}
+	if collectorURL.Scheme == "http" {
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+	exporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, _ := sdkresource.New(ctx,
+		sdkresource.WithProcess(),
+		sdkresource.WithTelemetrySDK(),
+		sdkresource.WithHost(),
+		sdkresource.WithAttributes(semconv.ServiceName("relayer")),
+	)
+	opts = append(opts,
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(resource),
+		Views(),
+	)
+	return sdkmetric.NewMeterProvider(opts...), nil
+}
+
+// Views returns the meter provider option that sets explicit histogram buckets of
+// duration and gas instruments. Every instrument is matched by at most one view
+// so that no instrument is exported twice with different buckets.
+func Views() sdkmetric.Option {
+	return sdkmetric.WithView(durationView, gasView)
+}
+
+func durationView(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+	switch {
+	case sessionDurationInstruments[instrument.Name]:
+		return histogramStream(instrument, SessionDurationBuckets), true
+	case instrument.Unit == "s":
+		return histogramStream(instrument, secondBuckets), true
+	default:
+		return sdkmetric.Stream{}, false
+	}
+}
+
+func gasView(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+	if instrument.Unit != "gas" {
+		return sdkmetric.Stream{}, false
+	}
+	return histogramStream(instrument, gasBuckets), true
+}
+
+func histogramStream(instrument sdkmetric.Instrument, boundaries []float64) sdkmetric.Stream {
+	return sdkmetric.Stream{
+		Name:        instrument.Name,
+		Description: instrument.Description,
+		Unit:        instrument.Unit,
+		Aggregation: aggregation.ExplicitBucketHistogram{Boundaries: boundaries},
+	}
+}
@@ -0,0 +1,104 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+type SessionMetrics struct {
+	opts            metric.MeasurementOption
+	sessions        api.Int64Counter
+	sessionDuration api.Float64Histogram
+	phaseDuration   api.Float64Histogram
+	retries         api.Int64Counter
+	excludedPeers   api.Int64Counter
+}
+
+// NewSessionMetrics initializes metrics related to tss sessions
+func NewSessionMetrics(ctx context.Context, meter metric.Meter, opts metric.MeasurementOption) (*SessionMetrics, error) {
+	sessions, err := meter.Int64Counter(
+		"relayer.Sessions",
+		api.WithDescription("Number of finished tss sessions by process and outcome"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	sessionDuration, err := meter.Float64Histogram(
+		"relayer.SessionDuration",
+		api.WithUnit("s"),
+		api.WithDescription("Duration of tss sessions by process and outcome"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	phaseDuration, err := meter.Float64Histogram(
+		"relayer.SessionPhaseDuration",
+		api.WithUnit("s"),
+		api.WithDescription("Duration of tss session phases: election, ready, protocol and protocol rounds"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := meter.Int64Counter(
+		"relayer.SessionRetries",
+		api.WithDescription("Number of tss session retries by process"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	excludedPeers, err := meter.Int64Counter(
+		"relayer.ExcludedPeers",
+		api.WithDescription("Number of times a peer was excluded from a tss session as a culprit"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionMetrics{
+		opts:            opts,
+		sessions:        sessions,
+		sessionDuration: sessionDuration,
+		phaseDuration:   phaseDuration,
+		retries:         retries,
+		excludedPeers:   excludedPeers,
+	}, nil
+}
+
+// TrackSession counts finished session and records its duration
+func (m *SessionMetrics) TrackSession(process string, outcome string, duration time.Duration) {
+	attributes := api.WithAttributes(
+		attribute.String("process", process),
+		attribute.String("outcome", outcome),
+	)
+	m.sessions.Add(context.Background(), 1, m.opts, attributes)
+	m.sessionDuration.Record(context.Background(), duration.Seconds(), m.opts, attributes)
+}
+
+// TrackSessionPhase records duration of a single session phase
+func (m *SessionMetrics) TrackSessionPhase(process string, phase string, duration time.Duration) {
+	m.phaseDuration.Record(context.Background(), duration.Seconds(), m.opts, api.WithAttributes(
+		attribute.String("process", process),
+		attribute.String("phase", phase),
+	))
+}
+
+// TrackSessionRetry counts session retry
+func (m *SessionMetrics) TrackSessionRetry(process string) {
+	m.retries.Add(context.Background(), 1, m.opts, api.WithAttributes(attribute.String("process", process)))
+}
+
+// TrackExcludedPeer counts peer excluded from the session as a culprit
+func (m *SessionMetrics) TrackExcludedPeer(process string, p peer.ID) {
+	m.excludedPeers.Add(context.Background(), 1, m.opts, api.WithAttributes(
+		attribute.String("process", process),
+		attribute.String("peer", p.Pretty()),
+	))
+}
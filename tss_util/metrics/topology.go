@@ -0,0 +1,61 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"tss-demo/tss_util/topology"
+
+	"go.opentelemetry.io/otel/metric"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+type TopologyMetrics struct {
+	opts                metric.MeasurementOption
+	topologyChanges     api.Int64Counter
+	topologyVersion     api.Int64ObservableGauge
+	topologyVersionSeen *int64
+}
+
+// NewTopologyMetrics initializes metrics related to network topology changes
+func NewTopologyMetrics(ctx context.Context, meter metric.Meter, opts metric.MeasurementOption) (*TopologyMetrics, error) {
+	topologyVersion := new(int64)
+	topologyChanges, err := meter.Int64Counter(
+		"relayer.TopologyChanges",
+		api.WithDescription("Number of topology changes applied by the relayer"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	topologyVersionGauge, err := meter.Int64ObservableGauge(
+		"relayer.TopologyVersion",
+		api.WithInt64Callback(func(context context.Context, result api.Int64Observer) error {
+			result.Observe(atomic.LoadInt64(topologyVersion), opts)
+			return nil
+		}),
+		api.WithDescription("Version of the currently applied topology"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TopologyMetrics{
+		opts:                opts,
+		topologyChanges:     topologyChanges,
+		topologyVersion:     topologyVersionGauge,
+		topologyVersionSeen: topologyVersion,
+	}, nil
+}
+
+// TrackTopologyVersion sets version of the currently applied topology
+func (m *TopologyMetrics) TrackTopologyVersion(version uint64) {
+	atomic.StoreInt64(m.topologyVersionSeen, int64(version))
+}
+
+// TrackTopologyChange counts applied topology change
+func (m *TopologyMetrics) TrackTopologyChange(change topology.TopologyChange) {
+	m.TrackTopologyVersion(change.Current.Version)
+	m.topologyChanges.Add(context.Background(), 1, m.opts)
+}
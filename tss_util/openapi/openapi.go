@@ -0,0 +1,344 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	JSONContentType = "application/json"
+
+	schemaRefPrefix    = "#/components/schemas/"
+	responseRefPrefix  = "#/components/responses/"
+	parameterRefPrefix = "#/components/parameters/"
+)
+
+// Spec is the subset of an OpenAPI 3 document used to validate API calls and
+// generate the API client
+type Spec struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Info       Info                `yaml:"info"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components"`
+}
+
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// PathItem are operations of the path by lower case HTTP method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Parameters  []Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody        `yaml:"requestBody"`
+	Responses   map[string]Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `yaml:"required"`
+	Content  map[string]MediaType `yaml:"content"`
+}
+
+type Response struct {
+	Ref         string               `yaml:"$ref"`
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema   `yaml:"schemas"`
+	Responses  map[string]Response  `yaml:"responses"`
+	Parameters map[string]Parameter `yaml:"parameters"`
+}
+
+// Schema is a JSON schema. GoType overrides the type of the generated client field.
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Description          string             `yaml:"description"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *Schema            `yaml:"items"`
+	Enum                 []string           `yaml:"enum"`
+	Pattern              string             `yaml:"pattern"`
+	MinLength            int                `yaml:"minLength"`
+	Nullable             bool               `yaml:"nullable"`
+	AdditionalProperties *bool              `yaml:"additionalProperties"`
+	GoType               string             `yaml:"x-go-type"`
+
+	// PropertyOrder are property names in the order of the document
+	PropertyOrder []string `yaml:"-"`
+}
+
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	type plain Schema
+	err := node.Decode((*plain)(s))
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "properties" {
+			continue
+		}
+		properties := node.Content[i+1]
+		for j := 0; j < len(properties.Content); j += 2 {
+			s.PropertyOrder = append(s.PropertyOrder, properties.Content[j].Value)
+		}
+	}
+	return nil
+}
+
+// RefName returns the component name of the schema reference
+func (s *Schema) RefName() string {
+	return strings.TrimPrefix(s.Ref, schemaRefPrefix)
+}
+
+// IsRequired returns true if the property is required by the schema
+func (s *Schema) IsRequired(property string) bool {
+	for _, r := range s.Required {
+		if r == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Route is an operation with its method and path template
+type Route struct {
+	Method    string
+	Path      string
+	Operation *Operation
+}
+
+// Load parses the YAML document and checks that all references resolve
+func Load(data []byte) (*Spec, error) {
+	spec := &Spec{}
+	err := yaml.Unmarshal(data, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing openapi spec: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %s", spec.OpenAPI)
+	}
+
+	for _, route := range spec.Routes() {
+		err = spec.checkOperation(route.Operation)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", route.Method, route.Path, err)
+		}
+	}
+	for name, schema := range spec.Components.Schemas {
+		err = spec.checkSchema(schema)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	return spec, nil
+}
+
+// Routes returns all operations sorted by path and method
+func (s *Spec) Routes() []Route {
+	routes := make([]Route, 0)
+	for path, item := range s.Paths {
+		for method, operation := range item {
+			routes = append(routes, Route{Method: strings.ToUpper(method), Path: path, Operation: operation})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Operation returns the operation of the method and path template
+func (s *Spec) Operation(method, path string) (*Operation, bool) {
+	item, ok := s.Paths[path]
+	if !ok {
+		return nil, false
+	}
+	operation, ok := item[strings.ToLower(method)]
+	return operation, ok
+}
+
+// Resolve follows the schema reference
+func (s *Spec) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Components.Schemas[schema.RefName()]
+	}
+	return schema
+}
+
+// Parameters returns parameters of the operation with references resolved
+func (s *Spec) Parameters(operation *Operation) []Parameter {
+	params := make([]Parameter, 0, len(operation.Parameters))
+	for _, param := range operation.Parameters {
+		if param.Ref != "" {
+			param = s.Components.Parameters[strings.TrimPrefix(param.Ref, parameterRefPrefix)]
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// Response returns the response of the status code or the default response
+func (s *Spec) Response(operation *Operation, status int) (Response, bool) {
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = operation.Responses["default"]
+	}
+	if !ok {
+		return Response{}, false
+	}
+	if response.Ref != "" {
+		response, ok = s.Components.Responses[strings.TrimPrefix(response.Ref, responseRefPrefix)]
+	}
+	return response, ok
+}
+
+// ValidateRequest validates path parameters and body of the call against the operation
+// of the method and path template
+func (s *Spec) ValidateRequest(method, path string, params map[string]string, body []byte) error {
+	operation, ok := s.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("operation %s %s is not specified", method, path)
+	}
+
+	for _, param := range s.Parameters(operation) {
+		if param.In != "path" {
+			continue
+		}
+		value, ok := params[param.Name]
+		if !ok || value == "" {
+			return &ValidationError{Field: param.Name, Reason: "is required"}
+		}
+		err := s.validateString(param.Schema, param.Name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if operation.RequestBody == nil {
+		return nil
+	}
+	if len(body) == 0 {
+		if operation.RequestBody.Required {
+			return &ValidationError{Reason: "request body is required"}
+		}
+		return nil
+	}
+	return s.ValidateJSON(operation.RequestBody.Content[JSONContentType].Schema, body)
+}
+
+// ValidateResponse validates the response body against the documented response
+// of the status code
+func (s *Spec) ValidateResponse(method, path string, status int, body []byte) error {
+	operation, ok := s.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("operation %s %s is not specified", method, path)
+	}
+	response, ok := s.Response(operation, status)
+	if !ok {
+		return fmt.Errorf("status %d of %s %s is not specified", status, method, path)
+	}
+	media, ok := response.Content[JSONContentType]
+	if !ok {
+		return nil
+	}
+	return s.ValidateJSON(media.Schema, body)
+}
+
+func (s *Spec) checkOperation(operation *Operation) error {
+	if operation.OperationID == "" {
+		return fmt.Errorf("missing operation id")
+	}
+	if len(operation.Responses) == 0 {
+		return fmt.Errorf("no responses")
+	}
+	for _, param := range operation.Parameters {
+		if param.Ref != "" {
+			resolved, ok := s.Components.Parameters[strings.TrimPrefix(param.Ref, parameterRefPrefix)]
+			if !ok {
+				return fmt.Errorf("unresolved reference %s", param.Ref)
+			}
+			param = resolved
+		}
+		if param.In != "path" && param.In != "query" && param.In != "header" {
+			return fmt.Errorf("unsupported parameter location %s", param.In)
+		}
+		err := s.checkSchema(param.Schema)
+		if err != nil {
+			return err
+		}
+	}
+	if operation.RequestBody != nil {
+		media, ok := operation.RequestBody.Content[JSONContentType]
+		if !ok {
+			return fmt.Errorf("request body is not %s", JSONContentType)
+		}
+		err := s.checkSchema(media.Schema)
+		if err != nil {
+			return err
+		}
+	}
+	for status, response := range operation.Responses {
+		if response.Ref != "" {
+			if _, ok := s.Components.Responses[strings.TrimPrefix(response.Ref, responseRefPrefix)]; !ok {
+				return fmt.Errorf("response %s: unresolved reference %s", status, response.Ref)
+			}
+			continue
+		}
+		for _, media := range response.Content {
+			err := s.checkSchema(media.Schema)
+			if err != nil {
+				return fmt.Errorf("response %s: %w", status, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Spec) checkSchema(schema *Schema) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		if _, ok := s.Components.Schemas[schema.RefName()]; !ok {
+			return fmt.Errorf("unresolved reference %s", schema.Ref)
+		}
+		return nil
+	}
+	for _, property := range schema.Properties {
+		err := s.checkSchema(property)
+		if err != nil {
+			return err
+		}
+	}
+	return s.checkSchema(schema.Items)
+}
@@ -0,0 +1,190 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package openapi_test
+
+import (
+	"testing"
+	"tss-demo/tss_util/openapi"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const document = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /sign/{hash}:
+    get:
+      operationId: getSign
+      parameters:
+        - $ref: "#/components/parameters/Hash"
+      responses:
+        "200":
+          description: Sign request
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/SignStatus"
+        default:
+          $ref: "#/components/responses/Error"
+  /sign:
+    post:
+      operationId: sign
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/SignRequest"
+      responses:
+        "200":
+          description: Signed
+components:
+  parameters:
+    Hash:
+      name: hash
+      in: path
+      required: true
+      schema:
+        type: string
+        pattern: "^[0-9a-f]{4}$"
+  responses:
+    Error:
+      description: Failed call
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [error]
+            properties:
+              error:
+                type: string
+  schemas:
+    SignRequest:
+      type: object
+      required: [hash]
+      additionalProperties: false
+      properties:
+        hash:
+          type: string
+          pattern: "^[0-9a-f]{4}$"
+        value:
+          type: integer
+    SignStatus:
+      type: object
+      required: [status, createdAt]
+      properties:
+        status:
+          type: string
+          enum: [pending, signed]
+        createdAt:
+          type: string
+          format: date-time
+        approvals:
+          type: array
+          nullable: true
+          items:
+            type: string
+`
+
+type SpecTestSuite struct {
+	suite.Suite
+	spec *openapi.Spec
+}
+
+func TestRunSpecTestSuite(t *testing.T) {
+	suite.Run(t, new(SpecTestSuite))
+}
+
+func (s *SpecTestSuite) SetupTest() {
+	spec, err := openapi.Load([]byte(document))
+	s.Nil(err)
+	s.spec = spec
+}
+
+func (s *SpecTestSuite) Test_Load_UnresolvedReference() {
+	_, err := openapi.Load([]byte(`
+openapi: 3.0.3
+paths:
+  /sign:
+    get:
+      operationId: sign
+      responses:
+        "200":
+          description: Signed
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Missing"
+`))
+
+	s.ErrorContains(err, "unresolved reference #/components/schemas/Missing")
+}
+
+func (s *SpecTestSuite) Test_Routes() {
+	routes := s.spec.Routes()
+
+	s.Len(routes, 2)
+	s.Equal("POST", routes[0].Method)
+	s.Equal("/sign", routes[0].Path)
+	s.Equal("GET", routes[1].Method)
+	s.Equal("/sign/{hash}", routes[1].Path)
+}
+
+func (s *SpecTestSuite) Test_Schema_PropertyOrder() {
+	s.Equal([]string{"hash", "value"}, s.spec.Components.Schemas["SignRequest"].PropertyOrder)
+}
+
+func (s *SpecTestSuite) Test_ValidateRequest_ValidBody() {
+	err := s.spec.ValidateRequest("POST", "/sign", nil, []byte(`{"hash": "ab01", "value": 1000000000000000000000000}`))
+
+	s.Nil(err)
+}
+
+func (s *SpecTestSuite) Test_ValidateRequest_InvalidBody() {
+	tests := map[string]*openapi.ValidationError{
+		``:                               {Reason: "request body is required"},
+		`{"value": 1}`:                   {Field: "hash", Reason: "is required"},
+		`{"hash": "xyz1"}`:               {Field: "hash", Reason: "must match ^[0-9a-f]{4}$"},
+		`{"hash": "ab01", "value": 1.5}`: {Field: "value", Reason: "must be an integer"},
+		`{"hash": "ab01", "chainId": 1}`: {Field: "chainId", Reason: "is not allowed"},
+		`{"hash": 1}`:                    {Field: "hash", Reason: "must be a string"},
+		`["ab01"]`:                       {Reason: "must be an object"},
+	}
+
+	for body, expected := range tests {
+		err := s.spec.ValidateRequest("POST", "/sign", nil, []byte(body))
+
+		s.Equal(expected, err, body)
+	}
+}
+
+func (s *SpecTestSuite) Test_ValidateRequest_PathParameter() {
+	s.Nil(s.spec.ValidateRequest("GET", "/sign/{hash}", map[string]string{"hash": "ab01"}, nil))
+
+	err := s.spec.ValidateRequest("GET", "/sign/{hash}", map[string]string{"hash": "ab"}, nil)
+	s.Equal(&openapi.ValidationError{Field: "hash", Reason: "must match ^[0-9a-f]{4}$"}, err)
+}
+
+func (s *SpecTestSuite) Test_ValidateRequest_UnknownOperation() {
+	err := s.spec.ValidateRequest("DELETE", "/sign", nil, nil)
+
+	s.EqualError(err, "operation DELETE /sign is not specified")
+}
+
+func (s *SpecTestSuite) Test_ValidateResponse() {
+	s.Nil(s.spec.ValidateResponse("GET", "/sign/{hash}", 200, []byte(`{"status": "signed", "createdAt": "2024-01-01T00:00:00Z", "approvals": null}`)))
+	s.Nil(s.spec.ValidateResponse("GET", "/sign/{hash}", 404, []byte(`{"error": "SIGN_REQUEST_NOT_FOUND"}`)))
+
+	err := s.spec.ValidateResponse("GET", "/sign/{hash}", 200, []byte(`{"status": "unknown", "createdAt": "2024-01-01T00:00:00Z"}`))
+	s.Equal(&openapi.ValidationError{Field: "status", Reason: "must be one of [pending signed]"}, err)
+
+	err = s.spec.ValidateResponse("GET", "/sign/{hash}", 200, []byte(`{"status": "signed", "createdAt": "yesterday"}`))
+	s.Equal(&openapi.ValidationError{Field: "createdAt", Reason: "must be an RFC3339 date-time"}, err)
+
+	err = s.spec.ValidateResponse("POST", "/sign", 500, nil)
+	s.EqualError(err, "status 500 of POST /sign is not specified")
+}
@@ -0,0 +1,163 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var integerPattern = regexp.MustCompile(`^-?[0-9]+$`)
+
+// ValidationError is returned if a value doesn't match its schema. Field is the path
+// of the invalid value in the document.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (ve *ValidationError) Error() string {
+	if ve.Field == "" {
+		return ve.Reason
+	}
+	return fmt.Sprintf("%s %s", ve.Field, ve.Reason)
+}
+
+// ValidateJSON validates the JSON document against the schema
+func (s *Spec) ValidateJSON(schema *Schema, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return &ValidationError{Reason: fmt.Sprintf("is not valid JSON: %s", err)}
+	}
+	return s.validate(schema, "", value)
+}
+
+func (s *Spec) validate(schema *Schema, field string, value interface{}) error {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return &ValidationError{Field: field, Reason: "must not be null"}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return &ValidationError{Field: field, Reason: "must be an object"}
+		}
+		return s.validateObject(schema, field, object)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return &ValidationError{Field: field, Reason: "must be an array"}
+		}
+		for i, item := range array {
+			err := s.validate(schema.Items, fmt.Sprintf("%s[%d]", field, i), item)
+			if err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &ValidationError{Field: field, Reason: "must be a string"}
+		}
+		return s.validateString(schema, field, str)
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return &ValidationError{Field: field, Reason: "must be an integer"}
+		}
+		// integers may be bigger than int64 so only the notation is checked
+		if !integerPattern.MatchString(number.String()) {
+			return &ValidationError{Field: field, Reason: "must be an integer"}
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return &ValidationError{Field: field, Reason: "must be a number"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &ValidationError{Field: field, Reason: "must be a boolean"}
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateObject(schema *Schema, field string, object map[string]interface{}) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return &ValidationError{Field: join(field, name), Reason: "is required"}
+		}
+	}
+	for name, property := range object {
+		propertySchema, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return &ValidationError{Field: join(field, name), Reason: "is not allowed"}
+			}
+			continue
+		}
+		err := s.validate(propertySchema, join(field, name), property)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateString(schema *Schema, field, value string) error {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	if len(value) < schema.MinLength {
+		return &ValidationError{Field: field, Reason: fmt.Sprintf("must be at least %d characters long", schema.MinLength)}
+	}
+	if len(schema.Enum) > 0 && !contains(schema.Enum, value) {
+		return &ValidationError{Field: field, Reason: fmt.Sprintf("must be one of %v", schema.Enum)}
+	}
+	if schema.Pattern != "" {
+		matched, err := regexp.MatchString(schema.Pattern, value)
+		if err != nil {
+			return fmt.Errorf("invalid pattern of %s: %w", field, err)
+		}
+		if !matched {
+			return &ValidationError{Field: field, Reason: fmt.Sprintf("must match %s", schema.Pattern)}
+		}
+	}
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			return &ValidationError{Field: field, Reason: "must be an RFC3339 date-time"}
+		}
+	}
+	return nil
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sessions
+
+import (
+	"sync"
+	"time"
+	"tss-demo/tss_util/tss"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// DefaultRetained is the number of finished sessions kept by the hub
+	DefaultRetained = 256
+	// maxEvents is the number of events kept per session, older rounds are dropped first
+	maxEvents = 128
+)
+
+// Status is the state of the last execution of a session
+type Status struct {
+	SessionID   string
+	Process     string
+	Phase       tss.SessionEventType
+	Round       int
+	RoundName   string
+	Coordinator peer.ID
+	Culprits    []peer.ID
+	Error       string
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Finished returns true if the session execution ended
+func (s Status) Finished() bool {
+	return s.Phase.Final()
+}
+
+type session struct {
+	status Status
+	events []tss.SessionEvent
+}
+
+// Hub keeps phase transitions of sessions executed by the node and streams them to
+// watchers. It implements tss.SessionObserver.
+type Hub struct {
+	mu          sync.Mutex
+	sessions    map[string]*session
+	finished    []string
+	retained    int
+	subscribers map[string]map[*Subscription]bool
+}
+
+// NewHub creates a hub that keeps the last retained finished sessions
+func NewHub(retained int) *Hub {
+	return &Hub{
+		sessions:    make(map[string]*session),
+		retained:    retained,
+		subscribers: make(map[string]map[*Subscription]bool),
+	}
+}
+
+// ObserveSession records the event and sends it to watchers of the session. Watchers that
+// don't keep up are dropped.
+func (h *Hub) ObserveSession(event tss.SessionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.sessions[event.SessionID]
+	if !ok || (s.status.Finished() && !event.Type.Final()) {
+		// a new execution of the session
+		h.forgetFinished(event.SessionID)
+		s = &session{status: Status{SessionID: event.SessionID, StartedAt: event.Time}}
+		h.sessions[event.SessionID] = s
+	}
+	s.record(event)
+	if event.Type.Final() {
+		h.finished = append(h.finished, event.SessionID)
+		for len(h.finished) > h.retained {
+			delete(h.sessions, h.finished[0])
+			h.finished = h.finished[1:]
+		}
+	}
+
+	for subscription := range h.subscribers[event.SessionID] {
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.lagged = true
+			h.unsubscribe(subscription)
+			continue
+		}
+		if event.Type.Final() {
+			h.unsubscribe(subscription)
+		}
+	}
+}
+
+// Status returns the status of the last execution of the session
+func (h *Hub) Status(sessionID string) (Status, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.sessions[sessionID]
+	if !ok {
+		return Status{}, false
+	}
+	status := s.status
+	status.Culprits = append([]peer.ID{}, s.status.Culprits...)
+	return status, true
+}
+
+// Watch subscribes to events of the session. Events of the running or last finished
+// execution are replayed first. The events channel is closed when the execution
+// finishes or when the watcher falls behind by more than buffer events. Sessions that
+// have not started yet are watched until they start and finish.
+func (h *Hub) Watch(sessionID string, buffer int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var history []tss.SessionEvent
+	s, ok := h.sessions[sessionID]
+	if ok {
+		history = s.events
+	}
+	subscription := &Subscription{
+		hub:       h,
+		sessionID: sessionID,
+		events:    make(chan tss.SessionEvent, len(history)+buffer),
+	}
+	for _, event := range history {
+		subscription.events <- event
+	}
+	if ok && s.status.Finished() {
+		subscription.closed = true
+		close(subscription.events)
+		return subscription
+	}
+
+	if h.subscribers[sessionID] == nil {
+		h.subscribers[sessionID] = make(map[*Subscription]bool)
+	}
+	h.subscribers[sessionID][subscription] = true
+	return subscription
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	close(subscription.events)
+
+	delete(h.subscribers[subscription.sessionID], subscription)
+	if len(h.subscribers[subscription.sessionID]) == 0 {
+		delete(h.subscribers, subscription.sessionID)
+	}
+}
+
+func (h *Hub) forgetFinished(sessionID string) {
+	for i, id := range h.finished {
+		if id == sessionID {
+			h.finished = append(h.finished[:i], h.finished[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *session) record(event tss.SessionEvent) {
+	if len(s.events) == maxEvents {
+		s.events = s.events[1:]
+	}
+	s.events = append(s.events, event)
+
+	status := &s.status
+	status.Process = event.Process
+	status.Phase = event.Type
+	status.UpdatedAt = event.Time
+	switch event.Type {
+	case tss.EventElection, tss.EventReady:
+		status.Coordinator = event.Coordinator
+	case tss.EventStarted:
+		status.Round = 0
+		status.RoundName = ""
+	case tss.EventRound:
+		status.Round = event.Round
+		status.RoundName = event.RoundName
+	case tss.EventFailed:
+		status.Culprits = event.Culprits
+	}
+	if event.Err != nil {
+		status.Error = event.Err.Error()
+	}
+}
+
+// Subscription streams events of a watched session
+type Subscription struct {
+	hub       *Hub
+	sessionID string
+	events    chan tss.SessionEvent
+	// lagged and closed are guarded by the hub lock
+	lagged bool
+	closed bool
+}
+
+// Events returns the channel of session events
+func (s *Subscription) Events() <-chan tss.SessionEvent {
+	return s.events
+}
+
+// Lagged returns true if the subscription was dropped because it fell behind
+func (s *Subscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.lagged
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
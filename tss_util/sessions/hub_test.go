@@ -0,0 +1,150 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package sessions_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"tss-demo/tss_util/sessions"
+	"tss-demo/tss_util/tss"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+const sessionID = "sid-sign-1234"
+
+type HubTestSuite struct {
+	suite.Suite
+	hub *sessions.Hub
+}
+
+func TestRunHubTestSuite(t *testing.T) {
+	suite.Run(t, new(HubTestSuite))
+}
+
+func (s *HubTestSuite) SetupTest() {
+	s.hub = sessions.NewHub(2)
+}
+
+func (s *HubTestSuite) observe(sessionID string, eventType tss.SessionEventType) {
+	s.hub.ObserveSession(tss.SessionEvent{SessionID: sessionID, Process: "signing", Type: eventType, Time: time.Now()})
+}
+
+func (s *HubTestSuite) received(subscription *sessions.Subscription) []tss.SessionEventType {
+	types := make([]tss.SessionEventType, 0)
+	for event := range subscription.Events() {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func (s *HubTestSuite) Test_Status_TracksPhases() {
+	s.hub.ObserveSession(tss.SessionEvent{SessionID: sessionID, Process: "signing", Type: tss.EventElection, Coordinator: peer.ID("QmCoordinator")})
+	s.observe(sessionID, tss.EventStarted)
+	s.hub.ObserveSession(tss.SessionEvent{SessionID: sessionID, Process: "signing", Type: tss.EventRound, Round: 2, RoundName: "SignRound2Message"})
+
+	status, ok := s.hub.Status(sessionID)
+
+	s.True(ok)
+	s.Equal(tss.EventRound, status.Phase)
+	s.Equal(2, status.Round)
+	s.Equal(peer.ID("QmCoordinator"), status.Coordinator)
+	s.False(status.Finished())
+}
+
+func (s *HubTestSuite) Test_Status_FailedWithCulprits() {
+	s.observe(sessionID, tss.EventStarted)
+	s.hub.ObserveSession(tss.SessionEvent{
+		SessionID: sessionID,
+		Type:      tss.EventFailed,
+		Culprits:  []peer.ID{"QmCulprit"},
+		Err:       errors.New("invalid share"),
+	})
+
+	status, _ := s.hub.Status(sessionID)
+
+	s.True(status.Finished())
+	s.Equal([]peer.ID{"QmCulprit"}, status.Culprits)
+	s.Equal("invalid share", status.Error)
+}
+
+func (s *HubTestSuite) Test_Status_UnknownSession() {
+	_, ok := s.hub.Status(sessionID)
+
+	s.False(ok)
+}
+
+func (s *HubTestSuite) Test_Watch_ReplaysAndStreamsUntilFinished() {
+	s.observe(sessionID, tss.EventElection)
+	subscription := s.hub.Watch(sessionID, 10)
+	s.observe(sessionID, tss.EventReady)
+	s.observe(sessionID, tss.EventCompleted)
+
+	s.Equal([]tss.SessionEventType{tss.EventElection, tss.EventReady, tss.EventCompleted}, s.received(subscription))
+	s.False(subscription.Lagged())
+}
+
+func (s *HubTestSuite) Test_Watch_SessionNotStarted() {
+	subscription := s.hub.Watch(sessionID, 10)
+	s.observe(sessionID, tss.EventElection)
+	s.observe(sessionID, tss.EventFailed)
+
+	s.Equal([]tss.SessionEventType{tss.EventElection, tss.EventFailed}, s.received(subscription))
+}
+
+func (s *HubTestSuite) Test_Watch_FinishedSessionReplayed() {
+	s.observe(sessionID, tss.EventElection)
+	s.observe(sessionID, tss.EventCompleted)
+
+	subscription := s.hub.Watch(sessionID, 10)
+
+	s.Equal([]tss.SessionEventType{tss.EventElection, tss.EventCompleted}, s.received(subscription))
+}
+
+func (s *HubTestSuite) Test_Watch_NewExecutionReplacesFinished() {
+	s.observe(sessionID, tss.EventElection)
+	s.observe(sessionID, tss.EventFailed)
+	s.observe(sessionID, tss.EventElection)
+
+	subscription := s.hub.Watch(sessionID, 10)
+	s.observe(sessionID, tss.EventCompleted)
+
+	s.Equal([]tss.SessionEventType{tss.EventElection, tss.EventCompleted}, s.received(subscription))
+}
+
+func (s *HubTestSuite) Test_Watch_SlowWatcherDropped() {
+	subscription := s.hub.Watch(sessionID, 1)
+	s.observe(sessionID, tss.EventElection)
+	s.observe(sessionID, tss.EventReady)
+	s.observe(sessionID, tss.EventStarted)
+
+	s.Equal([]tss.SessionEventType{tss.EventElection}, s.received(subscription))
+	s.True(subscription.Lagged())
+}
+
+func (s *HubTestSuite) Test_Watch_Close() {
+	subscription := s.hub.Watch(sessionID, 10)
+	subscription.Close()
+	subscription.Close()
+	s.observe(sessionID, tss.EventElection)
+
+	s.Empty(s.received(subscription))
+}
+
+func (s *HubTestSuite) Test_FinishedSessionsRetained() {
+	for _, id := range []string{"s1", "s2", "s3"} {
+		s.observe(id, tss.EventElection)
+		s.observe(id, tss.EventCompleted)
+	}
+	s.observe("s4", tss.EventElection)
+
+	_, ok := s.hub.Status("s1")
+	s.False(ok)
+	for _, id := range []string{"s2", "s3", "s4"} {
+		_, ok := s.hub.Status(id)
+		s.True(ok, id)
+	}
+}
@@ -0,0 +1,123 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CertReloader serves the certificate and client CAs from the configured files and
+// replaces them on Reload. Handshakes read the current files so established
+// connections and in-flight requests are not affected by a reload.
+type CertReloader struct {
+	config relayer.TLSConfig
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func NewCertReloader(config relayer.TLSConfig) (*CertReloader, error) {
+	r := &CertReloader{config: config}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate, key and client CAs again. Current files are kept
+// if any of the new files is invalid.
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed loading tls certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed loading tls client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("tls client CA file contains no certificates")
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	return nil
+}
+
+// TLSConfig returns server config that uses the current certificate and client CAs
+// on every handshake
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return r.tlsConfig(true)
+}
+
+// ProbeTLSConfig returns server config that uses the current certificate on every
+// handshake but doesn't ask for client certificates, so that liveness and readiness
+// probes of orchestrators can reach the endpoints without one
+func (r *CertReloader) ProbeTLSConfig() *tls.Config {
+	return r.tlsConfig(false)
+}
+
+func (r *CertReloader) tlsConfig(clientAuth bool) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.lock.RLock()
+			defer r.lock.RUnlock()
+
+			// the config replaces the one of the server, protocols are advertised here so
+			// that ALPN still negotiates HTTP/2
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			if clientAuth && r.clientCAs != nil {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				config.ClientCAs = r.clientCAs
+			}
+			return config, nil
+		},
+	}
+}
+
+// ReloadOnSIGHUP reloads the files on every SIGHUP until the context is cancelled
+func (r *CertReloader) ReloadOnSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-hup:
+			err := r.Reload()
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed reloading tls certificate %s, serving the previous one", r.config.CertFile)
+				continue
+			}
+			log.Info().Msgf("Reloaded tls certificate %s", r.config.CertFile)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
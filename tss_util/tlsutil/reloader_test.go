@@ -0,0 +1,216 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tlsutil_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"tss-demo/tss_util/tlsutil"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newCertificate(name string, parent *certificate) *certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	cert, _ := x509.ParseCertificate(der)
+	return &certificate{cert: cert, key: key, der: der}
+}
+
+func (c *certificate) write(certPath, keyPath string) {
+	_ = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600)
+	if keyPath != "" {
+		keyDER, _ := x509.MarshalECPrivateKey(c.key)
+		_ = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	}
+}
+
+func (c *certificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+type CertReloaderTestSuite struct {
+	suite.Suite
+	ca     *certificate
+	config relayer.TLSConfig
+}
+
+func TestRunCertReloaderTestSuite(t *testing.T) {
+	suite.Run(t, new(CertReloaderTestSuite))
+}
+
+func (s *CertReloaderTestSuite) SetupTest() {
+	dir := s.T().TempDir()
+	s.config = relayer.TLSConfig{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	s.ca = newCertificate("ca", nil)
+	s.ca.write(s.config.ClientCAFile, "")
+	newCertificate("server-1", s.ca).write(s.config.CertFile, s.config.KeyFile)
+}
+
+func (s *CertReloaderTestSuite) serve(reloader *tlsutil.CertReloader) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.TLS = reloader.TLSConfig()
+	server.StartTLS()
+	s.T().Cleanup(server.Close)
+	return server
+}
+
+// get returns common name of the server certificate
+func (s *CertReloaderTestSuite) get(url string, clientCert *certificate) (string, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	config := &tls.Config{RootCAs: roots}
+	if clientCert != nil {
+		config.Certificates = []tls.Certificate{clientCert.tlsCertificate()}
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+}
+
+func (s *CertReloaderTestSuite) Test_NewCertReloader_MissingFiles() {
+	_, err := tlsutil.NewCertReloader(relayer.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"})
+
+	s.NotNil(err)
+}
+
+func (s *CertReloaderTestSuite) Test_ClientCertificateRequired() {
+	reloader, err := tlsutil.NewCertReloader(s.config)
+	s.Nil(err)
+	server := s.serve(reloader)
+
+	_, err = s.get(server.URL, nil)
+	s.NotNil(err)
+
+	_, err = s.get(server.URL, newCertificate("client", newCertificate("other-ca", nil)))
+	s.NotNil(err)
+
+	name, err := s.get(server.URL, newCertificate("client", s.ca))
+	s.Nil(err)
+	s.Equal("server-1", name)
+}
+
+func (s *CertReloaderTestSuite) Test_ProbeTLSConfig_ClientCertificateNotRequired() {
+	reloader, err := tlsutil.NewCertReloader(s.config)
+	s.Nil(err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.TLS = reloader.ProbeTLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	name, err := s.get(server.URL, nil)
+	s.Nil(err)
+	s.Equal("server-1", name)
+
+	newCertificate("server-2", s.ca).write(s.config.CertFile, s.config.KeyFile)
+	s.Nil(reloader.Reload())
+
+	name, err = s.get(server.URL, nil)
+	s.Nil(err)
+	s.Equal("server-2", name)
+}
+
+func (s *CertReloaderTestSuite) Test_NegotiatesHTTP2() {
+	reloader, err := tlsutil.NewCertReloader(s.config)
+	s.Nil(err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.EnableHTTP2 = true
+	server.TLS = reloader.TLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{newCertificate("client", s.ca).tlsCertificate()},
+		},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get(server.URL)
+	s.Nil(err)
+	defer resp.Body.Close()
+
+	s.Equal(2, resp.ProtoMajor)
+	s.Equal("h2", resp.TLS.NegotiatedProtocol)
+}
+
+func (s *CertReloaderTestSuite) Test_Reload() {
+	reloader, err := tlsutil.NewCertReloader(s.config)
+	s.Nil(err)
+	server := s.serve(reloader)
+	client := newCertificate("client", s.ca)
+
+	newCertificate("server-2", s.ca).write(s.config.CertFile, s.config.KeyFile)
+	s.Nil(reloader.Reload())
+
+	name, err := s.get(server.URL, client)
+	s.Nil(err)
+	s.Equal("server-2", name)
+}
+
+func (s *CertReloaderTestSuite) Test_Reload_InvalidFilesKeepCertificate() {
+	reloader, err := tlsutil.NewCertReloader(s.config)
+	s.Nil(err)
+	server := s.serve(reloader)
+
+	_ = os.WriteFile(s.config.KeyFile, []byte("invalid"), 0600)
+	s.NotNil(reloader.Reload())
+
+	name, err := s.get(server.URL, newCertificate("client", s.ca))
+	s.Nil(err)
+	s.Equal("server-1", name)
+}
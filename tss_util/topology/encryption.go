@@ -8,8 +8,11 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 )
 
+var ErrCiphertextTooShort = errors.New("ciphertext shorter than iv")
+
 type AESEncryption struct {
 	block cipher.Block
 }
@@ -25,12 +28,17 @@ func NewAESEncryption(key []byte) (*AESEncryption, error) {
 	}, nil
 }
 
-func (ae *AESEncryption) Decrypt(ct []byte) []byte {
+// Decrypt decrypts iv + ct encrypted with AES in CTR mode
+func (ae *AESEncryption) Decrypt(ct []byte) ([]byte, error) {
+	if len(ct) < aes.BlockSize {
+		return nil, ErrCiphertextTooShort
+	}
+
 	iv := ct[:aes.BlockSize]
 	stream := cipher.NewCTR(ae.block, iv)
 	dst := make([]byte, len(ct[aes.BlockSize:]))
 	stream.XORKeyStream(dst, ct[aes.BlockSize:])
-	return dst
+	return dst, nil
 }
 
 // Encrypt is a function that encrypts provided bytes with AES in CTR mode
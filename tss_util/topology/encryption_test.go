@@ -42,7 +42,8 @@ func (s *AESEncryptionTestSuite) Test_EncrDecr() {
 	ct, err := s.aesEncryption.Encrypt(pt)
 	s.Nil(err)
 
-	resultingPt := s.aesEncryption.Decrypt(ct)
+	resultingPt, err := s.aesEncryption.Decrypt(ct)
+	s.Nil(err)
 
 	decryptedTopology := topology2.RawTopology{}
 
@@ -51,3 +52,9 @@ func (s *AESEncryptionTestSuite) Test_EncrDecr() {
 
 	s.Equal(expectedTopology, decryptedTopology)
 }
+
+func (s *AESEncryptionTestSuite) Test_DecryptShortCiphertext() {
+	_, err := s.aesEncryption.Decrypt([]byte("short"))
+
+	s.ErrorIs(err, topology2.ErrCiphertextTooShort)
+}
@@ -0,0 +1,124 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type Role string
+
+const (
+	// RoleCoordinator peers sign and can be elected as coordinators
+	RoleCoordinator Role = "coordinator"
+	// RoleSigner peers sign but are never elected as coordinators
+	RoleSigner Role = "signer"
+	// RoleObserver peers hold key shares but are never part of signing subset
+	// or elected as coordinators
+	RoleObserver Role = "observer"
+)
+
+const defaultWeight = 1
+
+// PeerMetadata describes operator of a peer and its role in the network
+type PeerMetadata struct {
+	Name     string
+	Operator string
+	Role     Role
+	// Weight defines preference of the peer when choosing coordinator and signing subset,
+	// peers with higher weight are preferred
+	Weight int
+}
+
+func (m PeerMetadata) CanCoordinate() bool {
+	return m.Role == RoleCoordinator
+}
+
+func (m PeerMetadata) CanSign() bool {
+	return m.Role != RoleObserver
+}
+
+// TopologyGetter returns currently applied topology
+type TopologyGetter interface {
+	Topology() *NetworkTopology
+}
+
+// Topology returns topology itself so that static topology can be used as TopologyGetter
+func (nt *NetworkTopology) Topology() *NetworkTopology {
+	return nt
+}
+
+// Current returns topology from getter or nil if getter is not provided
+func Current(getter TopologyGetter) *NetworkTopology {
+	if getter == nil {
+		return nil
+	}
+	return getter.Topology()
+}
+
+// PeerMetadata returns metadata of the peer. Peers without metadata are
+// coordinator eligible with default weight.
+func (nt *NetworkTopology) PeerMetadata(p peer.ID) PeerMetadata {
+	if nt != nil {
+		metadata, ok := nt.Metadata[p]
+		if ok {
+			return metadata
+		}
+	}
+
+	return PeerMetadata{
+		Role:   RoleCoordinator,
+		Weight: defaultWeight,
+	}
+}
+
+// PeerName returns human readable peer name for logs
+func (nt *NetworkTopology) PeerName(p peer.ID) string {
+	metadata := nt.PeerMetadata(p)
+	if metadata.Name == "" {
+		return p.Pretty()
+	}
+	return fmt.Sprintf("%s(%s)", metadata.Name, p.ShortString())
+}
+
+// PeerNames returns human readable names of the peers for logs
+func (nt *NetworkTopology) PeerNames(peers peer.IDSlice) []string {
+	names := make([]string, len(peers))
+	for i, p := range peers {
+		names[i] = nt.PeerName(p)
+	}
+	return names
+}
+
+func processRawPeerMetadata(rawPeer RawPeer) (PeerMetadata, error) {
+	metadata := PeerMetadata{
+		Name:     rawPeer.Name,
+		Operator: rawPeer.Operator,
+		Role:     Role(rawPeer.Role),
+		Weight:   defaultWeight,
+	}
+
+	switch metadata.Role {
+	case "":
+		metadata.Role = RoleCoordinator
+	case RoleCoordinator, RoleSigner, RoleObserver:
+	default:
+		return PeerMetadata{}, fmt.Errorf("invalid role %s of peer %s", rawPeer.Role, rawPeer.PeerAddress)
+	}
+
+	if rawPeer.Weight != "" {
+		weight, err := strconv.ParseInt(rawPeer.Weight, 0, 0)
+		if err != nil {
+			return PeerMetadata{}, fmt.Errorf("unable to parse weight of peer %s: %v", rawPeer.PeerAddress, err)
+		}
+		if weight < 0 {
+			return PeerMetadata{}, fmt.Errorf("weight of peer %s must not be negative", rawPeer.PeerAddress)
+		}
+		metadata.Weight = int(weight)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,100 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology_test
+
+import (
+	"testing"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type PeerMetadataTestSuite struct {
+	suite.Suite
+	rawTopology *topology.RawTopology
+	peer1       peer.ID
+	peer2       peer.ID
+}
+
+func TestRunPeerMetadataTestSuite(t *testing.T) {
+	suite.Run(t, new(PeerMetadataTestSuite))
+}
+
+func (s *PeerMetadataTestSuite) SetupTest() {
+	s.peer1, _ = peer.Decode("QmcvEg7jGvuxdsUFRUiE4VdrL2P1Yeju5L83BsJvvXz7zX")
+	s.peer2, _ = peer.Decode("QmeTuMtdpPB7zKDgmobEwSvxodrf5aFVSmBXX3SQJVjJaT")
+	s.rawTopology = &topology.RawTopology{
+		Peers: []topology.RawPeer{
+			{
+				PeerAddress: "/dns4/relayer1/tcp/9000/p2p/QmcvEg7jGvuxdsUFRUiE4VdrL2P1Yeju5L83BsJvvXz7zX",
+				Name:        "relayer1",
+				Operator:    "operator1",
+				Role:        "signer",
+				Weight:      "3",
+			},
+			{PeerAddress: "/dns4/relayer2/tcp/9001/p2p/QmeTuMtdpPB7zKDgmobEwSvxodrf5aFVSmBXX3SQJVjJaT"},
+		},
+		Threshold: "1",
+	}
+}
+
+func (s *PeerMetadataTestSuite) Test_ProcessRawTopology_Metadata() {
+	networkTopology, err := topology.ProcessRawTopology(s.rawTopology)
+
+	s.Nil(err)
+	s.Equal(topology.PeerMetadata{
+		Name:     "relayer1",
+		Operator: "operator1",
+		Role:     topology.RoleSigner,
+		Weight:   3,
+	}, networkTopology.PeerMetadata(s.peer1))
+	s.Equal(topology.PeerMetadata{Role: topology.RoleCoordinator, Weight: 1}, networkTopology.PeerMetadata(s.peer2))
+	s.True(networkTopology.PeerMetadata(s.peer1).CanSign())
+	s.False(networkTopology.PeerMetadata(s.peer1).CanCoordinate())
+	s.Contains(networkTopology.PeerName(s.peer1), "relayer1")
+	s.Equal(s.peer2.Pretty(), networkTopology.PeerName(s.peer2))
+}
+
+func (s *PeerMetadataTestSuite) Test_ProcessRawTopology_InvalidRole() {
+	s.rawTopology.Peers[0].Role = "invalid"
+
+	_, err := topology.ProcessRawTopology(s.rawTopology)
+
+	s.NotNil(err)
+}
+
+func (s *PeerMetadataTestSuite) Test_ProcessRawTopology_InvalidWeight() {
+	s.rawTopology.Peers[0].Weight = "-1"
+
+	_, err := topology.ProcessRawTopology(s.rawTopology)
+
+	s.NotNil(err)
+}
+
+func (s *PeerMetadataTestSuite) Test_NilTopology_DefaultMetadata() {
+	var networkTopology *topology.NetworkTopology
+
+	s.True(networkTopology.PeerMetadata(s.peer1).CanCoordinate())
+	s.Equal(1, networkTopology.PeerMetadata(s.peer1).Weight)
+}
+
+func (s *PeerMetadataTestSuite) Test_RawTopologyRoundTrip_KeepsMetadata() {
+	networkTopology, _ := topology.ProcessRawTopology(s.rawTopology)
+
+	processed, err := topology.ProcessRawTopology(networkTopology.ToRawTopology())
+
+	s.Nil(err)
+	s.Equal(networkTopology.Metadata, processed.Metadata)
+}
+
+func (s *PeerMetadataTestSuite) Test_Diff_MetadataChange() {
+	previous, _ := topology.ProcessRawTopology(s.rawTopology)
+	s.rawTopology.Peers[1].Role = "observer"
+	current, _ := topology.ProcessRawTopology(s.rawTopology)
+
+	change := topology.Diff(previous, current)
+
+	s.Equal(peer.IDSlice{s.peer2}, change.Updated)
+}
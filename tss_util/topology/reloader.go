@@ -0,0 +1,322 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrKeyshareLocked = errors.New("keyshare locked by pending tss process")
+
+// KeyshareLocker guards keyshare from being changed during keygen or resharing
+type KeyshareLocker interface {
+	TryLockKeyshare() bool
+	UnlockKeyshare()
+}
+
+// TopologyChange describes difference between previous and current topology
+type TopologyChange struct {
+	Previous *NetworkTopology
+	Current  *NetworkTopology
+	// Added contains peers that were not part of the previous topology
+	Added peer.IDSlice
+	// Removed contains peers that are not part of the current topology
+	Removed peer.IDSlice
+	// Updated contains peers with changed addresses or metadata
+	Updated peer.IDSlice
+}
+
+// IsEmpty returns true if topologies are equal
+func (c TopologyChange) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Updated) == 0 &&
+		c.Previous.Threshold == c.Current.Threshold &&
+		c.Previous.Version == c.Current.Version
+}
+
+// Diff compares previous and current topology
+func Diff(previous *NetworkTopology, current *NetworkTopology) TopologyChange {
+	change := TopologyChange{
+		Previous: previous,
+		Current:  current,
+		Added:    make(peer.IDSlice, 0),
+		Removed:  make(peer.IDSlice, 0),
+		Updated:  make(peer.IDSlice, 0),
+	}
+
+	previousPeers := make(map[peer.ID]*peer.AddrInfo)
+	for _, p := range previous.Peers {
+		previousPeers[p.ID] = p
+	}
+	for _, p := range current.Peers {
+		previousPeer, ok := previousPeers[p.ID]
+		if !ok {
+			change.Added = append(change.Added, p.ID)
+			continue
+		}
+		if !equalAddrs(previousPeer, p) || previous.PeerMetadata(p.ID) != current.PeerMetadata(p.ID) {
+			change.Updated = append(change.Updated, p.ID)
+		}
+		delete(previousPeers, p.ID)
+	}
+	for _, p := range previous.Peers {
+		if _, ok := previousPeers[p.ID]; ok {
+			change.Removed = append(change.Removed, p.ID)
+		}
+	}
+	return change
+}
+
+func equalAddrs(a *peer.AddrInfo, b *peer.AddrInfo) bool {
+	if len(a.Addrs) != len(b.Addrs) {
+		return false
+	}
+	for i := range a.Addrs {
+		if !a.Addrs[i].Equal(b.Addrs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type TopologyListener func(change TopologyChange)
+
+// RejectionListener is called with topology that failed validation against the current topology
+type RejectionListener func(topology *NetworkTopology, err error)
+
+// TopologyReloader keeps the node topology in sync with the topology source.
+//
+// Every change is validated against the currently applied topology and applied
+// only while keyshares are not locked by keygen or resharing. Changes refused
+// because of a pending tss process are retried on the next refresh.
+type TopologyReloader struct {
+	provider          NetworkTopologyProvider
+	store             *TopologyStore
+	lockers           []KeyshareLocker
+	hash              string
+	requireSignatures bool
+	trustedSigners    peer.IDSlice
+
+	mu        sync.Mutex
+	current   *NetworkTopology
+	latest    uint64
+	listeners []TopologyListener
+	rejected  []RejectionListener
+}
+
+func NewTopologyReloader(
+	provider NetworkTopologyProvider,
+	store *TopologyStore,
+	config relayer.TopologyConfiguration,
+	lockers ...KeyshareLocker,
+) *TopologyReloader {
+	// signers are validated with the configuration
+	trustedSigners := make(peer.IDSlice, 0, len(config.TrustedSigners))
+	for _, signer := range config.TrustedSigners {
+		id, err := peer.Decode(signer)
+		if err == nil {
+			trustedSigners = append(trustedSigners, id)
+		}
+	}
+	return &TopologyReloader{
+		provider:          provider,
+		store:             store,
+		lockers:           lockers,
+		hash:              config.Hash,
+		requireSignatures: config.SignaturesRequired(),
+		trustedSigners:    trustedSigners,
+		listeners:         make([]TopologyListener, 0),
+	}
+}
+
+// Load reads topology from the provider when the node starts. Stored topology is used
+// if the provider is unavailable or the fetched topology can't replace the stored one.
+//
+// Stored topology could have been edited or replaced by hand, so it is validated against
+// the trusted signers and the last applied version instead of against itself.
+func (r *TopologyReloader) Load() (*NetworkTopology, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	applied, err := r.store.AppliedVersion()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := r.store.Topology()
+	if err != nil {
+		stored = nil
+	} else {
+		err = ValidateAnchoredTopology(stored, applied, r.trustedSigners, r.requireSignatures)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Refusing stored topology version %d", stored.Version)
+			stored = nil
+		}
+	}
+
+	topology, err := r.provider.NetworkTopology(r.hash)
+	if err == nil {
+		r.trackLatest(topology)
+		if stored != nil {
+			err = ValidateTopology(stored, topology, r.requireSignatures)
+		} else {
+			err = ValidateAnchoredTopology(topology, applied, r.trustedSigners, r.requireSignatures)
+		}
+	}
+	if err != nil {
+		if stored == nil {
+			return nil, err
+		}
+
+		log.Warn().Err(err).Msgf("Unable to load latest topology, using stored topology version %d", stored.Version)
+		r.current = stored
+		return stored, nil
+	}
+
+	err = r.store.StoreTopology(topology)
+	if err != nil {
+		return nil, err
+	}
+	r.current = topology
+	return topology, nil
+}
+
+// OnChange registers listener called with every applied topology change.
+// Listeners are called while keyshares are locked.
+func (r *TopologyReloader) OnChange(listener TopologyListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, listener)
+}
+
+// OnRejected registers listener called with every fetched topology refused by validation
+func (r *TopologyReloader) OnRejected(listener RejectionListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rejected = append(r.rejected, listener)
+}
+
+// Topology returns currently applied topology
+func (r *TopologyReloader) Topology() *NetworkTopology {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// LatestVersion returns the highest topology version fetched from the provider.
+// It is higher than the applied version while a change is postponed or refused.
+func (r *TopologyReloader) LatestVersion() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != nil && r.current.Version > r.latest {
+		return r.current.Version
+	}
+	return r.latest
+}
+
+func (r *TopologyReloader) trackLatest(topology *NetworkTopology) {
+	if topology.Version > r.latest {
+		r.latest = topology.Version
+	}
+}
+
+// Reload fetches latest topology and applies it if it differs from the current topology
+func (r *TopologyReloader) Reload() (TopologyChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil {
+		return TopologyChange{}, errors.New("topology not loaded")
+	}
+	topology, err := r.provider.NetworkTopology(r.hash)
+	if err != nil {
+		return TopologyChange{}, err
+	}
+	r.trackLatest(topology)
+
+	change := Diff(r.current, topology)
+	if change.IsEmpty() {
+		return change, nil
+	}
+	err = ValidateTopology(r.current, topology, r.requireSignatures)
+	if err != nil {
+		for _, listener := range r.rejected {
+			listener(topology, err)
+		}
+		return change, err
+	}
+
+	for i, locker := range r.lockers {
+		if !locker.TryLockKeyshare() {
+			for _, l := range r.lockers[:i] {
+				l.UnlockKeyshare()
+			}
+			return change, ErrKeyshareLocked
+		}
+	}
+	defer func() {
+		for _, l := range r.lockers {
+			l.UnlockKeyshare()
+		}
+	}()
+
+	err = r.store.StoreTopology(topology)
+	if err != nil {
+		return change, err
+	}
+	r.current = topology
+	for _, listener := range r.listeners {
+		listener(change)
+	}
+	return change, nil
+}
+
+// Start reloads topology on every interval until context is cancelled. Topology pinned
+// by hash can't change, so it is not reloaded.
+func (r *TopologyReloader) Start(ctx context.Context, interval time.Duration) {
+	if r.hash != "" {
+		log.Info().Msg("Topology pinned by hash, reloading disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			change, err := r.Reload()
+			if errors.Is(err, ErrKeyshareLocked) {
+				log.Info().Msg("Topology change postponed until pending tss process finishes")
+				continue
+			}
+			if err != nil {
+				log.Warn().Err(err).Msg("Unable to reload topology")
+				continue
+			}
+			if change.IsEmpty() {
+				continue
+			}
+
+			log.Info().
+				Uint64("version", change.Current.Version).
+				Int("threshold", change.Current.Threshold).
+				Interface("added", change.Added).
+				Interface("removed", change.Removed).
+				Interface("updated", change.Updated).
+				Msg("Applied topology change")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+	"tss-demo/tss_util/topology"
+	mock_topology "tss-demo/tss_util/topology/mock"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TopologyReloaderTestSuite struct {
+	suite.Suite
+	path     string
+	store    *topology.TopologyStore
+	provider *mock_topology.MockNetworkTopologyProvider
+	locker   *mock_topology.MockKeyshareLocker
+	reloader *topology.TopologyReloader
+	v1       *topology.NetworkTopology
+	v2       *topology.NetworkTopology
+}
+
+func TestRunTopologyReloaderTestSuite(t *testing.T) {
+	suite.Run(t, new(TopologyReloaderTestSuite))
+}
+
+func (s *TopologyReloaderTestSuite) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.path = "reloader-topology.json"
+	s.store = topology.NewTopologyStore(s.path)
+	s.provider = mock_topology.NewMockNetworkTopologyProvider(ctrl)
+	s.locker = mock_topology.NewMockKeyshareLocker(ctrl)
+	s.reloader = topology.NewTopologyReloader(s.provider, s.store, relayer.TopologyConfiguration{Hash: "hash"}, s.locker)
+
+	s.v1, _ = topology.ProcessRawTopology(&topology.RawTopology{
+		Peers: []topology.RawPeer{
+			{PeerAddress: "/dns4/relayer1/tcp/9000/p2p/QmcvEg7jGvuxdsUFRUiE4VdrL2P1Yeju5L83BsJvvXz7zX"},
+			{PeerAddress: "/dns4/relayer2/tcp/9001/p2p/QmeTuMtdpPB7zKDgmobEwSvxodrf5aFVSmBXX3SQJVjJaT"},
+		},
+		Threshold: "1",
+		Version:   "1",
+	})
+	s.v2, _ = topology.ProcessRawTopology(&topology.RawTopology{
+		Peers: []topology.RawPeer{
+			{PeerAddress: "/dns4/relayer1/tcp/9010/p2p/QmcvEg7jGvuxdsUFRUiE4VdrL2P1Yeju5L83BsJvvXz7zX"},
+			{PeerAddress: "/dns4/relayer3/tcp/9002/p2p/QmYAYuLUPNwYEBYJaKHcE7NKjUhiUV8txx2xDXHvcYa1xK"},
+		},
+		Threshold: "1",
+		Version:   "2",
+	})
+}
+
+func (s *TopologyReloaderTestSuite) TearDownTest() {
+	os.Remove(s.path)
+	os.Remove(s.path + ".version")
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_ProviderPreferredOverStore() {
+	_ = s.store.StoreTopology(s.v1)
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+
+	tp, err := s.reloader.Load()
+
+	s.Nil(err)
+	s.Equal(s.v2.Version, tp.Version)
+	stored, _ := s.store.Topology()
+	s.Equal(s.v2.Version, stored.Version)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_ProviderUnavailable_UsesStore() {
+	_ = s.store.StoreTopology(s.v1)
+	s.provider.EXPECT().NetworkTopology("hash").Return(nil, errors.New("error"))
+
+	tp, err := s.reloader.Load()
+
+	s.Nil(err)
+	s.Equal(s.v1.Version, tp.Version)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_LowerVersion_UsesStore() {
+	_ = s.store.StoreTopology(s.v2)
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+
+	tp, err := s.reloader.Load()
+
+	s.Nil(err)
+	s.Equal(s.v2.Version, tp.Version)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_StoredOlderThanApplied_Refused() {
+	_ = s.store.StoreTopology(s.v2)
+	_ = s.store.StoreTopology(s.v1)
+	s.provider.EXPECT().NetworkTopology("hash").Return(nil, errors.New("error"))
+
+	_, err := s.reloader.Load()
+
+	s.NotNil(err)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_StoredOlderThanApplied_UsesProvider() {
+	_ = s.store.StoreTopology(s.v2)
+	_ = s.store.StoreTopology(s.v1)
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+
+	tp, err := s.reloader.Load()
+
+	s.Nil(err)
+	s.Equal(s.v2.Version, tp.Version)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Load_NoTopology_Error() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(nil, errors.New("error"))
+
+	_, err := s.reloader.Load()
+
+	s.NotNil(err)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Reload_AppliesChange() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+	_, _ = s.reloader.Load()
+	var applied *topology.TopologyChange
+	s.reloader.OnChange(func(change topology.TopologyChange) {
+		applied = &change
+	})
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+	s.locker.EXPECT().TryLockKeyshare().Return(true)
+	s.locker.EXPECT().UnlockKeyshare()
+
+	change, err := s.reloader.Reload()
+
+	s.Nil(err)
+	s.NotNil(applied)
+	s.Equal(s.v2.Peers[1].ID, change.Added[0])
+	s.Equal(s.v1.Peers[1].ID, change.Removed[0])
+	s.Equal(s.v1.Peers[0].ID, change.Updated[0])
+	s.Equal(s.v2, s.reloader.Topology())
+}
+
+func (s *TopologyReloaderTestSuite) Test_Reload_NoChange() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil).Times(2)
+	_, _ = s.reloader.Load()
+	s.reloader.OnChange(func(change topology.TopologyChange) {
+		s.Fail("listener called without change")
+	})
+
+	change, err := s.reloader.Reload()
+
+	s.Nil(err)
+	s.True(change.IsEmpty())
+}
+
+func (s *TopologyReloaderTestSuite) Test_Reload_KeyshareLocked_Refused() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+	_, _ = s.reloader.Load()
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+	s.locker.EXPECT().TryLockKeyshare().Return(false)
+
+	_, err := s.reloader.Reload()
+
+	s.True(errors.Is(err, topology.ErrKeyshareLocked))
+	s.Equal(s.v1, s.reloader.Topology())
+	stored, _ := s.store.Topology()
+	s.Equal(s.v1.Version, stored.Version)
+}
+
+func (s *TopologyReloaderTestSuite) Test_Reload_LowerVersion_Refused() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+	_, _ = s.reloader.Load()
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+
+	_, err := s.reloader.Reload()
+
+	s.True(errors.Is(err, topology.ErrVersionLowered))
+	s.Equal(s.v2, s.reloader.Topology())
+}
+
+func (s *TopologyReloaderTestSuite) Test_Reload_Refused_NotifiesRejection() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+	_, _ = s.reloader.Load()
+	var rejected *topology.NetworkTopology
+	var rejection error
+	s.reloader.OnRejected(func(topology *topology.NetworkTopology, err error) {
+		rejected = topology
+		rejection = err
+	})
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+
+	_, err := s.reloader.Reload()
+
+	s.Equal(s.v1, rejected)
+	s.Equal(err, rejection)
+}
+
+func (s *TopologyReloaderTestSuite) Test_LatestVersion_RefusedChangeTracked() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+	_, _ = s.reloader.Load()
+	s.Equal(s.v1.Version, s.reloader.LatestVersion())
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v2, nil)
+	s.locker.EXPECT().TryLockKeyshare().Return(false)
+
+	_, _ = s.reloader.Reload()
+
+	s.Equal(s.v1, s.reloader.Topology())
+	s.Equal(s.v2.Version, s.reloader.LatestVersion())
+}
+
+func (s *TopologyReloaderTestSuite) Test_Start_PinnedTopologyNotReloaded() {
+	s.provider.EXPECT().NetworkTopology("hash").Return(s.v1, nil)
+	_, _ = s.reloader.Load()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.reloader.Start(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.Fail("pinned topology reloaded")
+	}
+}
@@ -0,0 +1,231 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const signingDomain = "tss-topology-v1"
+
+var (
+	ErrVersionLowered   = errors.New("topology version lower than current version")
+	ErrVersionNotBumped = errors.New("topology changed without version increase")
+	ErrQuorumNotMet     = errors.New("topology not approved by quorum of operators")
+	ErrUntrusted        = errors.New("topology not approved by majority of trusted signers")
+)
+
+// TopologySignature is an approval of topology document by a single operator.
+// Public key is part of the signature because RSA peer IDs don't embed the key.
+type TopologySignature struct {
+	Signer    peer.ID `mapstructure:"Signer" json:"signer"`
+	PublicKey []byte  `mapstructure:"PublicKey" json:"publicKey"`
+	Signature []byte  `mapstructure:"Signature" json:"signature"`
+}
+
+// Digest returns hash of topology contents that operators sign.
+// Signatures are not part of the digest.
+func (nt NetworkTopology) Digest() ([]byte, error) {
+	type signedPeer struct {
+		ID       string   `json:"id"`
+		Addrs    []string `json:"addrs"`
+		Name     string   `json:"name"`
+		Operator string   `json:"operator"`
+		Role     Role     `json:"role"`
+		Weight   int      `json:"weight"`
+	}
+	peers := make([]signedPeer, len(nt.Peers))
+	for i, p := range nt.Peers {
+		addrs := make([]string, len(p.Addrs))
+		for j, a := range p.Addrs {
+			addrs[j] = a.String()
+		}
+		metadata := nt.PeerMetadata(p.ID)
+		peers[i] = signedPeer{
+			ID:       p.ID.String(),
+			Addrs:    addrs,
+			Name:     metadata.Name,
+			Operator: metadata.Operator,
+			Role:     metadata.Role,
+			Weight:   metadata.Weight,
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Domain    string       `json:"domain"`
+		Version   uint64       `json:"version"`
+		Threshold int          `json:"threshold"`
+		Peers     []signedPeer `json:"peers"`
+	}{
+		Domain:    signingDomain,
+		Version:   nt.Version,
+		Threshold: nt.Threshold,
+		Peers:     peers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(data)
+	return digest[:], nil
+}
+
+// Sign approves topology with provided operator key
+func (nt NetworkTopology) Sign(priv crypto.PrivKey) (*TopologySignature, error) {
+	digest, err := nt.Digest()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.MarshalPublicKey(priv.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := priv.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TopologySignature{
+		Signer:    signer,
+		PublicKey: pub,
+		Signature: sig,
+	}, nil
+}
+
+// Approvers returns operators whose signatures on topology are valid
+func (nt NetworkTopology) Approvers() (peer.IDSlice, error) {
+	digest, err := nt.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := make(peer.IDSlice, 0)
+	seen := make(map[peer.ID]bool)
+	for _, s := range nt.Signatures {
+		if seen[s.Signer] || s.verify(digest) != nil {
+			continue
+		}
+		seen[s.Signer] = true
+		approvers = append(approvers, s.Signer)
+	}
+	return approvers, nil
+}
+
+func (s TopologySignature) verify(digest []byte) error {
+	pub, err := crypto.UnmarshalPublicKey(s.PublicKey)
+	if err != nil {
+		return err
+	}
+	signer, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	if signer != s.Signer {
+		return fmt.Errorf("public key does not belong to signer %s", s.Signer.Pretty())
+	}
+
+	valid, err := pub.Verify(digest, s.Signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature from %s", s.Signer.Pretty())
+	}
+	return nil
+}
+
+// Quorum returns number of operator approvals required to change the topology.
+// It matches the number of parties required to sign with the current key.
+func (nt NetworkTopology) Quorum() int {
+	return nt.Threshold + 1
+}
+
+// ValidateTopology checks if topology can replace the current topology.
+// Version can't be lowered and contents can't change without increasing the version.
+// If signatures are required, topology has to be signed by a quorum of the current
+// operators or by a quorum of its own operators when there is no current topology.
+func ValidateTopology(current *NetworkTopology, topology *NetworkTopology, requireSignatures bool) error {
+	approving := topology
+	if current != nil {
+		approving = current
+
+		if topology.Version < current.Version {
+			return fmt.Errorf("%w: %d < %d", ErrVersionLowered, topology.Version, current.Version)
+		}
+		if topology.Version == current.Version {
+			currentDigest, err := current.Digest()
+			if err != nil {
+				return err
+			}
+			digest, err := topology.Digest()
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(currentDigest, digest) {
+				return fmt.Errorf("%w: version %d", ErrVersionNotBumped, topology.Version)
+			}
+		}
+	}
+
+	if !requireSignatures {
+		return nil
+	}
+
+	approvers, err := topology.Approvers()
+	if err != nil {
+		return err
+	}
+	approvals := 0
+	for _, a := range approvers {
+		if approving.IsAllowedPeer(a) {
+			approvals++
+		}
+	}
+	if approvals < approving.Quorum() {
+		return fmt.Errorf("%w: %d of %d required approvals", ErrQuorumNotMet, approvals, approving.Quorum())
+	}
+	return nil
+}
+
+// ValidateAnchoredTopology checks topology that is loaded without a current topology, e.g.
+// topology stored by the node when it starts. Version can't be lower than the last applied
+// version. If signatures are required, topology can't approve itself and has to be signed
+// by a majority of the trusted signers.
+func ValidateAnchoredTopology(topology *NetworkTopology, appliedVersion uint64, trustedSigners peer.IDSlice, requireSignatures bool) error {
+	if topology.Version < appliedVersion {
+		return fmt.Errorf("%w: %d < %d", ErrVersionLowered, topology.Version, appliedVersion)
+	}
+	if !requireSignatures {
+		return nil
+	}
+
+	approvers, err := topology.Approvers()
+	if err != nil {
+		return err
+	}
+	approvals := 0
+	for _, a := range approvers {
+		for _, trusted := range trustedSigners {
+			if a == trusted {
+				approvals++
+				break
+			}
+		}
+	}
+	if approvals < len(trustedSigners)/2+1 {
+		return fmt.Errorf("%w: %d of %d trusted signers", ErrUntrusted, approvals, len(trustedSigners))
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package topology_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"tss-demo/tss_util/topology"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type SignedTopologyTestSuite struct {
+	suite.Suite
+	keys    []crypto.PrivKey
+	current *topology.NetworkTopology
+}
+
+func TestRunSignedTopologyTestSuite(t *testing.T) {
+	suite.Run(t, new(SignedTopologyTestSuite))
+}
+
+func (s *SignedTopologyTestSuite) SetupTest() {
+	s.keys = make([]crypto.PrivKey, 4)
+	for i := range s.keys {
+		s.keys[i], _, _ = crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	}
+	s.current = s.topology(1, 1, s.keys[:3]...)
+}
+
+func (s *SignedTopologyTestSuite) topology(version uint64, threshold int, keys ...crypto.PrivKey) *topology.NetworkTopology {
+	rawTopology := &topology.RawTopology{
+		Threshold: fmt.Sprint(threshold),
+		Version:   fmt.Sprint(version),
+	}
+	for i, key := range keys {
+		id, _ := peer.IDFromPrivateKey(key)
+		rawTopology.Peers = append(rawTopology.Peers, topology.RawPeer{
+			PeerAddress: fmt.Sprintf("/ip4/127.0.0.1/tcp/%d/p2p/%s", 9000+i, id.Pretty()),
+		})
+	}
+	networkTopology, err := topology.ProcessRawTopology(rawTopology)
+	s.Nil(err)
+	return networkTopology
+}
+
+func (s *SignedTopologyTestSuite) sign(networkTopology *topology.NetworkTopology, keys ...crypto.PrivKey) {
+	for _, key := range keys {
+		signature, err := networkTopology.Sign(key)
+		s.Nil(err)
+		networkTopology.Signatures = append(networkTopology.Signatures, *signature)
+	}
+}
+
+func (s *SignedTopologyTestSuite) Test_QuorumOfCurrentOperators_Valid() {
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[1])
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.Nil(err)
+}
+
+func (s *SignedTopologyTestSuite) Test_SignaturesFromNewOperators_NotCounted() {
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[3])
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.True(errors.Is(err, topology.ErrQuorumNotMet))
+}
+
+func (s *SignedTopologyTestSuite) Test_DuplicateSignatures_NotCounted() {
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[0])
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.True(errors.Is(err, topology.ErrQuorumNotMet))
+}
+
+func (s *SignedTopologyTestSuite) Test_TamperedTopology_Invalid() {
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[1])
+	next.Threshold = 1
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.True(errors.Is(err, topology.ErrQuorumNotMet))
+}
+
+func (s *SignedTopologyTestSuite) Test_SignatureWithForeignPublicKey_Invalid() {
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[3])
+	next.Signatures[1].Signer, _ = peer.IDFromPrivateKey(s.keys[1])
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.True(errors.Is(err, topology.ErrQuorumNotMet))
+}
+
+func (s *SignedTopologyTestSuite) Test_LowerVersion_Invalid() {
+	s.current.Version = 3
+	next := s.topology(2, 2, s.keys...)
+	s.sign(next, s.keys[0], s.keys[1], s.keys[2])
+
+	err := topology.ValidateTopology(s.current, next, true)
+
+	s.True(errors.Is(err, topology.ErrVersionLowered))
+}
+
+func (s *SignedTopologyTestSuite) Test_ChangeWithoutVersionIncrease_Invalid() {
+	next := s.topology(1, 2, s.keys...)
+
+	err := topology.ValidateTopology(s.current, next, false)
+
+	s.True(errors.Is(err, topology.ErrVersionNotBumped))
+}
+
+func (s *SignedTopologyTestSuite) Test_SameTopology_Valid() {
+	s.sign(s.current, s.keys[0], s.keys[1])
+
+	err := topology.ValidateTopology(s.current, s.current, true)
+
+	s.Nil(err)
+}
+
+func (s *SignedTopologyTestSuite) Test_InitialTopology_SignedByOwnOperators() {
+	s.sign(s.current, s.keys[0])
+
+	err := topology.ValidateTopology(nil, s.current, true)
+	s.True(errors.Is(err, topology.ErrQuorumNotMet))
+
+	s.sign(s.current, s.keys[1])
+	err = topology.ValidateTopology(nil, s.current, true)
+	s.Nil(err)
+}
+
+func (s *SignedTopologyTestSuite) Test_UnsignedTopology_ValidWithoutRequiredSignatures() {
+	err := topology.ValidateTopology(nil, s.current, false)
+
+	s.Nil(err)
+}
+
+func (s *SignedTopologyTestSuite) Test_RawTopologyRoundTrip_KeepsSignatures() {
+	s.sign(s.current, s.keys[0], s.keys[1])
+
+	networkTopology, err := topology.ProcessRawTopology(s.current.ToRawTopology())
+	s.Nil(err)
+
+	err = topology.ValidateTopology(nil, networkTopology, true)
+	s.Nil(err)
+	s.Equal(s.current.Version, networkTopology.Version)
+}
+
+func (s *SignedTopologyTestSuite) trusted(keys ...crypto.PrivKey) peer.IDSlice {
+	ids := make(peer.IDSlice, 0)
+	for _, key := range keys {
+		id, _ := peer.IDFromPrivateKey(key)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *SignedTopologyTestSuite) Test_AnchoredTopology_SignedByTrustedMajority() {
+	s.sign(s.current, s.keys[0], s.keys[1])
+
+	err := topology.ValidateAnchoredTopology(s.current, 1, s.trusted(s.keys[0], s.keys[1], s.keys[3]), true)
+
+	s.Nil(err)
+}
+
+func (s *SignedTopologyTestSuite) Test_AnchoredTopology_SelfSignedUntrusted() {
+	forged := s.topology(5, 1, s.keys[3])
+	s.sign(forged, s.keys[3])
+
+	err := topology.ValidateAnchoredTopology(forged, 1, s.trusted(s.keys[0], s.keys[1], s.keys[2]), true)
+
+	s.True(errors.Is(err, topology.ErrUntrusted))
+}
+
+func (s *SignedTopologyTestSuite) Test_AnchoredTopology_OlderThanApplied() {
+	s.sign(s.current, s.keys[0], s.keys[1])
+
+	err := topology.ValidateAnchoredTopology(s.current, 2, s.trusted(s.keys[0], s.keys[1]), true)
+
+	s.True(errors.Is(err, topology.ErrVersionLowered))
+}
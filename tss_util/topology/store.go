@@ -5,10 +5,15 @@ package topology
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+const versionFileSuffix = ".version"
+
 type TopologyStore struct {
 	mu   sync.Mutex
 	path string
@@ -38,7 +43,35 @@ func (ts *TopologyStore) StoreTopology(topology *NetworkTopology) error {
 	}
 
 	_, err = f.Write(kb)
-	return err
+	if err != nil {
+		return err
+	}
+
+	applied, err := ts.appliedVersion()
+	if err != nil || topology.Version <= applied {
+		return err
+	}
+	return os.WriteFile(ts.path+versionFileSuffix, []byte(strconv.FormatUint(topology.Version, 10)), 0644)
+}
+
+// AppliedVersion returns the highest version of topology stored by the node. It is kept
+// apart from the topology, so that replacing the topology file doesn't roll it back.
+func (ts *TopologyStore) AppliedVersion() (uint64, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.appliedVersion()
+}
+
+func (ts *TopologyStore) appliedVersion() (uint64, error) {
+	vb, err := os.ReadFile(ts.path + versionFileSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(vb)), 10, 64)
 }
 
 // Topology fetches current topology from file
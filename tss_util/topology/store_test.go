@@ -30,6 +30,7 @@ func (s *TopologyStoreTestSuite) SetupTest() {
 }
 func (s *TopologyStoreTestSuite) TearDownTest() {
 	os.Remove(s.path)
+	os.Remove(s.path + ".version")
 }
 
 func (s *TopologyStoreTestSuite) Test_RetrieveNonExistentFile_Error() {
@@ -58,3 +59,16 @@ func (s *TopologyStoreTestSuite) Test_StoreAndRetrieveTopology() {
 
 	s.True(reflect.DeepEqual(networkTopology, storedTopology))
 }
+
+func (s *TopologyStoreTestSuite) Test_AppliedVersion_NotLowered() {
+	version, err := s.topologyStore.AppliedVersion()
+	s.Nil(err)
+	s.Equal(uint64(0), version)
+
+	_ = s.topologyStore.StoreTopology(&topology2.NetworkTopology{Threshold: 1, Version: 2})
+	_ = s.topologyStore.StoreTopology(&topology2.NetworkTopology{Threshold: 1, Version: 1})
+
+	version, err = s.topologyStore.AppliedVersion()
+	s.Nil(err)
+	s.Equal(uint64(2), version)
+}
@@ -4,11 +4,16 @@
 package topology
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"tss-demo/tss_util/tss_config/relayer"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -16,8 +21,11 @@ import (
 )
 
 type NetworkTopology struct {
-	Peers     []*peer.AddrInfo
-	Threshold int
+	Peers      []*peer.AddrInfo
+	Threshold  int
+	Version    uint64
+	Metadata   map[peer.ID]PeerMetadata `json:",omitempty"`
+	Signatures []TopologySignature      `json:",omitempty"`
 }
 
 func (nt NetworkTopology) IsAllowedPeer(peer peer.ID) bool {
@@ -31,19 +39,26 @@ func (nt NetworkTopology) IsAllowedPeer(peer peer.ID) bool {
 }
 
 type RawTopology struct {
-	Peers     []RawPeer `mapstructure:"Peers" json:"peers"`
-	Threshold string    `mapstructure:"Threshold" json:"threshold"`
+	Peers      []RawPeer           `mapstructure:"Peers" json:"peers"`
+	Threshold  string              `mapstructure:"Threshold" json:"threshold"`
+	Version    string              `mapstructure:"Version" json:"version,omitempty"`
+	Signatures []TopologySignature `mapstructure:"Signatures" json:"signatures,omitempty"`
 }
 
 type RawPeer struct {
 	PeerAddress string `mapstructure:"PeerAddress" json:"peerAddress"`
+	Name        string `mapstructure:"Name" json:"name,omitempty"`
+	Operator    string `mapstructure:"Operator" json:"operator,omitempty"`
+	Role        string `mapstructure:"Role" json:"role,omitempty"`
+	Weight      string `mapstructure:"Weight" json:"weight,omitempty"`
 }
+
 type Fetcher interface {
 	Get(url string) (*http.Response, error)
 }
 
 type Decrypter interface {
-	Decrypt(data []byte) []byte
+	Decrypt(data []byte) ([]byte, error)
 }
 
 type NetworkTopologyProvider interface {
@@ -52,51 +67,138 @@ type NetworkTopologyProvider interface {
 	NetworkTopology(hash string) (*NetworkTopology, error)
 }
 
+// NewNetworkTopologyProvider creates topology provider that fetches encrypted topology from the configured
+// URL or reads it from the configured path if URL is not provided. Topology fetched from the URL
+// is always encrypted, so the encryption key is required with the URL.
 func NewNetworkTopologyProvider(config relayer.TopologyConfiguration, fetcher Fetcher) (NetworkTopologyProvider, error) {
-	//decrypter, err := NewAESEncryption([]byte(config.EncryptionKey))
-	//if err != nil {
-	//	return nil, err
-	//}
+	if config.Url == "" {
+		return &FileTopologyProvider{
+			path: config.Path,
+		}, nil
+	}
+
+	if config.EncryptionKey == "" {
+		return nil, errors.New("topology encryption key is required to fetch topology from url")
+	}
+	aesEncryption, err := NewAESEncryption([]byte(config.EncryptionKey))
+	if err != nil {
+		return nil, err
+	}
 
 	return &TopologyProvider{
-		//decrypter: decrypter,
-		//url:       config.Url,
-		fetcher: fetcher,
-		path:    config.Path,
+		decrypter: aesEncryption,
+		url:       config.Url,
+		fetcher:   fetcher,
 	}, nil
 }
 
+// TopologyProvider fetches hex encoded topology from remote URL
 type TopologyProvider struct {
-	//url string
-	path string
-	//decrypter Decrypter
-	fetcher Fetcher
+	url       string
+	decrypter Decrypter
+	fetcher   Fetcher
 }
 
 func (t *TopologyProvider) NetworkTopology(hash string) (*NetworkTopology, error) {
+	log.Info().Msgf("Reading topology from URL: %s", t.url)
+
+	resp, err := t.fetcher.Get(t.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch topology: unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := hex.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode topology: %w", err)
+	}
+
+	err = verifyHash(ct, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, err := t.decrypter.Decrypt(ct)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt topology: %w", err)
+	}
+	rawTopology := &RawTopology{}
+	err = json.Unmarshal(pt, rawTopology)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal topology: %w", err)
+	}
+
+	return ProcessRawTopology(rawTopology)
+}
+
+// FileTopologyProvider reads plain text topology from local file
+type FileTopologyProvider struct {
+	path string
+}
+
+func (t *FileTopologyProvider) NetworkTopology(hash string) (*NetworkTopology, error) {
 	log.Info().Msgf("Reading topology from path: %s", t.path)
 
 	data, err := os.ReadFile(t.path)
 	if err != nil {
 		return nil, err
 	}
+
+	err = verifyHash(data, hash)
+	if err != nil {
+		return nil, err
+	}
+
 	rawTopology := &RawTopology{}
 	err = json.Unmarshal(data, rawTopology)
 	if err != nil {
-		return nil, err
+		// path is also used by TopologyStore so it can contain already processed topology
+		topology := &NetworkTopology{}
+		if json.Unmarshal(data, topology) != nil {
+			return nil, err
+		}
+		return topology, nil
 	}
 
 	return ProcessRawTopology(rawTopology)
 }
 
+// verifyHash checks that SHA-256 hash of data matches expected hex encoded hash.
+// Verification is skipped if expected hash is empty.
+func verifyHash(data []byte, expectedHash string) error {
+	if expectedHash == "" {
+		return nil
+	}
+
+	h := sha256.Sum256(data)
+	actualHash := hex.EncodeToString(h[:])
+	if !strings.EqualFold(actualHash, expectedHash) {
+		return fmt.Errorf("topology hash %s does not match expected hash %s", actualHash, expectedHash)
+	}
+	return nil
+}
+
 func ProcessRawTopology(rawTopology *RawTopology) (*NetworkTopology, error) {
 	var peers []*peer.AddrInfo
+	metadata := make(map[peer.ID]PeerMetadata)
 	for _, p := range rawTopology.Peers {
 		addrInfo, err := peer.AddrInfoFromString(p.PeerAddress)
 		if err != nil {
 			return nil, fmt.Errorf("invalid peer address %s: %w", p.PeerAddress, err)
 		}
 		peers = append(peers, addrInfo)
+
+		metadata[addrInfo.ID], err = processRawPeerMetadata(p)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	threshold, err := strconv.ParseInt(rawTopology.Threshold, 0, 0)
@@ -106,5 +208,46 @@ func ProcessRawTopology(rawTopology *RawTopology) (*NetworkTopology, error) {
 	if threshold < 1 {
 		return nil, fmt.Errorf("mpc threshold must be bigger then 0 %v", err)
 	}
-	return &NetworkTopology{Peers: peers, Threshold: int(threshold)}, nil
+
+	var version uint64
+	if rawTopology.Version != "" {
+		version, err = strconv.ParseUint(rawTopology.Version, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse topology version %v", err)
+		}
+	}
+
+	return &NetworkTopology{
+		Peers:      peers,
+		Threshold:  int(threshold),
+		Version:    version,
+		Metadata:   metadata,
+		Signatures: rawTopology.Signatures,
+	}, nil
+}
+
+// ToRawTopology converts topology into the document format read by topology providers
+func (nt NetworkTopology) ToRawTopology() *RawTopology {
+	peers := make([]RawPeer, 0)
+	for _, p := range nt.Peers {
+		addrs, err := peer.AddrInfoToP2pAddrs(p)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		metadata := nt.PeerMetadata(p.ID)
+		peers = append(peers, RawPeer{
+			PeerAddress: addrs[0].String(),
+			Name:        metadata.Name,
+			Operator:    metadata.Operator,
+			Role:        string(metadata.Role),
+			Weight:      strconv.Itoa(metadata.Weight),
+		})
+	}
+
+	return &RawTopology{
+		Peers:      peers,
+		Threshold:  strconv.Itoa(nt.Threshold),
+		Version:    strconv.FormatUint(nt.Version, 10),
+		Signatures: nt.Signatures,
+	}
 }
@@ -153,7 +153,7 @@ func (s *TopologyProviderTestSuite) Test_FetchingTopologyFails() {
 }
 
 func (s *TopologyProviderTestSuite) Test_ValidTopology() {
-	resp := &http.Response{}
+	resp := &http.Response{StatusCode: http.StatusOK}
 	resp.Body = io.NopCloser(strings.NewReader("f533758136cd1f62c3c7fd96b41d439ce3c899b0e705ecebd567275e4447683f80c21d9cf6287d3ac504f116c18308d34fd1f79cda675983dc01231cdb13db39f271f37bbc4ed9f89b87b04ed74cb4de382e43809a2e690c7a0872c1c2eec631455628621291803d34c73965917b52b44e713d927db805bbc145a2fe51c7352ab8b34f216a57c19e2e3dca27a1cf2013a9e6ece2989fd90bff45ad614520419bc132bd07d4aa89f1afb4016ba16b8de0b8921071ab99d86f4c15672c08ad98a55c0b179cff340dc128c3f8a56876d9a75aec735924fcba5f21ae6e64cf875f23cc1fdef4ae5c3d0f43e421d75161fd44d3a7a4cbab3c6ff84e7ff3b83582944c93627c75ad93262d057889e53d48263749dab0355adc8f949b946f3da3e9a4a104728a4f56214bb177bd5d59a257cf55befb53b6bff1b293f883bd60b7c1aa13c75e8ffd394b130ab6d867e60bfef67c78432663775093023c66bbad812bdda890de43b5491dd27a75ae27b79d85afc0ff390b531743642066c200ea5a405ef746041fa5fbf75c23c4dd35a1cc9854b01f1aaeec4265b4c46145a99e6b02eba82408903117fa34917368d5012420a2f985d2eac929c758d487e93f7779ae8ba6ff0f7f1eca1997abbc3ff0efdf"))
 	s.fetcher.EXPECT().Get("test.url").Return(resp, nil)
 	topologyConfiguration := relayer.TopologyConfiguration{
@@ -178,7 +178,7 @@ func (s *TopologyProviderTestSuite) Test_ValidTopology() {
 }
 
 func (s *TopologyProviderTestSuite) Test_InvalidHash() {
-	resp := &http.Response{}
+	resp := &http.Response{StatusCode: http.StatusOK}
 	resp.Body = io.NopCloser(strings.NewReader("f533758136cd1f62c3c7fd96b41d439ce3c899b0e705ecebd567275e4447683f80c21d9cf6287d3ac504f116c18308d34fd1f79cda675983dc01231cdb13db39f271f37bbc4ed9f89b87b04ed74cb4de382e43809a2e690c7a0872c1c2eec631455628621291803d34c73965917b52b44e713d927db805bbc145a2fe51c7352ab8b34f216a57c19e2e3dca27a1cf2013a9e6ece2989fd90bff45ad614520419bc132bd07d4aa89f1afb4016ba16b8de0b8921071ab99d86f4c15672c08ad98a55c0b179cff340dc128c3f8a56876d9a75aec735924fcba5f21ae6e64cf875f23cc1fdef4ae5c3d0f43e421d75161fd44d3a7a4cbab3c6ff84e7ff3b83582944c93627c75ad93262d057889e53d48263749dab0355adc8f949b946f3da3e9a4a104728a4f56214bb177bd5d59a257cf55befb53b6bff1b293f883bd60b7c1aa13c75e8ffd394b130ab6d867e60bfef67c78432663775093023c66bbad812bdda890de43b5491dd27a75ae27b79d85afc0ff390b531743642066c200ea5a405ef746041fa5fbf75c23c4dd35a1cc9854b01f1aaeec4265b4c46145a99e6b02eba82408903117fa34917368d5012420a2f985d2eac929c758d487e93f7779ae8ba6ff0f7f1eca1997abbc3ff0efdf"))
 	s.fetcher.EXPECT().Get("test.url").Return(resp, nil)
 	topologyConfiguration := relayer.TopologyConfiguration{
@@ -193,7 +193,7 @@ func (s *TopologyProviderTestSuite) Test_InvalidHash() {
 }
 
 func (s *TopologyProviderTestSuite) Test_ValidHash() {
-	resp := &http.Response{}
+	resp := &http.Response{StatusCode: http.StatusOK}
 	resp.Body = io.NopCloser(strings.NewReader("f533758136cd1f62c3c7fd96b41d439ce3c899b0e705ecebd567275e4447683f80c21d9cf6287d3ac504f116c18308d34fd1f79cda675983dc01231cdb13db39f271f37bbc4ed9f89b87b04ed74cb4de382e43809a2e690c7a0872c1c2eec631455628621291803d34c73965917b52b44e713d927db805bbc145a2fe51c7352ab8b34f216a57c19e2e3dca27a1cf2013a9e6ece2989fd90bff45ad614520419bc132bd07d4aa89f1afb4016ba16b8de0b8921071ab99d86f4c15672c08ad98a55c0b179cff340dc128c3f8a56876d9a75aec735924fcba5f21ae6e64cf875f23cc1fdef4ae5c3d0f43e421d75161fd44d3a7a4cbab3c6ff84e7ff3b83582944c93627c75ad93262d057889e53d48263749dab0355adc8f949b946f3da3e9a4a104728a4f56214bb177bd5d59a257cf55befb53b6bff1b293f883bd60b7c1aa13c75e8ffd394b130ab6d867e60bfef67c78432663775093023c66bbad812bdda890de43b5491dd27a75ae27b79d85afc0ff390b531743642066c200ea5a405ef746041fa5fbf75c23c4dd35a1cc9854b01f1aaeec4265b4c46145a99e6b02eba82408903117fa34917368d5012420a2f985d2eac929c758d487e93f7779ae8ba6ff0f7f1eca1997abbc3ff0efdf"))
 	s.fetcher.EXPECT().Get("test.url").Return(resp, nil)
 	topologyConfiguration := relayer.TopologyConfiguration{
@@ -217,3 +217,90 @@ func (s *TopologyProviderTestSuite) Test_ValidHash() {
 	s.Nil(err)
 	s.Equal(rawTp, tp)
 }
+
+func (s *TopologyProviderTestSuite) Test_UnexpectedStatus() {
+	resp := &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	resp.Body = io.NopCloser(strings.NewReader(""))
+	s.fetcher.EXPECT().Get("test.url").Return(resp, nil)
+	topologyConfiguration := relayer.TopologyConfiguration{
+		Url:           "test.url",
+		EncryptionKey: "qwertyuiopasdfgh",
+	}
+	topologyProvider, _ := topology.NewNetworkTopologyProvider(topologyConfiguration, s.fetcher)
+
+	_, err := topologyProvider.NetworkTopology("")
+
+	s.NotNil(err)
+}
+
+func (s *TopologyProviderTestSuite) Test_ShortResponse() {
+	resp := &http.Response{StatusCode: http.StatusOK}
+	resp.Body = io.NopCloser(strings.NewReader("f533"))
+	s.fetcher.EXPECT().Get("test.url").Return(resp, nil)
+	topologyConfiguration := relayer.TopologyConfiguration{
+		Url:           "test.url",
+		EncryptionKey: "qwertyuiopasdfgh",
+	}
+	topologyProvider, _ := topology.NewNetworkTopologyProvider(topologyConfiguration, s.fetcher)
+
+	_, err := topologyProvider.NetworkTopology("")
+
+	s.ErrorIs(err, topology.ErrCiphertextTooShort)
+}
+
+func (s *TopologyProviderTestSuite) Test_MissingEncryptionKey() {
+	topologyConfiguration := relayer.TopologyConfiguration{
+		Url: "test.url",
+	}
+
+	_, err := topology.NewNetworkTopologyProvider(topologyConfiguration, s.fetcher)
+
+	s.NotNil(err)
+}
+
+func (s *TopologyProviderTestSuite) Test_InvalidEncryptionKey() {
+	topologyConfiguration := relayer.TopologyConfiguration{
+		Url:           "test.url",
+		EncryptionKey: "invalid",
+	}
+
+	_, err := topology.NewNetworkTopologyProvider(topologyConfiguration, s.fetcher)
+
+	s.NotNil(err)
+}
+
+type FileTopologyProviderTestSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestRunFileTopologyProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(FileTopologyProviderTestSuite))
+}
+
+func (s *FileTopologyProviderTestSuite) SetupTest() {
+	s.path = "raw-topology.json"
+	_ = os.WriteFile(s.path, []byte(`{"peers":[{"peerAddress":"/dns4/relayer1/tcp/9000/p2p/QmcvEg7jGvuxdsUFRUiE4VdrL2P1Yeju5L83BsJvvXz7zX"}],"threshold":"1"}`), 0644)
+}
+
+func (s *FileTopologyProviderTestSuite) TearDownTest() {
+	os.Remove(s.path)
+}
+
+func (s *FileTopologyProviderTestSuite) Test_ValidHash() {
+	topologyProvider, _ := topology.NewNetworkTopologyProvider(relayer.TopologyConfiguration{Path: s.path}, nil)
+
+	tp, err := topologyProvider.NetworkTopology("50988a5b3e46b5ff6ce02318274219e1f5e3b0756784c0cb4bbab6d56f80f42b")
+
+	s.Nil(err)
+	s.Equal(1, tp.Threshold)
+	s.Len(tp.Peers, 1)
+}
+
+func (s *FileTopologyProviderTestSuite) Test_InvalidHash() {
+	topologyProvider, _ := topology.NewNetworkTopologyProvider(relayer.TopologyConfiguration{Path: s.path}, nil)
+
+	_, err := topologyProvider.NetworkTopology("invalid")
+
+	s.NotNil(err)
+}
@@ -0,0 +1,91 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sessions maps tss session ID to the trace contexts bound to the session, the last bound
+// context is the context of the span currently executing the session. Communication doesn't
+// pass context with messages, so the context is looked up by session ID when a session
+// message is sent.
+var sessions = struct {
+	mu       sync.RWMutex
+	next     uint64
+	bindings map[string][]binding
+}{
+	bindings: make(map[string][]binding),
+}
+
+type binding struct {
+	id      uint64
+	carrier propagation.MapCarrier
+}
+
+// BindSession sets span from the context as the span that is propagated to peers
+// with messages of the session. The returned function removes only this binding, so
+// the previously bound span is propagated again after a nested span unbinds.
+func BindSession(ctx context.Context, sessionID string) (unbind func()) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return func() {}
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+	sessions.next++
+	id := sessions.next
+	sessions.bindings[sessionID] = append(sessions.bindings[sessionID], binding{id: id, carrier: carrier})
+	return func() {
+		unbindSession(sessionID, id)
+	}
+}
+
+func unbindSession(sessionID string, id uint64) {
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	bindings := sessions.bindings[sessionID]
+	for i, b := range bindings {
+		if b.id != id {
+			continue
+		}
+		bindings = append(bindings[:i:i], bindings[i+1:]...)
+		break
+	}
+	if len(bindings) == 0 {
+		delete(sessions.bindings, sessionID)
+		return
+	}
+	sessions.bindings[sessionID] = bindings
+}
+
+// SessionCarrier returns trace context that should be sent with messages of the session
+// or nil if the session is not traced
+func SessionCarrier(sessionID string) map[string]string {
+	sessions.mu.RLock()
+	defer sessions.mu.RUnlock()
+
+	bindings := sessions.bindings[sessionID]
+	if len(bindings) == 0 {
+		return nil
+	}
+	return bindings[len(bindings)-1].carrier
+}
+
+// Extract returns context with the remote span from the trace context received with a message
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
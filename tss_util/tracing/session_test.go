@@ -0,0 +1,99 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"tss-demo/tss_util/tracing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SessionTestSuite struct {
+	suite.Suite
+	tracer trace.Tracer
+}
+
+func TestRunSessionTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTestSuite))
+}
+
+func (s *SessionTestSuite) SetupSuite() {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	s.tracer = sdktrace.NewTracerProvider().Tracer("test")
+}
+
+func (s *SessionTestSuite) Test_SessionCarrier_UnboundSession() {
+	s.Nil(tracing.SessionCarrier("session"))
+}
+
+func (s *SessionTestSuite) Test_BindSession_InvalidSpanIgnored() {
+	tracing.BindSession(context.Background(), "session")
+
+	s.Nil(tracing.SessionCarrier("session"))
+}
+
+func (s *SessionTestSuite) Test_BindSession_PropagatesSpan() {
+	ctx, span := s.tracer.Start(context.Background(), "session")
+	defer span.End()
+
+	unbind := tracing.BindSession(ctx, "session")
+	defer unbind()
+	remote := trace.SpanContextFromContext(tracing.Extract(context.Background(), tracing.SessionCarrier("session")))
+
+	s.True(remote.IsRemote())
+	s.Equal(span.SpanContext().TraceID(), remote.TraceID())
+	s.Equal(span.SpanContext().SpanID(), remote.SpanID())
+}
+
+func (s *SessionTestSuite) Test_UnbindSession() {
+	ctx, span := s.tracer.Start(context.Background(), "session")
+	defer span.End()
+
+	unbind := tracing.BindSession(ctx, "session")
+	unbind()
+
+	s.Nil(tracing.SessionCarrier("session"))
+}
+
+func (s *SessionTestSuite) Test_UnbindSession_NestedSpanRestoresParent() {
+	ctx, span := s.tracer.Start(context.Background(), "session")
+	defer span.End()
+	childCtx, childSpan := s.tracer.Start(ctx, "run")
+	defer childSpan.End()
+
+	unbind := tracing.BindSession(ctx, "session")
+	defer unbind()
+	unbindChild := tracing.BindSession(childCtx, "session")
+	unbindChild()
+	remote := trace.SpanContextFromContext(tracing.Extract(context.Background(), tracing.SessionCarrier("session")))
+
+	s.Equal(span.SpanContext().SpanID(), remote.SpanID())
+}
+
+func (s *SessionTestSuite) Test_UnbindSession_KeepsOtherBindings() {
+	ctx, span := s.tracer.Start(context.Background(), "first")
+	defer span.End()
+	otherCtx, otherSpan := s.tracer.Start(context.Background(), "second")
+	defer otherSpan.End()
+
+	unbind := tracing.BindSession(ctx, "session")
+	unbindOther := tracing.BindSession(otherCtx, "session")
+	defer unbindOther()
+	unbind()
+	remote := trace.SpanContextFromContext(tracing.Extract(context.Background(), tracing.SessionCarrier("session")))
+
+	s.Equal(otherSpan.SpanContext().SpanID(), remote.SpanID())
+}
+
+func (s *SessionTestSuite) Test_Extract_EmptyCarrier() {
+	ctx := tracing.Extract(context.Background(), nil)
+
+	s.False(trace.SpanContextFromContext(ctx).IsValid())
+}
@@ -0,0 +1,79 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"tss-demo/tss_util/tss_config/relayer"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+const (
+	OTLPExporter = "otlp"
+	FileExporter = "file"
+)
+
+// InitTracerProvider creates tracer provider that exports spans with the configured exporter
+// and registers it together with W3C trace context propagator as global. Spans are created
+// but not exported if exporter is not configured.
+func InitTracerProvider(ctx context.Context, config relayer.TracingConfig, relayerID string) (*sdktrace.TracerProvider, error) {
+	res, err := sdkresource.New(ctx,
+		sdkresource.WithHost(),
+		sdkresource.WithAttributes(
+			semconv.ServiceName("relayer"),
+			attribute.String("relayerid", relayerID),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+	}
+	switch config.Exporter {
+	case OTLPExporter:
+		{
+			exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+			if config.Insecure {
+				exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+			}
+			exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		}
+	case FileExporter:
+		{
+			file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+			if err != nil {
+				return nil, err
+			}
+			exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		}
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %s", config.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp, nil
+}
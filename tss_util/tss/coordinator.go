@@ -5,12 +5,16 @@ package tss
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/comm/elector"
+	"tss-demo/tss_util/tracing"
 	"tss-demo/tss_util/tss/ecdsa/common"
+	"tss-demo/tss_util/tss/liveness"
 	"tss-demo/tss_util/tss/message"
 
 	"github.com/binance-chain/tss-lib/tss"
@@ -18,6 +22,10 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 )
 
@@ -27,6 +35,8 @@ var (
 	tssTimeout         = 15 * time.Minute
 )
 
+var tracer = otel.Tracer("tss-demo/tss")
+
 type TssProcess interface {
 	Run(ctx context.Context, coordinator bool, resultChn chan interface{}, params []byte) error
 	Stop()
@@ -37,17 +47,60 @@ type TssProcess interface {
 	ValidCoordinators() []peer.ID
 }
 
+// SessionMeter measures outcomes, phases and retries of tss sessions
+type SessionMeter interface {
+	TrackSession(process string, outcome string, duration time.Duration)
+	TrackSessionPhase(process string, phase string, duration time.Duration)
+	TrackSessionRetry(process string)
+	TrackExcludedPeer(process string, p peer.ID)
+}
+
+// SessionAuditor records start and finish of tss sessions executed by the node
+type SessionAuditor interface {
+	SessionStarted(sessionID string, process string)
+	SessionFinished(sessionID string, process string, outcome string, err error)
+}
+
+// RoundTracker is implemented by processes that measure duration of their protocol rounds
+type RoundTracker interface {
+	SetRoundMeter(meter message.RoundMeter)
+}
+
+const (
+	SessionSuccess   = "success"
+	SessionFailure   = "failure"
+	SessionCancelled = "cancelled"
+
+	PhaseElection = "election"
+	PhaseReady    = "ready"
+	PhaseProtocol = "protocol"
+)
+
 type Coordinator struct {
 	host           host.Host
 	communication  comm2.Communication
 	electorFactory *elector.CoordinatorElectorFactory
 
 	pendingProcesses map[string]bool
+	pendingSince     map[string]time.Time
+	cancels          map[string]context.CancelFunc
 	processLock      sync.Mutex
 
 	CoordinatorTimeout time.Duration
 	TssTimeout         time.Duration
 	InitiatePeriod     time.Duration
+	// ElectorType is the strategy used to choose the first coordinator of the session,
+	// bully election is used on retries
+	ElectorType elector.CoordinatorElectorType
+
+	// Liveness collects peer latencies and failures from executed sessions
+	Liveness *liveness.Tracker
+	// Metrics measures executed sessions, metrics are discarded by default
+	Metrics SessionMeter
+	// Audit records executed sessions, sessions are not audited by default
+	Audit SessionAuditor
+	// Observer receives phase transitions of executed sessions, they are discarded by default
+	Observer SessionObserver
 }
 
 func NewCoordinator(
@@ -61,10 +114,16 @@ func NewCoordinator(
 		electorFactory: electorFactory,
 
 		pendingProcesses: make(map[string]bool),
+		pendingSince:     make(map[string]time.Time),
+		cancels:          make(map[string]context.CancelFunc),
 
 		CoordinatorTimeout: coordinatorTimeout,
 		TssTimeout:         tssTimeout,
 		InitiatePeriod:     initiatePeriod,
+		ElectorType:        elector.Static,
+		Metrics:            noopSessionMeter{},
+		Audit:              noopSessionAuditor{},
+		Observer:           noopSessionObserver{},
 	}
 }
 
@@ -72,16 +131,54 @@ func NewCoordinator(
 // Array of processes can be passed if all the processes have to have the same peer subset and
 // the result of all of them is needed. The processes should have an unique session ID for each one.
 func (c *Coordinator) Execute(ctx context.Context, tssProcesses []TssProcess, resultChn chan interface{}) error {
+	process := processName(tssProcesses[0])
 	sessionID := tssProcesses[0].SessionID()
-	value, ok := c.pendingProcesses[sessionID]
-	if ok && value {
-		log.Warn().Str("SessionID", sessionID).Msgf("Process already pending")
-		return fmt.Errorf("process already pending")
+	ctx, span := tracer.Start(ctx, "tss.session", trace.WithAttributes(
+		attribute.String("session.id", sessionID),
+		attribute.String("process", process),
+	))
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.Audit.SessionStarted(sessionID, process)
+	startedAt := time.Now()
+	err := withCulprits(c.execute(ctx, cancel, tssProcesses, resultChn))
+	outcome := SessionSuccess
+	if err != nil {
+		outcome = SessionFailure
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if ctx.Err() != nil {
+		outcome = SessionCancelled
+	}
+	span.SetAttributes(attribute.String("outcome", outcome))
+	c.Metrics.TrackSession(process, outcome, time.Since(startedAt))
+	c.Audit.SessionFinished(sessionID, process, outcome, err)
+	// duplicate executions don't end the running session
+	if !errors.Is(err, ErrSessionPending) {
+		c.Observer.ObserveSession(finalEvent(sessionID, process, outcome, err))
 	}
+	return err
+}
 
+func (c *Coordinator) execute(ctx context.Context, cancelSession context.CancelFunc, tssProcesses []TssProcess, resultChn chan interface{}) error {
+	sessionID := tssProcesses[0].SessionID()
 	c.processLock.Lock()
+	if c.pendingProcesses[sessionID] {
+		c.processLock.Unlock()
+		log.Warn().Str("SessionID", sessionID).Msgf("Process already pending")
+		return ErrSessionPending
+	}
 	c.pendingProcesses[sessionID] = true
+	c.pendingSince[sessionID] = time.Now()
+	c.cancels[sessionID] = cancelSession
 	c.processLock.Unlock()
+	// only the execution running the session binds its trace context, duplicates would
+	// replace and then remove it
+	unbind := tracing.BindSession(ctx, sessionID)
+	defer unbind()
 
 	ctx, cancel := context.WithCancel(ctx)
 	p := pool.New().WithContext(ctx).WithCancelOnError()
@@ -90,16 +187,24 @@ func (c *Coordinator) Execute(ctx context.Context, tssProcesses []TssProcess, re
 		c.communication.CloseSession(sessionID)
 		c.processLock.Lock()
 		c.pendingProcesses[sessionID] = false
+		delete(c.pendingSince, sessionID)
+		delete(c.cancels, sessionID)
 		c.processLock.Unlock()
 		for _, process := range tssProcesses {
 			process.Stop()
 		}
 	}()
 
-	coordinatorElector := c.electorFactory.CoordinatorElector(sessionID, elector.Static)
-	coordinator, _ := coordinatorElector.Coordinator(ctx, tssProcesses[0].ValidCoordinators())
+	electionStart := time.Now()
+	electionCtx, electionSpan := tracer.Start(ctx, "tss.election")
+	coordinatorElector := c.electorFactory.CoordinatorElector(sessionID, c.ElectorType)
+	coordinator, _ := coordinatorElector.Coordinator(electionCtx, tssProcesses[0].ValidCoordinators())
+	electionSpan.SetAttributes(attribute.String("coordinator", coordinator.Pretty()))
+	electionSpan.End()
+	c.Metrics.TrackSessionPhase(processName(tssProcesses[0]), PhaseElection, time.Since(electionStart))
+	c.observe(tssProcesses[0], SessionEvent{Type: EventElection, Coordinator: coordinator})
 
-	log.Info().Str("SessionID", sessionID).Msgf("Starting process with coordinator %s", coordinator.Pretty())
+	log.Info().Str("SessionID", sessionID).Msgf("Starting process with coordinator %s", c.electorFactory.Topology().PeerName(coordinator))
 
 	p.Go(func(ctx context.Context) error {
 		err := c.start(ctx, tssProcesses, coordinator, resultChn, []peer.ID{})
@@ -123,6 +228,32 @@ func (c *Coordinator) Execute(ctx context.Context, tssProcesses []TssProcess, re
 	return c.handleError(ctx, err, tssProcesses, resultChn)
 }
 
+// Cancel stops the local execution of the running session. Other peers fail the session
+// on their own once the node stops responding. Returns false if the session isn't running.
+func (c *Coordinator) Cancel(sessionID string) bool {
+	c.processLock.Lock()
+	defer c.processLock.Unlock()
+
+	cancel, ok := c.cancels[sessionID]
+	if !ok {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// PendingSessions returns running sessions with the time since they started
+func (c *Coordinator) PendingSessions() map[string]time.Duration {
+	c.processLock.Lock()
+	defer c.processLock.Unlock()
+
+	sessions := make(map[string]time.Duration)
+	for sessionID, since := range c.pendingSince {
+		sessions[sessionID] = time.Since(since)
+	}
+	return sessions
+}
+
 func (c *Coordinator) handleError(ctx context.Context, err error, tssProcesses []TssProcess, resultChn chan interface{}) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -132,10 +263,14 @@ func (c *Coordinator) handleError(ctx context.Context, err error, tssProcesses [
 		return c.watchExecution(ctx, tssProcesses[0], peer.ID(""))
 	})
 	sessionID := tssProcesses[0].SessionID()
+	process := processName(tssProcesses[0])
 	switch err := err.(type) {
 	case *CoordinatorError:
 		{
 			log.Warn().Str("SessionID", sessionID).Msgf("Tss process failed with error %+v", err)
+			c.Liveness.RecordFailure(err.Peer)
+			c.Metrics.TrackExcludedPeer(process, err.Peer)
+			c.Metrics.TrackSessionRetry(process)
 
 			excludedPeers := []peer.ID{err.Peer}
 			rp.Go(func(ctx context.Context) error { return c.retry(ctx, tssProcesses, resultChn, excludedPeers) })
@@ -143,6 +278,8 @@ func (c *Coordinator) handleError(ctx context.Context, err error, tssProcesses [
 	case *comm2.CommunicationError:
 		{
 			log.Err(err).Str("SessionID", sessionID).Msgf("Tss process failed with error %+v", err)
+			c.Liveness.RecordFailure(err.Peer)
+			c.Metrics.TrackSessionRetry(process)
 			rp.Go(func(ctx context.Context) error { return c.retry(ctx, tssProcesses, resultChn, []peer.ID{}) })
 		}
 	case *tss.Error:
@@ -152,6 +289,11 @@ func (c *Coordinator) handleError(ctx context.Context, err error, tssProcesses [
 			if err != nil {
 				return err
 			}
+			for _, p := range excludedPeers {
+				c.Liveness.RecordFailure(p)
+				c.Metrics.TrackExcludedPeer(process, p)
+			}
+			c.Metrics.TrackSessionRetry(process)
 			rp.Go(func(ctx context.Context) error { return c.retry(ctx, tssProcesses, resultChn, excludedPeers) })
 		}
 	case *SubsetError:
@@ -182,7 +324,7 @@ func (c *Coordinator) watchExecution(ctx context.Context, tssProcess TssProcess,
 		select {
 		case <-ticker.C:
 			{
-				return fmt.Errorf("tss process timed out after %v", c.TssTimeout)
+				return &TimeoutError{Timeout: c.TssTimeout}
 			}
 		case <-ctx.Done():
 			{
@@ -213,13 +355,55 @@ func (c *Coordinator) start(ctx context.Context, tssProcesses []TssProcess, coor
 // retry initiates full bully process to calculate coordinator and starts a new tss process after
 // an expected error ocurred during regular tss execution
 func (c *Coordinator) retry(ctx context.Context, tssProcesses []TssProcess, resultChn chan interface{}, excludedPeers []peer.ID) error {
+	electionStart := time.Now()
+	electionCtx, electionSpan := tracer.Start(ctx, "tss.election", trace.WithAttributes(attribute.Bool("retry", true)))
 	coordinatorElector := c.electorFactory.CoordinatorElector(tssProcesses[0].SessionID(), elector.Bully)
-	coordinator, err := coordinatorElector.Coordinator(ctx, common.ExcludePeers(tssProcesses[0].ValidCoordinators(), excludedPeers))
+	coordinator, err := coordinatorElector.Coordinator(electionCtx, common.ExcludePeers(tssProcesses[0].ValidCoordinators(), excludedPeers))
 	if err != nil {
+		electionSpan.RecordError(err)
+		electionSpan.SetStatus(codes.Error, err.Error())
+		electionSpan.End()
 		return err
 	}
+	electionSpan.SetAttributes(attribute.String("coordinator", coordinator.Pretty()))
+	electionSpan.End()
+	c.Metrics.TrackSessionPhase(processName(tssProcesses[0]), PhaseElection, time.Since(electionStart))
+	c.observe(tssProcesses[0], SessionEvent{Type: EventElection, Coordinator: coordinator})
+
+	watcher, ok := coordinatorElector.(elector.CoordinatorWatcher)
+	if !ok {
+		return c.start(ctx, tssProcesses, coordinator, resultChn, excludedPeers)
+	}
 
-	return c.start(ctx, tssProcesses, coordinator, resultChn, excludedPeers)
+	// restart the process with the re-elected coordinator if the current one is lost
+	for {
+		startCtx, cancel := context.WithCancel(ctx)
+		errChn := make(chan error, 1)
+		go func(coordinator peer.ID) {
+			errChn <- c.start(startCtx, tssProcesses, coordinator, resultChn, excludedPeers)
+		}(coordinator)
+
+		newCoordinator := coordinator
+		for newCoordinator == coordinator {
+			select {
+			case err := <-errChn:
+				cancel()
+				return err
+			case newCoordinator = <-watcher.Changes():
+			}
+		}
+
+		cancel()
+		<-errChn
+		log.Warn().Str("SessionID", tssProcesses[0].SessionID()).Msgf(
+			"Coordinator %s lost, restarting process with coordinator %s",
+			c.electorFactory.Topology().PeerName(coordinator),
+			c.electorFactory.Topology().PeerName(newCoordinator),
+		)
+		c.Liveness.RecordFailure(coordinator)
+		coordinator = newCoordinator
+		c.observe(tssProcesses[0], SessionEvent{Type: EventElection, Coordinator: coordinator})
+	}
 }
 
 // broadcastInitiateMsg sends TssInitiateMsg to all peers
@@ -239,18 +423,27 @@ func (c *Coordinator) initiate(ctx context.Context, tssProcesses []TssProcess, r
 	readyPeers = append(readyPeers, c.host.ID())
 
 	tssProcess := tssProcesses[0]
+	readyCtx, readySpan := tracer.Start(ctx, "tss.ready", trace.WithAttributes(attribute.Bool("coordinator", true)))
+	defer readySpan.End()
+	// participants start their protocol spans from the start message trace context
+	unbindReady := tracing.BindSession(readyCtx, tssProcess.SessionID())
+	defer unbindReady()
+
 	subID := c.communication.Subscribe(tssProcess.SessionID(), comm2.TssReadyMsg, readyChan)
 	defer c.communication.UnSubscribe(subID)
 
 	ticker := time.NewTicker(c.InitiatePeriod)
 	defer ticker.Stop()
 	c.broadcastInitiateMsg(tssProcess.SessionID())
+	readyStart := time.Now()
+	initiatedAt := readyStart
 	for {
 		select {
 		case wMsg := <-readyChan:
 			{
 				log.Debug().Str("SessionID", tssProcess.SessionID()).Msgf("received ready message from %s", wMsg.From)
 				if !slices.Contains(excludedPeers, wMsg.From) && !slices.Contains(readyPeers, wMsg.From) {
+					c.Liveness.RecordResponse(wMsg.From, time.Since(initiatedAt))
 					readyPeers = append(readyPeers, wMsg.From)
 				}
 				ready, err := tssProcess.Ready(readyPeers, excludedPeers)
@@ -268,18 +461,16 @@ func (c *Coordinator) initiate(ctx context.Context, tssProcesses []TssProcess, r
 				}
 
 				_ = c.communication.Broadcast(c.host.Peerstore().Peers(), startMsgBytes, comm2.TssStartMsg, tssProcess.SessionID())
-				p := pool.New().WithContext(ctx).WithCancelOnError()
-				for _, process := range tssProcesses {
-					tssProcess := process
-					p.Go(func(ctx context.Context) error {
-						return tssProcess.Run(ctx, true, resultChn, startParams)
-					})
-				}
-				return p.Wait()
+				readySpan.SetAttributes(attribute.Int("peers.ready", len(readyPeers)))
+				readySpan.End()
+				c.Metrics.TrackSessionPhase(processName(tssProcess), PhaseReady, time.Since(readyStart))
+				c.observe(tssProcess, SessionEvent{Type: EventReady, Coordinator: c.host.ID()})
+				return c.run(readyCtx, tssProcesses, resultChn, true, startParams)
 			}
 		case <-ticker.C:
 			{
 				c.broadcastInitiateMsg(tssProcess.SessionID())
+				initiatedAt = time.Now()
 			}
 		case <-ctx.Done():
 			{
@@ -302,6 +493,11 @@ func (c *Coordinator) waitForStart(
 	startMsgChn := make(chan *comm2.WrappedMessage)
 
 	tssProcess := tssProcesses[0]
+	readyCtx, readySpan := tracer.Start(ctx, "tss.ready", trace.WithAttributes(attribute.Bool("coordinator", false)))
+	defer readySpan.End()
+	unbindReady := tracing.BindSession(readyCtx, tssProcess.SessionID())
+	defer unbindReady()
+
 	initSubID := c.communication.Subscribe(tssProcess.SessionID(), comm2.TssInitiateMsg, msgChan)
 	defer c.communication.UnSubscribe(initSubID)
 	startSubID := c.communication.Subscribe(tssProcess.SessionID(), comm2.TssStartMsg, startMsgChn)
@@ -309,6 +505,7 @@ func (c *Coordinator) waitForStart(
 
 	coordinatorTimeoutTicker := time.NewTicker(timeout)
 	defer coordinatorTimeoutTicker.Stop()
+	readyStart := time.Now()
 	for {
 		select {
 		case wMsg := <-msgChan:
@@ -341,17 +538,20 @@ func (c *Coordinator) waitForStart(
 					return err
 				}
 
-				p := pool.New().WithContext(ctx).WithCancelOnError()
-				for _, process := range tssProcesses {
-					tssProcess := process
-					p.Go(func(ctx context.Context) error {
-						return tssProcess.Run(ctx, false, resultChn, msg.Params)
-					})
+				readySpan.End()
+				c.Metrics.TrackSessionPhase(processName(tssProcess), PhaseReady, time.Since(readyStart))
+				c.observe(tssProcess, SessionEvent{Type: EventReady, Coordinator: startMsg.From})
+				// protocol is traced as a part of the coordinator trace, linked to the local session
+				protocolCtx := readyCtx
+				remote := trace.SpanContextFromContext(tracing.Extract(ctx, startMsg.TraceContext))
+				if remote.IsValid() {
+					protocolCtx = trace.ContextWithRemoteSpanContext(readyCtx, remote)
 				}
-				return p.Wait()
+				return c.run(protocolCtx, tssProcesses, resultChn, false, msg.Params, trace.LinkFromContext(readyCtx))
 			}
 		case <-coordinatorTimeoutTicker.C:
 			{
+				readySpan.SetStatus(codes.Error, "coordinator timeout")
 				return &CoordinatorError{Peer: coordinator}
 			}
 		case <-ctx.Done():
@@ -361,3 +561,95 @@ func (c *Coordinator) waitForStart(
 		}
 	}
 }
+
+// run runs tss processes with the start params until all of them finish. Messages of each
+// process carry the trace context of its run span.
+func (c *Coordinator) run(
+	ctx context.Context,
+	tssProcesses []TssProcess,
+	resultChn chan interface{},
+	coordinator bool,
+	params []byte,
+	links ...trace.Link,
+) error {
+	protocolStart := time.Now()
+	ctx, span := tracer.Start(ctx, "tss.protocol", trace.WithLinks(links...))
+	defer span.End()
+
+	// rounds are numbered from the start of every run
+	meter := newRoundMeter(tssProcesses[0].SessionID(), processName(tssProcesses[0]), c.Metrics, c.Observer)
+	for _, tssProcess := range tssProcesses {
+		tracker, ok := tssProcess.(RoundTracker)
+		if ok {
+			tracker.SetRoundMeter(meter)
+		}
+	}
+	c.observe(tssProcesses[0], SessionEvent{Type: EventStarted})
+
+	p := pool.New().WithContext(ctx).WithCancelOnError()
+	for _, process := range tssProcesses {
+		tssProcess := process
+		p.Go(func(ctx context.Context) error {
+			sessionID := tssProcess.SessionID()
+			ctx, span := tracer.Start(ctx, "tss.run", trace.WithAttributes(
+				attribute.String("session.id", sessionID),
+				attribute.Bool("coordinator", coordinator),
+			))
+			defer span.End()
+			unbind := tracing.BindSession(ctx, sessionID)
+			defer unbind()
+
+			err := tssProcess.Run(ctx, coordinator, resultChn, params)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		})
+	}
+	err := p.Wait()
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	c.Metrics.TrackSessionPhase(processName(tssProcesses[0]), PhaseProtocol, time.Since(protocolStart))
+	return err
+}
+
+// withCulprits returns tss errors that blame parties as CulpritsError with peers of the parties
+func withCulprits(err error) error {
+	var tssErr *tss.Error
+	if !errors.As(err, &tssErr) || len(tssErr.Culprits()) == 0 {
+		return err
+	}
+	culprits, peersErr := common.PeersFromParties(tssErr.Culprits())
+	if peersErr != nil {
+		return err
+	}
+	return &CulpritsError{Culprits: culprits, Err: err}
+}
+
+// processName returns name of the process package used as metric label, e.g. keygen for *keygen.Keygen
+func processName(process TssProcess) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", process), "*")
+	return strings.Split(name, ".")[0]
+}
+
+// observe sends the event of the session executing the process
+func (c *Coordinator) observe(process TssProcess, event SessionEvent) {
+	event.SessionID = process.SessionID()
+	event.Process = processName(process)
+	event.Time = time.Now()
+	c.Observer.ObserveSession(event)
+}
+
+type noopSessionMeter struct{}
+
+func (noopSessionMeter) TrackSession(process string, outcome string, duration time.Duration)    {}
+func (noopSessionMeter) TrackSessionPhase(process string, phase string, duration time.Duration) {}
+func (noopSessionMeter) TrackSessionRetry(process string)                                       {}
+func (noopSessionMeter) TrackExcludedPeer(process string, p peer.ID)                            {}
+
+type noopSessionAuditor struct{}
+
+func (noopSessionAuditor) SessionStarted(sessionID, process string)                      {}
+func (noopSessionAuditor) SessionFinished(sessionID, process, outcome string, err error) {}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"runtime/debug"
+	"strings"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/tss/message"
 
@@ -33,6 +34,7 @@ type BaseTss struct {
 	Communication comm2.Communication
 	Peers         []peer.ID
 	Log           zerolog.Logger
+	message.RoundTimer
 
 	Cancel context.CancelFunc
 }
@@ -87,6 +89,9 @@ func (b *BaseTss) ProcessOutboundMessages(ctx context.Context, outChn chan tss.M
 		case msg := <-outChn:
 			{
 				b.Log.Debug().Msg(msg.String())
+				if b.Measuring() {
+					b.OutboundMessage(roundName(msg))
+				}
 				wireBytes, routing, err := msg.WireBytes()
 				if err != nil {
 					return err
@@ -125,6 +130,12 @@ func (b *BaseTss) BroadcastPeers(msg tss.Message) ([]peer.ID, error) {
 	}
 }
 
+// roundName returns short name of the tss message round, e.g. KGRound1Message
+func roundName(msg tss.Message) string {
+	msgType := msg.Type()
+	return msgType[strings.LastIndex(msgType, ".")+1:]
+}
+
 func (b *BaseTss) SessionID() string {
 	return b.SID
 }
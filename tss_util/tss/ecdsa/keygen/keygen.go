@@ -5,10 +5,11 @@ package keygen
 
 import (
 	"context"
-	"errors"
 	"math/big"
+	"tss-demo/logging"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/keyshare"
+	errors "tss-demo/tss_util/tss"
 	common2 "tss-demo/tss_util/tss/ecdsa/common"
 
 	"github.com/binance-chain/tss-lib/ecdsa/keygen"
@@ -16,7 +17,6 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
 )
 
@@ -49,7 +49,7 @@ func NewKeygen(
 			Communication: comm,
 			Peers:         host.Peerstore().Peers(),
 			SID:           sessionID,
-			Log:           log.With().Str("SessionID", sessionID).Str("Process", "keygen").Logger(),
+			Log:           logging.Session(sessionID, "keygen").Logger(),
 			Cancel:        func() {},
 		},
 		storer:    storer,
@@ -116,7 +116,8 @@ func (k *Keygen) Stop() {
 // in keygen process.
 func (k *Keygen) Ready(readyPeers []peer.ID, excludedPeers []peer.ID) (bool, error) {
 	if len(excludedPeers) > 0 {
-		return false, errors.New("error")
+		peers := len(k.Host.Peerstore().Peers())
+		return false, &errors.ThresholdError{Required: peers, Available: peers - len(excludedPeers)}
 	}
 
 	return len(readyPeers) == len(k.Host.Peerstore().Peers()), nil
@@ -9,6 +9,8 @@ import (
 	"time"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/comm/elector"
+	"tss-demo/tss_util/sessions"
+	"tss-demo/tss_util/tracing"
 	"tss-demo/tss_util/tss"
 	"tss-demo/tss_util/tss/ecdsa/keygen"
 	tsstest2 "tss-demo/tss_util/tss/test"
@@ -17,6 +19,9 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/sourcegraph/conc/pool"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type KeygenTestSuite struct {
@@ -39,7 +44,7 @@ func (s *KeygenTestSuite) Test_ValidKeygenProcess() {
 		}
 		communicationMap[host.ID()] = &communication
 		keygen := keygen.NewKeygen("keygen", s.Threshold, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, keygen)
 	}
@@ -57,6 +62,49 @@ func (s *KeygenTestSuite) Test_ValidKeygenProcess() {
 	s.Nil(err)
 }
 
+func (s *KeygenTestSuite) Test_KeygenSessionEvents() {
+	communicationMap := make(map[peer.ID]*tsstest2.TestCommunication)
+	coordinators := []*tss.Coordinator{}
+	processes := []tss.TssProcess{}
+	hubs := []*sessions.Hub{}
+
+	for _, host := range s.CoordinatorTestSuite.Hosts {
+		communication := tsstest2.TestCommunication{
+			Host:          host,
+			Subscriptions: make(map[comm2.SubscriptionID]chan *comm2.WrappedMessage),
+		}
+		communicationMap[host.ID()] = &communication
+		keygen := keygen.NewKeygen("keygen3", s.Threshold, host, &communication, s.MockECDSAStorer)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
+		coordinator := tss.NewCoordinator(host, &communication, electorFactory)
+		hub := sessions.NewHub(sessions.DefaultRetained)
+		coordinator.Observer = hub
+		coordinators = append(coordinators, coordinator)
+		processes = append(processes, keygen)
+		hubs = append(hubs, hub)
+	}
+	tsstest2.SetupCommunication(communicationMap)
+
+	s.MockECDSAStorer.EXPECT().LockKeyshare().Times(3)
+	s.MockECDSAStorer.EXPECT().UnlockKeyshare().Times(3)
+	s.MockECDSAStorer.EXPECT().StoreKeyshare(gomock.Any()).Times(3)
+	pool := pool.New().WithContext(context.Background()).WithCancelOnError()
+	for i, coordinator := range coordinators {
+		pool.Go(func(ctx context.Context) error { return coordinator.Execute(ctx, []tss.TssProcess{processes[i]}, nil) })
+	}
+
+	err := pool.Wait()
+	s.Nil(err)
+	for _, hub := range hubs {
+		status, ok := hub.Status("keygen3")
+		s.True(ok)
+		s.Equal(tss.EventCompleted, status.Phase)
+		s.Equal("keygen", status.Process)
+		s.NotEmpty(status.Coordinator)
+		s.Greater(status.Round, 0)
+	}
+}
+
 func (s *KeygenTestSuite) Test_KeygenTimeout() {
 	communicationMap := make(map[peer.ID]*tsstest2.TestCommunication)
 	coordinators := []*tss.Coordinator{}
@@ -68,7 +116,7 @@ func (s *KeygenTestSuite) Test_KeygenTimeout() {
 		}
 		communicationMap[host.ID()] = &communication
 		keygen := keygen.NewKeygen("keygen2", s.Threshold, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinator := tss.NewCoordinator(host, &communication, electorFactory)
 		coordinator.TssTimeout = time.Millisecond
 		coordinators = append(coordinators, coordinator)
@@ -87,3 +135,41 @@ func (s *KeygenTestSuite) Test_KeygenTimeout() {
 	err := pool.Wait()
 	s.NotNil(err)
 }
+
+func (s *KeygenTestSuite) Test_DuplicateExecuteKeepsSessionTrace() {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	communicationMap := make(map[peer.ID]*tsstest2.TestCommunication)
+	var coordinator *tss.Coordinator
+	var process tss.TssProcess
+	for _, host := range s.CoordinatorTestSuite.Hosts {
+		communication := tsstest2.TestCommunication{
+			Host:          host,
+			Subscriptions: make(map[comm2.SubscriptionID]chan *comm2.WrappedMessage),
+		}
+		communicationMap[host.ID()] = &communication
+		if coordinator == nil {
+			process = keygen.NewKeygen("keygen4", s.Threshold, host, &communication, s.MockECDSAStorer)
+			electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
+			coordinator = tss.NewCoordinator(host, &communication, electorFactory)
+		}
+	}
+	tsstest2.SetupCommunication(communicationMap)
+
+	s.MockECDSAStorer.EXPECT().LockKeyshare().AnyTimes()
+	s.MockECDSAStorer.EXPECT().UnlockKeyshare().AnyTimes()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errChn := make(chan error, 1)
+	go func() { errChn <- coordinator.Execute(ctx, []tss.TssProcess{process}, nil) }()
+	// other parties never execute so the session stays pending until cancelled
+	s.Eventually(func() bool { return tracing.SessionCarrier("keygen4") != nil }, time.Second*5, time.Millisecond*10)
+
+	err := coordinator.Execute(context.Background(), []tss.TssProcess{process}, nil)
+
+	s.ErrorIs(err, tss.ErrSessionPending)
+	s.NotNil(tracing.SessionCarrier("keygen4"))
+	cancel()
+	<-errChn
+	s.Nil(tracing.SessionCarrier("keygen4"))
+}
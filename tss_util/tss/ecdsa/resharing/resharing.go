@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"math/big"
+	"tss-demo/logging"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/keyshare"
 	common2 "tss-demo/tss_util/tss/ecdsa/common"
@@ -18,7 +19,6 @@ import (
 	"github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
 	"golang.org/x/exp/slices"
 )
@@ -66,7 +66,7 @@ func NewResharing(
 			Communication: comm,
 			Peers:         host.Peerstore().Peers(),
 			SID:           sessionID,
-			Log:           log.With().Str("SessionID", sessionID).Str("Process", "resharing").Logger(),
+			Log:           logging.Session(sessionID, "resharing").Str(logging.KeyIDField, key.ID()).Logger(),
 			Cancel:        func() {},
 		},
 		key:          key,
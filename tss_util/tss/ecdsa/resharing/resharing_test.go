@@ -59,7 +59,7 @@ func (s *ResharingTestSuite) Test_ValidResharingProcess_OldAndNewSubset() {
 		s.MockECDSAStorer.EXPECT().GetKeyshare().Return(share, nil)
 		s.MockECDSAStorer.EXPECT().StoreKeyshare(gomock.Any()).Return(nil)
 		resharing := resharing.NewResharing("resharing2", 1, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
@@ -106,7 +106,7 @@ func (s *ResharingTestSuite) Test_ValidResharingProcess_RemovePeer() {
 		s.MockECDSAStorer.EXPECT().GetKeyshare().Return(share, nil)
 		s.MockECDSAStorer.EXPECT().StoreKeyshare(gomock.Any()).Return(nil)
 		resharing := resharing.NewResharing("resharing2", 1, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
@@ -156,7 +156,7 @@ func (s *ResharingTestSuite) Test_InvalidResharingProcess_InvalidOldThreshold_Le
 		s.MockECDSAStorer.EXPECT().UnlockKeyshare().AnyTimes()
 		s.MockECDSAStorer.EXPECT().GetKeyshare().Return(share, nil)
 		resharing := resharing.NewResharing("resharing3", 1, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
@@ -205,7 +205,7 @@ func (s *ResharingTestSuite) Test_InvalidResharingProcess_InvalidOldThreshold_Bi
 		s.MockECDSAStorer.EXPECT().UnlockKeyshare().AnyTimes()
 		s.MockECDSAStorer.EXPECT().GetKeyshare().Return(share, nil)
 		resharing := resharing.NewResharing("resharing4", 1, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
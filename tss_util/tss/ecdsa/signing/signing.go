@@ -4,16 +4,20 @@
 package signing
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"reflect"
 	"time"
+	"tss-demo/logging"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/keyshare"
+	"tss-demo/tss_util/topology"
 	errors "tss-demo/tss_util/tss"
 	common2 "tss-demo/tss_util/tss/ecdsa/common"
+	"tss-demo/tss_util/tss/liveness"
 	"tss-demo/tss_util/tss/util"
 
 	tssCommon "github.com/binance-chain/tss-lib/common"
@@ -21,7 +25,6 @@ import (
 	"github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
 	"golang.org/x/exp/slices"
 )
@@ -32,6 +35,19 @@ type SaveDataFetcher interface {
 	UnlockKeyshare()
 }
 
+// LivenessGracePeriod is how long the coordinator waits for healthier peers once enough
+// peers are ready but the best signing subset contains degraded or unhealthy peers
+var LivenessGracePeriod = 10 * time.Second
+
+// startParams are sent by the coordinator with the start message. Ready peers and their
+// liveness tiers describe how the subset was selected. Participants can't verify the
+// liveness view of the coordinator, so they verify the subset against their own topology.
+type startParams struct {
+	Subset []peer.ID       `json:"subset"`
+	Ready  []peer.ID       `json:"ready"`
+	Tiers  []liveness.Tier `json:"tiers"`
+}
+
 type Signing struct {
 	common2.BaseTss
 	coordinator    bool
@@ -39,6 +55,9 @@ type Signing struct {
 	msg            *big.Int
 	resultChn      chan interface{}
 	subscriptionID comm2.SubscriptionID
+	topologies     topology.TopologyGetter
+	liveness       *liveness.Tracker
+	readySince     time.Time
 }
 
 func NewSigning(
@@ -48,6 +67,8 @@ func NewSigning(
 	host host.Host,
 	comm comm2.Communication,
 	fetcher SaveDataFetcher,
+	topologies topology.TopologyGetter,
+	liveness *liveness.Tracker,
 ) (*Signing, error) {
 	fetcher.LockKeyshare()
 	defer fetcher.UnlockKeyshare()
@@ -64,11 +85,13 @@ func NewSigning(
 			Communication: comm,
 			Peers:         key.Peers,
 			SID:           sessionID,
-			Log:           log.With().Str("SessionID", sessionID).Str("messageID", messageID).Str("Process", "signing").Logger(),
+			Log:           logging.Session(sessionID, "signing").Str("messageID", messageID).Str(logging.KeyIDField, key.ID()).Logger(),
 			Cancel:        func() {},
 		},
-		key: key,
-		msg: msg,
+		key:        key,
+		msg:        msg,
+		topologies: topologies,
+		liveness:   liveness,
 	}, nil
 }
 
@@ -82,6 +105,8 @@ func (s *Signing) Run(
 ) error {
 	s.coordinator = coordinator
 	s.resultChn = resultChn
+	// a retry of the process waits for healthy peers again
+	s.readySince = time.Time{}
 	ctx, s.Cancel = context.WithCancel(ctx)
 
 	peerSubset, err := s.unmarshallStartParams(params)
@@ -144,9 +169,31 @@ func (s *Signing) Stop() {
 }
 
 // Ready returns true if threshold+1 parties are ready to start the signing process.
+// While the best subset contains peers that are not healthy, the process waits up
+// to LivenessGracePeriod for the rest of the signers. Error is returned if excluded
+// peers leave less than threshold+1 signers.
 func (s *Signing) Ready(readyPeers []peer.ID, excludedPeers []peer.ID) (bool, error) {
+	if available := len(common2.ExcludePeers(s.key.Peers, excludedPeers)); available < s.key.Threshold+1 {
+		return false, &errors.ThresholdError{Required: s.key.Threshold + 1, Available: available}
+	}
 	readyPeers = s.readyParticipants(readyPeers)
-	return len(readyPeers) == s.key.Threshold+1, nil
+	if len(readyPeers) < s.key.Threshold+1 {
+		s.readySince = time.Time{}
+		return false, nil
+	}
+	if s.readySince.IsZero() {
+		s.readySince = time.Now()
+	}
+	if len(readyPeers) == len(s.readyParticipants(s.key.Peers)) || time.Since(s.readySince) >= LivenessGracePeriod {
+		return true, nil
+	}
+
+	for _, p := range s.selectSubset(readyPeers, s.liveness.Tier) {
+		if s.liveness.Tier(p) != liveness.Healthy {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 // ValidCoordinators returns only peers that have a valid keyshare
@@ -154,15 +201,40 @@ func (s *Signing) ValidCoordinators() []peer.ID {
 	return s.key.Peers
 }
 
-// StartParams returns peer subset for this tss process. It is calculated
-// by sorting hashes of peer IDs and session ID and chosing ready peers alphabetically
-// until threshold is satisfied.
+// StartParams returns peer subset for this tss process together with ready peers
+// and their liveness tiers used to select it.
 func (s *Signing) StartParams(readyPeers []peer.ID) []byte {
 	readyPeers = s.readyParticipants(readyPeers)
+	tiers := make([]liveness.Tier, len(readyPeers))
+	for i, p := range readyPeers {
+		tiers[i] = s.liveness.Tier(p)
+	}
+	peerSubset := s.selectSubset(readyPeers, s.liveness.Tier)
+
+	nt := topology.Current(s.topologies)
+	s.Log.Info().Strs("subset", nt.PeerNames(peerSubset)).Msg("Selected signing subset")
+	paramBytes, _ := json.Marshal(startParams{
+		Subset: peerSubset,
+		Ready:  readyPeers,
+		Tiers:  tiers,
+	})
+	return paramBytes
+}
+
+// selectSubset calculates peer subset by sorting hashes of peer IDs and session ID and
+// chosing ready peers alphabetically until threshold is satisfied. Peers with better
+// liveness tier are chosen first and peers with higher topology weight break ties.
+func (s *Signing) selectSubset(readyPeers []peer.ID, tier func(peer.ID) liveness.Tier) []peer.ID {
 	peers := []peer.ID{}
 	peers = append(peers, readyPeers...)
 
-	sortedPeers := util.SortPeersForSession(peers, s.SessionID())
+	nt := topology.Current(s.topologies)
+	sortedPeers := util.SortPeersByWeight(util.SortPeersForSession(peers, s.SessionID()), func(p peer.ID) int {
+		return nt.PeerMetadata(p).Weight
+	})
+	sortedPeers = util.SortPeersByTier(sortedPeers, func(p peer.ID) int {
+		return int(tier(p))
+	})
 	peerSubset := []peer.ID{}
 	for _, peer := range sortedPeers {
 		peerSubset = append(peerSubset, peer.ID)
@@ -170,19 +242,64 @@ func (s *Signing) StartParams(readyPeers []peer.ID) []byte {
 			break
 		}
 	}
-
-	paramBytes, _ := json.Marshal(peerSubset)
-	return paramBytes
+	return peerSubset
 }
 
+// unmarshallStartParams returns peer subset from start params. Coordinators before
+// liveness-aware selection send only the subset. Subset has to consist of threshold+1
+// distinct peers that are allowed to sign by the local topology and keyshare.
 func (s *Signing) unmarshallStartParams(paramBytes []byte) ([]peer.ID, error) {
-	var peerSubset []peer.ID
-	err := json.Unmarshal(paramBytes, &peerSubset)
+	var params startParams
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(paramBytes), []byte("[")) {
+		err = json.Unmarshal(paramBytes, &params.Subset)
+	} else {
+		err = json.Unmarshal(paramBytes, &params)
+	}
 	if err != nil {
 		return []peer.ID{}, err
 	}
 
-	return peerSubset, nil
+	if len(params.Subset) != s.key.Threshold+1 {
+		return []peer.ID{}, fmt.Errorf("signing subset %s doesn't have %d peers", peer.IDSlice(params.Subset), s.key.Threshold+1)
+	}
+	participants := s.readyParticipants(params.Subset)
+	seen := make(map[peer.ID]bool)
+	for _, p := range participants {
+		seen[p] = true
+	}
+	if len(participants) != len(params.Subset) || len(seen) != len(params.Subset) {
+		return []peer.ID{}, fmt.Errorf("signing subset %s contains peers not allowed to sign", peer.IDSlice(params.Subset))
+	}
+
+	// legacy coordinators don't send ready peers the subset was selected from
+	if params.Ready == nil {
+		return params.Subset, nil
+	}
+	if len(params.Ready) != len(params.Tiers) {
+		return []peer.ID{}, fmt.Errorf("signing start params have %d ready peers and %d tiers", len(params.Ready), len(params.Tiers))
+	}
+	expectedSubset := s.selectSubset(s.readyParticipants(params.Ready), tierFrom(params.Ready, params.Tiers))
+	matches := len(expectedSubset) == len(params.Subset)
+	for _, p := range expectedSubset {
+		matches = matches && seen[p]
+	}
+	if !matches {
+		return []peer.ID{}, fmt.Errorf("signing subset %s doesn't match subset %s selected from ready peers", peer.IDSlice(params.Subset), peer.IDSlice(expectedSubset))
+	}
+
+	return params.Subset, nil
+}
+
+// tierFrom returns liveness tiers of ready peers as reported by the coordinator
+func tierFrom(readyPeers []peer.ID, tiers []liveness.Tier) func(peer.ID) liveness.Tier {
+	peerTiers := make(map[peer.ID]liveness.Tier)
+	for i, p := range readyPeers {
+		peerTiers[p] = tiers[i]
+	}
+	return func(p peer.ID) liveness.Tier {
+		return peerTiers[p]
+	}
 }
 
 // processEndMessage routes signature to result channel.
@@ -212,13 +329,18 @@ func (s *Signing) processEndMessage(ctx context.Context, endChn chan tssCommon.S
 }
 
 // readyParticipants returns all ready peers that contain a valid key share
+// and are allowed to sign by their topology role
 func (s *Signing) readyParticipants(readyPeers []peer.ID) []peer.ID {
+	nt := topology.Current(s.topologies)
 	readyParticipants := make([]peer.ID, 0)
 	for _, peer := range readyPeers {
 
 		if !slices.Contains(s.key.Peers, peer) {
 			continue
 		}
+		if !nt.PeerMetadata(peer).CanSign() {
+			continue
+		}
 
 		readyParticipants = append(readyParticipants, peer)
 	}
@@ -230,6 +352,11 @@ func (s *Signing) Retryable() bool {
 	return true
 }
 
+// KeyID returns ID of the key used for signing
+func (s *Signing) KeyID() string {
+	return s.key.ID()
+}
+
 // monitorSigning checks if the process is stuck and waiting for peers and sends an error
 // if it is
 func (s *Signing) monitorSigning(ctx context.Context) error {
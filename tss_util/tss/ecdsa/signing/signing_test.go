@@ -5,6 +5,8 @@ package signing_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -15,6 +17,7 @@ import (
 	"tss-demo/tss_util/tss"
 	"tss-demo/tss_util/tss/ecdsa/keygen"
 	"tss-demo/tss_util/tss/ecdsa/signing"
+	"tss-demo/tss_util/tss/liveness"
 	tsstest2 "tss-demo/tss_util/tss/test"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -46,11 +49,11 @@ func (s *SigningTestSuite) Test_ValidSigningProcess() {
 		msgBytes := []byte("Message")
 		msg := big.NewInt(0)
 		msg.SetBytes(msgBytes)
-		signing, err := signing.NewSigning(msg, "signing1", "signing1", host, &communication, fetcher)
+		signing, err := signing.NewSigning(msg, "signing1", "signing1", host, &communication, fetcher, nil, nil)
 		if err != nil {
 			panic(err)
 		}
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, signing)
 	}
@@ -96,11 +99,11 @@ func (s *SigningTestSuite) Test_SigningTimeout() {
 		msgBytes := []byte("Message")
 		msg := big.NewInt(0)
 		msg.SetBytes(msgBytes)
-		signing, err := signing.NewSigning(msg, "signing2", "signing2", host, &communication, fetcher)
+		signing, err := signing.NewSigning(msg, "signing2", "signing2", host, &communication, fetcher, nil, nil)
 		if err != nil {
 			panic(err)
 		}
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinator := tss.NewCoordinator(host, &communication, electorFactory)
 		coordinator.TssTimeout = time.Nanosecond
 		coordinators = append(coordinators, coordinator)
@@ -132,7 +135,7 @@ func (s *SigningTestSuite) Test_PendingProcessExists() {
 		}
 		communicationMap[host.ID()] = &communication
 		keygen := keygen.NewKeygen("keygen3", s.Threshold, host, &communication, s.MockECDSAStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, keygen)
 	}
@@ -149,3 +152,158 @@ func (s *SigningTestSuite) Test_PendingProcessExists() {
 	err := pool.Wait()
 	s.NotNil(err)
 }
+
+func (s *SigningTestSuite) livenessSigning(tracker *liveness.Tracker) *signing.Signing {
+	communication := tsstest2.TestCommunication{
+		Host:          s.Hosts[0],
+		Subscriptions: make(map[comm2.SubscriptionID]chan *comm2.WrappedMessage),
+	}
+	fetcher := keyshare.NewECDSAKeyshareStore("../../test/keyshares/0.keyshare")
+	signing, err := signing.NewSigning(big.NewInt(1), "signing4", "signing4", s.Hosts[0], &communication, fetcher, nil, tracker)
+	s.Nil(err)
+	return signing
+}
+
+func (s *SigningTestSuite) Test_StartParams_PrefersHealthyPeers() {
+	tracker := liveness.NewTracker()
+	tracker.RecordFailure(s.Hosts[1].ID())
+	tracker.RecordFailure(s.Hosts[1].ID())
+	signing := s.livenessSigning(tracker)
+	readyPeers := []peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID(), s.Hosts[2].ID()}
+
+	ready, err := signing.Ready(readyPeers, []peer.ID{})
+	s.Nil(err)
+	s.True(ready)
+
+	var params struct {
+		Subset []peer.ID
+	}
+	err = json.Unmarshal(signing.StartParams(readyPeers), &params)
+	s.Nil(err)
+	s.ElementsMatch([]peer.ID{s.Hosts[0].ID(), s.Hosts[2].ID()}, params.Subset)
+}
+
+func (s *SigningTestSuite) Test_Ready_WaitsForHealthyPeers() {
+	tracker := liveness.NewTracker()
+	tracker.RecordFailure(s.Hosts[1].ID())
+	signing := s.livenessSigning(tracker)
+	readyPeers := []peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID()}
+
+	ready, err := signing.Ready(readyPeers, []peer.ID{})
+	s.Nil(err)
+	s.False(ready)
+
+	ready, err = signing.Ready(append(readyPeers, s.Hosts[2].ID()), []peer.ID{})
+	s.Nil(err)
+	s.True(ready)
+}
+
+func (s *SigningTestSuite) Test_Ready_GracePeriodExpired() {
+	gracePeriod := signing.LivenessGracePeriod
+	signing.LivenessGracePeriod = 0
+	defer func() { signing.LivenessGracePeriod = gracePeriod }()
+	tracker := liveness.NewTracker()
+	tracker.RecordFailure(s.Hosts[1].ID())
+	signing := s.livenessSigning(tracker)
+
+	ready, err := signing.Ready([]peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID()}, []peer.ID{})
+
+	s.Nil(err)
+	s.True(ready)
+}
+
+func (s *SigningTestSuite) Test_Ready_NotEnoughPartiesAfterExclusion() {
+	signing := s.livenessSigning(liveness.NewTracker())
+
+	_, err := signing.Ready([]peer.ID{s.Hosts[0].ID()}, []peer.ID{s.Hosts[1].ID(), s.Hosts[2].ID()})
+
+	s.Equal(&tss.ThresholdError{Required: 2, Available: 1}, err)
+}
+
+func (s *SigningTestSuite) Test_Ready_GracePeriodRestartsOnRetry() {
+	gracePeriod := signing.LivenessGracePeriod
+	signing.LivenessGracePeriod = 50 * time.Millisecond
+	defer func() { signing.LivenessGracePeriod = gracePeriod }()
+	tracker := liveness.NewTracker()
+	tracker.RecordFailure(s.Hosts[1].ID())
+	signing := s.livenessSigning(tracker)
+	readyPeers := []peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID()}
+
+	ready, _ := signing.Ready(readyPeers, []peer.ID{})
+	s.False(ready)
+	time.Sleep(60 * time.Millisecond)
+	// retry starts collecting ready peers from scratch
+	ready, _ = signing.Ready(readyPeers[:1], []peer.ID{})
+	s.False(ready)
+	ready, _ = signing.Ready(readyPeers, []peer.ID{})
+	s.False(ready)
+}
+
+func (s *SigningTestSuite) Test_Run_SubsetWithUnknownPeer_Fails() {
+	unknown, _ := peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+	paramBytes, _ := json.Marshal(map[string]interface{}{
+		"subset": []peer.ID{s.Hosts[0].ID(), unknown},
+		"ready":  []peer.ID{s.Hosts[0].ID(), unknown},
+		"tiers":  []liveness.Tier{liveness.Healthy, liveness.Healthy},
+	})
+
+	err := s.livenessSigning(nil).Run(context.Background(), false, make(chan interface{}, 1), paramBytes)
+
+	s.NotNil(err)
+	s.False(errors.As(err, new(*tss.SubsetError)))
+}
+
+func (s *SigningTestSuite) Test_Run_SubsetWithWrongSize_Fails() {
+	paramBytes, _ := json.Marshal([]peer.ID{s.Hosts[0].ID()})
+
+	err := s.livenessSigning(nil).Run(context.Background(), false, make(chan interface{}, 1), paramBytes)
+
+	s.NotNil(err)
+	s.False(errors.As(err, new(*tss.SubsetError)))
+}
+
+func (s *SigningTestSuite) Test_Run_AcceptsBothStartParamFormats() {
+	subset := []peer.ID{s.Hosts[1].ID(), s.Hosts[2].ID()}
+	legacyParams, _ := json.Marshal(subset)
+	params, _ := json.Marshal(map[string]interface{}{
+		"subset": subset,
+		"ready":  subset,
+		"tiers":  []liveness.Tier{liveness.Healthy, liveness.Healthy},
+	})
+
+	for _, paramBytes := range [][]byte{legacyParams, params} {
+		err := s.livenessSigning(nil).Run(context.Background(), false, make(chan interface{}, 1), paramBytes)
+
+		// subset is parsed and verified, local host is just not part of it
+		s.True(errors.As(err, new(*tss.SubsetError)))
+	}
+}
+
+func (s *SigningTestSuite) Test_Run_SubsetNotSelectedFromReadyPeers_Fails() {
+	readyPeers := []peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID(), s.Hosts[2].ID()}
+	tiers := []liveness.Tier{liveness.Healthy, liveness.Unhealthy, liveness.Healthy}
+	// healthy peers are selected first, the coordinator swapped in the unhealthy one
+	paramBytes, _ := json.Marshal(map[string]interface{}{
+		"subset": []peer.ID{s.Hosts[1].ID(), s.Hosts[2].ID()},
+		"ready":  readyPeers,
+		"tiers":  tiers,
+	})
+
+	err := s.livenessSigning(nil).Run(context.Background(), false, make(chan interface{}, 1), paramBytes)
+
+	s.NotNil(err)
+	s.False(errors.As(err, new(*tss.SubsetError)))
+}
+
+func (s *SigningTestSuite) Test_Run_SubsetSelectedFromReadyPeers() {
+	paramBytes, _ := json.Marshal(map[string]interface{}{
+		"subset": []peer.ID{s.Hosts[2].ID(), s.Hosts[1].ID()},
+		"ready":  []peer.ID{s.Hosts[0].ID(), s.Hosts[1].ID(), s.Hosts[2].ID()},
+		"tiers":  []liveness.Tier{liveness.Unhealthy, liveness.Healthy, liveness.Healthy},
+	})
+
+	err := s.livenessSigning(nil).Run(context.Background(), false, make(chan interface{}, 1), paramBytes)
+
+	// subset matches the selection, local host is just not part of it
+	s.True(errors.As(err, new(*tss.SubsetError)))
+}
@@ -4,11 +4,16 @@
 package tss
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+// ErrSessionPending is returned if the session is already running on the node
+var ErrSessionPending = errors.New("process already pending")
+
 type CoordinatorError struct {
 	Peer peer.ID
 }
@@ -24,3 +29,36 @@ type SubsetError struct {
 func (se *SubsetError) Error() string {
 	return fmt.Sprintf("party %s not in signing subset", se.Peer)
 }
+
+// ThresholdError is returned if not enough parties are left to run the process
+type ThresholdError struct {
+	Required  int
+	Available int
+}
+
+func (te *ThresholdError) Error() string {
+	return fmt.Sprintf("process requires %d parties but only %d are available", te.Required, te.Available)
+}
+
+// TimeoutError is returned if the process doesn't finish within the tss timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (te *TimeoutError) Error() string {
+	return fmt.Sprintf("tss process timed out after %v", te.Timeout)
+}
+
+// CulpritsError is returned if the process failed because of misbehaving parties
+type CulpritsError struct {
+	Culprits []peer.ID
+	Err      error
+}
+
+func (ce *CulpritsError) Error() string {
+	return fmt.Sprintf("tss process failed with culprits %v: %s", ce.Culprits, ce.Err)
+}
+
+func (ce *CulpritsError) Unwrap() error {
+	return ce.Err
+}
@@ -0,0 +1,141 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tss
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type SessionEventType string
+
+const (
+	// EventElection is sent when the coordinator of the session is elected, again on every retry
+	EventElection SessionEventType = "election"
+	// EventReady is sent when enough peers are ready and the protocol is about to start
+	EventReady SessionEventType = "ready"
+	// EventStarted is sent when the node starts running the protocol
+	EventStarted SessionEventType = "started"
+	// EventRound is sent when the node starts a new protocol round
+	EventRound SessionEventType = "round"
+	// EventCompleted is sent when the session finished successfully
+	EventCompleted SessionEventType = "completed"
+	// EventFailed is sent when the session failed, with culprits if parties were blamed
+	EventFailed SessionEventType = "failed"
+	// EventCancelled is sent when the session was cancelled before it finished
+	EventCancelled SessionEventType = "cancelled"
+)
+
+// Final returns true if no more events are sent for the session execution
+func (t SessionEventType) Final() bool {
+	return t == EventCompleted || t == EventFailed || t == EventCancelled
+}
+
+// SessionEvent is a phase transition of a tss session executed by the node
+type SessionEvent struct {
+	SessionID string
+	Process   string
+	Type      SessionEventType
+	Time      time.Time
+
+	// Coordinator is the elected coordinator of election events
+	Coordinator peer.ID
+	// Round is the number of the protocol round starting from 1 and RoundName
+	// the protocol name of the round
+	Round     int
+	RoundName string
+	// Culprits are peers blamed for a failed session
+	Culprits []peer.ID
+	Err      error
+}
+
+// SessionObserver receives phase transitions of tss sessions executed by the node
+type SessionObserver interface {
+	ObserveSession(event SessionEvent)
+}
+
+// SessionObservers sends session events to each of the observers in order
+type SessionObservers []SessionObserver
+
+func (o SessionObservers) ObserveSession(event SessionEvent) {
+	for _, observer := range o {
+		observer.ObserveSession(event)
+	}
+}
+
+// finalEvent returns the event sent when the session execution ends with the outcome
+func finalEvent(sessionID string, process string, outcome string, err error) SessionEvent {
+	event := SessionEvent{
+		SessionID: sessionID,
+		Process:   process,
+		Type:      EventCompleted,
+		Time:      time.Now(),
+		Err:       err,
+	}
+	switch outcome {
+	case SessionFailure:
+		event.Type = EventFailed
+		var culpritsErr *CulpritsError
+		if errors.As(err, &culpritsErr) {
+			event.Culprits = culpritsErr.Culprits
+		}
+	case SessionCancelled:
+		event.Type = EventCancelled
+	}
+	return event
+}
+
+// roundMeter reports protocol rounds of a process as session phases and numbers the
+// rounds of the session. Processes executed together share the meter so that a round
+// is reported once for all of them.
+type roundMeter struct {
+	sessionID string
+	process   string
+	metrics   SessionMeter
+	observer  SessionObserver
+
+	mu     sync.Mutex
+	rounds map[string]int
+}
+
+func newRoundMeter(sessionID string, process string, metrics SessionMeter, observer SessionObserver) *roundMeter {
+	return &roundMeter{
+		sessionID: sessionID,
+		process:   process,
+		metrics:   metrics,
+		observer:  observer,
+		rounds:    make(map[string]int),
+	}
+}
+
+func (m *roundMeter) TrackRound(round string, duration time.Duration) {
+	m.metrics.TrackSessionPhase(m.process, round, duration)
+}
+
+func (m *roundMeter) RoundStarted(round string) {
+	m.mu.Lock()
+	if _, ok := m.rounds[round]; ok {
+		m.mu.Unlock()
+		return
+	}
+	number := len(m.rounds) + 1
+	m.rounds[round] = number
+	m.mu.Unlock()
+
+	m.observer.ObserveSession(SessionEvent{
+		SessionID: m.sessionID,
+		Process:   m.process,
+		Type:      EventRound,
+		Time:      time.Now(),
+		Round:     number,
+		RoundName: round,
+	})
+}
+
+type noopSessionObserver struct{}
+
+func (noopSessionObserver) ObserveSession(event SessionEvent) {}
@@ -9,6 +9,7 @@ import (
 	"runtime/debug"
 	"time"
 	comm2 "tss-demo/tss_util/comm"
+	"tss-demo/tss_util/tss/message"
 
 	"github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -29,6 +30,7 @@ type BaseFrostTss struct {
 	Peers         []peer.ID
 	Handler       *protocol.MultiHandler
 	Done          chan bool
+	message.RoundTimer
 
 	Cancel context.CancelFunc
 }
@@ -78,6 +80,7 @@ func (k *BaseFrostTss) ProcessOutboundMessages(ctx context.Context, outChn chan
 					return nil
 				}
 
+				k.OutboundMessage(fmt.Sprintf("round%d", msg.RoundNumber))
 				msgBytes, err := msg.MarshalBinary()
 				if err != nil {
 					return err
@@ -39,7 +39,7 @@ func (s *KeygenTestSuite) Test_ValidKeygenProcess() {
 		communicationMap[host.ID()] = &communication
 		s.MockFrostStorer.EXPECT().LockKeyshare()
 		keygen := keygen.NewKeygen("keygen", s.Threshold, host, &communication, s.MockFrostStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, keygen)
 	}
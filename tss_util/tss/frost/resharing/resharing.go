@@ -6,6 +6,7 @@ package resharing
 import (
 	"context"
 	"encoding/json"
+	"tss-demo/logging"
 	comm2 "tss-demo/tss_util/comm"
 	"tss-demo/tss_util/keyshare"
 	common2 "tss-demo/tss_util/tss/frost/common"
@@ -13,7 +14,6 @@ import (
 	"github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/rs/zerolog/log"
 	"github.com/sourcegraph/conc/pool"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
@@ -71,7 +71,7 @@ func NewResharing(
 			Communication: comm,
 			Peers:         host.Peerstore().Peers(),
 			SID:           sessionID,
-			Log:           log.With().Str("SessionID", sessionID).Str("Process", "resharing").Logger(),
+			Log:           logging.Session(sessionID, "resharing").Str(logging.KeyIDField, key.ID()).Logger(),
 			Cancel:        func() {},
 			Done:          make(chan bool),
 		},
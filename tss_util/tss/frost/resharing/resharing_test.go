@@ -59,7 +59,7 @@ func (s *ResharingTestSuite) Test_ValidResharingProcess_OldAndNewSubset() {
 		s.MockFrostStorer.EXPECT().GetKeyshare().Return(share, err)
 		s.MockFrostStorer.EXPECT().StoreKeyshare(gomock.Any()).Return(nil)
 		resharing := resharing.NewResharing("resharing2", 1, host, &communication, s.MockFrostStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
@@ -106,7 +106,7 @@ func (s *ResharingTestSuite) Test_ValidResharingProcess_RemovePeer() {
 		s.MockFrostStorer.EXPECT().GetKeyshare().Return(share, err)
 		s.MockFrostStorer.EXPECT().StoreKeyshare(gomock.Any()).Return(nil)
 		resharing := resharing.NewResharing("resharing2", 1, host, &communication, s.MockFrostStorer)
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, resharing)
 	}
@@ -61,7 +61,7 @@ func (s *SigningTestSuite) Test_ValidSigningProcess() {
 		if err != nil {
 			panic(err)
 		}
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinators = append(coordinators, tss.NewCoordinator(host, &communication, electorFactory))
 		processes = append(processes, signing)
 	}
@@ -122,7 +122,7 @@ func (s *SigningTestSuite) Test_MultipleProcesses() {
 		if err != nil {
 			panic(err)
 		}
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinator := tss.NewCoordinator(host, &communication, electorFactory)
 		coordinators = append(coordinators, coordinator)
 		processes = append(processes, []tss.TssProcess{signing1, signing2, signing3})
@@ -180,7 +180,7 @@ func (s *SigningTestSuite) Test_ProcessTimeout() {
 		if err != nil {
 			panic(err)
 		}
-		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig)
+		electorFactory := elector.NewCoordinatorElectorFactory(host, s.BullyConfig, nil)
 		coordinator := tss.NewCoordinator(host, &communication, electorFactory)
 		coordinator.TssTimeout = time.Nanosecond
 		coordinators = append(coordinators, coordinator)
@@ -0,0 +1,162 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package liveness
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Tier is a coarse liveness classification of a peer. Peers with lower tier
+// are preferred when choosing the signing subset.
+type Tier int
+
+const (
+	Healthy Tier = iota
+	Degraded
+	Unhealthy
+)
+
+func (t Tier) String() string {
+	switch t {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	default:
+		return "unhealthy"
+	}
+}
+
+var (
+	// DegradedLatency is average response latency above which peer is considered degraded
+	DegradedLatency = 5 * time.Second
+	// latencySmoothing is the weight of the latest latency sample in the moving average
+	latencySmoothing = 0.2
+	// degradedScore and unhealthyScore are failure score limits of the tiers. Every failure
+	// increases the score by one and every response halves it.
+	degradedScore  = 1.0
+	unhealthyScore = 2.0
+)
+
+// PeerStats contains liveness statistics of a single peer
+type PeerStats struct {
+	// Latency is a moving average of peer response latency
+	Latency      time.Duration
+	Responses    uint64
+	Failures     uint64
+	FailureScore float64
+	LastSeen     time.Time
+	LastFailure  time.Time
+}
+
+// Tracker keeps per-peer latency and failure statistics gathered from tss sessions
+// and communication health checks. Nil tracker treats every peer as healthy.
+type Tracker struct {
+	mu    sync.RWMutex
+	stats map[peer.ID]*PeerStats
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		stats: make(map[peer.ID]*PeerStats),
+	}
+}
+
+// RecordResponse records that peer responded with the given latency
+func (t *Tracker) RecordResponse(p peer.ID, latency time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.peerStats(p)
+	if stats.Responses == 0 {
+		stats.Latency = latency
+	} else {
+		stats.Latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(stats.Latency))
+	}
+	t.recordAvailable(stats)
+}
+
+// RecordAvailable records that peer was reachable without measuring its latency
+func (t *Tracker) RecordAvailable(p peer.ID) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recordAvailable(t.peerStats(p))
+}
+
+// RecordFailure records that peer failed a session or a health check
+func (t *Tracker) RecordFailure(p peer.ID) {
+	if t == nil || p == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.peerStats(p)
+	stats.Failures++
+	stats.FailureScore++
+	stats.LastFailure = time.Now()
+}
+
+// Tier classifies peer by its failure score and average latency. Peers
+// without statistics are healthy.
+func (t *Tracker) Tier(p peer.ID) Tier {
+	if t == nil {
+		return Healthy
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stats, ok := t.stats[p]
+	if !ok {
+		return Healthy
+	}
+	switch {
+	case stats.FailureScore >= unhealthyScore:
+		return Unhealthy
+	case stats.FailureScore >= degradedScore, stats.Latency > DegradedLatency:
+		return Degraded
+	default:
+		return Healthy
+	}
+}
+
+// Stats returns a copy of statistics of all tracked peers
+func (t *Tracker) Stats() map[peer.ID]PeerStats {
+	stats := make(map[peer.ID]PeerStats)
+	if t == nil {
+		return stats
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for p, s := range t.stats {
+		stats[p] = *s
+	}
+	return stats
+}
+
+func (t *Tracker) recordAvailable(stats *PeerStats) {
+	stats.Responses++
+	stats.FailureScore /= 2
+	stats.LastSeen = time.Now()
+}
+
+func (t *Tracker) peerStats(p peer.ID) *PeerStats {
+	stats, ok := t.stats[p]
+	if !ok {
+		stats = &PeerStats{}
+		t.stats[p] = stats
+	}
+	return stats
+}
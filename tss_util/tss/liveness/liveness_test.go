@@ -0,0 +1,70 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package liveness_test
+
+import (
+	"testing"
+	"time"
+	"tss-demo/tss_util/tss/liveness"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrackerTestSuite struct {
+	suite.Suite
+	tracker *liveness.Tracker
+	peer    peer.ID
+}
+
+func TestRunTrackerTestSuite(t *testing.T) {
+	suite.Run(t, new(TrackerTestSuite))
+}
+
+func (s *TrackerTestSuite) SetupTest() {
+	s.tracker = liveness.NewTracker()
+	s.peer, _ = peer.Decode("QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR")
+}
+
+func (s *TrackerTestSuite) Test_UnknownPeer_Healthy() {
+	s.Equal(liveness.Healthy, s.tracker.Tier(s.peer))
+}
+
+func (s *TrackerTestSuite) Test_NilTracker_Healthy() {
+	var tracker *liveness.Tracker
+	tracker.RecordFailure(s.peer)
+
+	s.Equal(liveness.Healthy, tracker.Tier(s.peer))
+	s.Empty(tracker.Stats())
+}
+
+func (s *TrackerTestSuite) Test_Failures_DegradeTier() {
+	s.tracker.RecordFailure(s.peer)
+	s.Equal(liveness.Degraded, s.tracker.Tier(s.peer))
+
+	s.tracker.RecordFailure(s.peer)
+	s.Equal(liveness.Unhealthy, s.tracker.Tier(s.peer))
+	s.Equal(uint64(2), s.tracker.Stats()[s.peer].Failures)
+}
+
+func (s *TrackerTestSuite) Test_Responses_RecoverTier() {
+	s.tracker.RecordFailure(s.peer)
+	s.tracker.RecordFailure(s.peer)
+
+	s.tracker.RecordAvailable(s.peer)
+	s.Equal(liveness.Degraded, s.tracker.Tier(s.peer))
+
+	s.tracker.RecordResponse(s.peer, time.Millisecond)
+	s.Equal(liveness.Healthy, s.tracker.Tier(s.peer))
+}
+
+func (s *TrackerTestSuite) Test_SlowResponses_Degraded() {
+	s.tracker.RecordResponse(s.peer, liveness.DegradedLatency*2)
+	s.Equal(liveness.Degraded, s.tracker.Tier(s.peer))
+
+	for i := 0; i < 10; i++ {
+		s.tracker.RecordResponse(s.peer, time.Millisecond)
+	}
+	s.Equal(liveness.Healthy, s.tracker.Tier(s.peer))
+}
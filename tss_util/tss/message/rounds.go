@@ -0,0 +1,55 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"time"
+)
+
+// RoundMeter measures duration of tss protocol rounds
+type RoundMeter interface {
+	TrackRound(round string, duration time.Duration)
+}
+
+// RoundObserver is implemented by round meters that are notified when a round starts
+type RoundObserver interface {
+	RoundStarted(round string)
+}
+
+// RoundTimer measures a protocol round from the first outbound message of the round until
+// the first outbound message of the next round. It is not safe for concurrent use.
+type RoundTimer struct {
+	meter RoundMeter
+	round string
+	start time.Time
+}
+
+// SetRoundMeter sets meter that receives round durations, rounds aren't measured without it.
+// Rounds are measured from the start with the new meter.
+func (t *RoundTimer) SetRoundMeter(meter RoundMeter) {
+	t.meter = meter
+	t.round = ""
+}
+
+// Measuring returns true if rounds are measured
+func (t *RoundTimer) Measuring() bool {
+	return t.meter != nil
+}
+
+// OutboundMessage registers outbound message of the provided round
+func (t *RoundTimer) OutboundMessage(round string) {
+	if t.meter == nil || round == t.round {
+		return
+	}
+
+	now := time.Now()
+	if t.round != "" {
+		t.meter.TrackRound(t.round, now.Sub(t.start))
+	}
+	t.round = round
+	t.start = now
+	if observer, ok := t.meter.(RoundObserver); ok {
+		observer.RoundStarted(round)
+	}
+}
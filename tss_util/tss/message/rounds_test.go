@@ -0,0 +1,78 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message_test
+
+import (
+	"testing"
+	"time"
+	"tss-demo/tss_util/tss/message"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingRoundMeter struct {
+	rounds []string
+}
+
+func (m *recordingRoundMeter) TrackRound(round string, duration time.Duration) {
+	m.rounds = append(m.rounds, round)
+}
+
+type observingRoundMeter struct {
+	recordingRoundMeter
+	started []string
+}
+
+func (m *observingRoundMeter) RoundStarted(round string) {
+	m.started = append(m.started, round)
+}
+
+type RoundTimerTestSuite struct {
+	suite.Suite
+	meter *recordingRoundMeter
+	timer *message.RoundTimer
+}
+
+func TestRunRoundTimerTestSuite(t *testing.T) {
+	suite.Run(t, new(RoundTimerTestSuite))
+}
+
+func (s *RoundTimerTestSuite) SetupTest() {
+	s.meter = &recordingRoundMeter{}
+	s.timer = &message.RoundTimer{}
+	s.timer.SetRoundMeter(s.meter)
+}
+
+func (s *RoundTimerTestSuite) Test_RoundTrackedWhenNextRoundStarts() {
+	s.timer.OutboundMessage("round1")
+	s.timer.OutboundMessage("round1")
+	s.Empty(s.meter.rounds)
+
+	s.timer.OutboundMessage("round2")
+	s.timer.OutboundMessage("round3")
+
+	s.Equal([]string{"round1", "round2"}, s.meter.rounds)
+}
+
+func (s *RoundTimerTestSuite) Test_NoMeter_NothingTracked() {
+	timer := &message.RoundTimer{}
+
+	timer.OutboundMessage("round1")
+	timer.OutboundMessage("round2")
+
+	s.Empty(s.meter.rounds)
+}
+
+func (s *RoundTimerTestSuite) Test_RoundObserver_NotifiedWhenRoundStarts() {
+	meter := &observingRoundMeter{}
+	timer := &message.RoundTimer{}
+	timer.SetRoundMeter(meter)
+
+	timer.OutboundMessage("round1")
+	timer.OutboundMessage("round1")
+	timer.OutboundMessage("round2")
+
+	s.Equal([]string{"round1", "round2"}, meter.started)
+	s.Equal([]string{"round1"}, meter.rounds)
+}
@@ -22,6 +22,28 @@ func SortPeersForSession(peers []peer.ID, sessionID string) SortablePeerSlice {
 	return sortedPeers
 }
 
+// SortPeersByWeight orders peers by descending weight. Peers with equal
+// weight keep the order from sortedPeers.
+func SortPeersByWeight(sortedPeers SortablePeerSlice, weight func(peer.ID) int) SortablePeerSlice {
+	weightedPeers := make(SortablePeerSlice, len(sortedPeers))
+	copy(weightedPeers, sortedPeers)
+	sort.SliceStable(weightedPeers, func(i, j int) bool {
+		return weight(weightedPeers[i].ID) > weight(weightedPeers[j].ID)
+	})
+	return weightedPeers
+}
+
+// SortPeersByTier orders peers by ascending liveness tier. Peers with equal
+// tier keep the order from sortedPeers.
+func SortPeersByTier(sortedPeers SortablePeerSlice, tier func(peer.ID) int) SortablePeerSlice {
+	tieredPeers := make(SortablePeerSlice, len(sortedPeers))
+	copy(tieredPeers, sortedPeers)
+	sort.SliceStable(tieredPeers, func(i, j int) bool {
+		return tier(tieredPeers[i].ID) < tier(tieredPeers[j].ID)
+	})
+	return tieredPeers
+}
+
 func IsParticipant(peer peer.ID, peers peer.IDSlice) bool {
 	for _, p := range peers {
 		if p.Pretty() == peer.Pretty() {
@@ -67,3 +67,16 @@ func (s *SortPeersForSessionTestSuite) Test_ValidPeers() {
 		util2.PeerMsg{SessionID: "sessionID", ID: peer3},
 	})
 }
+
+func (s *SortPeersForSessionTestSuite) Test_SortPeersByWeight() {
+	peer1, _ := peer.Decode("QmcW3oMdSqoEcjbyd51auqC23vhKX6BqfcZcY2HJ3sKAZR")
+	peer2, _ := peer.Decode("QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54")
+	peer3, _ := peer.Decode("QmYayosTHxL2xa4jyrQ2PmbhGbrkSxsGM1kzXLTT8SsLVy")
+	sortedPeers := util2.SortPeersForSession([]peer.ID{peer3, peer2, peer1}, "sessionID")
+	weights := map[peer.ID]int{peer1: 1, peer2: 1, peer3: 5}
+
+	weightedPeers := util2.SortPeersByWeight(sortedPeers, func(p peer.ID) int { return weights[p] })
+
+	s.Equal(peer.IDSlice{peer3, peer1, peer2}, weightedPeers.GetPeerIDs())
+	s.Equal(peer.IDSlice{peer1, peer2, peer3}, sortedPeers.GetPeerIDs())
+}
@@ -0,0 +1,84 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package chain
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/mitchellh/mapstructure"
+)
+
+const EVMType = "evm"
+
+// EVMConfig configures transactions sent from the MPC address to the chain at Endpoint.
+// Receipts are polled every ReceiptPollInterval until they have BlockConfirmations.
+// Suggested fees above MaxGasPrice are refused, no cap is applied if it is not set.
+type EVMConfig struct {
+	GeneralChainConfig
+	TxType              string
+	MaxGasPrice         *big.Int
+	BlockConfirmations  uint64
+	ReceiptPollInterval time.Duration
+}
+
+type RawEVMConfig struct {
+	GeneralChainConfig  `mapstructure:",squash"`
+	TxType              string `mapstructure:"txType" default:"dynamic-fee"`
+	MaxGasPrice         string `mapstructure:"maxGasPrice"`
+	BlockConfirmations  uint64 `mapstructure:"blockConfirmations" default:"10"`
+	ReceiptPollInterval string `mapstructure:"receiptPollInterval" default:"5s"`
+}
+
+func (c *RawEVMConfig) Validate() error {
+	if err := c.GeneralChainConfig.Validate(); err != nil {
+		return err
+	}
+	switch c.TxType {
+	case "legacy", "access-list", "dynamic-fee":
+	default:
+		return fmt.Errorf("unknown transaction type %s of chain %d", c.TxType, *c.Id)
+	}
+	if c.BlockConfirmations == 0 {
+		return fmt.Errorf("block confirmations of chain %d must be at least 1", *c.Id)
+	}
+	return nil
+}
+
+// NewEVMConfig decodes and validates the configuration of the chain of evm type
+func NewEVMConfig(chainConfig map[string]interface{}) (*EVMConfig, error) {
+	var c RawEVMConfig
+	err := mapstructure.Decode(chainConfig, &c)
+	if err != nil {
+		return nil, err
+	}
+	err = defaults.Set(&c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &EVMConfig{
+		GeneralChainConfig: c.GeneralChainConfig,
+		TxType:             c.TxType,
+		BlockConfirmations: c.BlockConfirmations,
+	}
+	if c.MaxGasPrice != "" {
+		maxGasPrice, ok := new(big.Int).SetString(c.MaxGasPrice, 10)
+		if !ok || maxGasPrice.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid max gas price %s of chain %d", c.MaxGasPrice, *c.Id)
+		}
+		config.MaxGasPrice = maxGasPrice
+	}
+	config.ReceiptPollInterval, err = time.ParseDuration(c.ReceiptPollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse receipt poll interval of chain %d: %w", *c.Id, err)
+	}
+	return config, nil
+}
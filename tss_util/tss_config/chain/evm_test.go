@@ -0,0 +1,58 @@
+// The Licensed Work is (c) 2022 Sygma
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package chain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewEVMConfig(t *testing.T) {
+	config, err := NewEVMConfig(map[string]interface{}{
+		"id":          float64(1),
+		"name":        "sepolia",
+		"type":        "evm",
+		"endpoint":    "http://127.0.0.1:8545",
+		"maxGasPrice": "100000000000",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *config.Id != 1 || config.Endpoint != "http://127.0.0.1:8545" {
+		t.Fatalf("unexpected general config %+v", config.GeneralChainConfig)
+	}
+	if config.TxType != "dynamic-fee" || config.BlockConfirmations != 10 || config.ReceiptPollInterval != 5*time.Second {
+		t.Fatalf("defaults not set %+v", config)
+	}
+	if config.MaxGasPrice.Cmp(big.NewInt(100000000000)) != 0 {
+		t.Fatalf("unexpected max gas price %s", config.MaxGasPrice)
+	}
+}
+
+func TestNewEVMConfig_Invalid(t *testing.T) {
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"id":       float64(1),
+			"name":     "sepolia",
+			"type":     "evm",
+			"endpoint": "http://127.0.0.1:8545",
+		}
+	}
+
+	unknownType := base()
+	unknownType["txType"] = "blob"
+	invalidGasPrice := base()
+	invalidGasPrice["maxGasPrice"] = "1 gwei"
+	missingEndpoint := base()
+	delete(missingEndpoint, "endpoint")
+
+	for _, c := range []map[string]interface{}{unknownType, invalidGasPrice, missingEndpoint} {
+		_, err := NewEVMConfig(c)
+		if err == nil {
+			t.Fatalf("config %v must be invalid", c)
+		}
+	}
+}
@@ -87,11 +87,19 @@ func (s *GetConfigTestSuite) Test_GetConfigFromENV() {
 
 	s.Equal(tss_config.Config{
 		RelayerConfig: relayer.RelayerConfig{
-			LogLevel:   1,
-			LogFile:    "out.log",
-			Env:        "TEST",
-			Id:         "123",
-			HealthPort: 9001,
+			LogLevel:            1,
+			LogFormat:           "json",
+			LogMaxSize:          100,
+			LogMaxBackups:       5,
+			Env:                 "TEST",
+			Id:                  "123",
+			HealthPort:          9001,
+			ShutdownGracePeriod: time.Minute,
+			AuditConfig:         relayer.AuditConfig{Path: "audit.jsonl"},
+			ApprovalConfig:      relayer.ApprovalConfig{Deadline: 24 * time.Hour, StorePath: "approvals"},
+			LimitsConfig:        relayer.LimitsConfig{Velocity: []relayer.VelocityLimit{}, StorePath: "limits"},
+			EventsConfig:        relayer.EventsConfig{MaxRetries: 5, RetryInterval: time.Second, MaxElapsedTime: 5 * time.Minute},
+			NonceConfig:         relayer.NonceConfig{ReservationTimeout: 10 * time.Minute, StorePath: "nonces"},
 			MpcConfig: relayer.MpcRelayerConfig{
 				TopologyConfiguration: relayer.TopologyConfiguration{
 					EncryptionKey: "test-enc-key",
@@ -103,6 +111,8 @@ func (s *GetConfigTestSuite) Test_GetConfigFromENV() {
 				FrostKeysharePath:       "/cfg/keyshares/0-frost.keyshare",
 				Key:                     "test-pk",
 				CommHealthCheckInterval: 5 * time.Minute,
+				TopologyRefreshInterval: time.Minute,
+				CoordinatorElector:      "static",
 			},
 			BullyConfig: relayer.BullyConfig{
 				PingWaitTime:     1 * time.Second,
@@ -204,11 +214,19 @@ func (s *GetConfigTestSuite) Test_GetConfigFromENV_RandomOrder() {
 
 	s.Equal(tss_config.Config{
 		RelayerConfig: relayer.RelayerConfig{
-			LogLevel:   1,
-			LogFile:    "out.log",
-			Env:        "TEST",
-			Id:         "123",
-			HealthPort: 9001,
+			LogLevel:            1,
+			LogFormat:           "json",
+			LogMaxSize:          100,
+			LogMaxBackups:       5,
+			Env:                 "TEST",
+			Id:                  "123",
+			HealthPort:          9001,
+			ShutdownGracePeriod: time.Minute,
+			AuditConfig:         relayer.AuditConfig{Path: "audit.jsonl"},
+			ApprovalConfig:      relayer.ApprovalConfig{Deadline: 24 * time.Hour, StorePath: "approvals"},
+			LimitsConfig:        relayer.LimitsConfig{Velocity: []relayer.VelocityLimit{}, StorePath: "limits"},
+			EventsConfig:        relayer.EventsConfig{MaxRetries: 5, RetryInterval: time.Second, MaxElapsedTime: 5 * time.Minute},
+			NonceConfig:         relayer.NonceConfig{ReservationTimeout: 10 * time.Minute, StorePath: "nonces"},
 			MpcConfig: relayer.MpcRelayerConfig{
 				TopologyConfiguration: relayer.TopologyConfiguration{
 					EncryptionKey: "test-enc-key",
@@ -220,6 +238,8 @@ func (s *GetConfigTestSuite) Test_GetConfigFromENV_RandomOrder() {
 				FrostKeysharePath:       "/cfg/keyshares/0-frost.keyshare",
 				Key:                     "test-pk",
 				CommHealthCheckInterval: 5 * time.Minute,
+				TopologyRefreshInterval: time.Minute,
+				CoordinatorElector:      "static",
 			},
 			BullyConfig: relayer.BullyConfig{
 				PingWaitTime:     1 * time.Second,
@@ -336,6 +356,7 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 					LogLevel:                  "",
 					LogFile:                   "",
 					MpcConfig: relayer.RawMpcRelayerConfig{
+						Key:  "test-pk",
 						Port: "2020",
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
@@ -367,6 +388,7 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 				RelayerConfig: relayer.RawRelayerConfig{
 					LogLevel: "invalid",
 					MpcConfig: relayer.RawMpcRelayerConfig{
+						Key: "test-pk",
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
 							Url:           "url",
@@ -396,6 +418,7 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 				RelayerConfig: relayer.RawRelayerConfig{
 					LogLevel: "info",
 					MpcConfig: relayer.RawMpcRelayerConfig{
+						Key: "test-pk",
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
 							Url:           "url",
@@ -453,13 +476,164 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 			errorMsg:   "topology configuration encryption key not provided",
 			outConfig:  tss_config.Config{},
 		},
+		{
+			name: "unknown tracing exporter",
+			inConfig: tss_config.RawConfig{
+				RelayerConfig: relayer.RawRelayerConfig{
+					LogLevel: "info",
+					MpcConfig: relayer.RawMpcRelayerConfig{
+						TopologyConfiguration: relayer.TopologyConfiguration{
+							EncryptionKey: "enc-key",
+							Url:           "url",
+							Path:          "path",
+						},
+						Port: "2020",
+						Key:  "test-pk",
+					},
+					TracingConfig: relayer.TracingConfig{
+						Exporter: "jaeger",
+					},
+				},
+
+				ChainConfigs: []map[string]interface{}{{
+					"id":   float64(1),
+					"type": "evm",
+					"name": "chain1",
+				}},
+			},
+			shouldFail: true,
+			errorMsg:   "unknown tracing exporter jaeger",
+			outConfig:  tss_config.Config{},
+		},
+		{
+			name: "tls certificate without key",
+			inConfig: tss_config.RawConfig{
+				RelayerConfig: relayer.RawRelayerConfig{
+					LogLevel: "info",
+					MpcConfig: relayer.RawMpcRelayerConfig{
+						TopologyConfiguration: relayer.TopologyConfiguration{
+							EncryptionKey: "enc-key",
+							Url:           "url",
+							Path:          "path",
+						},
+						Port: "2020",
+						Key:  "test-pk",
+					},
+					TLSConfig: relayer.TLSConfig{
+						CertFile: "server.crt",
+					},
+				},
+
+				ChainConfigs: []map[string]interface{}{{
+					"id":   float64(1),
+					"type": "evm",
+					"name": "chain1",
+				}},
+			},
+			shouldFail: true,
+			errorMsg:   "tls certificate and key must be provided together",
+			outConfig:  tss_config.Config{},
+		},
+		{
+			name: "approval quorum larger than approvers",
+			inConfig: tss_config.RawConfig{
+				RelayerConfig: relayer.RawRelayerConfig{
+					LogLevel: "info",
+					MpcConfig: relayer.RawMpcRelayerConfig{
+						TopologyConfiguration: relayer.TopologyConfiguration{
+							EncryptionKey: "enc-key",
+							Url:           "url",
+							Path:          "path",
+						},
+						Port: "2020",
+						Key:  "test-pk",
+					},
+					ApprovalConfig: relayer.RawApprovalConfig{
+						Approvers: []relayer.Approver{
+							{Name: "alice", Address: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+						},
+						Quorum: 2,
+					},
+				},
+
+				ChainConfigs: []map[string]interface{}{{
+					"id":   float64(1),
+					"type": "evm",
+					"name": "chain1",
+				}},
+			},
+			shouldFail: true,
+			errorMsg:   "approval quorum must be between 1 and 1",
+			outConfig:  tss_config.Config{},
+		},
+		{
+			name: "invalid velocity window",
+			inConfig: tss_config.RawConfig{
+				RelayerConfig: relayer.RawRelayerConfig{
+					LogLevel: "info",
+					MpcConfig: relayer.RawMpcRelayerConfig{
+						TopologyConfiguration: relayer.TopologyConfiguration{
+							EncryptionKey: "enc-key",
+							Url:           "url",
+							Path:          "path",
+						},
+						Port: "2020",
+						Key:  "test-pk",
+					},
+					LimitsConfig: relayer.RawLimitsConfig{
+						Velocity: []relayer.RawVelocityLimit{
+							{Window: "day", MaxValue: "1000000000000000000"},
+						},
+					},
+				},
+
+				ChainConfigs: []map[string]interface{}{{
+					"id":   float64(1),
+					"type": "evm",
+					"name": "chain1",
+				}},
+			},
+			shouldFail: true,
+			errorMsg:   "invalid velocity window day",
+			outConfig:  tss_config.Config{},
+		},
+		{
+			name: "webhook without secret",
+			inConfig: tss_config.RawConfig{
+				RelayerConfig: relayer.RawRelayerConfig{
+					LogLevel: "info",
+					MpcConfig: relayer.RawMpcRelayerConfig{
+						TopologyConfiguration: relayer.TopologyConfiguration{
+							EncryptionKey: "enc-key",
+							Url:           "url",
+							Path:          "path",
+						},
+						Port: "2020",
+						Key:  "test-pk",
+					},
+					EventsConfig: relayer.RawEventsConfig{
+						Webhooks: []relayer.Webhook{{URL: "https://example.com/hooks"}},
+					},
+				},
+
+				ChainConfigs: []map[string]interface{}{{
+					"id":   float64(1),
+					"type": "evm",
+					"name": "chain1",
+				}},
+			},
+			shouldFail: true,
+			errorMsg:   "webhook https://example.com/hooks secret not provided",
+			outConfig:  tss_config.Config{},
+		},
 		{
 			name: "set default values in tss_config",
 			inConfig: tss_config.RawConfig{
 				RelayerConfig: relayer.RawRelayerConfig{
 					// LogLevel: use default value,
-					// LogFile: use default value
+					// LogFile: logs only to stdout
 					MpcConfig: relayer.RawMpcRelayerConfig{
+						Key: "test-pk",
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
 							Url:           "url",
@@ -485,17 +659,28 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 			outConfig: tss_config.Config{
 				RelayerConfig: relayer.RelayerConfig{
 					LogLevel:                  1,
-					LogFile:                   "out.log",
+					LogFormat:                 "json",
+					LogMaxSize:                100,
+					LogMaxBackups:             5,
 					OpenTelemetryCollectorURL: "",
 					HealthPort:                9001,
+					ShutdownGracePeriod:       time.Minute,
+					AuditConfig:               relayer.AuditConfig{Path: "audit.jsonl"},
+					ApprovalConfig:            relayer.ApprovalConfig{Deadline: 24 * time.Hour, StorePath: "approvals"},
+					LimitsConfig:              relayer.LimitsConfig{Velocity: []relayer.VelocityLimit{}, StorePath: "limits"},
+					EventsConfig:              relayer.EventsConfig{MaxRetries: 5, RetryInterval: time.Second, MaxElapsedTime: 5 * time.Minute},
+					NonceConfig:               relayer.NonceConfig{ReservationTimeout: 10 * time.Minute, StorePath: "nonces"},
 					MpcConfig: relayer.MpcRelayerConfig{
 						Port: 9000,
+						Key:  "test-pk",
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
 							Url:           "url",
 							Path:          "path",
 						},
 						CommHealthCheckInterval: 5 * time.Minute,
+						TopologyRefreshInterval: time.Minute,
+						CoordinatorElector:      "static",
 					},
 					BullyConfig: relayer.BullyConfig{
 						PingWaitTime:     1 * time.Second,
@@ -522,9 +707,12 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 			name: "valid tss_config",
 			inConfig: tss_config.RawConfig{
 				RelayerConfig: relayer.RawRelayerConfig{
-					LogLevel:   "debug",
-					LogFile:    "custom.log",
-					HealthPort: "9002",
+					LogLevel:      "debug",
+					LogFile:       "custom.log",
+					LogFormat:     "json",
+					LogMaxSize:    100,
+					LogMaxBackups: 5,
+					HealthPort:    "9002",
 					MpcConfig: relayer.RawMpcRelayerConfig{
 						TopologyConfiguration: relayer.TopologyConfiguration{
 							EncryptionKey: "enc-key",
@@ -562,8 +750,17 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 				RelayerConfig: relayer.RelayerConfig{
 					LogLevel:                  0,
 					LogFile:                   "custom.log",
+					LogFormat:                 "json",
+					LogMaxSize:                100,
+					LogMaxBackups:             5,
 					OpenTelemetryCollectorURL: "",
 					HealthPort:                9002,
+					ShutdownGracePeriod:       time.Minute,
+					AuditConfig:               relayer.AuditConfig{Path: "audit.jsonl"},
+					ApprovalConfig:            relayer.ApprovalConfig{Deadline: 24 * time.Hour, StorePath: "approvals"},
+					LimitsConfig:              relayer.LimitsConfig{Velocity: []relayer.VelocityLimit{}, StorePath: "limits"},
+					EventsConfig:              relayer.EventsConfig{MaxRetries: 5, RetryInterval: time.Second, MaxElapsedTime: 5 * time.Minute},
+					NonceConfig:               relayer.NonceConfig{ReservationTimeout: 10 * time.Minute, StorePath: "nonces"},
 					MpcConfig: relayer.MpcRelayerConfig{
 						Port:         2020,
 						KeysharePath: "./share.key",
@@ -574,6 +771,8 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 							Path:          "path",
 						},
 						CommHealthCheckInterval: 10 * time.Minute,
+						TopologyRefreshInterval: time.Minute,
+						CoordinatorElector:      "static",
 					},
 					BullyConfig: relayer.BullyConfig{
 						PingWaitTime:     time.Second,
@@ -623,3 +822,25 @@ func (s *GetConfigTestSuite) Test_GetConfigFromFile() {
 		})
 	}
 }
+
+func (s *GetConfigTestSuite) Test_TopologySignatures() {
+	disabled := false
+	enabled := true
+	signer := "QmZHPnN3CKiTAp8VaJqszbf8m7v4mPh15M421KpVdYHF54"
+	rawConfig := func(topologyConfig relayer.TopologyConfiguration) *relayer.RawRelayerConfig {
+		topologyConfig.Path = "path"
+		return &relayer.RawRelayerConfig{MpcConfig: relayer.RawMpcRelayerConfig{
+			Key:                   "test-pk",
+			TopologyConfiguration: topologyConfig,
+		}}
+	}
+
+	s.False(relayer.TopologyConfiguration{}.SignaturesRequired())
+	s.True(relayer.TopologyConfiguration{TrustedSigners: []string{signer}}.SignaturesRequired())
+	s.False(relayer.TopologyConfiguration{TrustedSigners: []string{signer}, RequireSignatures: &disabled}.SignaturesRequired())
+
+	s.Nil(rawConfig(relayer.TopologyConfiguration{TrustedSigners: []string{signer}}).Validate())
+	err := rawConfig(relayer.TopologyConfiguration{RequireSignatures: &enabled}).Validate()
+	s.NotNil(err)
+	s.Equal("topology trusted signers required to verify signatures", err.Error())
+}
@@ -6,9 +6,13 @@ package relayer
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog"
 )
 
@@ -16,12 +20,26 @@ type RelayerConfig struct {
 	OpenTelemetryCollectorURL string
 	LogLevel                  zerolog.Level
 	LogFile                   string
+	LogFormat                 string
+	LogMaxSize                int // megabytes
+	LogMaxBackups             int
 	HealthPort                uint16
 	Env                       string
 	Id                        string
 	MpcConfig                 MpcRelayerConfig
 	BullyConfig               BullyConfig
 	UploaderConfig            UploaderConfig
+	RecorderConfig            RecorderConfig
+	TracingConfig             TracingConfig
+	AuditConfig               AuditConfig
+	AuthConfig                AuthConfig
+	TLSConfig                 TLSConfig
+	ApprovalConfig            ApprovalConfig
+	LimitsConfig              LimitsConfig
+	EventsConfig              EventsConfig
+	NonceConfig               NonceConfig
+	// ShutdownGracePeriod is how long running tss sessions can take to finish on shutdown
+	ShutdownGracePeriod time.Duration
 }
 
 type MpcRelayerConfig struct {
@@ -31,6 +49,10 @@ type MpcRelayerConfig struct {
 	FrostKeysharePath       string
 	Key                     string
 	CommHealthCheckInterval time.Duration
+	TopologyRefreshInterval time.Duration
+	// CoordinatorElector is the strategy used to choose session coordinator
+	// before falling back to bully election
+	CoordinatorElector string
 }
 
 type BullyConfig struct {
@@ -41,10 +63,30 @@ type BullyConfig struct {
 	BullyWaitTime    time.Duration
 }
 
+// TopologyConfiguration defines where topology is read from. Topology is fetched from
+// Url if it is set and read from Path otherwise. Path is also used to cache the latest
+// remote topology. If Hash is set, SHA-256 of fetched topology must match it and the
+// topology is not reloaded. Use RequireSignatures to verify topology that can change.
+// If RequireSignatures is set, topology must be approved by a quorum of operators.
+// Topology loaded without an applied topology, e.g. the cached one, must be approved by
+// a majority of TrustedSigners, which are peer IDs of operator keys. Signatures are
+// required by default if TrustedSigners are configured.
 type TopologyConfiguration struct {
-	//EncryptionKey string `mapstructure:"EncryptionKey" json:"encryptionKey"`
-	//Url           string `mapstructure:"Url" json:"url"`
-	Path string `mapstructure:"Path" json:"path"`
+	EncryptionKey     string   `mapstructure:"EncryptionKey" json:"encryptionKey"`
+	Url               string   `mapstructure:"Url" json:"url"`
+	Path              string   `mapstructure:"Path" json:"path"`
+	Hash              string   `mapstructure:"Hash" json:"hash"`
+	RequireSignatures *bool    `mapstructure:"RequireSignatures" json:"requireSignatures"`
+	TrustedSigners    []string `mapstructure:"TrustedSigners" json:"trustedSigners"`
+}
+
+// SignaturesRequired returns true if topology has to be signed by operators. It is
+// true if TrustedSigners are configured and RequireSignatures is not disabled.
+func (c TopologyConfiguration) SignaturesRequired() bool {
+	if c.RequireSignatures != nil {
+		return *c.RequireSignatures
+	}
+	return len(c.TrustedSigners) > 0
 }
 
 type UploaderConfig struct {
@@ -53,16 +95,189 @@ type UploaderConfig struct {
 	MaxRetries     uint64        `mapstructure:"MaxRetries" json:"maxRetries" default:"5"`
 	MaxElapsedTime time.Duration `mapstructure:"MaxElapsedTime" json:"maxElapsedTime" default:"300000"` // 5 min
 }
+
+// RecorderConfig enables recording of tss session messages into Path.
+// Secret payloads are encrypted with EncryptionKey or redacted if key is not provided.
+type RecorderConfig struct {
+	Path          string `mapstructure:"Path" json:"path"`
+	EncryptionKey string `mapstructure:"EncryptionKey" json:"encryptionKey"`
+}
+
+// TracingConfig enables export of session traces. Exporter is "otlp" to send spans to
+// the OTLP gRPC Endpoint or "file" to append them as JSON into Path.
+type TracingConfig struct {
+	Exporter string `mapstructure:"Exporter" json:"exporter"`
+	Endpoint string `mapstructure:"Endpoint" json:"endpoint"`
+	Insecure bool   `mapstructure:"Insecure" json:"insecure"`
+	Path     string `mapstructure:"Path" json:"path"`
+}
+
+// AuditConfig defines path of the tamper-evident audit log of the node
+type AuditConfig struct {
+	Path string `mapstructure:"Path" json:"path" default:"audit.jsonl"`
+}
+
+// AuthConfig defines callers of the HTTP API. API keys are stored as hex encoded SHA-256
+// hashes of the keys. JWTs are HS256 tokens signed with JWTSecret with the caller in the
+// subject, its role in the role claim and an expiration. Calls are denied if no callers
+// are configured, unless AllowAnonymous opens the API to every caller.
+type AuthConfig struct {
+	APIKeys        []APIKey `mapstructure:"ApiKeys" json:"apiKeys"`
+	JWTSecret      string   `mapstructure:"JwtSecret" json:"jwtSecret"`
+	AllowAnonymous bool     `mapstructure:"AllowAnonymous" json:"allowAnonymous"`
+}
+
+type APIKey struct {
+	Name    string `mapstructure:"Name" json:"name"`
+	KeyHash string `mapstructure:"KeyHash" json:"keyHash"`
+	Role    string `mapstructure:"Role" json:"role"`
+}
+
+// TLSConfig enables TLS with the PEM certificate and key files. If ClientCAFile is set,
+// clients must present a certificate signed by one of the CAs in the bundle.
+type TLSConfig struct {
+	CertFile     string `mapstructure:"CertFile" json:"certFile"`
+	KeyFile      string `mapstructure:"KeyFile" json:"keyFile"`
+	ClientCAFile string `mapstructure:"ClientCaFile" json:"clientCaFile"`
+}
+
+// Enabled returns true if the certificate is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate checks that certificate and key are configured together
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("tls certificate and key must be provided together")
+	}
+	if c.ClientCAFile != "" && c.CertFile == "" {
+		return errors.New("tls client CA requires tls certificate")
+	}
+	return nil
+}
+
+// ApprovalConfig holds sign requests until Quorum of Approvers approve them. Requests
+// expire if they are not approved within Deadline. Approval is disabled without approvers.
+type ApprovalConfig struct {
+	Approvers []Approver
+	Quorum    int
+	Deadline  time.Duration
+	// StorePath is the directory of the approval state database
+	StorePath string
+}
+
+type Approver struct {
+	Name    string `mapstructure:"Name" json:"name"`
+	Address string `mapstructure:"Address" json:"address"`
+}
+
+type RawApprovalConfig struct {
+	Approvers []Approver `mapstructure:"Approvers" json:"approvers"`
+	Quorum    int        `mapstructure:"Quorum" json:"quorum"`
+	Deadline  string     `mapstructure:"Deadline" json:"deadline" default:"24h"`
+	StorePath string     `mapstructure:"StorePath" json:"storePath" default:"approvals"`
+}
+
+// LimitsConfig limits sign requests per API client and sign sessions per key with token
+// buckets refilled at the per minute rate up to the burst. Velocity limits cap the total
+// transaction value signed by a key within a window. Zero rates and no velocity limits
+// disable the limits.
+type LimitsConfig struct {
+	ClientRatePerMinute int
+	ClientBurst         int
+	KeyRatePerMinute    int
+	KeyBurst            int
+	Velocity            []VelocityLimit
+	// StorePath is the directory of the limit counters database
+	StorePath string
+}
+
+// Enabled returns true if any of the limits is configured
+func (c LimitsConfig) Enabled() bool {
+	return c.ClientRatePerMinute > 0 || c.KeyRatePerMinute > 0 || len(c.Velocity) > 0
+}
+
+type VelocityLimit struct {
+	Window time.Duration
+	// MaxValue is the total value in wei
+	MaxValue *big.Int
+}
+
+type RawLimitsConfig struct {
+	ClientRatePerMinute int                `mapstructure:"ClientRatePerMinute" json:"clientRatePerMinute"`
+	ClientBurst         int                `mapstructure:"ClientBurst" json:"clientBurst"`
+	KeyRatePerMinute    int                `mapstructure:"KeyRatePerMinute" json:"keyRatePerMinute"`
+	KeyBurst            int                `mapstructure:"KeyBurst" json:"keyBurst"`
+	Velocity            []RawVelocityLimit `mapstructure:"Velocity" json:"velocity"`
+	StorePath           string             `mapstructure:"StorePath" json:"storePath" default:"limits"`
+}
+
+type RawVelocityLimit struct {
+	Window   string `mapstructure:"Window" json:"window"`
+	MaxValue string `mapstructure:"MaxValue" json:"maxValue"`
+}
+
+// EventsConfig delivers node events to Webhooks. Failed deliveries are retried with
+// exponential backoff starting at RetryInterval, up to MaxRetries times within MaxElapsedTime.
+type EventsConfig struct {
+	Webhooks       []Webhook
+	MaxRetries     uint64
+	RetryInterval  time.Duration
+	MaxElapsedTime time.Duration
+}
+
+// Webhook receives events of Events types, or all events if no types are set. Payloads
+// are signed with HMAC-SHA256 of Secret.
+type Webhook struct {
+	URL    string   `mapstructure:"Url" json:"url"`
+	Secret string   `mapstructure:"Secret" json:"secret"`
+	Events []string `mapstructure:"Events" json:"events"`
+}
+
+type RawEventsConfig struct {
+	Webhooks       []Webhook `mapstructure:"Webhooks" json:"webhooks"`
+	MaxRetries     uint64    `mapstructure:"MaxRetries" json:"maxRetries" default:"5"`
+	RetryInterval  string    `mapstructure:"RetryInterval" json:"retryInterval" default:"1s"`
+	MaxElapsedTime string    `mapstructure:"MaxElapsedTime" json:"maxElapsedTime" default:"5m"`
+}
+
+// NonceConfig configures nonces of the MPC address on evm chains. Nonces reserved by
+// built transactions that are not sent within ReservationTimeout are handed out again.
+type NonceConfig struct {
+	ReservationTimeout time.Duration
+	// StorePath is the directory of the nonce ledger database
+	StorePath string
+}
+
+type RawNonceConfig struct {
+	ReservationTimeout string `mapstructure:"ReservationTimeout" json:"reservationTimeout" default:"10m"`
+	StorePath          string `mapstructure:"StorePath" json:"storePath" default:"nonces"`
+}
+
 type RawRelayerConfig struct {
 	OpenTelemetryCollectorURL string              `mapstructure:"OpenTelemetryCollectorURL" json:"opentelemetryCollectorURL"`
 	LogLevel                  string              `mapstructure:"LogLevel" json:"logLevel" default:"info"`
-	LogFile                   string              `mapstructure:"LogFile" json:"logFile" default:"out.log"`
+	LogFile                   string              `mapstructure:"LogFile" json:"logFile"`
+	LogFormat                 string              `mapstructure:"LogFormat" json:"logFormat" default:"json"`
+	LogMaxSize                int                 `mapstructure:"LogMaxSize" json:"logMaxSize" default:"100"`
+	LogMaxBackups             int                 `mapstructure:"LogMaxBackups" json:"logMaxBackups" default:"5"`
 	HealthPort                string              `mapstructure:"HealthPort" json:"healthPort" default:"9001"`
 	Env                       string              `mapstructure:"Env" json:"env"`
 	Id                        string              `mapstructure:"Id" json:"id"`
 	MpcConfig                 RawMpcRelayerConfig `mapstructure:"MpcConfig" json:"mpcConfig"`
 	BullyConfig               RawBullyConfig      `mapstructure:"BullyConfig" json:"bullyConfig"`
 	UploaderConfig            UploaderConfig      `mapstructure:"uploaderConfig"`
+	RecorderConfig            RecorderConfig      `mapstructure:"RecorderConfig" json:"recorderConfig"`
+	TracingConfig             TracingConfig       `mapstructure:"TracingConfig" json:"tracingConfig"`
+	AuditConfig               AuditConfig         `mapstructure:"AuditConfig" json:"auditConfig"`
+	AuthConfig                AuthConfig          `mapstructure:"AuthConfig" json:"authConfig"`
+	TLSConfig                 TLSConfig           `mapstructure:"TlsConfig" json:"tlsConfig"`
+	ApprovalConfig            RawApprovalConfig   `mapstructure:"ApprovalConfig" json:"approvalConfig"`
+	LimitsConfig              RawLimitsConfig     `mapstructure:"LimitsConfig" json:"limitsConfig"`
+	EventsConfig              RawEventsConfig     `mapstructure:"EventsConfig" json:"eventsConfig"`
+	NonceConfig               RawNonceConfig      `mapstructure:"NonceConfig" json:"nonceConfig"`
+	ShutdownGracePeriod       string              `mapstructure:"ShutdownGracePeriod" json:"shutdownGracePeriod" default:"1m"`
 }
 
 type RawMpcRelayerConfig struct {
@@ -72,6 +287,8 @@ type RawMpcRelayerConfig struct {
 	Port                    string                `mapstructure:"Port" json:"port" default:"9000"`
 	TopologyConfiguration   TopologyConfiguration `mapstructure:"TopologyConfiguration" json:"topologyConfiguration"`
 	CommHealthCheckInterval string                `mapstructure:"CommHealthCheckInterval" json:"commHealthCheckInterval" default:"5m"`
+	TopologyRefreshInterval string                `mapstructure:"TopologyRefreshInterval" json:"topologyRefreshInterval" default:"1m"`
+	CoordinatorElector      string                `mapstructure:"CoordinatorElector" json:"coordinatorElector" default:"static"`
 }
 
 type RawBullyConfig struct {
@@ -83,17 +300,89 @@ type RawBullyConfig struct {
 }
 
 func (c *RawRelayerConfig) Validate() error {
-	//if c.MpcConfig.TopologyConfiguration.EncryptionKey == "" {
-	//	return errors.New("topology configuration encryption key not provided")
-	//}
-	//if c.MpcConfig.TopologyConfiguration.Url == "" {
-	//	return errors.New("topology configuration url not provided")
-	//}
+	topologyConfig := c.MpcConfig.TopologyConfiguration
+	if topologyConfig.Url != "" || topologyConfig.Path == "" {
+		if topologyConfig.EncryptionKey == "" {
+			return errors.New("topology configuration encryption key not provided")
+		}
+		if topologyConfig.Url == "" {
+			return errors.New("topology configuration url not provided")
+		}
+	}
+	if topologyConfig.Path == "" {
+		return errors.New("topology configuration path not provided")
+	}
+	if topologyConfig.SignaturesRequired() && len(topologyConfig.TrustedSigners) == 0 {
+		return errors.New("topology trusted signers required to verify signatures")
+	}
+	for _, signer := range topologyConfig.TrustedSigners {
+		if _, err := peer.Decode(signer); err != nil {
+			return fmt.Errorf("invalid topology trusted signer %s: %w", signer, err)
+		}
+	}
 	if c.MpcConfig.Key == "" {
 		return errors.New("topology configuration mpc key not provided")
 	}
-	if c.MpcConfig.TopologyConfiguration.Path == "" {
-		return errors.New("topology configuration path not provided")
+	if c.LogFormat != "" && c.LogFormat != "json" && c.LogFormat != "console" {
+		return fmt.Errorf("unknown log format %s", c.LogFormat)
+	}
+	if l := len(c.RecorderConfig.EncryptionKey); l != 0 && l != 16 && l != 24 && l != 32 {
+		return errors.New("recorder encryption key must be 16, 24 or 32 bytes long")
+	}
+	switch c.TracingConfig.Exporter {
+	case "":
+	case "otlp":
+		if c.TracingConfig.Endpoint == "" {
+			return errors.New("tracing endpoint not provided")
+		}
+	case "file":
+		if c.TracingConfig.Path == "" {
+			return errors.New("tracing path not provided")
+		}
+	default:
+		return fmt.Errorf("unknown tracing exporter %s", c.TracingConfig.Exporter)
+	}
+	err := c.validateApprovalConfig()
+	if err != nil {
+		return err
+	}
+	err = c.validateEventsConfig()
+	if err != nil {
+		return err
+	}
+	return c.TLSConfig.Validate()
+}
+
+func (c *RawRelayerConfig) validateApprovalConfig() error {
+	approvers := make(map[string]bool)
+	for _, approver := range c.ApprovalConfig.Approvers {
+		if !common.IsHexAddress(approver.Address) {
+			return fmt.Errorf("invalid address %s of approver %s", approver.Address, approver.Name)
+		}
+		address := common.HexToAddress(approver.Address).Hex()
+		if approvers[address] {
+			return fmt.Errorf("duplicate approver address %s", approver.Address)
+		}
+		approvers[address] = true
+	}
+	if len(approvers) == 0 && c.ApprovalConfig.Quorum != 0 {
+		return errors.New("approval quorum set without approvers")
+	}
+	if len(approvers) != 0 && (c.ApprovalConfig.Quorum < 1 || c.ApprovalConfig.Quorum > len(approvers)) {
+		return fmt.Errorf("approval quorum must be between 1 and %d", len(approvers))
+	}
+	return nil
+}
+
+func (c *RawRelayerConfig) validateEventsConfig() error {
+	for _, webhook := range c.EventsConfig.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url %s", webhook.URL)
+		}
+		if webhook.Secret == "" {
+			return fmt.Errorf("webhook %s secret not provided", webhook.URL)
+		}
 	}
 	return nil
 }
@@ -113,6 +402,9 @@ func NewRelayerConfig(rawConfig RawRelayerConfig) (RelayerConfig, error) {
 	config.LogLevel = logLevel
 
 	config.LogFile = rawConfig.LogFile
+	config.LogFormat = rawConfig.LogFormat
+	config.LogMaxSize = rawConfig.LogMaxSize
+	config.LogMaxBackups = rawConfig.LogMaxBackups
 	config.OpenTelemetryCollectorURL = rawConfig.OpenTelemetryCollectorURL
 
 	healthPort, err := strconv.ParseInt(rawConfig.HealthPort, 0, 16)
@@ -135,9 +427,111 @@ func NewRelayerConfig(rawConfig RawRelayerConfig) (RelayerConfig, error) {
 	config.Env = rawConfig.Env
 	config.Id = rawConfig.Id
 	config.UploaderConfig = rawConfig.UploaderConfig
+	config.RecorderConfig = rawConfig.RecorderConfig
+	config.TracingConfig = rawConfig.TracingConfig
+	config.AuditConfig = rawConfig.AuditConfig
+	config.AuthConfig = rawConfig.AuthConfig
+	config.TLSConfig = rawConfig.TLSConfig
+
+	deadline, err := time.ParseDuration(rawConfig.ApprovalConfig.Deadline)
+	if err != nil {
+		return RelayerConfig{}, fmt.Errorf("unable to parse approval deadline: %w", err)
+	}
+	config.ApprovalConfig = ApprovalConfig{
+		Approvers: rawConfig.ApprovalConfig.Approvers,
+		Quorum:    rawConfig.ApprovalConfig.Quorum,
+		Deadline:  deadline,
+		StorePath: rawConfig.ApprovalConfig.StorePath,
+	}
+
+	limitsConfig, err := parseLimitsConfig(rawConfig.LimitsConfig)
+	if err != nil {
+		return RelayerConfig{}, err
+	}
+	config.LimitsConfig = limitsConfig
+
+	eventsConfig, err := parseEventsConfig(rawConfig.EventsConfig)
+	if err != nil {
+		return RelayerConfig{}, err
+	}
+	config.EventsConfig = eventsConfig
+
+	nonceConfig, err := parseNonceConfig(rawConfig.NonceConfig)
+	if err != nil {
+		return RelayerConfig{}, err
+	}
+	config.NonceConfig = nonceConfig
+
+	gracePeriod, err := time.ParseDuration(rawConfig.ShutdownGracePeriod)
+	if err != nil {
+		return RelayerConfig{}, fmt.Errorf("unable to parse shutdown grace period: %w", err)
+	}
+	config.ShutdownGracePeriod = gracePeriod
+	return config, nil
+}
+
+func parseLimitsConfig(rawConfig RawLimitsConfig) (LimitsConfig, error) {
+	if rawConfig.ClientRatePerMinute < 0 || rawConfig.KeyRatePerMinute < 0 {
+		return LimitsConfig{}, errors.New("rate limits can't be negative")
+	}
+	config := LimitsConfig{
+		ClientRatePerMinute: rawConfig.ClientRatePerMinute,
+		ClientBurst:         rawConfig.ClientBurst,
+		KeyRatePerMinute:    rawConfig.KeyRatePerMinute,
+		KeyBurst:            rawConfig.KeyBurst,
+		Velocity:            []VelocityLimit{},
+		StorePath:           rawConfig.StorePath,
+	}
+	// burst defaults to one minute of requests
+	if config.ClientBurst <= 0 {
+		config.ClientBurst = config.ClientRatePerMinute
+	}
+	if config.KeyBurst <= 0 {
+		config.KeyBurst = config.KeyRatePerMinute
+	}
+
+	for _, v := range rawConfig.Velocity {
+		window, err := time.ParseDuration(v.Window)
+		if err != nil || window <= 0 {
+			return LimitsConfig{}, fmt.Errorf("invalid velocity window %s", v.Window)
+		}
+		maxValue, ok := new(big.Int).SetString(v.MaxValue, 10)
+		if !ok || maxValue.Sign() < 0 {
+			return LimitsConfig{}, fmt.Errorf("invalid velocity max value %s", v.MaxValue)
+		}
+		config.Velocity = append(config.Velocity, VelocityLimit{Window: window, MaxValue: maxValue})
+	}
 	return config, nil
 }
 
+func parseEventsConfig(rawConfig RawEventsConfig) (EventsConfig, error) {
+	retryInterval, err := time.ParseDuration(rawConfig.RetryInterval)
+	if err != nil {
+		return EventsConfig{}, fmt.Errorf("unable to parse webhook retry interval: %w", err)
+	}
+	maxElapsedTime, err := time.ParseDuration(rawConfig.MaxElapsedTime)
+	if err != nil {
+		return EventsConfig{}, fmt.Errorf("unable to parse webhook max elapsed time: %w", err)
+	}
+	return EventsConfig{
+		Webhooks:       rawConfig.Webhooks,
+		MaxRetries:     rawConfig.MaxRetries,
+		RetryInterval:  retryInterval,
+		MaxElapsedTime: maxElapsedTime,
+	}, nil
+}
+
+func parseNonceConfig(rawConfig RawNonceConfig) (NonceConfig, error) {
+	reservationTimeout, err := time.ParseDuration(rawConfig.ReservationTimeout)
+	if err != nil {
+		return NonceConfig{}, fmt.Errorf("unable to parse nonce reservation timeout: %w", err)
+	}
+	return NonceConfig{
+		ReservationTimeout: reservationTimeout,
+		StorePath:          rawConfig.StorePath,
+	}, nil
+}
+
 func parseMpcConfig(rawConfig RawRelayerConfig) (MpcRelayerConfig, error) {
 	var mpcConfig MpcRelayerConfig
 
@@ -158,6 +552,15 @@ func parseMpcConfig(rawConfig RawRelayerConfig) (MpcRelayerConfig, error) {
 	}
 	mpcConfig.CommHealthCheckInterval = duration
 
+	refreshInterval, err := time.ParseDuration(rawConfig.MpcConfig.TopologyRefreshInterval)
+	if err != nil {
+		return MpcRelayerConfig{}, fmt.Errorf("unable to parse topology refresh interval time: %w", err)
+	}
+	mpcConfig.TopologyRefreshInterval = refreshInterval
+
+	// elector name is validated by the elector package when the node starts
+	mpcConfig.CoordinatorElector = rawConfig.MpcConfig.CoordinatorElector
+
 	return mpcConfig, nil
 }
 